@@ -5,16 +5,26 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/tomoki-den-uhd/go-study/internal/handlers"
+	"github.com/tomoki-den-uhd/go-study/internal/mail"
+	appmiddleware "github.com/tomoki-den-uhd/go-study/internal/middleware"
+	"github.com/tomoki-den-uhd/go-study/internal/oidc"
 	"github.com/tomoki-den-uhd/go-study/internal/repositories"
 	"github.com/tomoki-den-uhd/go-study/internal/services"
+	"github.com/tomoki-den-uhd/go-study/internal/storage"
 )
 
+// calendarFeedRoute カレンダー配信のルート（URLに購読用のトークンを含むため、アクセスログにはURLを記録しない）
+const calendarFeedRoute = "/calendar/:token"
+
 func main() {
     // .env読み込み
     err := godotenv.Load(".env")
@@ -29,6 +39,12 @@ func main() {
     dbPassword := os.Getenv("DB_PASSWORD")
     dbName := os.Getenv("DB_NAME")
 
+    // トークン署名用の秘密鍵
+    jwtSecret := os.Getenv("JWT_SECRET")
+    if jwtSecret == "" {
+        log.Fatalf("JWT_SECRET is not set")
+    }
+
     // 接続文字列作成
     dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", dbUser, dbPassword, dbHost, dbPort, dbName)
 
@@ -44,29 +60,179 @@ func main() {
     // Echoインスタンスの作成
     e := echo.New()
 
+    // 環境変数TRUSTED_PROXIES（カンマ区切りのCIDR）のプロキシが付けたX-Forwarded-Forのみ信頼する
+    ipExtractor, err := appmiddleware.NewIPExtractor(os.Getenv("TRUSTED_PROXIES"))
+    if err != nil {
+        log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+    }
+    e.IPExtractor = ipExtractor
+
     // ミドルウェアの追加
-    e.Use(middleware.Logger())
+    e.Use(middleware.RequestID())
+    e.Use(appmiddleware.AccessLogger(calendarFeedRoute))
     e.Use(middleware.Recover())
-    e.Use(middleware.CORS())
+    e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+        ExposeHeaders: handlers.TusExposedHeaders,
+    }))
 
     // 依存関係の注入
     userRepo := repositories.NewUserRepository(pool)
     testRepo := repositories.NewTestRepository(pool)
     gradeRepo := repositories.NewGradeRepository(pool)
     courseRepo := repositories.NewCourseRepository(pool)
-    userService := services.NewUserService(userRepo)
-    testService := services.NewTestService(testRepo, userService)
-    gradeService := services.NewGradeService(gradeRepo, userService)
-    courseService := services.NewCourseService(courseRepo, userService)
+    sessionRepo := repositories.NewSessionRepository(pool)
+    guardianRepo := repositories.NewGuardianRepository(pool)
+    attendanceRepo := repositories.NewAttendanceRepository(pool)
+    throttleRepo := repositories.NewLoginThrottleRepository(pool)
+    twoFactorRepo := repositories.NewTwoFactorRepository(pool)
+    apiKeyRepo := repositories.NewAPIKeyRepository(pool)
+    oidcRepo := repositories.NewOIDCRepository(pool)
+    auditRepo := repositories.NewAuditLogRepository(pool)
+    rosterRepo := repositories.NewRosterRepository(pool)
+    subjectRepo := repositories.NewSubjectRepository(pool)
+    enrollmentRepo := repositories.NewEnrollmentRepository(pool)
+    videoRepo := repositories.NewCourseVideoRepository(pool)
+    videoUploadRepo := repositories.NewVideoUploadRepository(pool)
+    videoProgressRepo := repositories.NewVideoProgressRepository(pool)
+    courseSessionRepo := repositories.NewCourseSessionRepository(pool)
+    courseStaffRepo := repositories.NewCourseStaffRepository(pool)
+    calendarFeedRepo := repositories.NewCalendarFeedRepository(pool)
+    mailer := newMailer()
+    auditService := services.NewAuditService(auditRepo, userRepo)
+    userService := services.NewUserService(userRepo, sessionRepo, mailer, auditService)
+    throttleService := services.NewLoginThrottleService(throttleRepo, userRepo, userService, auditService)
+    twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, userService, auditService, totpIssuer(), strings.Split(os.Getenv("TOTP_REQUIRED_ROLES"), ","))
+    authService := services.NewAuthService(userRepo, sessionRepo, throttleService, twoFactorService, auditService, jwtSecret, 15*time.Minute, 30*24*time.Hour)
+    apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, userService, auditService)
+    oidcRoleMapping, err := services.ParseRoleMapping(os.Getenv("OIDC_ROLE_MAPPING"))
+    if err != nil {
+        log.Fatalf("Invalid OIDC_ROLE_MAPPING: %v", err)
+    }
+    oidcService := services.NewOIDCService(newOIDCProvider(), oidcRepo, userRepo, authService, os.Getenv("OIDC_ROLE_CLAIM"), oidcRoleMapping)
+    testService := services.NewTestService(testRepo, guardianRepo, userService)
+    gradeService := services.NewGradeService(gradeRepo, guardianRepo, userService)
+    courseService := services.NewCourseService(courseRepo, courseSessionRepo, userService, auditService)
+    guardianService := services.NewGuardianService(guardianRepo, userRepo, userService, auditService)
+    attendanceService := services.NewAttendanceService(attendanceRepo, courseRepo, guardianRepo, userService)
+    rosterImportService := services.NewRosterImportService(rosterRepo, sessionRepo, userService, auditService)
+    subjectService := services.NewSubjectService(subjectRepo, userService, auditService)
+    enrollmentService := services.NewEnrollmentService(enrollmentRepo, courseRepo, userRepo, userService, auditService)
+    videoService := services.NewCourseVideoService(videoRepo, courseRepo, newStorage(), userService, auditService, videoMaxUploadBytes())
+    videoUploadService := services.NewVideoUploadService(videoUploadRepo, videoService, newPartialStore(), userService, 24*time.Hour)
+
+    // 放置されたアップロードの受信途中のファイルを定期的に削除する
+    go videoUploadService.PurgeExpiredPeriodically(context.Background(), time.Hour)
+    videoProgressService := services.NewVideoProgressService(videoProgressRepo, videoRepo, courseRepo, enrollmentRepo, userService)
+    courseSessionService := services.NewCourseSessionService(courseSessionRepo, courseRepo, attendanceRepo, enrollmentRepo, userService, auditService)
+    courseStaffService := services.NewCourseStaffService(courseStaffRepo, courseRepo, userRepo, userService, auditService)
+    calendarFeedService := services.NewCalendarFeedService(calendarFeedRepo, userService, auditService)
     testHandler := handlers.NewTestHandler(testService)
     gradeHandler := handlers.NewGradeHandler(gradeService)
     courseHandler := handlers.NewCourseHandler(courseService)
+    guardianHandler := handlers.NewGuardianHandler(guardianService)
+    attendanceHandler := handlers.NewAttendanceHandler(attendanceService)
+    passwordResetService := services.NewPasswordResetService(userRepo, sessionRepo, throttleService, mailer, auditService)
+    authHandler := handlers.NewAuthHandler(authService)
+    passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+    throttleHandler := handlers.NewLoginThrottleHandler(throttleService)
+    twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+    apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+    oidcHandler := handlers.NewOIDCHandler(oidcService)
+    auditLogHandler := handlers.NewAuditLogHandler(auditService)
+    rosterImportHandler := handlers.NewRosterImportHandler(rosterImportService)
+    subjectHandler := handlers.NewSubjectHandler(subjectService)
+    enrollmentHandler := handlers.NewEnrollmentHandler(enrollmentService)
+    videoHandler := handlers.NewCourseVideoHandler(videoService)
+    videoUploadHandler := handlers.NewVideoUploadHandler(videoUploadService)
+    videoProgressHandler := handlers.NewVideoProgressHandler(videoProgressService)
+    courseSessionHandler := handlers.NewCourseSessionHandler(courseSessionService)
+    courseStaffHandler := handlers.NewCourseStaffHandler(courseStaffService)
+    calendarFeedHandler := handlers.NewCalendarFeedHandler(calendarFeedService)
+    userHandler := handlers.NewUserHandler(userService)
 
-    // ルーティングの設定
-    e.GET("/tests", testHandler.GetTestsHandler)
-    e.GET("/grades/:grade_id", gradeHandler.GetGradeDetailHandler)
-    e.POST("/courses", courseHandler.CreateCourseHandler)
-    e.PUT("/courses/:course_id", courseHandler.UpdateCourseHandler)
+    // ルーティングの設定（認証不要）
+    e.POST("/auth/login", authHandler.LoginHandler)
+    e.POST("/auth/login/2fa", authHandler.VerifyTwoFactorHandler)
+    e.GET("/auth/oidc/authorize", oidcHandler.AuthorizeHandler)
+    e.POST("/auth/oidc/callback", oidcHandler.CallbackHandler)
+    e.POST("/auth/refresh", authHandler.RefreshHandler)
+    e.POST("/auth/forgot-password", passwordResetHandler.ForgotPasswordHandler)
+    e.POST("/auth/reset-password", passwordResetHandler.ResetPasswordHandler)
+    e.POST("/users", userHandler.RegisterUserHandler)
+    e.POST("/auth/verify-email", userHandler.VerifyEmailHandler)
+    e.OPTIONS("/courses/:course_id/videos/uploads", videoUploadHandler.OptionsHandler)
+    e.GET(calendarFeedRoute, calendarFeedHandler.DownloadFeedHandler)
+
+    // ルーティングの設定（認証必須）
+    api := e.Group("", appmiddleware.Auth(authService, apiKeyService))
+    api.POST("/auth/logout", authHandler.LogoutHandler)
+    api.GET("/admin/users", userHandler.ListUsersHandler)
+    api.POST("/admin/users", userHandler.AdminCreateUserHandler)
+    api.POST("/admin/users/:user_id/deactivate", userHandler.DeactivateUserHandler)
+    api.POST("/admin/users/:user_id/reactivate", userHandler.ReactivateUserHandler)
+    api.PUT("/admin/users/:user_id/role", userHandler.ChangeUserRoleHandler)
+    api.DELETE("/admin/users/:user_id/sessions", authHandler.RevokeUserSessionsHandler)
+    api.POST("/admin/users/:user_id/unlock", throttleHandler.UnlockUserHandler)
+    api.GET("/admin/lockout-events", throttleHandler.ListLockoutEventsHandler)
+    api.DELETE("/admin/users/:user_id/2fa", twoFactorHandler.AdminResetHandler)
+    api.POST("/admin/service-accounts", userHandler.CreateServiceAccountHandler)
+    api.GET("/admin/api-keys", apiKeyHandler.ListAPIKeysHandler)
+    api.POST("/admin/api-keys", apiKeyHandler.CreateAPIKeyHandler)
+    api.DELETE("/admin/api-keys/:api_key_id", apiKeyHandler.RevokeAPIKeyHandler)
+    api.GET("/admin/audit-logs", auditLogHandler.ListAuditLogsHandler)
+    api.POST("/admin/imports/roster", rosterImportHandler.ImportRosterHandler)
+    api.GET("/admin/subjects", subjectHandler.ListSubjectsHandler)
+    api.POST("/admin/subjects", subjectHandler.CreateSubjectHandler)
+    api.PUT("/admin/subjects/:subject_id", subjectHandler.RenameSubjectHandler)
+    api.DELETE("/admin/subjects/:subject_id", subjectHandler.DeleteSubjectHandler)
+    api.POST("/admin/subjects/:subject_id/restore", subjectHandler.RestoreSubjectHandler)
+    api.GET("/admin/students/:user_id/guardians", guardianHandler.ListStudentGuardiansHandler)
+    api.POST("/admin/guardians", guardianHandler.CreateGuardianHandler)
+    api.DELETE("/admin/guardians/:guardian_id", guardianHandler.DeleteGuardianHandler)
+    api.GET("/me", userHandler.GetProfileHandler)
+    api.PUT("/me", userHandler.UpdateProfileHandler)
+    api.GET("/me/children", guardianHandler.ListMyChildrenHandler)
+    api.GET("/me/2fa", twoFactorHandler.GetStatusHandler)
+    api.DELETE("/me/2fa", twoFactorHandler.DisableHandler)
+    api.POST("/me/2fa/enroll", twoFactorHandler.EnrollHandler)
+    api.POST("/me/2fa/confirm", twoFactorHandler.ConfirmHandler)
+    api.POST("/me/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodesHandler)
+    api.GET("/me/calendar-feed", calendarFeedHandler.GetFeedHandler)
+    api.POST("/me/calendar-feed", calendarFeedHandler.CreateFeedHandler)
+    api.DELETE("/me/calendar-feed", calendarFeedHandler.RevokeFeedHandler)
+    api.GET("/tests", testHandler.GetTestsHandler)
+    api.GET("/grades/:grade_id", gradeHandler.GetGradeDetailHandler)
+    api.GET("/attendances", attendanceHandler.ListAttendancesHandler)
+    api.GET("/courses", courseHandler.ListCoursesHandler)
+    api.GET("/courses/:course_id", courseHandler.GetCourseHandler)
+    api.POST("/courses", courseHandler.CreateCourseHandler)
+    api.PUT("/courses/:course_id", courseHandler.UpdateCourseHandler)
+    api.DELETE("/courses/:course_id", courseHandler.DeleteCourseHandler)
+    api.POST("/courses/:course_id/restore", courseHandler.RestoreCourseHandler)
+    api.GET("/courses/:course_id/enrollments", enrollmentHandler.ListEnrollmentsHandler)
+    api.POST("/courses/:course_id/enrollments", enrollmentHandler.EnrollHandler)
+    api.PUT("/courses/:course_id/enrollments/:user_id", enrollmentHandler.UpdateEnrollmentHandler)
+    api.DELETE("/courses/:course_id/enrollments/:user_id", enrollmentHandler.UnenrollHandler)
+    api.GET("/courses/:course_id/staff", courseStaffHandler.ListStaffHandler)
+    api.POST("/courses/:course_id/staff", courseStaffHandler.AddStaffHandler)
+    api.PUT("/courses/:course_id/staff/:user_id", courseStaffHandler.UpdateStaffHandler)
+    api.DELETE("/courses/:course_id/staff/:user_id", courseStaffHandler.RemoveStaffHandler)
+    api.GET("/courses/:course_id/sessions", courseSessionHandler.ListSessionsHandler)
+    api.PUT("/courses/:course_id/sessions/:session_id", courseSessionHandler.UpdateSessionHandler)
+    api.GET("/courses/:course_id/sessions/:session_id/attendances", courseSessionHandler.ListSessionAttendancesHandler)
+    api.PUT("/courses/:course_id/sessions/:session_id/attendances", courseSessionHandler.RecordSessionAttendancesHandler)
+    api.GET("/courses/:course_id/videos", videoHandler.ListVideosHandler)
+    api.POST("/courses/:course_id/videos", videoHandler.UploadVideoHandler)
+    api.GET("/courses/:course_id/videos/:video_id/stream", videoHandler.StreamVideoHandler)
+    api.PUT("/courses/:course_id/videos/:video_id", videoHandler.UpdateVideoHandler)
+    api.DELETE("/courses/:course_id/videos/:video_id", videoHandler.DeleteVideoHandler)
+    api.GET("/courses/:course_id/videos/progress", videoProgressHandler.GetCourseReportHandler)
+    api.GET("/courses/:course_id/videos/:video_id/progress", videoProgressHandler.GetProgressHandler)
+    api.POST("/courses/:course_id/videos/:video_id/progress", videoProgressHandler.RecordProgressHandler)
+    api.POST("/courses/:course_id/videos/uploads", videoUploadHandler.CreateUploadHandler)
+    api.HEAD("/courses/:course_id/videos/uploads/:upload_id", videoUploadHandler.HeadUploadHandler)
+    api.PATCH("/courses/:course_id/videos/uploads/:upload_id", videoUploadHandler.PatchUploadHandler)
+    api.DELETE("/courses/:course_id/videos/uploads/:upload_id", videoUploadHandler.TerminateUploadHandler)
 
     // サーバーの起動
     port := os.Getenv("PORT")
@@ -78,4 +244,136 @@ func main() {
     if err := e.Start(":" + port); err != nil {
         log.Fatalf("Failed to start server: %v", err)
     }
-}
\ No newline at end of file
+}
+
+// newMailer 環境変数MAIL_TRANSPORTに応じてメール送信の実装を選択する
+// メールにはパスワード再設定などのトークンが含まれるため、未設定の場合は起動しない
+// 標準出力への出力（log）は開発用で、明示的に指定した場合のみ使う
+func newMailer() mail.Mailer {
+    from := os.Getenv("MAIL_FROM")
+    if from == "" {
+        from = "no-reply@localhost"
+    }
+
+    switch os.Getenv("MAIL_TRANSPORT") {
+    case "smtp":
+        return mail.NewSMTPMailer(
+            os.Getenv("SMTP_HOST"),
+            os.Getenv("SMTP_PORT"),
+            os.Getenv("SMTP_USERNAME"),
+            os.Getenv("SMTP_PASSWORD"),
+            from,
+        )
+    case "outbox":
+        dir := os.Getenv("MAIL_OUTBOX_DIR")
+        if dir == "" {
+            dir = "outbox"
+        }
+        mailer, err := mail.NewOutboxMailer(dir, from)
+        if err != nil {
+            log.Fatalf("Failed to initialize outbox mailer: %v", err)
+        }
+        return mailer
+    case "log":
+        log.Printf("WARNING: MAIL_TRANSPORT=log prints mail bodies including tokens to stdout; use it for development only")
+        return mail.NewLogMailer()
+    default:
+        log.Fatalf("MAIL_TRANSPORT must be one of smtp, outbox, log (log is for development only)")
+        return nil
+    }
+}
+
+// totpIssuer 認証アプリに表示する発行者名（環境変数TOTP_ISSUER、未設定時はgo-study）
+func totpIssuer() string {
+    issuer := os.Getenv("TOTP_ISSUER")
+    if issuer == "" {
+        issuer = "go-study"
+    }
+    return issuer
+}
+
+// newOIDCProvider 環境変数からOIDCプロバイダーを設定する（OIDC_ISSUER未設定の場合は無効）
+func newOIDCProvider() *oidc.Provider {
+    issuer := os.Getenv("OIDC_ISSUER")
+    if issuer == "" {
+        return nil
+    }
+
+    clientID := os.Getenv("OIDC_CLIENT_ID")
+    redirectURL := os.Getenv("OIDC_REDIRECT_URL")
+    if clientID == "" || redirectURL == "" {
+        log.Fatalf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER is set")
+    }
+
+    scopes := os.Getenv("OIDC_SCOPES")
+    if scopes == "" {
+        scopes = "email profile"
+    }
+
+    return oidc.NewProvider(oidc.Config{
+        Issuer:       issuer,
+        ClientID:     clientID,
+        ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+        RedirectURL:  redirectURL,
+        Scopes:       strings.Fields(scopes),
+    })
+}
+
+// newStorage 環境変数STORAGE_DRIVERに応じて動画の保存先を選択する
+func newStorage() storage.Storage {
+    switch os.Getenv("STORAGE_DRIVER") {
+    case "s3":
+        // MinIOなどS3互換のストレージはS3_ENDPOINTとS3_USE_PATH_STYLE=trueを指定する
+        usePathStyle, _ := strconv.ParseBool(os.Getenv("S3_USE_PATH_STYLE"))
+        store, err := storage.NewS3Storage(storage.S3Config{
+            Endpoint:        os.Getenv("S3_ENDPOINT"),
+            Region:          os.Getenv("S3_REGION"),
+            Bucket:          os.Getenv("S3_BUCKET"),
+            AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+            SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+            UsePathStyle:    usePathStyle,
+        })
+        if err != nil {
+            log.Fatalf("Failed to initialize s3 storage: %v", err)
+        }
+        return store
+    default:
+        dir := os.Getenv("STORAGE_DIR")
+        if dir == "" {
+            dir = "uploads"
+        }
+        store, err := storage.NewLocalStorage(dir)
+        if err != nil {
+            log.Fatalf("Failed to initialize local storage: %v", err)
+        }
+        return store
+    }
+}
+
+// newPartialStore 再開可能なアップロードの受信途中のファイルを置くディレクトリ（環境変数VIDEO_UPLOAD_PARTIAL_DIR、未設定時はuploads-partial）
+// 複数のインスタンスで動かす場合は、同じアップロードへのリクエストがどのインスタンスに届いても続きを書き込めるよう共有のディレクトリ（NFSなど）を指定する
+func newPartialStore() *storage.PartialStore {
+    dir := os.Getenv("VIDEO_UPLOAD_PARTIAL_DIR")
+    if dir == "" {
+        dir = "uploads-partial"
+    }
+    partials, err := storage.NewPartialStore(dir)
+    if err != nil {
+        log.Fatalf("Failed to initialize partial upload directory: %v", err)
+    }
+    return partials
+}
+
+// videoMaxUploadBytes アップロードできる動画の最大サイズ（環境変数VIDEO_MAX_UPLOAD_BYTES、未設定時は2GiB）
+func videoMaxUploadBytes() int64 {
+    value := os.Getenv("VIDEO_MAX_UPLOAD_BYTES")
+    if value == "" {
+        return 2 << 30
+    }
+
+    maxBytes, err := strconv.ParseInt(value, 10, 64)
+    if err != nil || maxBytes <= 0 {
+        log.Fatalf("VIDEO_MAX_UPLOAD_BYTES must be a positive integer")
+    }
+    return maxBytes
+}
@@ -0,0 +1,116 @@
+// roster-import OneRoster形式の名簿CSVをインポートするコマンド
+//
+// 既定ではドライランとして差分レポートのみを出力し、-applyを指定した場合のみ適用する。
+// エラーのある行が1つでもある場合は何も適用せず、終了コード1で終了する。
+//
+//	go run ./cmd/roster-import -dir ./oneroster          # users.csv などをディレクトリから読み込む
+//	go run ./cmd/roster-import -users users.csv -apply  # ファイルを個別に指定する
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/tomoki-den-uhd/go-study/internal/mail"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"github.com/tomoki-den-uhd/go-study/internal/roster"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+func main() {
+	dir := flag.String("dir", "", "directory containing users.csv, classes.csv, enrollments.csv and guardians.csv")
+	usersPath := flag.String("users", "", "path to users.csv")
+	classesPath := flag.String("classes", "", "path to classes.csv")
+	enrollmentsPath := flag.String("enrollments", "", "path to enrollments.csv")
+	guardiansPath := flag.String("guardians", "", "path to guardians.csv")
+	apply := flag.Bool("apply", false, "apply the changes (default is a dry run)")
+	flag.Parse()
+
+	// .envがある場合は読み込む（環境変数で直接指定してもよい）
+	_ = godotenv.Load(".env")
+
+	// 読み込むCSVを開く
+	var files roster.Files
+	targets := []struct {
+		path   string
+		name   string
+		reader *io.Reader
+	}{
+		{*usersPath, models.RosterFileUsers, &files.Users},
+		{*classesPath, models.RosterFileClasses, &files.Classes},
+		{*enrollmentsPath, models.RosterFileEnrollments, &files.Enrollments},
+		{*guardiansPath, models.RosterFileGuardians, &files.Guardians},
+	}
+
+	opened := 0
+	for _, target := range targets {
+		path := target.path
+		if path == "" && *dir != "" {
+			path = filepath.Join(*dir, target.name)
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				continue
+			}
+		}
+		if path == "" {
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", path, err)
+		}
+		defer file.Close()
+
+		*target.reader = file
+		opened++
+	}
+
+	if opened == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// DB接続
+	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s",
+		os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	// 依存関係の注入
+	userRepo := repositories.NewUserRepository(pool)
+	sessionRepo := repositories.NewSessionRepository(pool)
+	auditService := services.NewAuditService(repositories.NewAuditLogRepository(pool), userRepo)
+	userService := services.NewUserService(userRepo, sessionRepo, mail.NewLogMailer(), auditService)
+	rosterImportService := services.NewRosterImportService(repositories.NewRosterRepository(pool), sessionRepo, userService, auditService)
+
+	// 監査ログにはコマンドからの実行であることをリクエストIDとして記録する
+	audit := models.AuditContext{RequestID: "roster-import-" + time.Now().Format("20060102T150405")}
+
+	report, err := rosterImportService.Import(files, !*apply, audit)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
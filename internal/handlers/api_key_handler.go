@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// APIKeyHandler APIキーハンドラーの構造体
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+// NewAPIKeyHandler APIキーハンドラーのコンストラクタ
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKeyHandler APIキー発行のハンドラー（管理者用）
+func (h *APIKeyHandler) CreateAPIKeyHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.APIKeyCreateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してキーを発行
+	response, err := h.apiKeyService.CreateAPIKey(userID, &request, auditContext(c))
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListAPIKeysHandler APIキー一覧取得のハンドラー（管理者用）
+func (h *APIKeyHandler) ListAPIKeysHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.APIKeyListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して一覧を取得
+	response, err := h.apiKeyService.ListAPIKeys(userID, &request)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKeyHandler APIキー無効化のハンドラー（管理者用）
+func (h *APIKeyHandler) RevokeAPIKeyHandler(c echo.Context) error {
+	// パスパラメータからAPIキーIDを取得
+	apiKeyID := c.Param("api_key_id")
+	if apiKeyID == "" {
+		errorResponse := models.MissingRequiredResponse("api_key_id")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してキーを無効化
+	if err := h.apiKeyService.RevokeAPIKey(userID, apiKeyID, auditContext(c)); err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "APIキーを無効化しました"))
+}
+
+// apiKeyError APIキー管理のエラーをレスポンスに変換する
+func apiKeyError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "user not found"), strings.Contains(errorMsg, "api key not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// AttendanceHandler 出席ハンドラーの構造体
+type AttendanceHandler struct {
+	attendanceService *services.AttendanceService
+}
+
+// NewAttendanceHandler 出席ハンドラーのコンストラクタ
+func NewAttendanceHandler(attendanceService *services.AttendanceService) *AttendanceHandler {
+	return &AttendanceHandler{
+		attendanceService: attendanceService,
+	}
+}
+
+// ListAttendancesHandler 出席一覧取得のハンドラー
+func (h *AttendanceHandler) ListAttendancesHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.AttendanceListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して出席一覧を取得
+	response, err := h.attendanceService.ListAttendances(userID, &request)
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+			return c.JSON(http.StatusForbidden, errorResponse)
+		case strings.Contains(errorMsg, "course not found"):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+			return c.JSON(http.StatusNotFound, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
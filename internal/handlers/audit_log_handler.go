@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// AuditLogHandler 監査ログハンドラーの構造体
+type AuditLogHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditLogHandler 監査ログハンドラーのコンストラクタ
+func NewAuditLogHandler(auditService *services.AuditService) *AuditLogHandler {
+	return &AuditLogHandler{
+		auditService: auditService,
+	}
+}
+
+// ListAuditLogsHandler 監査ログ検索のハンドラー（管理者用）
+func (h *AuditLogHandler) ListAuditLogsHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.AuditLogListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して監査ログを検索
+	response, err := h.auditService.ListAuditLogs(userID, &request)
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+			return c.JSON(http.StatusForbidden, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
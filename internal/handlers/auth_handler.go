@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/middleware"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// AuthHandler 認証ハンドラーの構造体
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+// NewAuthHandler 認証ハンドラーのコンストラクタ
+func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// LoginHandler ログインのハンドラー
+func (h *AuthHandler) LoginHandler(c echo.Context) error {
+	// リクエストボディをパース
+	var request models.UserLoginRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して認証
+	response, err := h.authService.Login(&request, middleware.ClientIP(c))
+	if err != nil {
+		return loginError(c, err)
+	}
+
+	// ログイン結果をJSON形式で返す
+	return c.JSON(http.StatusOK, response)
+}
+
+// VerifyTwoFactorHandler ログイン時の二要素認証のハンドラー
+func (h *AuthHandler) VerifyTwoFactorHandler(c echo.Context) error {
+	// リクエストボディをパース
+	var request models.TwoFactorLoginRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して認証コードを検証
+	response, err := h.authService.VerifyTwoFactorLogin(&request, middleware.ClientIP(c))
+	if err != nil {
+		return loginError(c, err)
+	}
+
+	// ログイン結果をJSON形式で返す
+	return c.JSON(http.StatusOK, response)
+}
+
+// RefreshHandler トークン再発行のハンドラー
+func (h *AuthHandler) RefreshHandler(c echo.Context) error {
+	// リクエストボディをパース
+	var request models.RefreshTokenRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してトークンを再発行
+	response, err := h.authService.Refresh(&request)
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case errorMsg == models.ErrorMessageUnauthorized:
+			return unauthorized(c)
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	// 新しいトークンをJSON形式で返す
+	return c.JSON(http.StatusOK, response)
+}
+
+// LogoutHandler ログアウトのハンドラー
+func (h *AuthHandler) LogoutHandler(c echo.Context) error {
+	// 認証済みプリンシパルをコンテキストから取得
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してセッションを無効化
+	if err := h.authService.Logout(principal); err != nil {
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, err.Error())
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "ログアウトしました"))
+}
+
+// RevokeUserSessionsHandler ユーザーの全セッション無効化のハンドラー（管理者用）
+func (h *AuthHandler) RevokeUserSessionsHandler(c echo.Context) error {
+	// パスパラメータからユーザーIDを取得
+	targetUserID := c.Param("user_id")
+	if targetUserID == "" {
+		errorResponse := models.MissingRequiredResponse("user_id")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// 認証済みプリンシパルをコンテキストから取得
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してセッションを無効化
+	response, err := h.authService.RevokeUserSessions(principal, targetUserID, auditContext(c))
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+			return c.JSON(http.StatusForbidden, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// currentUserID 認証済みプリンシパルのユーザーIDを文字列で取得する
+func currentUserID(c echo.Context) (string, bool) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		return "", false
+	}
+	return strconv.Itoa(principal.UserID), true
+}
+
+// auditContext 監査ログに記録する操作者とリクエストの情報を取得する
+func auditContext(c echo.Context) models.AuditContext {
+	audit := models.AuditContext{
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		IPAddress: middleware.ClientIP(c),
+	}
+	if principal, ok := middleware.GetPrincipal(c); ok {
+		audit.ActorUserID = principal.UserID
+		audit.APIKeyID = principal.APIKeyID
+	}
+	return audit
+}
+
+// unauthorized 401 Unauthorizedレスポンスを返す
+func unauthorized(c echo.Context) error {
+	errorResponse := models.NewErrorResponse(models.ErrorCodeUnauthorized, models.ErrorMessageUnauthorized, "")
+	return c.JSON(http.StatusUnauthorized, errorResponse)
+}
+
+// loginError ログイン処理のエラーをレスポンスに変換する
+func loginError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	// ログイン試行制限中の場合は再試行までの秒数を返す
+	var throttleErr *services.ThrottleError
+	if errors.As(err, &throttleErr) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(throttleErr.RetryAfter.Seconds())))
+		errorResponse := models.NewErrorResponse(models.ErrorCodeTooManyRequests, models.ErrorMessageTooManyRequests, errorMsg)
+		return c.JSON(http.StatusTooManyRequests, errorResponse)
+	}
+
+	switch {
+	case errorMsg == models.ErrorMessageUnauthorized:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeUnauthorized, models.ErrorMessageUnauthorized, "")
+		return c.JSON(http.StatusUnauthorized, errorResponse)
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// CalendarFeedHandler カレンダー購読ハンドラーの構造体
+type CalendarFeedHandler struct {
+	feedService *services.CalendarFeedService
+}
+
+// NewCalendarFeedHandler カレンダー購読ハンドラーのコンストラクタ
+func NewCalendarFeedHandler(feedService *services.CalendarFeedService) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		feedService: feedService,
+	}
+}
+
+// GetFeedHandler 自分のカレンダー購読の発行状況取得のハンドラー
+func (h *CalendarFeedHandler) GetFeedHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して発行状況を取得
+	response, err := h.feedService.GetFeed(userID)
+	if err != nil {
+		return calendarFeedError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CreateFeedHandler 自分のカレンダー購読のURL発行（再発行）のハンドラー
+func (h *CalendarFeedHandler) CreateFeedHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してURLを発行
+	response, err := h.feedService.CreateFeed(userID, auditContext(c))
+	if err != nil {
+		return calendarFeedError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// RevokeFeedHandler 自分のカレンダー購読削除のハンドラー
+func (h *CalendarFeedHandler) RevokeFeedHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して購読を削除
+	if err := h.feedService.RevokeFeed(userID, auditContext(c)); err != nil {
+		return calendarFeedError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "カレンダー購読を削除しました"))
+}
+
+// DownloadFeedHandler カレンダー購読（.ics）のハンドラー（認証不要、URLのトークンで利用者を特定する）
+func (h *CalendarFeedHandler) DownloadFeedHandler(c echo.Context) error {
+	// パスパラメータからトークンを取得（拡張子.icsは省略可）
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	// サービスクラスを呼び出してiCalendarを作成
+	body, err := h.feedService.RenderFeed(token)
+	if err != nil {
+		return calendarFeedError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="go-study.ics"`)
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", body)
+}
+
+// calendarFeedError カレンダー購読サービスのエラーを適切なHTTPステータスコードで返す
+func calendarFeedError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "calendar feed not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
@@ -1,9 +1,7 @@
 package handlers
 
 import (
-	"bytes"
-	"fmt"
-	"io"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -26,35 +24,28 @@ func NewCourseHandler(courseService *services.CourseService) *CourseHandler {
 
 // CreateCourseHandler 授業登録のハンドラー
 func (h *CourseHandler) CreateCourseHandler(c echo.Context) error {
-	// ユーザーIDをリクエストヘッダーから取得
-	userID := c.Request().Header.Get("X-User-Id")
-	if userID == "" {
-		errorResponse := models.MissingRequiredResponse("X-User-Id")
-		return c.JSON(http.StatusBadRequest, errorResponse)
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
 	}
 
 	// リクエストボディをパース
 	var request models.CreateCourseRequest
-	
-	// デバッグ用：リクエストボディの内容をログ出力
-	bodyBytes, _ := io.ReadAll(c.Request().Body)
-	fmt.Printf("DEBUG: Request Body: %s\n", string(bodyBytes))
-	
-	// ボディを再度設定（io.ReadAllで消費されるため）
-	c.Request().Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-	
 	if err := c.Bind(&request); err != nil {
 		errorResponse := models.InvalidFormatResponse("request body", err.Error())
 		return c.JSON(http.StatusBadRequest, errorResponse)
 	}
-	
-	// デバッグ用：パースされた構造体の内容をログ出力
-	fmt.Printf("DEBUG: Parsed Request - Title: %s, Description: %s, SubjectID: %d, ScheduledAt: %v\n", 
-		request.Title, request.Description, request.SubjectID, request.ScheduledAt)
 
 	// サービスクラスを呼び出して授業を登録
-	response, err := h.courseService.CreateCourse(&request, userID)
+	response, err := h.courseService.CreateCourse(&request, userID, auditContext(c))
 	if err != nil {
+		// 予定が重複している場合は重複の一覧を返す
+		var conflictErr *services.ScheduleConflictError
+		if errors.As(err, &conflictErr) {
+			return scheduleConflictError(c, conflictErr)
+		}
+
 		// エラーメッセージに基づいて適切なHTTPステータスコードを返す
 		errorMsg := err.Error()
 		
@@ -65,6 +56,9 @@ func (h *CourseHandler) CreateCourseHandler(c echo.Context) error {
 		case errorMsg == "教科情報が存在しません" || strings.HasPrefix(errorMsg, "入力値エラーがあります"):
 			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
 			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+			return c.JSON(http.StatusForbidden, errorResponse)
 		default:
 			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
 			return c.JSON(http.StatusInternalServerError, errorResponse)
@@ -84,35 +78,28 @@ func (h *CourseHandler) UpdateCourseHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, errorResponse)
 	}
 
-	// ユーザーIDをリクエストヘッダーから取得
-	userID := c.Request().Header.Get("X-User-Id")
-	if userID == "" {
-		errorResponse := models.MissingRequiredResponse("X-User-Id")
-		return c.JSON(http.StatusBadRequest, errorResponse)
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
 	}
 
 	// リクエストボディをパース
 	var request models.UpdateCourseRequest
-	
-	// デバッグ用：リクエストボディの内容をログ出力
-	bodyBytes, _ := io.ReadAll(c.Request().Body)
-	fmt.Printf("DEBUG: Request Body: %s\n", string(bodyBytes))
-	
-	// ボディを再度設定（io.ReadAllで消費されるため）
-	c.Request().Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-	
 	if err := c.Bind(&request); err != nil {
 		errorResponse := models.InvalidFormatResponse("request body", err.Error())
 		return c.JSON(http.StatusBadRequest, errorResponse)
 	}
-	
-	// デバッグ用：パースされた構造体の内容をログ出力
-	fmt.Printf("DEBUG: Parsed Request - Title: %s, Description: %s, SubjectID: %d, ScheduledAt: %v\n", 
-		request.Title, request.Description, request.SubjectID, request.ScheduledAt)
 
 	// サービスクラスを呼び出して授業を更新
-	response, err := h.courseService.UpdateCourse(courseID, &request, userID)
+	response, err := h.courseService.UpdateCourse(courseID, &request, userID, auditContext(c))
 	if err != nil {
+		// 予定が重複している場合は重複の一覧を返す
+		var conflictErr *services.ScheduleConflictError
+		if errors.As(err, &conflictErr) {
+			return scheduleConflictError(c, conflictErr)
+		}
+
 		// エラーメッセージに基づいて適切なHTTPステータスコードを返す
 		errorMsg := err.Error()
 		
@@ -123,7 +110,7 @@ func (h *CourseHandler) UpdateCourseHandler(c echo.Context) error {
 		case errorMsg == "教科情報が存在しません" || strings.HasPrefix(errorMsg, "入力値エラーがあります"):
 			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
 			return c.JSON(http.StatusBadRequest, errorResponse)
-		case strings.Contains(errorMsg, "you can only update your own courses"):
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
 			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
 			return c.JSON(http.StatusForbidden, errorResponse)
 		case strings.Contains(errorMsg, "course not found"):
@@ -137,4 +124,109 @@ func (h *CourseHandler) UpdateCourseHandler(c echo.Context) error {
 
 	// 授業更新結果をJSON形式で返す
 	return c.JSON(http.StatusOK, response)
-} 
\ No newline at end of file
+} 
+
+// ListCoursesHandler 授業一覧取得のハンドラー
+func (h *CourseHandler) ListCoursesHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.CourseListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して授業一覧を取得
+	response, err := h.courseService.ListCourses(userID, &request)
+	if err != nil {
+		return courseError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetCourseHandler 授業詳細取得のハンドラー
+func (h *CourseHandler) GetCourseHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して授業詳細を取得
+	response, err := h.courseService.GetCourse(userID, c.Param("course_id"))
+	if err != nil {
+		return courseError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteCourseHandler 授業削除のハンドラー（?force=trueで成績があっても削除する）
+func (h *CourseHandler) DeleteCourseHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.DeleteCourseRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して授業を削除
+	response, err := h.courseService.DeleteCourse(userID, c.Param("course_id"), &request, auditContext(c))
+	if err != nil {
+		return courseError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RestoreCourseHandler 授業復元のハンドラー
+func (h *CourseHandler) RestoreCourseHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して授業を復元
+	response, err := h.courseService.RestoreCourse(userID, c.Param("course_id"), auditContext(c))
+	if err != nil {
+		return courseError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// courseError 授業サービスのエラーをHTTPレスポンスに変換する
+func courseError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case errorMsg == "教科情報が存在しません" || strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "course not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	case strings.Contains(errorMsg, "course has graded work") || strings.Contains(errorMsg, "course not deleted"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
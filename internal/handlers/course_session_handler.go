@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// CourseSessionHandler 授業回ハンドラーの構造体
+type CourseSessionHandler struct {
+	sessionService *services.CourseSessionService
+}
+
+// NewCourseSessionHandler 授業回ハンドラーのコンストラクタ
+func NewCourseSessionHandler(sessionService *services.CourseSessionService) *CourseSessionHandler {
+	return &CourseSessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// ListSessionsHandler 授業回一覧取得のハンドラー
+func (h *CourseSessionHandler) ListSessionsHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.CourseSessionListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して授業回一覧を取得
+	response, err := h.sessionService.ListSessions(userID, c.Param("course_id"), &request)
+	if err != nil {
+		return courseSessionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// UpdateSessionHandler 授業回の個別変更（移動・休講）のハンドラー
+func (h *CourseSessionHandler) UpdateSessionHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.CourseSessionUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して授業回を変更
+	response, err := h.sessionService.UpdateSession(userID, c.Param("course_id"), c.Param("session_id"), &request, auditContext(c))
+	if err != nil {
+		return courseSessionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListSessionAttendancesHandler 授業回の出席一覧取得のハンドラー
+func (h *CourseSessionHandler) ListSessionAttendancesHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して出席一覧を取得
+	response, err := h.sessionService.ListSessionAttendances(userID, c.Param("course_id"), c.Param("session_id"))
+	if err != nil {
+		return courseSessionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RecordSessionAttendancesHandler 授業回の出席記録のハンドラー
+func (h *CourseSessionHandler) RecordSessionAttendancesHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.SessionAttendanceRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して出席を記録
+	response, err := h.sessionService.RecordSessionAttendances(userID, c.Param("course_id"), c.Param("session_id"), &request, auditContext(c))
+	if err != nil {
+		return courseSessionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// courseSessionError 授業回サービスのエラーを適切なHTTPステータスコードで返す
+func courseSessionError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	// 予定が重複している場合は重複の一覧を返す
+	var conflictErr *services.ScheduleConflictError
+	if errors.As(err, &conflictErr) {
+		return scheduleConflictError(c, conflictErr)
+	}
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "course not found") || strings.Contains(errorMsg, "session not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	case strings.Contains(errorMsg, "session cancelled"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
+
+// scheduleConflictError 予定の重複エラーを重複の一覧を含む409 Conflictで返す
+func scheduleConflictError(c echo.Context, conflictErr *services.ScheduleConflictError) error {
+	errorResponse := models.ScheduleConflictResponse(conflictErr.Error(), conflictErr.Total, conflictErr.Conflicts)
+	return c.JSON(http.StatusConflict, errorResponse)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// CourseStaffHandler 授業の担当教師ハンドラーの構造体
+type CourseStaffHandler struct {
+	staffService *services.CourseStaffService
+}
+
+// NewCourseStaffHandler 授業の担当教師ハンドラーのコンストラクタ
+func NewCourseStaffHandler(staffService *services.CourseStaffService) *CourseStaffHandler {
+	return &CourseStaffHandler{
+		staffService: staffService,
+	}
+}
+
+// ListStaffHandler 担当教師一覧取得のハンドラー
+func (h *CourseStaffHandler) ListStaffHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して担当教師一覧を取得
+	response, err := h.staffService.ListStaff(userID, c.Param("course_id"))
+	if err != nil {
+		return courseStaffError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// AddStaffHandler 担当教師追加のハンドラー
+func (h *CourseStaffHandler) AddStaffHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.CourseStaffCreateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して担当教師を追加
+	response, err := h.staffService.AddStaff(userID, c.Param("course_id"), &request, auditContext(c))
+	if err != nil {
+		return courseStaffError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// UpdateStaffHandler 担当変更（主担当の交代を含む）のハンドラー
+func (h *CourseStaffHandler) UpdateStaffHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.CourseStaffUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して担当を変更
+	response, err := h.staffService.UpdateStaff(userID, c.Param("course_id"), c.Param("user_id"), &request, auditContext(c))
+	if err != nil {
+		return courseStaffError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RemoveStaffHandler 担当教師削除のハンドラー
+func (h *CourseStaffHandler) RemoveStaffHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して担当から外す
+	if err := h.staffService.RemoveStaff(userID, c.Param("course_id"), c.Param("user_id"), auditContext(c)); err != nil {
+		return courseStaffError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "担当教師を削除しました"))
+}
+
+// courseStaffError 授業の担当教師サービスのエラーを適切なHTTPステータスコードで返す
+func courseStaffError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "course not found") || strings.Contains(errorMsg, "staff not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	case strings.Contains(errorMsg, "staff already exists") || strings.Contains(errorMsg, "course must have an owner"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
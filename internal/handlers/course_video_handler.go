@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// multipartOverheadBytes 動画以外のmultipartの境界・ヘッダー分として許容するサイズ
+const multipartOverheadBytes = 1 << 20
+
+// CourseVideoHandler 授業動画ハンドラーの構造体
+type CourseVideoHandler struct {
+	videoService *services.CourseVideoService
+}
+
+// NewCourseVideoHandler 授業動画ハンドラーのコンストラクタ
+func NewCourseVideoHandler(videoService *services.CourseVideoService) *CourseVideoHandler {
+	return &CourseVideoHandler{
+		videoService: videoService,
+	}
+}
+
+// UploadVideoHandler 授業動画アップロードのハンドラー
+// multipart/form-dataのfileに動画を、duration_secondsに動画の長さ（秒）を指定する
+func (h *CourseVideoHandler) UploadVideoHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// 上限を超えるリクエストは読み込みを打ち切る
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, h.videoService.MaxUploadBytes()+multipartOverheadBytes)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			errorResponse := models.NewErrorResponse(models.ErrorCodePayloadTooLarge, models.ErrorMessagePayloadTooLarge, err.Error())
+			return c.JSON(http.StatusRequestEntityTooLarge, errorResponse)
+		}
+		if errors.Is(err, http.ErrMissingFile) {
+			errorResponse := models.MissingRequiredResponse("file")
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+		errorResponse := models.InvalidFormatResponse("file", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, err.Error())
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+	defer file.Close()
+
+	// サービスクラスを呼び出して動画を保存
+	response, err := h.videoService.UploadVideo(c.Request().Context(), userID, c.Param("course_id"), fileHeader.Filename, c.FormValue("duration_seconds"), file, fileHeader.Size, auditContext(c))
+	if err != nil {
+		return courseVideoError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListVideosHandler 授業動画一覧取得のハンドラー
+func (h *CourseVideoHandler) ListVideosHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して動画一覧を取得
+	response, err := h.videoService.ListVideos(userID, c.Param("course_id"))
+	if err != nil {
+		return courseVideoError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// StreamVideoHandler 授業動画配信のハンドラー（Rangeリクエストに対応）
+func (h *CourseVideoHandler) StreamVideoHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して動画を開く
+	stream, err := h.videoService.OpenVideo(c.Request().Context(), userID, c.Param("course_id"), c.Param("video_id"))
+	if err != nil {
+		return courseVideoError(c, err)
+	}
+	defer stream.Content.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, stream.Video.ContentType)
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("inline", map[string]string{"filename": stream.Video.Filename}))
+	// 受講者以外に共有キャッシュから配信されないようにする
+	header.Set("Cache-Control", "private, no-cache")
+
+	// Range・If-Range・HEADの処理はServeContentに任せる
+	http.ServeContent(c.Response(), c.Request(), stream.Video.Filename, stream.ModTime, stream.Content)
+	return nil
+}
+
+// UpdateVideoHandler 授業動画の長さを設定するハンドラー
+func (h *CourseVideoHandler) UpdateVideoHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.CourseVideoUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して動画を更新
+	response, err := h.videoService.UpdateVideo(userID, c.Param("course_id"), c.Param("video_id"), &request, auditContext(c))
+	if err != nil {
+		return courseVideoError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteVideoHandler 授業動画削除のハンドラー
+func (h *CourseVideoHandler) DeleteVideoHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して動画を削除
+	if err := h.videoService.DeleteVideo(userID, c.Param("course_id"), c.Param("video_id"), auditContext(c)); err != nil {
+		return courseVideoError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "動画を削除しました"))
+}
+
+// courseVideoError 授業動画サービスのエラーを適切なHTTPステータスコードで返す
+func courseVideoError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "course not found") || strings.Contains(errorMsg, "video not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	case strings.Contains(errorMsg, "video too large"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodePayloadTooLarge, models.ErrorMessagePayloadTooLarge, errorMsg)
+		return c.JSON(http.StatusRequestEntityTooLarge, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// EnrollmentHandler 受講登録ハンドラーの構造体
+type EnrollmentHandler struct {
+	enrollmentService *services.EnrollmentService
+}
+
+// NewEnrollmentHandler 受講登録ハンドラーのコンストラクタ
+func NewEnrollmentHandler(enrollmentService *services.EnrollmentService) *EnrollmentHandler {
+	return &EnrollmentHandler{
+		enrollmentService: enrollmentService,
+	}
+}
+
+// ListEnrollmentsHandler 受講者一覧取得のハンドラー
+func (h *EnrollmentHandler) ListEnrollmentsHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.EnrollmentListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して受講者一覧を取得
+	response, err := h.enrollmentService.ListEnrollments(userID, c.Param("course_id"), &request)
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// EnrollHandler 受講登録のハンドラー
+func (h *EnrollmentHandler) EnrollHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.EnrollmentCreateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して受講登録
+	response, err := h.enrollmentService.Enroll(userID, c.Param("course_id"), &request, auditContext(c))
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// UpdateEnrollmentHandler 受講状態変更のハンドラー
+func (h *EnrollmentHandler) UpdateEnrollmentHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.EnrollmentUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して受講状態を変更
+	response, err := h.enrollmentService.UpdateEnrollment(userID, c.Param("course_id"), c.Param("user_id"), &request, auditContext(c))
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// UnenrollHandler 受講取消のハンドラー
+func (h *EnrollmentHandler) UnenrollHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して受講登録を取り消す
+	response, err := h.enrollmentService.Unenroll(userID, c.Param("course_id"), c.Param("user_id"), auditContext(c))
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// enrollmentError 受講登録サービスのエラーを適切なHTTPステータスコードで返す
+func enrollmentError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "course not found") || strings.Contains(errorMsg, "enrollment not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	case strings.Contains(errorMsg, "student already enrolled"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
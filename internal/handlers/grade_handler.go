@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/tomoki-den-uhd/go-study/internal/models"
@@ -29,18 +30,29 @@ func (h *GradeHandler) GetGradeDetailHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, errorResponse)
 	}
 
-	// ユーザーIDをリクエストヘッダーから取得
-	userID := c.Request().Header.Get("X-User-Id")
-	if userID == "" {
-		errorResponse := models.MissingRequiredResponse("X-User-Id")
-		return c.JSON(http.StatusBadRequest, errorResponse)
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
 	}
 
 	// サービスクラスを呼び出して成績詳細を取得
 	gradeDetail, err := h.gradeService.GetGradeDetail(gradeID, userID)
 	if err != nil {
-		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, err.Error())
-		return c.JSON(http.StatusInternalServerError, errorResponse)
+		// エラーメッセージに基づいて適切なHTTPステータスコードを返す
+		errorMsg := err.Error()
+
+		switch {
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+			return c.JSON(http.StatusForbidden, errorResponse)
+		case strings.Contains(errorMsg, "no rows in result set"):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+			return c.JSON(http.StatusNotFound, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
 	}
 
 	// 成績詳細をJSON形式で返す
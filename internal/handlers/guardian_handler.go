@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// GuardianHandler 保護者紐付けハンドラーの構造体
+type GuardianHandler struct {
+	guardianService *services.GuardianService
+}
+
+// NewGuardianHandler 保護者紐付けハンドラーのコンストラクタ
+func NewGuardianHandler(guardianService *services.GuardianService) *GuardianHandler {
+	return &GuardianHandler{
+		guardianService: guardianService,
+	}
+}
+
+// CreateGuardianHandler 保護者紐付け登録のハンドラー（管理者用）
+func (h *GuardianHandler) CreateGuardianHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.GuardianCreateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して紐付けを登録
+	response, err := h.guardianService.CreateGuardian(userID, &request, auditContext(c))
+	if err != nil {
+		return guardianError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// DeleteGuardianHandler 保護者紐付け解除のハンドラー（管理者用）
+func (h *GuardianHandler) DeleteGuardianHandler(c echo.Context) error {
+	// パスパラメータから紐付けIDを取得
+	guardianID := c.Param("guardian_id")
+	if guardianID == "" {
+		errorResponse := models.MissingRequiredResponse("guardian_id")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して紐付けを解除
+	if err := h.guardianService.DeleteGuardian(userID, guardianID, auditContext(c)); err != nil {
+		return guardianError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "保護者の紐付けを解除しました"))
+}
+
+// ListStudentGuardiansHandler 学生の保護者一覧取得のハンドラー（管理者用）
+func (h *GuardianHandler) ListStudentGuardiansHandler(c echo.Context) error {
+	// パスパラメータから学生のユーザーIDを取得
+	studentUserID := c.Param("user_id")
+	if studentUserID == "" {
+		errorResponse := models.MissingRequiredResponse("user_id")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して保護者一覧を取得
+	response, err := h.guardianService.ListStudentGuardians(userID, studentUserID)
+	if err != nil {
+		return guardianError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListMyChildrenHandler 保護者本人の子ども一覧取得のハンドラー
+func (h *GuardianHandler) ListMyChildrenHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して子ども一覧を取得
+	response, err := h.guardianService.ListMyChildren(userID)
+	if err != nil {
+		return guardianError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// guardianError 保護者紐付け系のエラーを適切なHTTPステータスコードで返す
+func guardianError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "guardian already linked"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	case strings.Contains(errorMsg, "guardian not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// LoginThrottleHandler ログイン試行制限ハンドラーの構造体
+type LoginThrottleHandler struct {
+	throttleService *services.LoginThrottleService
+}
+
+// NewLoginThrottleHandler ログイン試行制限ハンドラーのコンストラクタ
+func NewLoginThrottleHandler(throttleService *services.LoginThrottleService) *LoginThrottleHandler {
+	return &LoginThrottleHandler{
+		throttleService: throttleService,
+	}
+}
+
+// UnlockUserHandler アカウントロック解除のハンドラー（管理者用）
+func (h *LoginThrottleHandler) UnlockUserHandler(c echo.Context) error {
+	// パスパラメータからユーザーIDを取得
+	targetUserID := c.Param("user_id")
+	if targetUserID == "" {
+		errorResponse := models.MissingRequiredResponse("user_id")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してロックを解除
+	wasLocked, err := h.throttleService.UnlockUser(userID, targetUserID, auditContext(c))
+	if err != nil {
+		return userAdminError(c, err)
+	}
+
+	message := "ロックされていないため、失敗回数のみリセットしました"
+	if wasLocked {
+		message = "アカウントのロックを解除しました"
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(map[string]interface{}{"was_locked": wasLocked}, message))
+}
+
+// ListLockoutEventsHandler ロックアウト記録一覧取得のハンドラー（管理者用）
+func (h *LoginThrottleHandler) ListLockoutEventsHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.LockoutEventListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してロックアウト記録を取得
+	response, err := h.throttleService.ListLockoutEvents(userID, &request)
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+			return c.JSON(http.StatusForbidden, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
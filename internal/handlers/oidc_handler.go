@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// oidcStateCookie OIDCログインを開始したブラウザに紐付けるstateのCookie名
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler OIDCログインハンドラーの構造体
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+}
+
+// NewOIDCHandler OIDCログインハンドラーのコンストラクタ
+func NewOIDCHandler(oidcService *services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+// AuthorizeHandler OIDCログイン開始のハンドラー
+func (h *OIDCHandler) AuthorizeHandler(c echo.Context) error {
+	// サービスクラスを呼び出して認可URLを生成
+	response, state, err := h.oidcService.StartLogin()
+	if err != nil {
+		return oidcError(c, err)
+	}
+
+	// stateをこのブラウザに紐付ける（コールバックで一致を確認する）
+	setOIDCStateCookie(c, state, response.ExpiresIn)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CallbackHandler OIDCログイン完了のハンドラー
+func (h *OIDCHandler) CallbackHandler(c echo.Context) error {
+	// リクエストボディをパース
+	var request models.OIDCCallbackRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// ログインを開始したブラウザのstateを取得（1回限りのため削除する）
+	boundState := ""
+	if cookie, err := c.Cookie(oidcStateCookie); err == nil {
+		boundState = cookie.Value
+	}
+	setOIDCStateCookie(c, "", -1)
+
+	// サービスクラスを呼び出してログイン
+	response, err := h.oidcService.CompleteLogin(&request, boundState)
+	if err != nil {
+		return oidcError(c, err)
+	}
+
+	// ログイン結果をJSON形式で返す
+	return c.JSON(http.StatusOK, response)
+}
+
+// setOIDCStateCookie stateのCookieを設定する（maxAgeが負の場合は削除する）
+// JavaScriptから読めず、他サイトからのリクエストでは送信されないようにする
+func setOIDCStateCookie(c echo.Context, state string, maxAge int) {
+	c.SetCookie(&http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// oidcError OIDCログインのエラーをレスポンスに変換する
+func oidcError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.Contains(errorMsg, "oidc is not configured"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, errorMsg)
+		return c.JSON(http.StatusNotFound, errorResponse)
+	case strings.HasPrefix(errorMsg, "oidc login failed"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeUnauthorized, models.ErrorMessageUnauthorized, errorMsg)
+		return c.JSON(http.StatusUnauthorized, errorResponse)
+	case strings.HasPrefix(errorMsg, "oidc provider error"):
+		errorResponse := models.NewErrorResponse(http.StatusBadGateway, http.StatusText(http.StatusBadGateway), errorMsg)
+		return c.JSON(http.StatusBadGateway, errorResponse)
+	default:
+		return loginError(c, err)
+	}
+}
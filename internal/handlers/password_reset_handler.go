@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/middleware"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// PasswordResetHandler パスワード再設定ハンドラーの構造体
+type PasswordResetHandler struct {
+	passwordResetService *services.PasswordResetService
+}
+
+// NewPasswordResetHandler パスワード再設定ハンドラーのコンストラクタ
+func NewPasswordResetHandler(passwordResetService *services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passwordResetService: passwordResetService,
+	}
+}
+
+// ForgotPasswordHandler パスワード再設定メール送信のハンドラー
+func (h *PasswordResetHandler) ForgotPasswordHandler(c echo.Context) error {
+	// リクエストボディをパース
+	var request models.ForgotPasswordRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して再設定メールを送信
+	if err := h.passwordResetService.RequestReset(&request, middleware.ClientIP(c)); err != nil {
+		errorMsg := err.Error()
+
+		// 送信要求が制限されている場合は再試行までの秒数を返す
+		var throttleErr *services.ThrottleError
+		if errors.As(err, &throttleErr) {
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(throttleErr.RetryAfter.Seconds())))
+			errorResponse := models.NewErrorResponse(models.ErrorCodeTooManyRequests, models.ErrorMessageTooManyRequests, errorMsg)
+			return c.JSON(http.StatusTooManyRequests, errorResponse)
+		}
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	// 登録有無にかかわらず同じレスポンスを返す
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "登録済みのメールアドレスの場合、パスワード再設定のご案内を送信しました"))
+}
+
+// ResetPasswordHandler パスワード再設定のハンドラー
+func (h *PasswordResetHandler) ResetPasswordHandler(c echo.Context) error {
+	// リクエストボディをパース
+	var request models.ResetPasswordRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してパスワードを再設定
+	if err := h.passwordResetService.ResetPassword(&request, auditContext(c)); err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "パスワードを再設定しました"))
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/roster"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// RosterImportHandler 名簿インポートハンドラーの構造体
+type RosterImportHandler struct {
+	rosterImportService *services.RosterImportService
+}
+
+// NewRosterImportHandler 名簿インポートハンドラーのコンストラクタ
+func NewRosterImportHandler(rosterImportService *services.RosterImportService) *RosterImportHandler {
+	return &RosterImportHandler{
+		rosterImportService: rosterImportService,
+	}
+}
+
+// ImportRosterHandler 名簿CSVインポートのハンドラー（管理者用）
+// multipart/form-dataのusers・classes・enrollments・guardiansにCSVを指定する（1つ以上）
+// dry_run=falseを指定した場合のみ適用し、それ以外は差分レポートのみ返す
+func (h *RosterImportHandler) ImportRosterHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	dryRun := true
+	if value := c.FormValue("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			errorResponse := models.InvalidFormatResponse("dry_run", err.Error())
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+		dryRun = parsed
+	}
+
+	// アップロードされたCSVを開く
+	var files roster.Files
+	targets := []struct {
+		field  string
+		reader *io.Reader
+	}{
+		{"users", &files.Users},
+		{"classes", &files.Classes},
+		{"enrollments", &files.Enrollments},
+		{"guardians", &files.Guardians},
+	}
+
+	uploaded := 0
+	for _, target := range targets {
+		fileHeader, err := c.FormFile(target.field)
+		if errors.Is(err, http.ErrMissingFile) {
+			continue
+		}
+		if err != nil {
+			errorResponse := models.InvalidFormatResponse(target.field, err.Error())
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, err.Error())
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+		defer file.Close()
+
+		*target.reader = file
+		uploaded++
+	}
+
+	if uploaded == 0 {
+		errorResponse := models.MissingRequiredResponse("users, classes, enrollments or guardians")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してインポート
+	report, err := h.rosterImportService.ImportRoster(userID, files, dryRun, auditContext(c))
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+			return c.JSON(http.StatusForbidden, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	// 適用を指定したがエラーのため適用しなかった場合は422でレポートを返す
+	if !dryRun && !report.Applied {
+		return c.JSON(http.StatusUnprocessableEntity, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
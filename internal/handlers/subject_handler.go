@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// SubjectHandler 教科ハンドラーの構造体
+type SubjectHandler struct {
+	subjectService *services.SubjectService
+}
+
+// NewSubjectHandler 教科ハンドラーのコンストラクタ
+func NewSubjectHandler(subjectService *services.SubjectService) *SubjectHandler {
+	return &SubjectHandler{
+		subjectService: subjectService,
+	}
+}
+
+// ListSubjectsHandler 教科一覧取得のハンドラー（管理者用）
+func (h *SubjectHandler) ListSubjectsHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.SubjectListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して教科一覧を取得
+	response, err := h.subjectService.ListSubjects(userID, &request)
+	if err != nil {
+		return subjectError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CreateSubjectHandler 教科登録のハンドラー（管理者用）
+func (h *SubjectHandler) CreateSubjectHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.SubjectRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して教科を登録
+	response, err := h.subjectService.CreateSubject(userID, &request, auditContext(c))
+	if err != nil {
+		return subjectError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// RenameSubjectHandler 教科名変更のハンドラー（管理者用）
+func (h *SubjectHandler) RenameSubjectHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.SubjectRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して教科名を変更
+	response, err := h.subjectService.RenameSubject(userID, c.Param("subject_id"), &request, auditContext(c))
+	if err != nil {
+		return subjectError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteSubjectHandler 教科削除のハンドラー（管理者用）
+func (h *SubjectHandler) DeleteSubjectHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して教科を削除
+	if err := h.subjectService.DeleteSubject(userID, c.Param("subject_id"), auditContext(c)); err != nil {
+		return subjectError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "教科を削除しました"))
+}
+
+// RestoreSubjectHandler 教科復元のハンドラー（管理者用）
+func (h *SubjectHandler) RestoreSubjectHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して教科を復元
+	response, err := h.subjectService.RestoreSubject(userID, c.Param("subject_id"), auditContext(c))
+	if err != nil {
+		return subjectError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// subjectError 教科サービスのエラーを適切なHTTPステータスコードで返す
+func subjectError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "subject not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	case strings.Contains(errorMsg, "subject name already exists") ||
+		strings.Contains(errorMsg, "subject in use") ||
+		strings.Contains(errorMsg, "subject not deleted"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
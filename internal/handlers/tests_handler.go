@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/tomoki-den-uhd/go-study/internal/models"
@@ -22,18 +23,28 @@ func NewTestHandler(testService *services.TestService) *TestHandler {
 
 // GetTestsHandler 小テスト一覧取得のハンドラー
 func (h *TestHandler) GetTestsHandler(c echo.Context) error {
-	// ユーザーIDをリクエストヘッダーから取得
-	userID := c.Request().Header.Get("X-User-Id")
-	if userID == "" {
-		errorResponse := models.MissingRequiredResponse("X-User-Id")
-		return c.JSON(http.StatusBadRequest, errorResponse)
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
 	}
 
-	// サービスクラスを呼び出してテスト一覧を取得
-	tests, err := h.testService.GetTests(userID)
+	// サービスクラスを呼び出してテスト一覧を取得（保護者は対象の学生を指定）
+	tests, err := h.testService.GetTests(userID, c.QueryParam("student_user_id"))
 	if err != nil {
-		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, err.Error())
-		return c.JSON(http.StatusInternalServerError, errorResponse)
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+			return c.JSON(http.StatusForbidden, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
 	}
 
 	// テストの一覧をJSON形式で返す
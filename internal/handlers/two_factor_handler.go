@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// TwoFactorHandler 二要素認証ハンドラーの構造体
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+}
+
+// NewTwoFactorHandler 二要素認証ハンドラーのコンストラクタ
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// GetStatusHandler 自分の二要素認証の状態取得のハンドラー
+func (h *TwoFactorHandler) GetStatusHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	response, err := h.twoFactorService.GetStatus(userID)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// EnrollHandler 二要素認証の登録開始のハンドラー
+func (h *TwoFactorHandler) EnrollHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してシークレットを発行
+	response, err := h.twoFactorService.Enroll(userID, auditContext(c))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ConfirmHandler 二要素認証の登録完了のハンドラー
+func (h *TwoFactorHandler) ConfirmHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.TwoFactorCodeRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して有効化
+	response, err := h.twoFactorService.Confirm(userID, &request, auditContext(c))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RegenerateRecoveryCodesHandler リカバリーコード再発行のハンドラー
+func (h *TwoFactorHandler) RegenerateRecoveryCodesHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.TwoFactorCodeRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して再発行
+	response, err := h.twoFactorService.RegenerateRecoveryCodes(userID, &request, auditContext(c))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DisableHandler 自分の二要素認証無効化のハンドラー
+func (h *TwoFactorHandler) DisableHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.TwoFactorCodeRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して無効化
+	if err := h.twoFactorService.Disable(userID, &request, auditContext(c)); err != nil {
+		return twoFactorError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "二要素認証を無効化しました"))
+}
+
+// AdminResetHandler ユーザーの二要素認証リセットのハンドラー（管理者用）
+func (h *TwoFactorHandler) AdminResetHandler(c echo.Context) error {
+	// パスパラメータからユーザーIDを取得
+	targetUserID := c.Param("user_id")
+	if targetUserID == "" {
+		errorResponse := models.MissingRequiredResponse("user_id")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してリセット
+	if err := h.twoFactorService.AdminReset(userID, targetUserID, auditContext(c)); err != nil {
+		return twoFactorError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "二要素認証をリセットしました"))
+}
+
+// twoFactorError 二要素認証のエラーをレスポンスに変換する
+func twoFactorError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, errorMsg)
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "two-factor already enabled"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	case strings.Contains(errorMsg, "user not found"),
+		strings.Contains(errorMsg, "two-factor not enabled"),
+		strings.Contains(errorMsg, "two-factor enrollment not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, errorMsg)
+		return c.JSON(http.StatusNotFound, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
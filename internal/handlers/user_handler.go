@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// UserHandler ユーザーハンドラーの構造体
+type UserHandler struct {
+	userService *services.UserService
+}
+
+// NewUserHandler ユーザーハンドラーのコンストラクタ
+func NewUserHandler(userService *services.UserService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+	}
+}
+
+// RegisterUserHandler ユーザー登録のハンドラー
+func (h *UserHandler) RegisterUserHandler(c echo.Context) error {
+	// リクエストボディをパース
+	var request models.UserRegistrationRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してユーザーを登録
+	response, err := h.userService.RegisterUser(&request, auditContext(c))
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, "email already registered"):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+			return c.JSON(http.StatusConflict, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	// 登録結果をJSON形式で返す
+	return c.JSON(http.StatusCreated, response)
+}
+
+// GetProfileHandler 自分のプロフィール取得のハンドラー
+func (h *UserHandler) GetProfileHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してプロフィールを取得
+	response, err := h.userService.GetProfile(userID)
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.Contains(errorMsg, "user not found"):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+			return c.JSON(http.StatusNotFound, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	// プロフィールをJSON形式で返す
+	return c.JSON(http.StatusOK, response)
+}
+
+// UpdateProfileHandler 自分のプロフィール更新のハンドラー
+func (h *UserHandler) UpdateProfileHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.UserProfileUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してプロフィールを更新
+	response, err := h.userService.UpdateProfile(userID, &request, auditContext(c))
+	if err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, "email already registered"):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+			return c.JSON(http.StatusConflict, errorResponse)
+		case strings.Contains(errorMsg, "user not found"):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+			return c.JSON(http.StatusNotFound, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	// 更新後のプロフィールをJSON形式で返す
+	return c.JSON(http.StatusOK, response)
+}
+
+// VerifyEmailHandler メールアドレス変更確認のハンドラー
+func (h *UserHandler) VerifyEmailHandler(c echo.Context) error {
+	// リクエストボディをパース
+	var request models.EmailVerificationRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してメールアドレスの変更を確定
+	if err := h.userService.VerifyEmailChange(&request, auditContext(c)); err != nil {
+		errorMsg := err.Error()
+
+		switch {
+		case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+			errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		case strings.Contains(errorMsg, "email already registered"):
+			errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+			return c.JSON(http.StatusConflict, errorResponse)
+		default:
+			errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+			return c.JSON(http.StatusInternalServerError, errorResponse)
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, "メールアドレスを変更しました"))
+}
+
+// ListUsersHandler ユーザー一覧取得のハンドラー（管理者用）
+func (h *UserHandler) ListUsersHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// クエリパラメータをパース
+	var request models.UserListRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("query", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してユーザー一覧を取得
+	response, err := h.userService.ListUsers(userID, &request)
+	if err != nil {
+		return userAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// AdminCreateUserHandler ユーザー作成のハンドラー（管理者用）
+func (h *UserHandler) AdminCreateUserHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.AdminUserCreateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してユーザーを作成
+	response, err := h.userService.AdminCreateUser(userID, &request, auditContext(c))
+	if err != nil {
+		return userAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// CreateServiceAccountHandler サービスアカウント作成のハンドラー（管理者用）
+func (h *UserHandler) CreateServiceAccountHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.ServiceAccountCreateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してサービスアカウントを作成
+	response, err := h.userService.AdminCreateServiceAccount(userID, &request, auditContext(c))
+	if err != nil {
+		return userAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// DeactivateUserHandler ユーザー無効化のハンドラー（管理者用）
+func (h *UserHandler) DeactivateUserHandler(c echo.Context) error {
+	return h.setUserActive(c, false, "ユーザーを無効化しました")
+}
+
+// ReactivateUserHandler ユーザー再有効化のハンドラー（管理者用）
+func (h *UserHandler) ReactivateUserHandler(c echo.Context) error {
+	return h.setUserActive(c, true, "ユーザーを再有効化しました")
+}
+
+// setUserActive ユーザーの有効・無効を切り替える
+func (h *UserHandler) setUserActive(c echo.Context, active bool, message string) error {
+	// パスパラメータからユーザーIDを取得
+	targetUserID := c.Param("user_id")
+	if targetUserID == "" {
+		errorResponse := models.MissingRequiredResponse("user_id")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して状態を変更
+	if err := h.userService.SetUserActive(userID, targetUserID, active, auditContext(c)); err != nil {
+		return userAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse(nil, message))
+}
+
+// ChangeUserRoleHandler ユーザーの役割変更のハンドラー（管理者用）
+func (h *UserHandler) ChangeUserRoleHandler(c echo.Context) error {
+	// パスパラメータからユーザーIDを取得
+	targetUserID := c.Param("user_id")
+	if targetUserID == "" {
+		errorResponse := models.MissingRequiredResponse("user_id")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.UserRoleUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して役割を変更
+	response, err := h.userService.ChangeUserRole(userID, targetUserID, &request, auditContext(c))
+	if err != nil {
+		return userAdminError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// userAdminError ユーザー管理系のエラーを適切なHTTPステータスコードで返す
+func userAdminError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "email already registered"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	case strings.Contains(errorMsg, "user not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// VideoProgressHandler 動画視聴状況ハンドラーの構造体
+type VideoProgressHandler struct {
+	progressService *services.VideoProgressService
+}
+
+// NewVideoProgressHandler 動画視聴状況ハンドラーのコンストラクタ
+func NewVideoProgressHandler(progressService *services.VideoProgressService) *VideoProgressHandler {
+	return &VideoProgressHandler{
+		progressService: progressService,
+	}
+}
+
+// RecordProgressHandler 視聴状況の記録（ハートビート）のハンドラー
+func (h *VideoProgressHandler) RecordProgressHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// リクエストボディをパース
+	var request models.VideoProgressRequest
+	if err := c.Bind(&request); err != nil {
+		errorResponse := models.InvalidFormatResponse("request body", err.Error())
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して視聴状況を記録
+	response, err := h.progressService.RecordProgress(userID, c.Param("course_id"), c.Param("video_id"), &request)
+	if err != nil {
+		return videoProgressError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetProgressHandler 学生本人の視聴状況（再開位置）取得のハンドラー
+func (h *VideoProgressHandler) GetProgressHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出して視聴状況を取得
+	response, err := h.progressService.GetProgress(userID, c.Param("course_id"), c.Param("video_id"))
+	if err != nil {
+		return videoProgressError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetCourseReportHandler 授業の視聴状況レポート取得のハンドラー
+func (h *VideoProgressHandler) GetCourseReportHandler(c echo.Context) error {
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してレポートを作成
+	response, err := h.progressService.GetCourseReport(userID, c.Param("course_id"))
+	if err != nil {
+		return videoProgressError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// videoProgressError 動画視聴状況サービスのエラーを適切なHTTPステータスコードで返す
+func videoProgressError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "course not found") || strings.Contains(errorMsg, "video not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// tusプロトコル（1.0）の定数
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,termination,expiration"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// TusExposedHeaders ブラウザのtusクライアントが参照するレスポンスヘッダー（CORSで公開する）
+var TusExposedHeaders = []string{
+	"Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size",
+	"Upload-Offset", "Upload-Length", "Upload-Metadata", "Upload-Expires",
+}
+
+// VideoUploadHandler 再開可能な動画アップロード（tus 1.0）ハンドラーの構造体
+type VideoUploadHandler struct {
+	uploadService *services.VideoUploadService
+}
+
+// NewVideoUploadHandler 再開可能な動画アップロードハンドラーのコンストラクタ
+func NewVideoUploadHandler(uploadService *services.VideoUploadService) *VideoUploadHandler {
+	return &VideoUploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// OptionsHandler サーバーが対応するtusのバージョン・拡張を返すハンドラー
+func (h *VideoUploadHandler) OptionsHandler(c echo.Context) error {
+	header := c.Response().Header()
+	header.Set("Tus-Resumable", tusVersion)
+	header.Set("Tus-Version", tusVersion)
+	header.Set("Tus-Extension", tusExtensions)
+	header.Set("Tus-Max-Size", strconv.FormatInt(h.uploadService.MaxUploadBytes(), 10))
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CreateUploadHandler アップロード作成のハンドラー（Upload-LengthとUpload-Metadataのfilename・durationが必須）
+func (h *VideoUploadHandler) CreateUploadHandler(c echo.Context) error {
+	if !tusResumable(c) {
+		return tusVersionMismatch(c)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	lengthHeader := c.Request().Header.Get("Upload-Length")
+	if lengthHeader == "" {
+		errorResponse := models.MissingRequiredResponse("Upload-Length")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+	length, err := strconv.ParseInt(lengthHeader, 10, 64)
+	if err != nil || length < 0 {
+		errorResponse := models.InvalidFormatResponse("Upload-Length", "must be a non-negative integer")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出してアップロードを作成
+	upload, err := h.uploadService.CreateUpload(userID, c.Param("course_id"), length, c.Request().Header.Get("Upload-Metadata"))
+	if err != nil {
+		return videoUploadError(c, err)
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderLocation, fmt.Sprintf(models.VideoUploadPath, upload.CourseID, upload.UploadID))
+	header.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	return c.NoContent(http.StatusCreated)
+}
+
+// HeadUploadHandler 受信済みのバイト数を返すハンドラー（再開時にクライアントが参照する）
+func (h *VideoUploadHandler) HeadUploadHandler(c echo.Context) error {
+	if !tusResumable(c) {
+		return tusVersionMismatch(c)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してアップロードを取得
+	upload, err := h.uploadService.GetUpload(userID, c.Param("course_id"), c.Param("upload_id"))
+	if err != nil {
+		return videoUploadError(c, err)
+	}
+
+	header := c.Response().Header()
+	header.Set("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+	header.Set("Upload-Length", strconv.FormatInt(upload.UploadLength, 10))
+	if upload.Metadata != "" {
+		header.Set("Upload-Metadata", upload.Metadata)
+	}
+	if !upload.IsCompleted() {
+		header.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	header.Set("Cache-Control", "no-store")
+	return c.NoContent(http.StatusOK)
+}
+
+// PatchUploadHandler Upload-Offsetの位置から内容を書き込むハンドラー
+func (h *VideoUploadHandler) PatchUploadHandler(c echo.Context) error {
+	if !tusResumable(c) {
+		return tusVersionMismatch(c)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != tusContentType {
+		errorResponse := models.NewErrorResponse(models.ErrorCodeUnsupportedMediaType, models.ErrorMessageUnsupportedMediaType, "Content-Type must be "+tusContentType)
+		return c.JSON(http.StatusUnsupportedMediaType, errorResponse)
+	}
+
+	offsetHeader := c.Request().Header.Get("Upload-Offset")
+	if offsetHeader == "" {
+		errorResponse := models.MissingRequiredResponse("Upload-Offset")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+	offset, err := strconv.ParseInt(offsetHeader, 10, 64)
+	if err != nil || offset < 0 {
+		errorResponse := models.InvalidFormatResponse("Upload-Offset", "must be a non-negative integer")
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	}
+
+	// サービスクラスを呼び出して書き込み
+	upload, err := h.uploadService.WriteChunk(c.Request().Context(), userID, c.Param("course_id"), c.Param("upload_id"), offset, c.Request().Body, auditContext(c))
+	if err != nil {
+		return videoUploadError(c, err)
+	}
+
+	header := c.Response().Header()
+	header.Set("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+	if !upload.IsCompleted() {
+		header.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TerminateUploadHandler アップロード中止のハンドラー
+func (h *VideoUploadHandler) TerminateUploadHandler(c echo.Context) error {
+	if !tusResumable(c) {
+		return tusVersionMismatch(c)
+	}
+
+	// 認証済みユーザーIDをコンテキストから取得
+	userID, ok := currentUserID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// サービスクラスを呼び出してアップロードを中止
+	if err := h.uploadService.TerminateUpload(userID, c.Param("course_id"), c.Param("upload_id"), auditContext(c)); err != nil {
+		return videoUploadError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// tusResumable レスポンスにTus-Resumableを設定し、リクエストのバージョンに対応しているかを返す
+func tusResumable(c echo.Context) bool {
+	c.Response().Header().Set("Tus-Resumable", tusVersion)
+	return c.Request().Header.Get("Tus-Resumable") == tusVersion
+}
+
+// tusVersionMismatch 対応していないtusのバージョンの場合に412を返す
+func tusVersionMismatch(c echo.Context) error {
+	c.Response().Header().Set("Tus-Version", tusVersion)
+	errorResponse := models.NewErrorResponse(models.ErrorCodePreconditionFailed, models.ErrorMessagePreconditionFailed, "Tus-Resumable must be "+tusVersion)
+	return c.JSON(http.StatusPreconditionFailed, errorResponse)
+}
+
+// videoUploadError 再開可能な動画アップロードサービスのエラーを適切なHTTPステータスコードで返す
+func videoUploadError(c echo.Context, err error) error {
+	errorMsg := err.Error()
+
+	switch {
+	case strings.HasPrefix(errorMsg, models.ErrorMessageInvalidInput):
+		errorResponse := models.BadRequestResponse(models.ErrorMessageInvalidInput, errorMsg)
+		return c.JSON(http.StatusBadRequest, errorResponse)
+	case strings.Contains(errorMsg, models.ErrorMessageForbidden):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "")
+		return c.JSON(http.StatusForbidden, errorResponse)
+	case strings.Contains(errorMsg, "course not found") || strings.Contains(errorMsg, "upload not found"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeNotFound, models.ErrorMessageNotFound, "")
+		return c.JSON(http.StatusNotFound, errorResponse)
+	case strings.Contains(errorMsg, "upload expired"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeGone, models.ErrorMessageGone, errorMsg)
+		return c.JSON(http.StatusGone, errorResponse)
+	case strings.Contains(errorMsg, "upload offset mismatch"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeConflict, models.ErrorMessageConflict, errorMsg)
+		return c.JSON(http.StatusConflict, errorResponse)
+	case strings.Contains(errorMsg, "upload locked"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodeLocked, models.ErrorMessageLocked, errorMsg)
+		return c.JSON(http.StatusLocked, errorResponse)
+	case strings.Contains(errorMsg, "video too large"):
+		errorResponse := models.NewErrorResponse(models.ErrorCodePayloadTooLarge, models.ErrorMessagePayloadTooLarge, errorMsg)
+		return c.JSON(http.StatusRequestEntityTooLarge, errorResponse)
+	default:
+		errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, errorMsg)
+		return c.JSON(http.StatusInternalServerError, errorResponse)
+	}
+}
@@ -0,0 +1,158 @@
+// Package ical RFC 5545のiCalendar形式で予定の一覧を出力する
+//
+// 授業・テストの日時はタイムゾーンを持たない壁時計の時刻として保存しているため、
+// 日時はAsia/Tokyoの時刻としてTZID付きで出力し、VTIMEZONEを同梱する。
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TimeZone 日時を解釈するタイムゾーン
+const TimeZone = "Asia/Tokyo"
+
+// Location TimeZoneのタイムゾーン（1951年以降は夏時間がないため固定のUTC+9）
+var Location = time.FixedZone(TimeZone, 9*60*60)
+
+// vtimezone Asia/TokyoのVTIMEZONE（1951年以降は夏時間がないため標準時のみ）
+var vtimezone = []string{
+	"BEGIN:VTIMEZONE",
+	"TZID:" + TimeZone,
+	"BEGIN:STANDARD",
+	"DTSTART:19700101T000000",
+	"TZOFFSETFROM:+0900",
+	"TZOFFSETTO:+0900",
+	"TZNAME:JST",
+	"END:STANDARD",
+	"END:VTIMEZONE",
+}
+
+// maxLineOctets 折り返し前の1行の最大オクテット数（改行を除く）
+const maxLineOctets = 75
+
+// 予定の状態（STATUS）
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+// Event 予定（VEVENT）
+type Event struct {
+	UID         string // 同じ予定は常に同じ値にする（カレンダーアプリが更新を判定する）
+	Start       time.Time
+	End         time.Time // ゼロ値の場合は出力しない
+	Summary     string
+	Description string
+	Status      string
+}
+
+// Calendar 予定の一覧（VCALENDAR）
+type Calendar struct {
+	ProdID          string
+	Name            string        // カレンダーアプリでの表示名（X-WR-CALNAME）
+	RefreshInterval time.Duration // 購読の更新間隔の目安（0の場合は出力しない）
+	Events          []Event
+}
+
+// Encode カレンダーをiCalendar形式で出力する（stampはDTSTAMPに使う生成日時）
+func (c *Calendar) Encode(stamp time.Time) []byte {
+	var b strings.Builder
+	write := func(line string) {
+		writeFolded(&b, line)
+	}
+
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:" + c.ProdID)
+	write("CALSCALE:GREGORIAN")
+	write("METHOD:PUBLISH")
+	if c.Name != "" {
+		write("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+	write("X-WR-TIMEZONE:" + TimeZone)
+	if c.RefreshInterval > 0 {
+		interval := formatDuration(c.RefreshInterval)
+		write("REFRESH-INTERVAL;VALUE=DURATION:" + interval)
+		write("X-PUBLISHED-TTL:" + interval)
+	}
+	for _, line := range vtimezone {
+		write(line)
+	}
+
+	dtstamp := stamp.UTC().Format("20060102T150405Z")
+	for _, event := range c.Events {
+		write("BEGIN:VEVENT")
+		write("UID:" + event.UID)
+		write("DTSTAMP:" + dtstamp)
+		write("DTSTART;TZID=" + TimeZone + ":" + formatLocal(event.Start))
+		if !event.End.IsZero() {
+			write("DTEND;TZID=" + TimeZone + ":" + formatLocal(event.End))
+		}
+		write("SUMMARY:" + escapeText(event.Summary))
+		if event.Description != "" {
+			write("DESCRIPTION:" + escapeText(event.Description))
+		}
+		if event.Status != "" {
+			write("STATUS:" + event.Status)
+		}
+		write("END:VEVENT")
+	}
+
+	write("END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// formatLocal 壁時計の時刻をTZID付きの日時の形式にする
+func formatLocal(t time.Time) string {
+	return t.Format("20060102T150405")
+}
+
+// formatDuration 期間をRFC 5545のDURATIONの形式（PT1H30Mなど）にする
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Seconds())
+	value := "PT"
+	if hours := seconds / 3600; hours > 0 {
+		value += fmt.Sprintf("%dH", hours)
+	}
+	if minutes := seconds % 3600 / 60; minutes > 0 {
+		value += fmt.Sprintf("%dM", minutes)
+	}
+	if rest := seconds % 60; rest > 0 || value == "PT" {
+		value += fmt.Sprintf("%dS", rest)
+	}
+	return value
+}
+
+// escapeText TEXT型の値をエスケープする（バックスラッシュ・セミコロン・カンマ・改行）
+func escapeText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(value)
+}
+
+// writeFolded 1行を75オクテットごとに折り返してCRLFで出力する（マルチバイト文字の途中では折り返さない）
+func writeFolded(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// 継続行は先頭の空白を含めて75オクテット
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
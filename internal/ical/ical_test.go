@@ -0,0 +1,189 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// unfold 折り返した行を元に戻す（RFC 5545 3.1）
+func unfold(s string) string {
+	return strings.ReplaceAll(s, "\r\n ", "")
+}
+
+func TestWriteFolded(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Algebra"},
+		{"exactly 75 octets", "SUMMARY:" + strings.Repeat("a", 67)},
+		{"76 octets", "SUMMARY:" + strings.Repeat("a", 68)},
+		{"long ascii", "DESCRIPTION:" + strings.Repeat("abcdefghij", 30)},
+		{"multibyte", "SUMMARY:" + strings.Repeat("数学の授業", 20)},
+		{"multibyte at the boundary", "SUMMARY:" + strings.Repeat("a", 66) + strings.Repeat("あ", 10)},
+		{"four-byte runes", "SUMMARY:" + strings.Repeat("😀", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeFolded(&b, tt.line)
+			out := b.String()
+
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", out)
+			}
+			if got := unfold(strings.TrimSuffix(out, "\r\n")); got != tt.line {
+				t.Errorf("unfolded = %q, want %q", got, tt.line)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			for i, line := range lines {
+				// 継続行の先頭の空白を含めて75オクテット以内
+				if len(line) > maxLineOctets {
+					t.Errorf("line %d is %d octets: %q", i, len(line), line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space: %q", i, line)
+				}
+				// マルチバイト文字の途中で折り返さない
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a multibyte character: %q", i, line)
+				}
+			}
+
+			if len(tt.line) <= maxLineOctets && len(lines) != 1 {
+				t.Errorf("line of %d octets was folded into %d lines", len(tt.line), len(lines))
+			}
+			if len(tt.line) > maxLineOctets && len(lines) == 1 {
+				t.Errorf("line of %d octets was not folded", len(tt.line))
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", "plain"},
+		{"a,b;c", `a\,b\;c`},
+		{`C:\path`, `C:\\path`},
+		{"line1\nline2", `line1\nline2`},
+		{"line1\r\nline2", `line1\nline2`},
+		{"line1\rline2", `line1\nline2`},
+		{`\n`, `\\n`}, // 入力のバックスラッシュは改行として解釈させない
+		{"数学、第1回; 教室A", `数学、第1回\; 教室A`},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := escapeText(tt.value); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{time.Hour, "PT1H"},
+		{90 * time.Minute, "PT1H30M"},
+		{15 * time.Minute, "PT15M"},
+		{time.Hour + 5*time.Second, "PT1H5S"},
+		{0, "PT0S"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	start := time.Date(2025, 4, 7, 9, 0, 0, 0, time.UTC) // 壁時計の時刻（Asia/Tokyoの9:00）
+	calendar := &Calendar{
+		ProdID:          "-//go-study//calendar//JA",
+		Name:            "時間割, 2025年度",
+		RefreshInterval: time.Hour,
+		Events: []Event{
+			{
+				UID:         "course-session-1@go-study",
+				Start:       start,
+				End:         start.Add(90 * time.Minute),
+				Summary:     "数学; 第1回",
+				Description: "教科書を持参\n" + strings.Repeat("予習してください。", 10),
+				Status:      StatusConfirmed,
+			},
+			{
+				UID:     "test-2@go-study",
+				Start:   start.AddDate(0, 0, 7),
+				Summary: "小テスト",
+			},
+		},
+	}
+
+	out := string(calendar.Encode(time.Date(2025, 4, 1, 3, 4, 5, 0, time.FixedZone("JST", 9*60*60))))
+
+	for i, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets || !utf8.ValidString(line) {
+			t.Errorf("line %d is not folded correctly: %q", i, line)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+		t.Error("output contains a bare LF")
+	}
+
+	lines := strings.Split(unfold(out), "\r\n")
+	has := func(want string) bool {
+		for _, line := range lines {
+			if line == want {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"X-WR-CALNAME:時間割\\, 2025年度",
+		"X-WR-TIMEZONE:Asia/Tokyo",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H",
+		"X-PUBLISHED-TTL:PT1H",
+		// VTIMEZONEを同梱し、日時はTZID付きの壁時計の時刻で出力する
+		"BEGIN:VTIMEZONE",
+		"TZID:Asia/Tokyo",
+		"TZOFFSETFROM:+0900",
+		"TZOFFSETTO:+0900",
+		"END:VTIMEZONE",
+		"DTSTAMP:20250331T180405Z",
+		"DTSTART;TZID=Asia/Tokyo:20250407T090000",
+		"DTEND;TZID=Asia/Tokyo:20250407T103000",
+		"SUMMARY:数学\\; 第1回",
+		"DESCRIPTION:教科書を持参\\n" + strings.Repeat("予習してください。", 10),
+		"STATUS:CONFIRMED",
+		"DTSTART;TZID=Asia/Tokyo:20250414T090000",
+		"SUMMARY:小テスト",
+		"END:VCALENDAR",
+	} {
+		if !has(want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	// 終了日時のない予定はDTENDを出力しない
+	if got := strings.Count(out, "DTEND"); got != 1 {
+		t.Errorf("DTEND count = %d, want 1", got)
+	}
+	// VTIMEZONEはイベントより前に1つだけ出力する
+	if strings.Count(out, "BEGIN:VTIMEZONE") != 1 || strings.Index(out, "END:VTIMEZONE") > strings.Index(out, "BEGIN:VEVENT") {
+		t.Error("VTIMEZONE must appear once before the events")
+	}
+}
@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"fmt"
+)
+
+// Message 送信するメールの構造体
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer メール送信のインターフェース
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// LogMailer メールを送信せず標準出力に出力するMailer（開発用）
+// 本文に含まれるトークンもそのまま出力されるため、本番環境では使わないこと
+type LogMailer struct{}
+
+// NewLogMailer LogMailerのコンストラクタ
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send メールの内容を標準出力に出力する
+func (m *LogMailer) Send(msg *Message) error {
+	fmt.Printf("[MAIL] To: %s Subject: %s\n%s\n", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// OutboxMailer メールを送信せずディレクトリに.emlファイルとして保存するMailer（ローカル検証用）
+type OutboxMailer struct {
+	dir   string
+	from  string
+	count atomic.Int64
+}
+
+// NewOutboxMailer OutboxMailerのコンストラクタ
+func NewOutboxMailer(dir string, from string) (*OutboxMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	return &OutboxMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+// Send メールを.emlファイルとして書き出す
+func (m *OutboxMailer) Send(msg *Message) error {
+	// ファイル名が衝突しないよう時刻と連番を付与
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().Format("20060102T150405.000000000"), m.count.Add(1))
+	path := filepath.Join(m.dir, name)
+
+	if err := os.WriteFile(path, buildMessage(m.from, msg), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail to outbox: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// AccessLogger アクセスログのミドルウェア（EchoのLoggerの形式）
+// secretRoutes（カレンダー配信など、URLにトークンを含むルート）はURLの代わりにルートのパターンを記録する
+func AccessLogger(secretRoutes ...string) echo.MiddlewareFunc {
+	return newAccessLogger(os.Stdout, secretRoutes)
+}
+
+// newAccessLogger 出力先を指定してアクセスログのミドルウェアを作成する
+func newAccessLogger(output io.Writer, secretRoutes []string) echo.MiddlewareFunc {
+	isSecret := func(c echo.Context) bool {
+		for _, route := range secretRoutes {
+			if c.Path() == route {
+				return true
+			}
+		}
+		return false
+	}
+
+	logger := echomiddleware.LoggerWithConfig(echomiddleware.LoggerConfig{
+		Skipper: isSecret,
+		Format:  echomiddleware.DefaultLoggerConfig.Format,
+		Output:  output,
+	})
+	redacted := echomiddleware.LoggerWithConfig(echomiddleware.LoggerConfig{
+		Skipper: func(c echo.Context) bool { return !isSecret(c) },
+		Format:  strings.Replace(echomiddleware.DefaultLoggerConfig.Format, `"uri":"${uri}"`, `"route":"${route}"`, 1),
+		Output:  output,
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return logger(redacted(next))
+	}
+}
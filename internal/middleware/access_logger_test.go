@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TestAccessLoggerRedactsSecretRoutes トークンを含むルートはURLを記録せず、他のルートはURLを記録すること
+func TestAccessLoggerRedactsSecretRoutes(t *testing.T) {
+	const token = "c2VjcmV0LWNhbGVuZGFyLXRva2Vu"
+
+	tests := []struct {
+		name   string
+		target string
+		field  string
+		want   string
+	}{
+		{"secret route", "/calendar/" + token + ".ics?alarm=1", "route", "/calendar/:token"},
+		{"other route", "/courses/1?page=2", "uri", "/courses/1?page=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			e := echo.New()
+			e.Use(newAccessLogger(&output, []string{"/calendar/:token"}))
+			e.GET("/calendar/:token", func(c echo.Context) error { return c.String(http.StatusOK, "feed") })
+			e.GET("/courses/:course_id", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			// 1リクエストにつき1行だけ記録する
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			if len(lines) != 1 {
+				t.Fatalf("log lines = %q, want 1 line", lines)
+			}
+			if strings.Contains(lines[0], token) {
+				t.Fatalf("log contains the token: %s", lines[0])
+			}
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+				t.Fatalf("log is not json: %v: %s", err, lines[0])
+			}
+			if entry[tt.field] != tt.want {
+				t.Errorf("%s = %v, want %q", tt.field, entry[tt.field], tt.want)
+			}
+			if entry["status"] != float64(http.StatusOK) || entry["method"] != http.MethodGet {
+				t.Errorf("log = %v, want status and method", entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/services"
+)
+
+// principalContextKey プリンシパルを保存するコンテキストのキー
+const principalContextKey = "principal"
+
+// mfaEnrollmentPaths 二要素認証の登録が必須で未登録のユーザーでも利用できるルート
+var mfaEnrollmentPaths = map[string]bool{
+	"/me/2fa":         true,
+	"/me/2fa/enroll":  true,
+	"/me/2fa/confirm": true,
+	"/auth/logout":    true,
+}
+
+// Auth Authorizationヘッダーのトークンを検証し、プリンシパルをコンテキストに設定するミドルウェア
+// アクセストークンに加えて連携用のAPIキーも受け付ける（APIキーはルートに必要なスコープを持つ場合のみ許可）
+func Auth(authService *services.AuthService, apiKeyService *services.APIKeyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Bearerトークンを取得
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			tokenString, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || tokenString == "" {
+				errorResponse := models.NewErrorResponse(models.ErrorCodeUnauthorized, models.ErrorMessageUnauthorized, "")
+				return c.JSON(http.StatusUnauthorized, errorResponse)
+			}
+
+			// トークンを検証してプリンシパルを取得
+			var principal *models.Principal
+			var err error
+			if strings.HasPrefix(tokenString, services.APIKeyPrefix) {
+				principal, err = apiKeyService.Authenticate(tokenString, ClientIP(c))
+			} else {
+				principal, err = authService.ParseToken(tokenString)
+			}
+			if err != nil {
+				if err.Error() == models.ErrorMessageUnauthorized {
+					errorResponse := models.NewErrorResponse(models.ErrorCodeUnauthorized, models.ErrorMessageUnauthorized, "")
+					return c.JSON(http.StatusUnauthorized, errorResponse)
+				}
+				errorResponse := models.NewErrorResponse(models.ErrorCodeInternalServer, models.ErrorMessageInternalServer, err.Error())
+				return c.JSON(http.StatusInternalServerError, errorResponse)
+			}
+
+			// APIキーはルートに必要なスコープを持つ場合のみ許可
+			if principal.APIKeyID != 0 {
+				scope, ok := policy.RequiredScope(c.Request().Method, c.Path())
+				if !ok {
+					errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "api keys cannot be used for this route")
+					return c.JSON(http.StatusForbidden, errorResponse)
+				}
+				if !policy.HasScope(principal, scope) {
+					errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "api key scope required: "+scope)
+					return c.JSON(http.StatusForbidden, errorResponse)
+				}
+			}
+
+			// 二要素認証の登録が必須で未登録の場合は登録手続き以外を拒否
+			if principal.MFAEnrollmentRequired && !mfaEnrollmentPaths[c.Path()] {
+				errorResponse := models.NewErrorResponse(models.ErrorCodeForbidden, models.ErrorMessageForbidden, "two-factor enrollment required")
+				return c.JSON(http.StatusForbidden, errorResponse)
+			}
+
+			c.Set(principalContextKey, principal)
+			return next(c)
+		}
+	}
+}
+
+// GetPrincipal コンテキストから認証済みプリンシパルを取得する
+func GetPrincipal(c echo.Context) (*models.Principal, bool) {
+	principal, ok := c.Get(principalContextKey).(*models.Principal)
+	return principal, ok && principal != nil
+}
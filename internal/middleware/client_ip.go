@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NewIPExtractor 信頼するプロキシ（カンマ区切りのCIDR）に応じてクライアントIPの取得方法を選択する
+// 空の場合は接続元アドレスのみを使い、クライアントが送るX-Forwarded-For・X-Real-IPは信頼しない
+func NewIPExtractor(trustedProxies string) (echo.IPExtractor, error) {
+	var options []echo.TrustOption
+	for _, cidr := range strings.Split(trustedProxies, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	if len(options) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	// 指定した範囲のプロキシのみ信頼する（ループバック・プライベートアドレスも既定では信頼しない）
+	options = append([]echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}, options...)
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// ClientIP リクエスト元のIPアドレスを正規化して返す
+// ログイン試行制限のキーや監査ログに使うため、Echoに設定したIPExtractorの結果をIPアドレスとして検証し、
+// IPv4射影アドレス・ゾーンを取り除いた表記に揃える
+// 抽出結果を解析できない場合は接続元アドレスを使う（空文字にすると全員が同じキーで制限されるため）
+func ClientIP(c echo.Context) string {
+	if ip, ok := normalizeIP(c.RealIP()); ok {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(c.Request().RemoteAddr)
+	if err != nil {
+		host = c.Request().RemoteAddr
+	}
+	if ip, ok := normalizeIP(host); ok {
+		return ip
+	}
+	return host
+}
+
+// normalizeIP IPアドレスを解析し、IPv4射影アドレス・ゾーンを取り除いた表記にする
+func normalizeIP(value string) (string, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+	return addr.Unmap().WithZone("").String(), true
+}
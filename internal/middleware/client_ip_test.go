@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newClientIPContext IPExtractorを設定したEchoでリクエストのコンテキストを作成する
+func newClientIPContext(extractor echo.IPExtractor, remoteAddr string, xff string) echo.Context {
+	e := echo.New()
+	e.IPExtractor = extractor
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	req.RemoteAddr = remoteAddr
+	if xff != "" {
+		req.Header.Set(echo.HeaderXForwardedFor, xff)
+		req.Header.Set(echo.HeaderXRealIP, xff)
+	}
+	return e.NewContext(req, httptest.NewRecorder())
+}
+
+func TestClientIP(t *testing.T) {
+	direct, err := NewIPExtractor("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := NewIPExtractor("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		extractor  echo.IPExtractor
+		remoteAddr string
+		xff        string
+		want       string
+	}{
+		{"direct ignores forged header", direct, "203.0.113.5:4000", "198.51.100.1", "203.0.113.5"},
+		{"direct ignores oversized header", direct, "203.0.113.5:4000", strings.Repeat("1", 400), "203.0.113.5"},
+		{"ipv6 is normalized", direct, "[2001:DB8:0:0::1]:4000", "", "2001:db8::1"},
+		{"ipv4-mapped ipv6 is unmapped", direct, "[::ffff:203.0.113.5]:4000", "", "203.0.113.5"},
+		{"untrusted peer cannot set header", trusted, "203.0.113.5:4000", "198.51.100.1", "203.0.113.5"},
+		{"trusted proxy forwards client", trusted, "10.1.2.3:4000", "198.51.100.1", "198.51.100.1"},
+		{"trusted proxy with garbage header", trusted, "10.1.2.3:4000", strings.Repeat("x", 400), "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClientIPContext(tt.extractor, tt.remoteAddr, tt.xff)
+			if got := ClientIP(c); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestClientIPFallback 抽出結果を解析できない場合は接続元アドレスを使い、空文字を返さないこと
+func TestClientIPFallback(t *testing.T) {
+	unparsable := func(*http.Request) string { return "unknown" }
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{"remote address with port", "203.0.113.5:4000", "203.0.113.5"},
+		{"remote address without port", "203.0.113.5", "203.0.113.5"},
+		{"ipv6 remote address", "[2001:db8::1]:4000", "2001:db8::1"},
+		{"unparsable remote address", "pipe:4000", "pipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClientIPContext(unparsable, tt.remoteAddr, "")
+			if got := ClientIP(c); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewIPExtractor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		xff            string
+		want           string
+	}{
+		{"unset uses the peer address", "", "10.1.2.3:4000", "198.51.100.1", "10.1.2.3"},
+		{"blank entries are ignored", " , ", "10.1.2.3:4000", "198.51.100.1", "10.1.2.3"},
+		{"trusted range", "10.0.0.0/8", "10.1.2.3:4000", "198.51.100.1", "198.51.100.1"},
+		{"outside the trusted range", "10.0.0.0/8", "192.0.2.10:4000", "198.51.100.1", "192.0.2.10"},
+		{"several ranges with spaces", "192.0.2.0/24, 10.0.0.0/8", "10.1.2.3:4000", "198.51.100.1", "198.51.100.1"},
+		{"ipv6 range", "2001:db8::/32", "[2001:db8::10]:4000", "198.51.100.1", "198.51.100.1"},
+		// 指定しない限りプライベート・ループバックアドレスのプロキシは信頼しない
+		{"private network is not trusted by default", "192.0.2.0/24", "10.1.2.3:4000", "198.51.100.1", "10.1.2.3"},
+		{"loopback is not trusted by default", "192.0.2.0/24", "127.0.0.1:4000", "198.51.100.1", "127.0.0.1"},
+		// 信頼するプロキシの前に付けられた値は使わない（右端の信頼しないアドレスを使う）
+		{"forged leftmost entry", "10.0.0.0/8", "10.1.2.3:4000", "192.0.2.99, 198.51.100.1", "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractor, err := NewIPExtractor(tt.trustedProxies)
+			if err != nil {
+				t.Fatalf("NewIPExtractor(%q) error = %v", tt.trustedProxies, err)
+			}
+
+			c := newClientIPContext(extractor, tt.remoteAddr, tt.xff)
+			if got := ClientIP(c); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"10.0.0.0", "10.0.0.0/33", "example.com", "10.0.0.0/8,bogus"} {
+		if _, err := NewIPExtractor(invalid); err == nil {
+			t.Errorf("NewIPExtractor(%q) error = nil, want error", invalid)
+		}
+	}
+}
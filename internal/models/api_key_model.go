@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey 連携用APIキーテーブル
+type APIKey struct {
+	APIKeyID   int        `json:"api_key_id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`   // NULL許容（無期限）
+	LastUsedAt *time.Time `json:"last_used_at"` // NULL許容
+	LastUsedIP *string    `json:"last_used_ip"` // NULL許容
+	CreatedBy  int        `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at"` // NULL許容
+}
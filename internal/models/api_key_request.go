@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKeyCreateRequest APIキー発行リクエストの構造体
+type APIKeyCreateRequest struct {
+	UserID    int        `json:"user_id" validate:"required"`
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required"` // "courses:read" など
+	ExpiresAt *time.Time `json:"expires_at"`                 // 省略時は無期限
+}
+
+// APIKeyCreateResponse APIキー発行レスポンスの構造体
+type APIKeyCreateResponse struct {
+	APIKey APIKey `json:"api_key"`
+	Key    string `json:"key"` // この応答でのみ表示される
+}
+
+// APIKeyListRequest APIキー一覧取得リクエストの構造体
+type APIKeyListRequest struct {
+	UserID         int  `query:"user_id"`
+	IncludeRevoked bool `query:"include_revoked"`
+}
+
+// APIKeyListResponse APIキー一覧レスポンスの構造体
+type APIKeyListResponse struct {
+	Count   int      `json:"count"`
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+// ServiceAccountCreateRequest サービスアカウント作成リクエストの構造体
+type ServiceAccountCreateRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"` // 管理担当者の連絡先
+	Role  string `json:"role" validate:"required,oneof=student teacher parent admin"`
+}
@@ -12,4 +12,5 @@ type Attendance struct {
 	Status        string    `json:"status"` // enumなのでstringやintどちらかに
 	AttendedAt    time.Time `json:"attended_at"`
 	IsDeleted     bool      `json:"is_deleted"`
+	SessionID     *int      `json:"session_id"` // 授業回（NULL許容、授業回の導入前の記録）
 } 
\ No newline at end of file
@@ -18,13 +18,13 @@ type AttendanceUpdateRequest struct {
 
 // AttendanceListRequest 出席一覧取得リクエストの構造体
 type AttendanceListRequest struct {
-	StudentUserID *int   `json:"student_user_id"`
-	CourseID      *int   `json:"course_id"`
-	Status        string `json:"status"`
-	StartDate     string `json:"start_date"`
-	EndDate       string `json:"end_date"`
-	Limit         int    `json:"limit"`
-	Offset        int    `json:"offset"`
+	StudentUserID *int   `json:"student_user_id" query:"student_user_id"`
+	CourseID      *int   `json:"course_id" query:"course_id"`
+	Status        string `json:"status" query:"status"`
+	StartDate     string `json:"start_date" query:"start_date"` // YYYY-MM-DD
+	EndDate       string `json:"end_date" query:"end_date"`     // YYYY-MM-DD
+	Limit         int    `json:"limit" query:"limit"`
+	Offset        int    `json:"offset" query:"offset"`
 }
 
 // AttendanceResponse 出席レスポンスの構造体
@@ -34,6 +34,7 @@ type AttendanceResponse struct {
 	StudentName   string    `json:"student_name"`
 	CourseID      int       `json:"course_id"`
 	CourseTitle   string    `json:"course_title"`
+	SessionID     *int      `json:"session_id"` // 授業回の導入前の記録はnull
 	Status        string    `json:"status"`
 	AttendedAt    time.Time `json:"attended_at"`
 }
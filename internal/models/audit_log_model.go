@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog 監査ログテーブル
+type AuditLog struct {
+	AuditLogID    int64           `json:"audit_log_id"`
+	ActorUserID   *int            `json:"actor_user_id"`    // NULL許容
+	ActorAPIKeyID *int            `json:"actor_api_key_id"` // NULL許容
+	Action        string          `json:"action"`
+	EntityType    string          `json:"entity_type"`
+	EntityID      string          `json:"entity_id"`
+	BeforeData    json.RawMessage `json:"before"`  // NULL許容（作成時）
+	AfterData     json.RawMessage `json:"after"`   // NULL許容（削除時）
+	Changes       json.RawMessage `json:"changes"` // 項目ごとの {"before": ..., "after": ...}
+	RequestID     *string         `json:"request_id"`
+	IPAddress     *string         `json:"ip_address"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// 監査ログの操作
+const (
+	AuditActionCreate                  = "create"
+	AuditActionUpdate                  = "update"
+	AuditActionDelete                  = "delete"
+	AuditActionDeactivate              = "deactivate"
+	AuditActionReactivate              = "reactivate"
+	AuditActionChangeRole              = "change_role"
+	AuditActionRevokeSessions          = "revoke_sessions"
+	AuditActionUnlock                  = "unlock"
+	AuditActionEnroll2FA               = "enroll_2fa"
+	AuditActionEnable2FA               = "enable_2fa"
+	AuditActionDisable2FA              = "disable_2fa"
+	AuditActionReset2FA                = "reset_2fa"
+	AuditActionRegenerateRecoveryCodes = "regenerate_recovery_codes"
+	AuditActionRevoke                  = "revoke"
+	AuditActionRestore                 = "restore"
+	AuditActionResetPassword           = "reset_password"
+)
+
+// AuditContext 監査ログに記録する操作者とリクエストの情報（ハンドラーで作成してサービスに渡す）
+type AuditContext struct {
+	ActorUserID int
+	APIKeyID    int // APIキーで認証された場合のみ
+	RequestID   string
+	IPAddress   string
+}
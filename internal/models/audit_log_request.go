@@ -0,0 +1,20 @@
+package models
+
+// AuditLogListRequest 監査ログ検索リクエストの構造体
+type AuditLogListRequest struct {
+	ActorUserID int    `query:"actor_user_id"`
+	EntityType  string `query:"entity_type"`
+	EntityID    string `query:"entity_id"`
+	Action      string `query:"action"`
+	From        string `query:"from"` // RFC3339またはYYYY-MM-DD（この日時以降）
+	To          string `query:"to"`   // RFC3339またはYYYY-MM-DD（この日時より前、日付のみの場合はその日を含む）
+	Limit       int    `query:"limit"`
+	Offset      int    `query:"offset"`
+}
+
+// AuditLogListResponse 監査ログ検索レスポンスの構造体
+type AuditLogListResponse struct {
+	Count     int        `json:"count"`
+	Total     int        `json:"total"`
+	AuditLogs []AuditLog `json:"audit_logs"`
+}
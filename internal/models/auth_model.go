@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// Principal 認証済みユーザー（プリンシパル）の構造体
+type Principal struct {
+	UserID    int    `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID int    `json:"session_id"`
+	// 役割により二要素認証が必須だが未登録（登録以外の操作は拒否される）
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required"`
+	// APIキーで認証された場合のキーIDとスコープ（セッションの場合はゼロ値）
+	APIKeyID int      `json:"api_key_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// AuthSession ログインセッションテーブル
+type AuthSession struct {
+	SessionID     int        `json:"session_id"`
+	UserID        int        `json:"user_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`     // NULL許容
+	RevokedReason *string    `json:"revoked_reason"` // NULL許容
+}
+
+// RefreshToken リフレッシュトークンテーブル
+type RefreshToken struct {
+	RefreshTokenID int        `json:"refresh_token_id"`
+	SessionID      int        `json:"session_id"`
+	TokenHash      string     `json:"-"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	UsedAt         *time.Time `json:"used_at"` // NULL許容
+	CreatedAt      time.Time  `json:"created_at"`
+}
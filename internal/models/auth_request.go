@@ -0,0 +1,44 @@
+package models
+
+// RefreshTokenRequest トークン再発行リクエストの構造体
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse トークン再発行レスポンスの構造体
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // アクセストークンの有効秒数
+}
+
+// RevokeSessionsResponse セッション一括無効化レスポンスの構造体
+type RevokeSessionsResponse struct {
+	Status          string `json:"status"`
+	UserID          int    `json:"user_id"`
+	RevokedSessions int    `json:"revoked_sessions"`
+}
+
+// ForgotPasswordRequest パスワード再設定メール送信リクエストの構造体
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest パスワード再設定リクエストの構造体
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+// LockoutEventListRequest ロックアウト記録一覧取得リクエストの構造体
+type LockoutEventListRequest struct {
+	UserID *int `query:"user_id"`
+	Limit  int  `query:"limit"`
+	Offset int  `query:"offset"`
+}
+
+// LockoutEventListResponse ロックアウト記録一覧レスポンスの構造体
+type LockoutEventListResponse struct {
+	Count  int            `json:"count"`
+	Events []LockoutEvent `json:"events"`
+}
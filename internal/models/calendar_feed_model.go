@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// CalendarFeed カレンダー購読テーブル（ユーザーごとに1つ）
+type CalendarFeed struct {
+	UserID     int        `json:"user_id"`
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"` // NULL許容
+}
+
+// CalendarFeedPath カレンダー購読のURLのパス（トークンを埋め込む）
+const CalendarFeedPath = "/calendar/%s.ics"
+
+// CalendarFeedSession カレンダーに出力する授業回
+type CalendarFeedSession struct {
+	SessionID         int
+	CourseID          int
+	CourseTitle       string
+	CourseDescription string
+	OriginalStartsAt  time.Time
+	StartsAt          time.Time
+	EndsAt            time.Time
+	Status            string
+	Note              string
+}
+
+// CalendarFeedTest カレンダーに出力するテスト
+type CalendarFeedTest struct {
+	TeacherTestID   int
+	CourseTitle     string
+	Title           string
+	Description     string
+	DurationMinutes int
+	ScheduledAt     time.Time
+	IsDraft         bool
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// CalendarFeedResponse カレンダー購読の状態レスポンスの構造体
+type CalendarFeedResponse struct {
+	Enabled    bool       `json:"enabled"`
+	CreatedAt  *time.Time `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// CalendarFeedCreateResponse カレンダー購読の発行レスポンスの構造体
+type CalendarFeedCreateResponse struct {
+	Path      string    `json:"path"`  // 購読用URLのパス（この応答でのみ表示される）
+	Token     string    `json:"token"` // この応答でのみ表示される
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -77,6 +77,21 @@ const (
 	ErrorCodeNotFound         = 404
 	ErrorCodeResourceNotFound = 404
 	
+	// 競合エラー (409系)
+	ErrorCodeConflict         = 409
+	ErrorCodeGone             = 410
+	ErrorCodePreconditionFailed = 412
+	
+	// サイズ超過エラー (413系)
+	ErrorCodePayloadTooLarge  = 413
+	ErrorCodeUnsupportedMediaType = 415
+	
+	// ロック中エラー (423系)
+	ErrorCodeLocked           = 423
+	
+	// リクエスト過多エラー (429系)
+	ErrorCodeTooManyRequests  = 429
+	
 	// サーバーエラー (500系)
 	ErrorCodeInternalServer   = 500
 	ErrorCodeDatabaseError    = 500
@@ -90,6 +105,13 @@ const (
 	ErrorMessageUnauthorized     = "認証に失敗しました"
 	ErrorMessageForbidden        = "アクセス権限がありません"
 	ErrorMessageNotFound         = "リソースが見つかりません"
+	ErrorMessageConflict         = "データが競合しています"
+	ErrorMessageGone             = "有効期限が切れています"
+	ErrorMessagePreconditionFailed = "前提条件を満たしていません"
+	ErrorMessagePayloadTooLarge  = "ファイルサイズが大きすぎます"
+	ErrorMessageUnsupportedMediaType = "サポートされていない形式です"
+	ErrorMessageLocked           = "処理中のため操作できません"
+	ErrorMessageTooManyRequests  = "試行回数が多すぎます。しばらくしてから再度お試しください"
 	ErrorMessageInternalServer   = "サーバー内部エラーが発生しました"
 	ErrorMessageDatabaseError    = "データベースエラーが発生しました"
 ) 
\ No newline at end of file
@@ -24,6 +24,12 @@ type Course struct {
 	UpdatedAt     time.Time `json:"updated_at"`
 	ScheduledAt   time.Time `json:"scheduled_at"`
 	IsDeleted     bool      `json:"is_deleted"`
+	SourcedID     *string   `json:"sourced_id,omitempty"` // 名簿インポートの外部ID（NULL許容）
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"` // 論理削除日時（NULL許容）
+	DeletedBy     *int      `json:"deleted_by,omitempty"` // 論理削除したユーザーID（NULL許容）
+	RecurrenceRule  *string     `json:"recurrence_rule"`  // RFC 5545のRRULE（NULLの場合はscheduled_atの1回のみ）
+	ExDates         []time.Time `json:"exdates"`          // 繰り返しから除く日時（EXDATE）
+	DurationMinutes int         `json:"duration_minutes"` // 1回あたりの時間（分）
 }
 
 // CourseVideo コース動画テーブル
@@ -34,4 +40,9 @@ type CourseVideo struct {
 	URL       string    `json:"url"`
 	UploadedAt time.Time `json:"uploaded_at"`
 	IsDeleted bool      `json:"is_deleted"`
+	StorageKey  string  `json:"-"`            // ストレージ上のキー
+	ContentType string  `json:"content_type"`
+	SizeBytes   int64   `json:"size_bytes"`
+	UploadedBy  *int    `json:"uploaded_by"`  // NULL許容
+	DurationSeconds *float64 `json:"duration_seconds"` // NULL許容（担当教師が設定するまで不明）
 } 
\ No newline at end of file
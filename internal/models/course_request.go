@@ -9,7 +9,11 @@ type CreateCourseRequest struct {
 	Title         string    `json:"title" validate:"required"`
 	Description   string    `json:"description" validate:"required"`
 	SubjectID     int       `json:"subject_id" validate:"required"`
-	ScheduledAt   time.Time `json:"scheduled_at" validate:"required"`
+	ScheduledAt   time.Time `json:"scheduled_at" validate:"required"` // 初回の日時（繰り返しのDTSTART）
+	RecurrenceRule  string      `json:"recurrence_rule"`  // RRULE（例: FREQ=WEEKLY;BYDAY=MO;UNTIL=20270331、未指定の場合は1回のみ）
+	ExDates         []time.Time `json:"exdates"`          // 繰り返しから除く日時
+	DurationMinutes int         `json:"duration_minutes"` // 1回あたりの時間（分、未指定の場合は60）
+	OverrideConflicts bool      `json:"override_conflicts"` // 予定の重複があっても登録する（管理者のみ）
 }
 
 // CreateCourseResponse 授業登録レスポンスの構造体
@@ -28,14 +32,22 @@ type CourseData struct {
 	SubjectID     int       `json:"subject_id"`
 	ScheduledAt   time.Time `json:"scheduled_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	RecurrenceRule  *string     `json:"recurrence_rule"`
+	ExDates         []time.Time `json:"exdates"`
+	DurationMinutes int         `json:"duration_minutes"`
 }
 
 // UpdateCourseRequest 授業更新リクエストの構造体
+// 繰り返しの項目は未指定の場合は変更しない（recurrence_ruleに空文字を指定すると繰り返しを解除する）
 type UpdateCourseRequest struct {
 	Title         string    `json:"title" validate:"required"`
 	Description   string    `json:"description" validate:"required"`
 	SubjectID     int       `json:"subject_id" validate:"required"`
 	ScheduledAt   time.Time `json:"scheduled_at" validate:"required"`
+	RecurrenceRule  *string      `json:"recurrence_rule"`
+	ExDates         *[]time.Time `json:"exdates"`
+	DurationMinutes *int         `json:"duration_minutes"`
+	OverrideConflicts bool       `json:"override_conflicts"` // 予定の重複があっても更新する（管理者のみ）
 }
 
 // UpdateCourseResponse 授業更新レスポンスの構造体
@@ -43,4 +55,54 @@ type UpdateCourseResponse struct {
 	Status string                 `json:"status"`
 	Info   map[string]interface{} `json:"info"`
 	Data   CourseData             `json:"data"`
-} 
\ No newline at end of file
+} 
+
+// CourseListRequest 授業一覧取得リクエストの構造体
+type CourseListRequest struct {
+	SubjectID     *int   `query:"subject_id"`
+	TeacherUserID *int   `query:"teacher_user_id"`
+	From          string `query:"from"`    // scheduled_atがこの日時以降（RFC3339またはYYYY-MM-DD）
+	To            string `query:"to"`      // scheduled_atがこの日時より前（日付のみの場合はその日を含む）
+	Keyword       string `query:"keyword"` // タイトル・説明の部分一致
+	Sort          string `query:"sort"`    // scheduled_at, title, created_at, updated_at（先頭に-で降順）
+	Limit         int    `query:"limit"`
+	Offset        int    `query:"offset"`
+}
+
+// CourseDetailResponse 授業詳細レスポンスの構造体
+type CourseDetailResponse struct {
+	CourseData
+	SubjectName string `json:"subject_name"`
+	TeacherName string `json:"teacher_name"`
+	VideoCount  int    `json:"video_count"`
+	TestCount   int    `json:"test_count"` // 学生には下書きを含めない
+}
+
+// CourseListResponse 授業一覧レスポンスの構造体
+type CourseListResponse struct {
+	Count   int                    `json:"count"`
+	Total   int                    `json:"total"`
+	Courses []CourseDetailResponse `json:"courses"`
+}
+
+// DeleteCourseRequest 授業削除リクエストの構造体
+type DeleteCourseRequest struct {
+	Force bool `query:"force"` // 成績・提出済みの解答があっても削除する
+}
+
+// CourseCascadeResult 授業の削除・復元で連動した件数
+type CourseCascadeResult struct {
+	CourseID    int        `json:"course_id"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // 復元時は未設定
+	Tests       int        `json:"tests"`                // 連動して削除・復元したテスト数
+	Videos      int        `json:"videos"`               // 連動して削除・復元した動画数
+	Grades      int        `json:"grades"`               // 保持している成績数（削除中は表示しない）
+	Submissions int        `json:"submissions"`          // 保持している提出済み解答数（削除中は表示しない）
+	Attendances int        `json:"attendances"`          // 保持している出席数（削除中は表示しない）
+}
+
+// CourseCascadeResponse 授業削除・復元レスポンスの構造体
+type CourseCascadeResponse struct {
+	Status string              `json:"status"`
+	Data   CourseCascadeResult `json:"data"`
+}
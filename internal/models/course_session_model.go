@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// CourseSession 授業回テーブル（授業の繰り返しを展開した1回分）
+type CourseSession struct {
+	SessionID        int       `json:"session_id"`
+	CourseID         int       `json:"course_id"`
+	OriginalStartsAt time.Time `json:"original_starts_at"` // 繰り返しから展開した日時（RECURRENCE-ID）
+	StartsAt         time.Time `json:"starts_at"`
+	EndsAt           time.Time `json:"ends_at"`
+	Status           string    `json:"status"`
+	IsOverride       bool      `json:"is_override"` // 個別に移動・休講した回か（繰り返しの変更で上書きしない）
+	Note             string    `json:"note"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// 授業回の状態
+const (
+	CourseSessionStatusScheduled = "scheduled"
+	CourseSessionStatusCancelled = "cancelled"
+)
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// CourseSessionListRequest 授業回一覧取得リクエストの構造体
+type CourseSessionListRequest struct {
+	From             string `query:"from"` // starts_atがこの日時以降（RFC3339またはYYYY-MM-DD）
+	To               string `query:"to"`   // starts_atがこの日時より前（日付のみの場合はその日を含む）
+	IncludeCancelled bool   `query:"include_cancelled"`
+}
+
+// CourseSessionListResponse 授業回一覧レスポンスの構造体
+type CourseSessionListResponse struct {
+	Count    int             `json:"count"`
+	Sessions []CourseSession `json:"sessions"`
+}
+
+// CourseSessionUpdateRequest 授業回の個別変更（移動・休講）リクエストの構造体
+// 指定した項目のみ変更し、変更した回は以降の繰り返しの変更で上書きしない
+type CourseSessionUpdateRequest struct {
+	StartsAt          *time.Time `json:"starts_at"`
+	DurationMinutes   *int       `json:"duration_minutes"`
+	Status            *string    `json:"status"` // scheduled, cancelled
+	Note              *string    `json:"note"`
+	OverrideConflicts bool       `json:"override_conflicts"` // 予定の重複があっても変更する（管理者のみ）
+}
+
+// SessionAttendanceEntry 授業回の出席1件
+type SessionAttendanceEntry struct {
+	StudentUserID int    `json:"student_user_id" validate:"required"`
+	Status        string `json:"status" validate:"required,oneof=present absent late"`
+}
+
+// SessionAttendanceRequest 授業回の出席記録リクエストの構造体（指定した学生の出席を登録・更新する）
+type SessionAttendanceRequest struct {
+	Attendances []SessionAttendanceEntry `json:"attendances" validate:"required"`
+}
+
+// SessionAttendanceResponse 授業回の出席レスポンスの構造体（未記録の学生はstatusがnull）
+type SessionAttendanceResponse struct {
+	StudentUserID int     `json:"student_user_id"`
+	StudentName   string  `json:"student_name"`
+	AttendanceID  *int    `json:"attendance_id"`
+	Status        *string `json:"status"`
+}
+
+// SessionAttendanceListResponse 授業回の出席一覧レスポンスの構造体
+type SessionAttendanceListResponse struct {
+	Session     CourseSession               `json:"session"`
+	Count       int                         `json:"count"`
+	Attendances []SessionAttendanceResponse `json:"attendances"`
+}
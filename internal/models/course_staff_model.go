@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// CourseStaff 授業の担当教師テーブル
+type CourseStaff struct {
+	CourseID  int       `json:"course_id"`
+	UserID    int       `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 授業での担当（主担当は授業ごとに1人）
+// 主担当は担当教師の管理・授業の削除、副担当は授業・テスト・成績の編集、アシスタントは出席の記録ができる
+const (
+	CourseStaffRoleOwner     = "owner"
+	CourseStaffRoleCoTeacher = "co_teacher"
+	CourseStaffRoleAssistant = "assistant"
+)
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// CourseStaffCreateRequest 担当教師追加リクエストの構造体
+type CourseStaffCreateRequest struct {
+	UserID int    `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required"` // co_teacher, assistant
+}
+
+// CourseStaffUpdateRequest 担当変更リクエストの構造体
+type CourseStaffUpdateRequest struct {
+	Role string `json:"role" validate:"required"` // owner（主担当の交代）, co_teacher, assistant
+}
+
+// CourseStaffResponse 担当教師レスポンスの構造体
+type CourseStaffResponse struct {
+	CourseID  int       `json:"course_id"`
+	UserID    int       `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	UserEmail string    `json:"user_email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CourseStaffListResponse 担当教師一覧レスポンスの構造体
+type CourseStaffListResponse struct {
+	Count int                   `json:"count"`
+	Staff []CourseStaffResponse `json:"staff"`
+}
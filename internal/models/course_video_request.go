@@ -0,0 +1,15 @@
+package models
+
+// CourseVideoListResponse 授業動画一覧レスポンスの構造体
+type CourseVideoListResponse struct {
+	Count  int           `json:"count"`
+	Videos []CourseVideo `json:"videos"`
+}
+
+// CourseVideoUpdateRequest 授業動画の更新リクエストの構造体
+type CourseVideoUpdateRequest struct {
+	DurationSeconds *float64 `json:"duration_seconds" validate:"required"` // 動画の長さ（秒）
+}
+
+// CourseVideoStreamPath 授業動画の配信エンドポイントのパス（course_id, video_id）
+const CourseVideoStreamPath = "/courses/%d/videos/%d/stream"
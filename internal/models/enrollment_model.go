@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Enrollment 受講登録テーブル
+type Enrollment struct {
+	EnrollmentID  int       `json:"enrollment_id"`
+	CourseID      int       `json:"course_id"`
+	StudentUserID int       `json:"student_user_id"`
+	Status        string    `json:"status"`
+	SourcedID     *string   `json:"sourced_id,omitempty"` // 名簿インポートの外部ID（NULL許容）
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// 受講登録の状態（受講中・修了の学生は授業を参照できる）
+const (
+	EnrollmentStatusActive    = "active"
+	EnrollmentStatusDropped   = "dropped"
+	EnrollmentStatusCompleted = "completed"
+)
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// EnrollmentCreateRequest 受講登録リクエストの構造体
+type EnrollmentCreateRequest struct {
+	StudentUserID int `json:"student_user_id" validate:"required"`
+}
+
+// EnrollmentUpdateRequest 受講状態変更リクエストの構造体
+type EnrollmentUpdateRequest struct {
+	Status string `json:"status" validate:"required"` // active, dropped, completed
+}
+
+// EnrollmentListRequest 受講者一覧取得リクエストの構造体
+type EnrollmentListRequest struct {
+	Status string `query:"status"` // 未指定の場合はすべての状態
+}
+
+// EnrollmentResponse 受講登録レスポンスの構造体
+type EnrollmentResponse struct {
+	EnrollmentID  int       `json:"enrollment_id"`
+	CourseID      int       `json:"course_id"`
+	StudentUserID int       `json:"student_user_id"`
+	StudentName   string    `json:"student_name"`
+	StudentEmail  string    `json:"student_email"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// EnrollmentListResponse 受講者一覧レスポンスの構造体
+type EnrollmentListResponse struct {
+	Count       int                  `json:"count"`
+	Enrollments []EnrollmentResponse `json:"enrollments"`
+}
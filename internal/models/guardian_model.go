@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// Guardian 保護者・学生の紐付けテーブル
+type Guardian struct {
+	GuardianID    int       `json:"guardian_id"`
+	ParentUserID  int       `json:"parent_user_id"`
+	StudentUserID int       `json:"student_user_id"`
+	Relationship  string    `json:"relationship"` // 続柄（母、父など）
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	IsDeleted     bool      `json:"is_deleted"`
+	SourcedID     *string   `json:"sourced_id,omitempty"` // 名簿インポートの外部ID（NULL許容）
+}
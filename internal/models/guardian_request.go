@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// GuardianCreateRequest 保護者紐付け登録リクエストの構造体
+type GuardianCreateRequest struct {
+	ParentUserID  int    `json:"parent_user_id" validate:"required"`
+	StudentUserID int    `json:"student_user_id" validate:"required"`
+	Relationship  string `json:"relationship"`
+}
+
+// GuardianResponse 保護者紐付けレスポンスの構造体
+type GuardianResponse struct {
+	GuardianID    int       `json:"guardian_id"`
+	ParentUserID  int       `json:"parent_user_id"`
+	ParentName    string    `json:"parent_name"`
+	StudentUserID int       `json:"student_user_id"`
+	StudentName   string    `json:"student_name"`
+	Relationship  string    `json:"relationship"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// GuardianListResponse 保護者紐付け一覧レスポンスの構造体
+type GuardianListResponse struct {
+	Count     int                `json:"count"`
+	Guardians []GuardianResponse `json:"guardians"`
+}
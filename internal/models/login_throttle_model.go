@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginThrottle ログイン失敗カウンターテーブル
+type LoginThrottle struct {
+	ThrottleKey  string     `json:"throttle_key"`
+	FailedCount  int        `json:"failed_count"`
+	LastFailedAt *time.Time `json:"last_failed_at"` // NULL許容
+	LockedUntil  *time.Time `json:"locked_until"`   // NULL許容
+}
+
+// LockoutEvent ロックアウト記録テーブル
+type LockoutEvent struct {
+	LockoutEventID int        `json:"lockout_event_id"`
+	ThrottleKey    string     `json:"throttle_key"`
+	UserID         *int       `json:"user_id"` // NULL許容（IP単位のロック）
+	IPAddress      string     `json:"ip_address"`
+	FailedCount    int        `json:"failed_count"`
+	LockedUntil    time.Time  `json:"locked_until"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UnlockedAt     *time.Time `json:"unlocked_at"` // NULL許容
+	UnlockedBy     *int       `json:"unlocked_by"` // NULL許容
+}
@@ -0,0 +1,13 @@
+package models
+
+// OIDCAuthorizeResponse OIDCログイン開始レスポンスの構造体
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"` // ブラウザをこのURLへ遷移させる
+	ExpiresIn        int    `json:"expires_in"`        // stateの有効秒数
+}
+
+// OIDCCallbackRequest OIDCログイン完了リクエストの構造体（リダイレクトURIに渡されたcodeとstate）
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
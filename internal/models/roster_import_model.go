@@ -0,0 +1,62 @@
+package models
+
+// 名簿インポートのCSVファイル名
+const (
+	RosterFileUsers       = "users.csv"
+	RosterFileClasses     = "classes.csv"
+	RosterFileEnrollments = "enrollments.csv"
+	RosterFileGuardians   = "guardians.csv"
+)
+
+// 名簿インポートの行ごとの処理内容
+const (
+	RosterActionCreate    = "create"
+	RosterActionUpdate    = "update"
+	RosterActionDelete    = "delete"
+	RosterActionUnchanged = "unchanged"
+	RosterActionSkip      = "skip"
+)
+
+// RosterImportReport 名簿インポートの結果（ドライランの場合は適用した場合の差分）
+type RosterImportReport struct {
+	DryRun  bool                            `json:"dry_run"`
+	Applied bool                            `json:"applied"` // エラーがある場合は何も適用しない
+	Summary map[string]*RosterImportSummary `json:"summary"` // ファイル名ごとの件数
+	Changes []RosterImportChange            `json:"changes"`
+	Errors  []RosterImportError             `json:"errors"`
+}
+
+// RosterImportSummary ファイルごとの処理件数
+type RosterImportSummary struct {
+	Create    int `json:"create"`
+	Update    int `json:"update"`
+	Delete    int `json:"delete"`
+	Unchanged int `json:"unchanged"`
+	Skip      int `json:"skip"`
+	Error     int `json:"error"`
+}
+
+// RosterImportChange 行ごとの処理内容
+type RosterImportChange struct {
+	File      string                       `json:"file"`
+	Line      int                          `json:"line"`
+	SourcedID string                       `json:"sourced_id"`
+	Action    string                       `json:"action"`
+	EntityID  int                          `json:"entity_id,omitempty"` // ドライランでの新規作成時は未設定
+	Changes   map[string]RosterFieldChange `json:"changes,omitempty"`
+	Note      string                       `json:"note,omitempty"`
+}
+
+// RosterFieldChange 項目ごとの変更前・変更後の値
+type RosterFieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// RosterImportError 行ごとの入力値エラー（Lineが0の場合はファイル全体のエラー）
+type RosterImportError struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	SourcedID string `json:"sourced_id,omitempty"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// ScheduleSlot 予定の時間帯（壁時計の時刻）
+type ScheduleSlot struct {
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// ScheduleConflict 予定の重複1件（登録・変更しようとした時間帯と、重複する他の授業回・テスト）
+type ScheduleConflict struct {
+	Kind         string    `json:"kind"` // teacher, student
+	UserID       int       `json:"user_id"`
+	UserName     string    `json:"user_name"`
+	StartsAt     time.Time `json:"starts_at"` // 登録・変更しようとした時間帯
+	EndsAt       time.Time `json:"ends_at"`
+	ItemType     string    `json:"item_type"` // session, test
+	ItemID       int       `json:"item_id"`   // 授業回IDまたはテストID
+	CourseID     int       `json:"course_id"`
+	CourseTitle  string    `json:"course_title"`
+	Title        string    `json:"title"` // 授業名またはテスト名
+	ItemStartsAt time.Time `json:"item_starts_at"`
+	ItemEndsAt   time.Time `json:"item_ends_at"`
+}
+
+// 予定が重複する対象
+const (
+	ScheduleConflictKindTeacher = "teacher"
+	ScheduleConflictKindStudent = "student"
+)
+
+// 重複する予定の種類
+const (
+	ScheduleItemSession = "session"
+	ScheduleItemTest    = "test"
+)
+
+// ScheduleConflictData 予定の重複エラーで返す重複の一覧
+type ScheduleConflictData struct {
+	Total     int                `json:"total"` // 重複の総数（conflictsは先頭のみ）
+	Count     int                `json:"count"`
+	Conflicts []ScheduleConflict `json:"conflicts"`
+}
+
+// ScheduleConflictResponse 予定の重複エラー（409 Conflict）レスポンスのヘルパー関数
+func ScheduleConflictResponse(details string, total int, conflicts []ScheduleConflict) CommonResponse {
+	response := NewErrorResponse(ErrorCodeConflict, ErrorMessageConflict, details)
+	response.Data = ScheduleConflictData{
+		Total:     total,
+		Count:     len(conflicts),
+		Conflicts: conflicts,
+	}
+	return response
+}
@@ -0,0 +1,23 @@
+package models
+
+// SubjectRequest 教科登録・名称変更リクエストの構造体
+type SubjectRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// SubjectListRequest 教科一覧取得リクエストの構造体
+type SubjectListRequest struct {
+	IncludeDeleted bool `query:"include_deleted"`
+}
+
+// SubjectResponse 教科レスポンスの構造体
+type SubjectResponse struct {
+	Subject
+	CourseCount int `json:"course_count"` // 教科を参照している有効な授業数
+}
+
+// SubjectListResponse 教科一覧レスポンスの構造体
+type SubjectListResponse struct {
+	Count    int               `json:"count"`
+	Subjects []SubjectResponse `json:"subjects"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// UserTOTP TOTP二要素認証設定テーブル
+type UserTOTP struct {
+	UserID       int        `json:"user_id"`
+	Secret       string     `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"` // NULL許容（登録手続き中）
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// TOTPRecoveryCode リカバリーコードテーブル
+type TOTPRecoveryCode struct {
+	RecoveryCodeID int        `json:"recovery_code_id"`
+	UserID         int        `json:"user_id"`
+	CodeHash       string     `json:"-"`
+	UsedAt         *time.Time `json:"used_at"` // NULL許容
+	CreatedAt      time.Time  `json:"created_at"`
+}
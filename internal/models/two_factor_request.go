@@ -0,0 +1,32 @@
+package models
+
+// TwoFactorEnrollResponse 二要素認証登録開始レスポンスの構造体
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest 認証コード送信リクエストの構造体
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TwoFactorConfirmResponse 二要素認証登録完了レスポンスの構造体
+type TwoFactorConfirmResponse struct {
+	Status        string   `json:"status"`
+	RecoveryCodes []string `json:"recovery_codes"` // この応答でのみ表示される
+}
+
+// TwoFactorStatusResponse 二要素認証の状態レスポンスの構造体
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RemainingRecoveryCodes int  `json:"remaining_recovery_codes"`
+}
+
+// TwoFactorLoginRequest 二要素認証ログインリクエストの構造体（codeかrecovery_codeのどちらか）
+type TwoFactorLoginRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
@@ -2,10 +2,23 @@ package models
 
 // User ユーザーの構造体
 type User struct {
-	UserID   int    `json:"user_id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Phone    string `json:"phone"`
-	Role     string `json:"role"` // "student", "teacher", "parent" など
-} 
\ No newline at end of file
+	UserID    int    `json:"user_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Password  string `json:"-"` // bcryptハッシュ（シリアライズしない）
+	Phone     string `json:"phone"`
+	Role      string `json:"role"` // "student", "teacher", "parent", "admin" など
+	IsDeleted bool   `json:"is_deleted"`
+	// サービスアカウント（パスワードでログインできず、APIキーでのみ利用する）
+	IsServiceAccount bool `json:"is_service_account"`
+	// 名簿インポートの外部ID（OneRosterのsourcedId、NULL許容）
+	SourcedID *string `json:"sourced_id,omitempty"`
+}
+
+// ユーザーの役割
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleParent  = "parent"
+	RoleAdmin   = "admin"
+)
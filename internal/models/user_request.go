@@ -8,12 +8,19 @@ type UserLoginRequest struct {
 
 // UserLoginResponse ユーザーログインレスポンスの構造体
 type UserLoginResponse struct {
-	UserID   int    `json:"user_id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
-	Token    string `json:"token,omitempty"`
-	Message  string `json:"message"`
+	UserID       int    `json:"user_id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"` // アクセストークンの有効秒数
+	// 二要素認証が必要な場合はトークンの代わりにmfa_tokenを返す
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+	// 役割により二要素認証が必須だが未登録の場合（登録以外の操作は拒否される）
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	Message               string `json:"message"`
 }
 
 // UserRegistrationRequest ユーザー登録リクエストの構造体
@@ -22,7 +29,7 @@ type UserRegistrationRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
 	Phone    string `json:"phone"`
-	Role     string `json:"role" validate:"required,oneof=student teacher parent"`
+	Role     string `json:"role" validate:"required,oneof=student parent"`
 }
 
 // UserProfileUpdateRequest ユーザープロフィール更新リクエストの構造体
@@ -30,4 +37,68 @@ type UserProfileUpdateRequest struct {
 	Name  string `json:"name" validate:"required"`
 	Email string `json:"email" validate:"required,email"`
 	Phone string `json:"phone"`
-} 
\ No newline at end of file
+}
+
+// UserResponse ユーザー情報レスポンスの構造体
+type UserResponse struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Phone  string `json:"phone"`
+	Role   string `json:"role"`
+}
+
+// UserProfileResponse ユーザープロフィールレスポンスの構造体
+type UserProfileResponse struct {
+	Status       string       `json:"status"`
+	Data         UserResponse `json:"data"`
+	PendingEmail string       `json:"pending_email,omitempty"` // 確認待ちの新しいメールアドレス
+	Message      string       `json:"message,omitempty"`
+}
+
+// EmailVerificationRequest メールアドレス変更確認リクエストの構造体
+type EmailVerificationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// UserListRequest ユーザー一覧取得リクエストの構造体（管理者用）
+type UserListRequest struct {
+	Query          string `query:"q"`
+	Role           string `query:"role"`
+	IncludeDeleted bool   `query:"include_deleted"`
+	Limit          int    `query:"limit"`
+	Offset         int    `query:"offset"`
+}
+
+// AdminUserResponse ユーザー情報レスポンスの構造体（管理者用）
+type AdminUserResponse struct {
+	UserID    int    `json:"user_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	Role      string `json:"role"`
+	IsDeleted bool   `json:"is_deleted"`
+	// サービスアカウントかどうか
+	IsServiceAccount bool `json:"is_service_account"`
+}
+
+// UserListResponse ユーザー一覧レスポンスの構造体
+type UserListResponse struct {
+	Count int                 `json:"count"`
+	Total int                 `json:"total"`
+	Users []AdminUserResponse `json:"users"`
+}
+
+// AdminUserCreateRequest ユーザー作成リクエストの構造体（管理者用）
+type AdminUserCreateRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8"`
+	Phone    string `json:"phone"`
+	Role     string `json:"role" validate:"required,oneof=student teacher parent admin"`
+}
+
+// UserRoleUpdateRequest ユーザーの役割変更リクエストの構造体
+type UserRoleUpdateRequest struct {
+	Role string `json:"role" validate:"required,oneof=student teacher parent admin"`
+}
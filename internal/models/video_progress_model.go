@@ -0,0 +1,67 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// WatchInterval 動画の視聴した区間（秒）
+type WatchInterval struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// VideoWatchProgress 動画視聴状況テーブル
+type VideoWatchProgress struct {
+	VideoID        int             `json:"video_id"`
+	StudentUserID  int             `json:"student_user_id"`
+	Intervals      []WatchInterval `json:"intervals"`       // 重なりを統合した視聴区間
+	WatchedSeconds float64         `json:"watched_seconds"` // 視聴区間の合計
+	LastPosition   float64         `json:"last_position"`   // 再開位置
+	LastWatchedAt  time.Time       `json:"last_watched_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// MergeWatchIntervals 視聴区間を開始位置順に並べ、重なる区間とgap秒以内で隣り合う区間を統合する
+func MergeWatchIntervals(intervals []WatchInterval, gap float64) []WatchInterval {
+	sorted := make([]WatchInterval, len(intervals))
+	copy(sorted, intervals)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	merged := []WatchInterval{}
+	for _, interval := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && interval.Start <= merged[last].End+gap {
+			if interval.End > merged[last].End {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+
+	return merged
+}
+
+// ClampWatchInterval 視聴区間の長さをmaxSeconds以内に切り詰める（残らない場合はnil）
+func ClampWatchInterval(interval WatchInterval, maxSeconds float64) *WatchInterval {
+	if interval.End-interval.Start > maxSeconds {
+		interval.End = interval.Start + maxSeconds
+	}
+	if interval.End <= interval.Start {
+		return nil
+	}
+	return &interval
+}
+
+// TotalWatchedSeconds 統合済みの視聴区間の合計秒数
+func TotalWatchedSeconds(intervals []WatchInterval) float64 {
+	var total float64
+	for _, interval := range intervals {
+		total += interval.End - interval.Start
+	}
+	return total
+}
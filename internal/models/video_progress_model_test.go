@@ -0,0 +1,112 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testWatchIntervalGap 視聴状況の保存時に使う統合の間隔（repositories.watchIntervalGapSeconds）
+const testWatchIntervalGap = 1
+
+func TestMergeWatchIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []WatchInterval
+		gap       float64
+		want      []WatchInterval
+	}{
+		{"empty", nil, testWatchIntervalGap, []WatchInterval{}},
+		{"single", []WatchInterval{{10, 20}}, testWatchIntervalGap, []WatchInterval{{10, 20}}},
+		{"overlap", []WatchInterval{{0, 10}, {5, 15}}, testWatchIntervalGap, []WatchInterval{{0, 15}}},
+		{"touching", []WatchInterval{{0, 10}, {10, 20}}, 0, []WatchInterval{{0, 20}}},
+		{"adjacent within gap", []WatchInterval{{0, 10}, {10.5, 20}}, testWatchIntervalGap, []WatchInterval{{0, 20}}},
+		{"adjacent at gap", []WatchInterval{{0, 10}, {11, 20}}, testWatchIntervalGap, []WatchInterval{{0, 20}}},
+		{"beyond gap", []WatchInterval{{0, 10}, {11.5, 20}}, testWatchIntervalGap, []WatchInterval{{0, 10}, {11.5, 20}}},
+		{"gap zero keeps separate", []WatchInterval{{0, 10}, {10.5, 20}}, 0, []WatchInterval{{0, 10}, {10.5, 20}}},
+		{"containment", []WatchInterval{{0, 30}, {5, 10}}, testWatchIntervalGap, []WatchInterval{{0, 30}}},
+		{"contained first", []WatchInterval{{5, 10}, {0, 30}}, testWatchIntervalGap, []WatchInterval{{0, 30}}},
+		{"duplicate", []WatchInterval{{0, 10}, {0, 10}}, testWatchIntervalGap, []WatchInterval{{0, 10}}},
+		{
+			"unsorted",
+			[]WatchInterval{{40, 50}, {0, 10}, {20, 30}, {9, 21}},
+			testWatchIntervalGap,
+			[]WatchInterval{{0, 30}, {40, 50}},
+		},
+		{
+			"chain of heartbeats",
+			[]WatchInterval{{0, 10}, {10.2, 20}, {20.9, 30}, {45, 55}, {55.5, 60}},
+			testWatchIntervalGap,
+			[]WatchInterval{{0, 30}, {45, 60}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input []WatchInterval
+			if tt.intervals != nil {
+				input = append([]WatchInterval{}, tt.intervals...)
+			}
+
+			got := MergeWatchIntervals(input, tt.gap)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeWatchIntervals(%v, %v) = %v, want %v", tt.intervals, tt.gap, got, tt.want)
+			}
+			// 引数の区間は変更しない
+			if !reflect.DeepEqual(input, tt.intervals) {
+				t.Errorf("MergeWatchIntervals modified its input: %v, want %v", input, tt.intervals)
+			}
+		})
+	}
+}
+
+func TestClampWatchInterval(t *testing.T) {
+	tests := []struct {
+		name       string
+		interval   WatchInterval
+		maxSeconds float64
+		want       *WatchInterval
+	}{
+		{"within limit", WatchInterval{10, 20}, 30, &WatchInterval{10, 20}},
+		{"exactly the limit", WatchInterval{10, 40}, 30, &WatchInterval{10, 40}},
+		{"longer than limit", WatchInterval{10, 100}, 30, &WatchInterval{10, 40}},
+		{"fractional limit", WatchInterval{0, 10}, 2.5, &WatchInterval{0, 2.5}},
+		{"zero limit", WatchInterval{10, 20}, 0, nil},
+		{"empty interval", WatchInterval{10, 10}, 30, nil},
+		{"reversed interval", WatchInterval{20, 10}, 30, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClampWatchInterval(tt.interval, tt.maxSeconds)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClampWatchInterval(%v, %v) = %v, want %v", tt.interval, tt.maxSeconds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotalWatchedSeconds(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []WatchInterval
+		want      float64
+	}{
+		{"empty", nil, 0},
+		{"single", []WatchInterval{{10, 25.5}}, 15.5},
+		{"several", []WatchInterval{{0, 30}, {45, 60}}, 45},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TotalWatchedSeconds(tt.intervals); got != tt.want {
+				t.Errorf("TotalWatchedSeconds(%v) = %v, want %v", tt.intervals, got, tt.want)
+			}
+		})
+	}
+
+	// 重なる区間は統合してから合計するため、同じ区間を繰り返し視聴しても増えない
+	rewatched := MergeWatchIntervals([]WatchInterval{{0, 30}, {10, 20}, {0, 30}, {25, 35}}, testWatchIntervalGap)
+	if got := TotalWatchedSeconds(rewatched); got != 35 {
+		t.Errorf("TotalWatchedSeconds(merged) = %v, want 35", got)
+	}
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// VideoProgressRequest 動画視聴状況の記録（ハートビート）リクエストの構造体
+// 前回の記録以降に再生した区間をstart・endで、現在の再生位置をpositionで送る
+// 動画の長さは担当教師が設定した値を使うため、プレイヤーから送らない
+type VideoProgressRequest struct {
+	Start    *float64 `json:"start"`                        // 再生した区間の開始（秒、endと組で指定）
+	End      *float64 `json:"end"`                          // 再生した区間の終了（秒）
+	Position *float64 `json:"position" validate:"required"` // 現在の再生位置（秒）
+}
+
+// VideoProgressResponse 動画視聴状況レスポンスの構造体（学生本人の再開位置・視聴率）
+type VideoProgressResponse struct {
+	VideoID           int             `json:"video_id"`
+	LastPosition      float64         `json:"last_position"`
+	WatchedSeconds    float64         `json:"watched_seconds"`
+	DurationSeconds   *float64        `json:"duration_seconds"`   // 不明な場合はnull
+	CompletionPercent *float64        `json:"completion_percent"` // 動画の長さが不明な場合はnull
+	Intervals         []WatchInterval `json:"intervals"`
+	LastWatchedAt     *time.Time      `json:"last_watched_at"` // 未視聴の場合はnull
+}
+
+// WatchReportVideo 視聴状況レポートの対象動画
+type WatchReportVideo struct {
+	VideoID         int      `json:"video_id"`
+	Filename        string   `json:"filename"`
+	DurationSeconds *float64 `json:"duration_seconds"`
+}
+
+// StudentVideoWatch 学生1人の動画ごとの視聴状況
+type StudentVideoWatch struct {
+	VideoID           int        `json:"video_id"`
+	WatchedSeconds    float64    `json:"watched_seconds"`
+	CompletionPercent *float64   `json:"completion_percent"` // 動画の長さが不明な場合はnull
+	LastPosition      float64    `json:"last_position"`
+	LastWatchedAt     *time.Time `json:"last_watched_at"` // 未視聴の場合はnull
+}
+
+// StudentWatchReport 学生1人の授業全体の視聴状況
+type StudentWatchReport struct {
+	StudentUserID     int                 `json:"student_user_id"`
+	StudentName       string              `json:"student_name"`
+	StudentEmail      string              `json:"student_email"`
+	CompletionPercent *float64            `json:"completion_percent"` // 長さが判明している動画の合計に対する視聴率
+	LastWatchedAt     *time.Time          `json:"last_watched_at"`    // 未視聴の場合はnull
+	Videos            []StudentVideoWatch `json:"videos"`
+}
+
+// CourseWatchReportResponse 授業の視聴状況レポートレスポンスの構造体
+type CourseWatchReportResponse struct {
+	CourseID int                  `json:"course_id"`
+	Videos   []WatchReportVideo   `json:"videos"`
+	Count    int                  `json:"count"`
+	Students []StudentWatchReport `json:"students"`
+}
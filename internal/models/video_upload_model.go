@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// VideoUpload 再開可能な動画アップロードテーブル（tus）
+type VideoUpload struct {
+	UploadID     string     `json:"upload_id"`
+	CourseID     int        `json:"course_id"`
+	UserID       int        `json:"user_id"`
+	Filename     string     `json:"filename"`
+	ContentType  string     `json:"content_type"`
+	UploadLength int64      `json:"upload_length"`
+	UploadOffset int64      `json:"upload_offset"`
+	Metadata     string     `json:"metadata"` // 作成時のUpload-Metadataヘッダー
+	VideoID      *int       `json:"video_id"` // NULL許容（完了時に登録した動画）
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CompletedAt  *time.Time `json:"completed_at"` // NULL許容
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// IsCompleted アップロードが完了して動画が登録済みか
+func (u *VideoUpload) IsCompleted() bool {
+	return u.CompletedAt != nil
+}
+
+// VideoUploadPath 再開可能なアップロードのエンドポイントのパス（course_id, upload_id）
+const VideoUploadPath = "/courses/%d/videos/uploads/%s"
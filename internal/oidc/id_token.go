@@ -0,0 +1,92 @@
+package oidc
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// IDToken 検証済みIDトークンのクレーム
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool // クレームがない場合はnil
+	Name          string
+	Claims        jwt.MapClaims
+}
+
+// VerifyIDToken IDトークンの署名（JWKSのRS256鍵）・発行者・対象者・有効期限・nonceを検証する
+func (p *Provider) VerifyIDToken(rawIDToken string, nonce string) (*IDToken, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		// RS256以外の署名方式は受け付けない（alg=noneやHS256への差し替えを防ぐ）
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		keyID, _ := token.Header["kid"].(string)
+		return p.publicKey(keyID)
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid id token: %v", err)
+	}
+
+	now := jwt.TimeFunc().Unix()
+	if !claims.VerifyExpiresAt(now, true) {
+		return nil, fmt.Errorf("invalid id token: exp is required")
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if strings.TrimSuffix(issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("invalid id token: issuer mismatch")
+	}
+
+	if !claims.VerifyAudience(p.config.ClientID, true) {
+		return nil, fmt.Errorf("invalid id token: audience mismatch")
+	}
+
+	// 対象者が複数の場合はazpが自分である必要がある
+	if audiences, ok := claims["aud"].([]interface{}); ok && len(audiences) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.config.ClientID {
+			return nil, fmt.Errorf("invalid id token: authorized party mismatch")
+		}
+	}
+
+	tokenNonce, _ := claims["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("invalid id token: nonce mismatch")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("invalid id token: sub is required")
+	}
+
+	idToken := &IDToken{Subject: subject, Claims: claims}
+	idToken.Email, _ = claims["email"].(string)
+	idToken.Name, _ = claims["name"].(string)
+	if verified, ok := claims["email_verified"].(bool); ok {
+		idToken.EmailVerified = &verified
+	}
+
+	return idToken, nil
+}
+
+// StringValues クレームの値を文字列の配列として取得する（文字列・配列のどちらにも対応）
+func (t *IDToken) StringValues(name string) []string {
+	switch v := t.Claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
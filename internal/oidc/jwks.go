@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// jwksRefreshInterval 未知の鍵IDに対してJWKSを再取得する最小間隔
+const jwksRefreshInterval = time.Minute
+
+// jsonWebKey JWKSに含まれる鍵（RSAのみ使用）
+type jsonWebKey struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+}
+
+// keySet 取得済みの署名検証鍵
+type keySet struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// publicKey 鍵IDに対応する署名検証鍵を返す
+// 見つからない場合は鍵のローテーションを考慮してJWKSを再取得する
+func (p *Provider) publicKey(keyID string) (*rsa.PublicKey, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil {
+		if key, ok := p.lookupKey(keyID); ok {
+			return key, nil
+		}
+		if time.Since(p.keys.fetchedAt) < jwksRefreshInterval {
+			return nil, fmt.Errorf("signing key %q not found", keyID)
+		}
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(meta.JWKSURI, &document); err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range document.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := parseRSAPublicKey(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	p.keys = &keySet{keys: keys, fetchedAt: time.Now()}
+
+	if key, ok := p.lookupKey(keyID); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("signing key %q not found", keyID)
+}
+
+// lookupKey キャッシュから鍵を探す（鍵IDが省略されていて鍵が1つだけの場合はそれを使う）
+func (p *Provider) lookupKey(keyID string) (*rsa.PublicKey, bool) {
+	if keyID == "" && len(p.keys.keys) == 1 {
+		for _, key := range p.keys.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys.keys[keyID]
+	return key, ok
+}
+
+// parseRSAPublicKey JWKのモジュラスと指数からRSA公開鍵を組み立てる
+func parseRSAPublicKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
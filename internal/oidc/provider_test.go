@@ -0,0 +1,470 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	testClientID = "go-study"
+	testKeyID    = "test-key-1"
+	testNonce    = "test-nonce"
+)
+
+// testIdP テスト用のIdP（ディスカバリー・JWKS・トークンエンドポイント）
+type testIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu            sync.Mutex
+	issuer        string // ディスカバリーで返す発行者（空の場合はサーバーのURL）
+	codeChallenge string // 認可リクエストで受け取ったcode_challenge
+	jwksRequests  int
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &testIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		issuer := idp.issuer
+		idp.mu.Unlock()
+		if issuer == "" {
+			issuer = idp.server.URL
+		}
+		writeTestJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 issuer,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		idp.jwksRequests++
+		idp.mu.Unlock()
+		writeTestJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": testKeyID,
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+			writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+			return
+		}
+
+		// PKCE（S256）の検証（CodeChallengeを使わずに計算する）
+		idp.mu.Lock()
+		challenge := idp.codeChallenge
+		idp.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if challenge == "" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		writeTestJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, idp.claims(), testKeyID),
+			"expires_in":   300,
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// provider IdPに接続するプロバイダークライアントを作成する
+func (idp *testIdP) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:      idp.server.URL + "/",
+		ClientID:    testClientID,
+		RedirectURL: "https://app.example.com/callback",
+		Scopes:      []string{"email", "openid"},
+	})
+}
+
+// authorize 認可URLのcode_challengeをIdPに記録する（ブラウザでの認可の代わり）
+func (idp *testIdP) authorize(t *testing.T, authorizationURL string) url.Values {
+	t.Helper()
+
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+
+	idp.mu.Lock()
+	idp.codeChallenge = query.Get("code_challenge")
+	idp.mu.Unlock()
+
+	return query
+}
+
+// claims 有効なIDトークンのクレーム
+func (idp *testIdP) claims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            testClientID,
+		"sub":            "user-1",
+		"email":          "student@example.com",
+		"email_verified": true,
+		"nonce":          testNonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+// sign IdPの鍵でIDトークンに署名する
+func (idp *testIdP) sign(t *testing.T, claims jwt.MapClaims, keyID string) string {
+	t.Helper()
+	return signTestToken(t, idp.key, claims, keyID)
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims, keyID string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if keyID != "" {
+		token.Header["kid"] = keyID
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestDiscovery(t *testing.T) {
+	idp := newTestIdP(t)
+	provider := idp.provider()
+
+	if provider.Issuer() != idp.server.URL {
+		t.Errorf("Issuer() = %q, want trailing slash trimmed %q", provider.Issuer(), idp.server.URL)
+	}
+
+	meta, err := provider.discover()
+	if err != nil {
+		t.Fatalf("discover() error = %v", err)
+	}
+	if meta.TokenEndpoint != idp.server.URL+"/token" || meta.JWKSURI != idp.server.URL+"/jwks" {
+		t.Errorf("discover() = %+v", meta)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newTestIdP(t)
+	idp.issuer = "https://evil.example.com"
+
+	if _, err := idp.provider().discover(); err == nil || !strings.Contains(err.Error(), "issuer mismatch") {
+		t.Errorf("discover() error = %v, want issuer mismatch", err)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := newTestIdP(t)
+
+	authorizationURL, err := idp.provider().AuthCodeURL("state-1", testNonce, "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	if !strings.HasPrefix(authorizationURL, idp.server.URL+"/authorize?") {
+		t.Errorf("AuthCodeURL() = %q, want authorization endpoint", authorizationURL)
+	}
+
+	query := idp.authorize(t, authorizationURL)
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          "https://app.example.com/callback",
+		"scope":                 "openid email",
+		"state":                 "state-1",
+		"nonce":                 testNonce,
+		"code_challenge":        CodeChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := query.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestExchangePKCE(t *testing.T) {
+	idp := newTestIdP(t)
+	provider := idp.provider()
+
+	authorizationURL, err := provider.AuthCodeURL("state-1", testNonce, "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.authorize(t, authorizationURL)
+
+	// 認可リクエストと異なるcode_verifierはIdPが拒否する
+	if _, err := provider.Exchange("code", "other-verifier"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Exchange(wrong verifier) error = %v, want invalid_grant", err)
+	}
+
+	token, err := provider.Exchange("code", "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	idToken, err := provider.VerifyIDToken(token.IDToken, testNonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	if idToken.Subject != "user-1" || idToken.Email != "student@example.com" {
+		t.Errorf("VerifyIDToken() = %+v", idToken)
+	}
+	if idToken.EmailVerified == nil || !*idToken.EmailVerified {
+		t.Errorf("EmailVerified = %v, want true", idToken.EmailVerified)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := newTestIdP(t)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		nonce   string
+		wantErr string // 空の場合は成功
+	}{
+		{
+			name:  "valid",
+			token: func() string { return idp.sign(t, idp.claims(), testKeyID) },
+		},
+		{
+			name:  "single key without kid",
+			token: func() string { return idp.sign(t, idp.claims(), "") },
+		},
+		{
+			name:    "signed by another key",
+			token:   func() string { return signTestToken(t, otherKey, idp.claims(), testKeyID) },
+			wantErr: "invalid id token",
+		},
+		{
+			name:    "unknown kid",
+			token:   func() string { return idp.sign(t, idp.claims(), "rotated-key") },
+			wantErr: "signing key",
+		},
+		{
+			name: "hs256 with public key as secret",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, idp.claims())
+				token.Header["kid"] = testKeyID
+				signed, err := token.SignedString(idp.key.PublicKey.N.Bytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			},
+			wantErr: "unexpected signing method",
+		},
+		{
+			name: "issuer mismatch",
+			token: func() string {
+				claims := idp.claims()
+				claims["iss"] = "https://evil.example.com"
+				return idp.sign(t, claims, testKeyID)
+			},
+			wantErr: "issuer mismatch",
+		},
+		{
+			name: "audience mismatch",
+			token: func() string {
+				claims := idp.claims()
+				claims["aud"] = "other-client"
+				return idp.sign(t, claims, testKeyID)
+			},
+			wantErr: "audience mismatch",
+		},
+		{
+			name: "multiple audiences without azp",
+			token: func() string {
+				claims := idp.claims()
+				claims["aud"] = []string{testClientID, "other-client"}
+				return idp.sign(t, claims, testKeyID)
+			},
+			wantErr: "authorized party mismatch",
+		},
+		{
+			name: "multiple audiences with azp",
+			token: func() string {
+				claims := idp.claims()
+				claims["aud"] = []string{testClientID, "other-client"}
+				claims["azp"] = testClientID
+				return idp.sign(t, claims, testKeyID)
+			},
+		},
+		{
+			name: "expired",
+			token: func() string {
+				claims := idp.claims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return idp.sign(t, claims, testKeyID)
+			},
+			wantErr: "invalid id token",
+		},
+		{
+			name: "missing exp",
+			token: func() string {
+				claims := idp.claims()
+				delete(claims, "exp")
+				return idp.sign(t, claims, testKeyID)
+			},
+			wantErr: "exp is required",
+		},
+		{
+			name:    "nonce mismatch",
+			token:   func() string { return idp.sign(t, idp.claims(), testKeyID) },
+			nonce:   "other-nonce",
+			wantErr: "nonce mismatch",
+		},
+		{
+			name: "missing sub",
+			token: func() string {
+				claims := idp.claims()
+				delete(claims, "sub")
+				return idp.sign(t, claims, testKeyID)
+			},
+			wantErr: "sub is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce := tt.nonce
+			if nonce == "" {
+				nonce = testNonce
+			}
+
+			_, err := idp.provider().VerifyIDToken(tt.token(), nonce)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("VerifyIDToken() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("VerifyIDToken() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestVerifyIDTokenUnknownKeyRefetch 未知の鍵IDによるJWKSの再取得は一定間隔に制限されること
+func TestVerifyIDTokenUnknownKeyRefetch(t *testing.T) {
+	idp := newTestIdP(t)
+	provider := idp.provider()
+
+	if _, err := provider.VerifyIDToken(idp.sign(t, idp.claims(), testKeyID), testNonce); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := provider.VerifyIDToken(idp.sign(t, idp.claims(), "rotated-key"), testNonce); err == nil {
+			t.Fatal("VerifyIDToken(unknown kid) error = nil")
+		}
+	}
+
+	if idp.jwksRequests != 1 {
+		t.Errorf("jwks requests = %d, want 1", idp.jwksRequests)
+	}
+}
+
+func TestVerifyIDTokenEmailVerified(t *testing.T) {
+	idp := newTestIdP(t)
+
+	tests := []struct {
+		name  string
+		value interface{} // nilの場合はクレームなし
+		want  *bool
+	}{
+		{"true", true, boolPtr(true)},
+		{"false", false, boolPtr(false)},
+		{"missing", nil, nil},
+		{"string is not a boolean", "true", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.claims()
+			delete(claims, "email_verified")
+			if tt.value != nil {
+				claims["email_verified"] = tt.value
+			}
+
+			idToken, err := idp.provider().VerifyIDToken(idp.sign(t, claims, testKeyID), testNonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (idToken.EmailVerified == nil) != (tt.want == nil) || (tt.want != nil && *idToken.EmailVerified != *tt.want) {
+				t.Errorf("EmailVerified = %v, want %v", idToken.EmailVerified, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringValues(t *testing.T) {
+	idToken := &IDToken{Claims: jwt.MapClaims{
+		"role":   "teacher",
+		"groups": []interface{}{"staff", 1, "teachers"},
+		"number": 1,
+	}}
+
+	if got := idToken.StringValues("role"); len(got) != 1 || got[0] != "teacher" {
+		t.Errorf("StringValues(role) = %v", got)
+	}
+	if got := idToken.StringValues("groups"); len(got) != 2 || got[0] != "staff" || got[1] != "teachers" {
+		t.Errorf("StringValues(groups) = %v", got)
+	}
+	if got := idToken.StringValues("number"); got != nil {
+		t.Errorf("StringValues(number) = %v, want nil", got)
+	}
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
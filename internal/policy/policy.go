@@ -0,0 +1,234 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// Action 認可判定の対象となる操作
+type Action string
+
+const (
+	ActionList     Action = "list"
+	ActionRead     Action = "read"
+	ActionCreate   Action = "create"
+	ActionUpdate   Action = "update"
+	ActionDelete   Action = "delete"
+	ActionRestore  Action = "restore"
+	ActionOverride Action = "override" // 予定の重複などのチェックを無視して実行する
+)
+
+// ResourceType 認可判定の対象となるリソースの種類
+type ResourceType string
+
+const (
+	ResourceCourse     ResourceType = "course"
+	ResourceGrade      ResourceType = "grade"
+	ResourceTest       ResourceType = "test"
+	ResourceAttendance ResourceType = "attendance"
+	ResourceUser       ResourceType = "user"
+	ResourceGuardian   ResourceType = "guardian"
+	ResourceAPIKey     ResourceType = "api_key"
+	ResourceAuditLog   ResourceType = "audit_log"
+	ResourceEnrollment ResourceType = "enrollment"
+	ResourceRoster     ResourceType = "roster"
+	ResourceSubject    ResourceType = "subject"
+	ResourceVideo      ResourceType = "video"
+	ResourceWatch      ResourceType = "watch_progress"
+	ResourceSession    ResourceType = "course_session"
+	ResourceCalendar   ResourceType = "calendar_feed"
+	ResourceStaff      ResourceType = "course_staff"
+)
+
+// Resource 認可判定の対象リソース
+// 関係性（授業での担当、受講しているか）は呼び出し側のサービスが解決して設定する
+type Resource struct {
+	Type          ResourceType
+	CourseRole    string // 操作ユーザーの授業での担当（models.CourseStaffRole*、担当でない場合は空）
+	IsEnrolled    bool   // 操作ユーザーが授業を受講しているか（受講中または修了）
+	IsGuardian    bool   // 操作ユーザーが対象学生の保護者か
+	StudentUserID int    // 成績・出席の対象学生のユーザーID
+}
+
+// rule 認可ルール
+type rule func(principal *models.Principal, resource Resource) bool
+
+// rules リソース種別・操作ごとの認可ルール（定義のない組み合わせは拒否）
+// 授業の担当は、主担当（isCourseOwner）⊃ 副担当を含む教師（isCourseTeacher）⊃ アシスタントを含む担当全体（isCourseStaff）
+var rules = map[ResourceType]map[Action]rule{
+	ResourceCourse: {
+		ActionList:     hasRole(models.RoleAdmin, models.RoleTeacher, models.RoleStudent),
+		ActionCreate:   hasRole(models.RoleTeacher),
+		ActionRead:     anyOf(isAdmin, isCourseStaff, isEnrolledStudent),
+		ActionUpdate:   anyOf(isAdmin, isCourseTeacher),
+		ActionDelete:   anyOf(isAdmin, isCourseOwner),
+		ActionRestore:  anyOf(isAdmin, isCourseOwner),
+		ActionOverride: isAdmin,
+	},
+	ResourceGrade: {
+		// アシスタントは成績を参照できるが編集はできない
+		ActionRead:   anyOf(isAdmin, isCourseStaff, isOwnStudentRecord, isGuardianOfStudent),
+		ActionCreate: isCourseTeacher,
+		ActionUpdate: isCourseTeacher,
+		ActionDelete: isCourseTeacher,
+	},
+	ResourceTest: {
+		ActionList:   anyOf(hasRole(models.RoleAdmin, models.RoleTeacher, models.RoleStudent), isGuardianOfStudent),
+		ActionRead:   anyOf(isAdmin, isCourseStaff, isEnrolledStudent),
+		ActionCreate: isCourseTeacher,
+		ActionUpdate: isCourseTeacher,
+		ActionDelete: isCourseTeacher,
+	},
+	ResourceAttendance: {
+		// 出席はアシスタントも記録できる
+		ActionList:   anyOf(hasRole(models.RoleAdmin, models.RoleTeacher, models.RoleStudent), isGuardianOfStudent),
+		ActionRead:   anyOf(isAdmin, isCourseStaff, isOwnStudentRecord, isGuardianOfStudent),
+		ActionCreate: isCourseStaff,
+		ActionUpdate: isCourseStaff,
+		ActionDelete: isCourseStaff,
+	},
+	ResourceUser: {
+		ActionList:   isAdmin,
+		ActionRead:   isAdmin,
+		ActionCreate: isAdmin,
+		ActionUpdate: isAdmin,
+		ActionDelete: isAdmin,
+	},
+	ResourceGuardian: {
+		ActionList:   hasRole(models.RoleAdmin, models.RoleParent),
+		ActionRead:   isAdmin,
+		ActionCreate: isAdmin,
+		ActionDelete: isAdmin,
+	},
+	ResourceAPIKey: {
+		ActionList:   isAdmin,
+		ActionCreate: isAdmin,
+		ActionDelete: isAdmin,
+	},
+	ResourceAuditLog: {
+		ActionList: isAdmin,
+	},
+	ResourceEnrollment: {
+		ActionList:   anyOf(isAdmin, isCourseStaff),
+		ActionCreate: anyOf(isAdmin, isCourseTeacher),
+		ActionUpdate: anyOf(isAdmin, isCourseTeacher),
+		ActionDelete: anyOf(isAdmin, isCourseTeacher),
+	},
+	ResourceRoster: {
+		ActionCreate: isAdmin,
+	},
+	ResourceVideo: {
+		ActionList:   anyOf(isAdmin, isCourseStaff, isEnrolledStudent),
+		ActionRead:   anyOf(isAdmin, isCourseStaff, isEnrolledStudent),
+		ActionCreate: isCourseTeacher,
+		ActionUpdate: anyOf(isAdmin, isCourseTeacher),
+		ActionDelete: anyOf(isAdmin, isCourseTeacher),
+	},
+	ResourceWatch: {
+		// 視聴状況の記録・再開位置の取得は受講中の学生本人、受講者全体の集計は授業の担当・管理者
+		ActionList:   anyOf(isAdmin, isCourseStaff),
+		ActionRead:   isEnrolledStudent,
+		ActionUpdate: isEnrolledStudent,
+	},
+	ResourceSession: {
+		ActionList:     anyOf(isAdmin, isCourseStaff, isEnrolledStudent),
+		ActionUpdate:   anyOf(isAdmin, isCourseTeacher),
+		ActionOverride: isAdmin,
+	},
+	ResourceStaff: {
+		// 担当の一覧は授業の担当全員、追加・変更・削除は主担当と管理者
+		ActionList:   anyOf(isAdmin, isCourseStaff),
+		ActionCreate: anyOf(isAdmin, isCourseOwner),
+		ActionUpdate: anyOf(isAdmin, isCourseOwner),
+		ActionDelete: anyOf(isAdmin, isCourseOwner),
+	},
+	ResourceCalendar: {
+		// 自分のカレンダー購読の発行・確認・削除（授業・テストの予定を持つ役割のみ）
+		ActionRead:   hasRole(models.RoleStudent, models.RoleTeacher, models.RoleParent),
+		ActionCreate: hasRole(models.RoleStudent, models.RoleTeacher, models.RoleParent),
+		ActionDelete: hasRole(models.RoleStudent, models.RoleTeacher, models.RoleParent),
+	},
+	ResourceSubject: {
+		ActionList:    isAdmin,
+		ActionCreate:  isAdmin,
+		ActionUpdate:  isAdmin,
+		ActionDelete:  isAdmin,
+		ActionRestore: isAdmin,
+	},
+}
+
+// Authorize プリンシパルがリソースに対して操作を行えるか判定する
+// 許可されない場合は models.ErrorMessageForbidden を含むエラーを返す
+func Authorize(principal *models.Principal, action Action, resource Resource) error {
+	if principal == nil {
+		return fmt.Errorf("%s: no principal", models.ErrorMessageForbidden)
+	}
+
+	allowed, ok := rules[resource.Type][action]
+	if !ok || !allowed(principal, resource) {
+		return fmt.Errorf("%s: %s cannot %s %s", models.ErrorMessageForbidden, principal.Role, action, resource.Type)
+	}
+
+	return nil
+}
+
+// hasRole 指定した役割のいずれかを持つ場合に許可する
+func hasRole(roles ...string) rule {
+	return func(principal *models.Principal, resource Resource) bool {
+		for _, role := range roles {
+			if principal.Role == role {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// anyOf いずれかのルールが許可した場合に許可する
+func anyOf(rs ...rule) rule {
+	return func(principal *models.Principal, resource Resource) bool {
+		for _, r := range rs {
+			if r(principal, resource) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// isAdmin 管理者の場合に許可する
+func isAdmin(principal *models.Principal, resource Resource) bool {
+	return principal.Role == models.RoleAdmin
+}
+
+// isCourseOwner 授業の主担当の教師の場合に許可する
+func isCourseOwner(principal *models.Principal, resource Resource) bool {
+	return principal.Role == models.RoleTeacher && resource.CourseRole == models.CourseStaffRoleOwner
+}
+
+// isCourseTeacher 授業の主担当・副担当の教師の場合に許可する
+func isCourseTeacher(principal *models.Principal, resource Resource) bool {
+	return principal.Role == models.RoleTeacher &&
+		(resource.CourseRole == models.CourseStaffRoleOwner || resource.CourseRole == models.CourseStaffRoleCoTeacher)
+}
+
+// isCourseStaff アシスタントを含む授業の担当の教師の場合に許可する
+func isCourseStaff(principal *models.Principal, resource Resource) bool {
+	return principal.Role == models.RoleTeacher && resource.CourseRole != ""
+}
+
+// isEnrolledStudent 授業を受講している学生の場合に許可する
+func isEnrolledStudent(principal *models.Principal, resource Resource) bool {
+	return principal.Role == models.RoleStudent && resource.IsEnrolled
+}
+
+// isOwnStudentRecord 学生本人の記録の場合に許可する
+func isOwnStudentRecord(principal *models.Principal, resource Resource) bool {
+	return principal.Role == models.RoleStudent && resource.StudentUserID == principal.UserID
+}
+
+// isGuardianOfStudent 対象学生に紐付く保護者の場合に許可する
+func isGuardianOfStudent(principal *models.Principal, resource Resource) bool {
+	return principal.Role == models.RoleParent && resource.IsGuardian
+}
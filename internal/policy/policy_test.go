@@ -0,0 +1,245 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// subject 認可判定の主体（プリンシパルと、サービスが解決するリソースとの関係性）
+type subject struct {
+	name      string
+	principal models.Principal
+	resource  Resource // Typeはテストごとに設定する
+}
+
+const (
+	subjectUserID = 10
+	otherUserID   = 99
+)
+
+// subjects 役割と関係性の組み合わせ
+var subjects = []subject{
+	{name: "admin", principal: models.Principal{UserID: subjectUserID, Role: models.RoleAdmin}},
+	{name: "owner", principal: models.Principal{UserID: subjectUserID, Role: models.RoleTeacher}, resource: Resource{CourseRole: models.CourseStaffRoleOwner}},
+	{name: "co_teacher", principal: models.Principal{UserID: subjectUserID, Role: models.RoleTeacher}, resource: Resource{CourseRole: models.CourseStaffRoleCoTeacher}},
+	{name: "assistant", principal: models.Principal{UserID: subjectUserID, Role: models.RoleTeacher}, resource: Resource{CourseRole: models.CourseStaffRoleAssistant}},
+	{name: "teacher_none", principal: models.Principal{UserID: subjectUserID, Role: models.RoleTeacher}},
+	{name: "student_enrolled", principal: models.Principal{UserID: subjectUserID, Role: models.RoleStudent}, resource: Resource{IsEnrolled: true, StudentUserID: otherUserID}},
+	{name: "student_not_enrolled", principal: models.Principal{UserID: subjectUserID, Role: models.RoleStudent}, resource: Resource{StudentUserID: otherUserID}},
+	{name: "student_self", principal: models.Principal{UserID: subjectUserID, Role: models.RoleStudent}, resource: Resource{StudentUserID: subjectUserID}},
+	{name: "guardian", principal: models.Principal{UserID: subjectUserID, Role: models.RoleParent}, resource: Resource{IsGuardian: true, StudentUserID: otherUserID}},
+	{name: "parent", principal: models.Principal{UserID: subjectUserID, Role: models.RoleParent}, resource: Resource{StudentUserID: otherUserID}},
+	// 関係性のフラグは役割と一致する場合のみ効く
+	{name: "student_with_staff_role", principal: models.Principal{UserID: subjectUserID, Role: models.RoleStudent}, resource: Resource{CourseRole: models.CourseStaffRoleOwner}},
+	{name: "teacher_enrolled", principal: models.Principal{UserID: subjectUserID, Role: models.RoleTeacher}, resource: Resource{IsEnrolled: true, IsGuardian: true, StudentUserID: subjectUserID}},
+	{name: "parent_with_staff_role", principal: models.Principal{UserID: subjectUserID, Role: models.RoleParent}, resource: Resource{CourseRole: models.CourseStaffRoleOwner, StudentUserID: subjectUserID}},
+}
+
+var allResources = []ResourceType{
+	ResourceCourse, ResourceGrade, ResourceTest, ResourceAttendance, ResourceUser, ResourceGuardian,
+	ResourceAPIKey, ResourceAuditLog, ResourceEnrollment, ResourceRoster, ResourceSubject, ResourceVideo,
+	ResourceWatch, ResourceSession, ResourceCalendar, ResourceStaff,
+}
+
+var allActions = []Action{
+	ActionList, ActionRead, ActionCreate, ActionUpdate, ActionDelete, ActionRestore, ActionOverride,
+}
+
+// 主体のグループ
+var (
+	admin       = []string{"admin"}
+	staff       = []string{"owner", "co_teacher", "assistant"}
+	teachers    = []string{"owner", "co_teacher", "assistant", "teacher_none", "teacher_enrolled"}
+	students    = []string{"student_enrolled", "student_not_enrolled", "student_self", "student_with_staff_role"}
+	parents     = []string{"guardian", "parent", "parent_with_staff_role"}
+	owner       = []string{"owner"}
+	courseTeach = []string{"owner", "co_teacher"}
+	enrolled    = []string{"student_enrolled"}
+	ownRecord   = []string{"student_self"}
+	guardian    = []string{"guardian"}
+)
+
+func join(groups ...[]string) []string {
+	var names []string
+	for _, g := range groups {
+		names = append(names, g...)
+	}
+	return names
+}
+
+// expected 許可される主体の一覧（記載のない組み合わせはすべて拒否）
+var expected = map[ResourceType]map[Action][]string{
+	ResourceCourse: {
+		ActionList:     join(admin, teachers, students),
+		ActionCreate:   teachers,
+		ActionRead:     join(admin, staff, enrolled),
+		ActionUpdate:   join(admin, courseTeach),
+		ActionDelete:   join(admin, owner),
+		ActionRestore:  join(admin, owner),
+		ActionOverride: admin,
+	},
+	ResourceGrade: {
+		// 保護者は紐付く学生の成績のみ（紐付かない保護者が任意の成績を参照できた不具合の回帰テスト）
+		ActionRead:   join(admin, staff, ownRecord, guardian),
+		ActionCreate: courseTeach,
+		ActionUpdate: courseTeach,
+		ActionDelete: courseTeach,
+	},
+	ResourceTest: {
+		ActionList:   join(admin, teachers, students, guardian),
+		ActionRead:   join(admin, staff, enrolled),
+		ActionCreate: courseTeach,
+		ActionUpdate: courseTeach,
+		ActionDelete: courseTeach,
+	},
+	ResourceAttendance: {
+		ActionList:   join(admin, teachers, students, guardian),
+		ActionRead:   join(admin, staff, ownRecord, guardian),
+		ActionCreate: staff,
+		ActionUpdate: staff,
+		ActionDelete: staff,
+	},
+	ResourceUser: {
+		ActionList:   admin,
+		ActionRead:   admin,
+		ActionCreate: admin,
+		ActionUpdate: admin,
+		ActionDelete: admin,
+	},
+	ResourceGuardian: {
+		ActionList:   join(admin, parents),
+		ActionRead:   admin,
+		ActionCreate: admin,
+		ActionDelete: admin,
+	},
+	ResourceAPIKey: {
+		ActionList:   admin,
+		ActionCreate: admin,
+		ActionDelete: admin,
+	},
+	ResourceAuditLog: {
+		ActionList: admin,
+	},
+	ResourceEnrollment: {
+		ActionList:   join(admin, staff),
+		ActionCreate: join(admin, courseTeach),
+		ActionUpdate: join(admin, courseTeach),
+		ActionDelete: join(admin, courseTeach),
+	},
+	ResourceRoster: {
+		ActionCreate: admin,
+	},
+	ResourceVideo: {
+		ActionList:   join(admin, staff, enrolled),
+		ActionRead:   join(admin, staff, enrolled),
+		ActionCreate: courseTeach,
+		ActionUpdate: join(admin, courseTeach),
+		ActionDelete: join(admin, courseTeach),
+	},
+	ResourceWatch: {
+		ActionList:   join(admin, staff),
+		ActionRead:   enrolled,
+		ActionUpdate: enrolled,
+	},
+	ResourceSession: {
+		ActionList:     join(admin, staff, enrolled),
+		ActionUpdate:   join(admin, courseTeach),
+		ActionOverride: admin,
+	},
+	ResourceCalendar: {
+		ActionRead:   join(teachers, students, parents),
+		ActionCreate: join(teachers, students, parents),
+		ActionDelete: join(teachers, students, parents),
+	},
+	ResourceSubject: {
+		ActionList:    admin,
+		ActionCreate:  admin,
+		ActionUpdate:  admin,
+		ActionDelete:  admin,
+		ActionRestore: admin,
+	},
+	ResourceStaff: {
+		ActionList:   join(admin, staff),
+		ActionCreate: join(admin, owner),
+		ActionUpdate: join(admin, owner),
+		ActionDelete: join(admin, owner),
+	},
+}
+
+func TestAuthorize(t *testing.T) {
+	for _, resourceType := range allResources {
+		for _, action := range allActions {
+			allowed := map[string]bool{}
+			for _, name := range expected[resourceType][action] {
+				allowed[name] = true
+			}
+
+			for _, sub := range subjects {
+				t.Run(string(resourceType)+"/"+string(action)+"/"+sub.name, func(t *testing.T) {
+					principal := sub.principal
+					resource := sub.resource
+					resource.Type = resourceType
+
+					err := Authorize(&principal, action, resource)
+					if allowed[sub.name] {
+						if err != nil {
+							t.Fatalf("expected allow, got %v", err)
+						}
+						return
+					}
+					if err == nil {
+						t.Fatal("expected deny, got allow")
+					}
+					if !strings.Contains(err.Error(), models.ErrorMessageForbidden) {
+						t.Fatalf("deny error %q does not contain %q", err, models.ErrorMessageForbidden)
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestAuthorizeCoversAllRules ルールの追加時にテスト表の更新漏れを検出する
+func TestAuthorizeCoversAllRules(t *testing.T) {
+	known := map[ResourceType]bool{}
+	for _, resourceType := range allResources {
+		known[resourceType] = true
+	}
+
+	for resourceType, actions := range rules {
+		if !known[resourceType] {
+			t.Errorf("resource %s is not covered by the test table", resourceType)
+		}
+		for action := range actions {
+			if len(expected[resourceType][action]) == 0 {
+				t.Errorf("%s %s has a rule but no expected subjects", resourceType, action)
+			}
+		}
+	}
+
+	for _, name := range join(teachers, students, parents) {
+		found := false
+		for _, sub := range subjects {
+			found = found || sub.name == name
+		}
+		if !found {
+			t.Errorf("subject group refers to unknown subject %s", name)
+		}
+	}
+}
+
+func TestAuthorizeNilPrincipal(t *testing.T) {
+	err := Authorize(nil, ActionRead, Resource{Type: ResourceCourse})
+	if err == nil || !strings.Contains(err.Error(), models.ErrorMessageForbidden) {
+		t.Fatalf("expected forbidden for nil principal, got %v", err)
+	}
+}
+
+func TestAuthorizeUnknownResource(t *testing.T) {
+	principal := &models.Principal{UserID: subjectUserID, Role: models.RoleAdmin}
+	if err := Authorize(principal, ActionRead, Resource{Type: "unknown"}); err == nil {
+		t.Fatal("expected deny for unknown resource type")
+	}
+}
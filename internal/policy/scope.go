@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"slices"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// routeScopes APIキーで利用できるルート（メソッドとechoのルートパス）と必要なスコープ
+// スコープは "<リソース>:<read|write>" の形式（例: courses:read, attendances:write）
+// 表にないルートはAPIキーで利用できないため、ルートを追加してAPIキーで利用させる場合はここにも追加する
+var routeScopes = map[string]string{
+	"GET /admin/users":                                         "users:read",
+	"POST /admin/users":                                        "users:write",
+	"POST /admin/users/:user_id/deactivate":                    "users:write",
+	"POST /admin/users/:user_id/reactivate":                    "users:write",
+	"PUT /admin/users/:user_id/role":                           "users:write",
+	"DELETE /admin/users/:user_id/sessions":                    "users:write",
+	"POST /admin/users/:user_id/unlock":                        "users:write",
+	"DELETE /admin/users/:user_id/2fa":                         "users:write",
+	"GET /admin/lockout-events":                                "lockout-events:read",
+	"GET /admin/audit-logs":                                    "audit-logs:read",
+	"POST /admin/imports/roster":                               "imports:write",
+	"GET /admin/subjects":                                      "subjects:read",
+	"POST /admin/subjects":                                     "subjects:write",
+	"PUT /admin/subjects/:subject_id":                          "subjects:write",
+	"DELETE /admin/subjects/:subject_id":                       "subjects:write",
+	"POST /admin/subjects/:subject_id/restore":                 "subjects:write",
+	"GET /admin/students/:user_id/guardians":                   "guardians:read",
+	"POST /admin/guardians":                                    "guardians:write",
+	"DELETE /admin/guardians/:guardian_id":                     "guardians:write",
+	"GET /tests":                                               "tests:read",
+	"GET /grades/:grade_id":                                    "grades:read",
+	"GET /attendances":                                         "attendances:read",
+	"GET /courses":                                             "courses:read",
+	"GET /courses/:course_id":                                  "courses:read",
+	"POST /courses":                                            "courses:write",
+	"PUT /courses/:course_id":                                  "courses:write",
+	"DELETE /courses/:course_id":                               "courses:write",
+	"POST /courses/:course_id/restore":                         "courses:write",
+	"GET /courses/:course_id/enrollments":                      "courses:read",
+	"POST /courses/:course_id/enrollments":                     "courses:write",
+	"PUT /courses/:course_id/enrollments/:user_id":             "courses:write",
+	"DELETE /courses/:course_id/enrollments/:user_id":          "courses:write",
+	"GET /courses/:course_id/staff":                            "courses:read",
+	"POST /courses/:course_id/staff":                           "courses:write",
+	"PUT /courses/:course_id/staff/:user_id":                   "courses:write",
+	"DELETE /courses/:course_id/staff/:user_id":                "courses:write",
+	"GET /courses/:course_id/sessions":                         "courses:read",
+	"PUT /courses/:course_id/sessions/:session_id":             "courses:write",
+	"GET /courses/:course_id/sessions/:session_id/attendances": "attendances:read",
+	"PUT /courses/:course_id/sessions/:session_id/attendances": "attendances:write",
+	"GET /courses/:course_id/videos":                           "courses:read",
+	"POST /courses/:course_id/videos":                          "courses:write",
+	"GET /courses/:course_id/videos/:video_id/stream":          "courses:read",
+	"PUT /courses/:course_id/videos/:video_id":                 "courses:write",
+	"DELETE /courses/:course_id/videos/:video_id":              "courses:write",
+	"GET /courses/:course_id/videos/progress":                  "courses:read",
+	"GET /courses/:course_id/videos/:video_id/progress":        "courses:read",
+	"POST /courses/:course_id/videos/uploads":                  "courses:write",
+	"HEAD /courses/:course_id/videos/uploads/:upload_id":       "courses:write",
+	"PATCH /courses/:course_id/videos/uploads/:upload_id":      "courses:write",
+	"DELETE /courses/:course_id/videos/uploads/:upload_id":     "courses:write",
+}
+
+// grantableScopes APIキーに付与できるスコープ（いずれかのルートで必要とされるもの）
+var grantableScopes = func() map[string]bool {
+	scopes := make(map[string]bool)
+	for _, scope := range routeScopes {
+		scopes[scope] = true
+	}
+	return scopes
+}()
+
+// ValidScope スコープが付与可能かどうかを返す（どのルートにも使えないスコープは付与できない）
+func ValidScope(scope string) bool {
+	return grantableScopes[scope]
+}
+
+// RequiredScope ルートの操作に必要なスコープを返す
+// pathはリクエストのURLではなくechoのルートパス（c.Path()）を渡す
+// 表にないルート（ログイン・本人設定・APIキー管理など）の場合はfalseを返す
+func RequiredScope(method string, path string) (string, bool) {
+	scope, ok := routeScopes[method+" "+path]
+	return scope, ok
+}
+
+// HasScope プリンシパルがスコープを持つかどうかを返す
+func HasScope(principal *models.Principal, scope string) bool {
+	return slices.Contains(principal.Scopes, scope)
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+func TestValidScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{"courses:read", true},
+		{"courses:write", true},
+		{"attendances:write", true},
+		{"audit-logs:read", true},
+		{"grades:read", true},
+		{"grades:write", false},         // 成績を書き込むルートはない
+		{"lockout-events:write", false}, // 閲覧のみ
+		{"imports:read", false},         // 取り込みのみ
+		{"students:read", false},
+		{"courses:admin", false},
+		{"courses", false},
+		{"courses:", false},
+		{":read", false},
+		{"api-keys:read", false}, // APIキー管理はAPIキーで操作できない
+		{"me:read", false},
+		{"Courses:read", false},
+		{"courses:read:extra", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			if got := ValidScope(tt.scope); got != tt.want {
+				t.Errorf("ValidScope(%q) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+		ok     bool
+	}{
+		{http.MethodGet, "/courses", "courses:read", true},
+		{http.MethodGet, "/courses/:course_id", "courses:read", true},
+		{http.MethodPost, "/courses", "courses:write", true},
+		{http.MethodPut, "/courses/:course_id/staff/:user_id", "courses:write", true},
+		{http.MethodDelete, "/courses/:course_id", "courses:write", true},
+		{http.MethodPut, "/courses/:course_id/sessions/:session_id", "courses:write", true},
+		{http.MethodGet, "/courses/:course_id/sessions/:session_id/attendances", "attendances:read", true},
+		{http.MethodPut, "/courses/:course_id/sessions/:session_id/attendances", "attendances:write", true},
+		{http.MethodGet, "/attendances", "attendances:read", true},
+		{http.MethodGet, "/grades/:grade_id", "grades:read", true},
+		{http.MethodHead, "/courses/:course_id/videos/uploads/:upload_id", "courses:write", true},
+		{http.MethodGet, "/admin/users", "users:read", true},
+		{http.MethodPost, "/admin/imports/roster", "imports:write", true},
+		{http.MethodGet, "/admin/audit-logs", "audit-logs:read", true},
+		{http.MethodGet, "/admin/lockout-events", "lockout-events:read", true},
+		{http.MethodGet, "/admin/students/:user_id/guardians", "guardians:read", true},
+
+		// 表にないルートは拒否する
+		{http.MethodPatch, "/grades/:grade_id", "", false},
+		{http.MethodHead, "/courses", "", false},
+		{http.MethodPost, "/courses/:course_id/videos/:video_id/progress", "", false},
+		{http.MethodPost, "/admin/service-accounts", "", false},
+		{http.MethodGet, "/admin/api-keys", "", false},
+		{http.MethodGet, "/me", "", false},
+		{http.MethodGet, "/me/calendar-feed", "", false},
+		{http.MethodPost, "/auth/login", "", false},
+		{http.MethodGet, "/admin", "", false},
+		{http.MethodGet, "/", "", false},
+		{http.MethodGet, "/courses/123", "", false}, // ルートパスではなくURLを渡した場合
+		{"get", "/courses", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			got, ok := RequiredScope(tt.method, tt.path)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("RequiredScope(%s, %s) = (%q, %v), want (%q, %v)", tt.method, tt.path, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// TestRouteScopesAreValid 表のスコープはすべて付与可能な形式であること
+func TestRouteScopesAreValid(t *testing.T) {
+	for route, scope := range routeScopes {
+		resource, access, ok := strings.Cut(scope, ":")
+		if !ok || resource == "" || (access != "read" && access != "write") {
+			t.Errorf("%s: invalid scope %q", route, scope)
+		}
+
+		method, _, _ := strings.Cut(route, " ")
+		if access == "read" && method != http.MethodGet {
+			t.Errorf("%s: read scope %q for a %s route", route, scope, method)
+		}
+	}
+}
+
+// TestCoursesWriteCannotWriteAttendance 授業の書き込みスコープでは出席を記録できないこと
+func TestCoursesWriteCannotWriteAttendance(t *testing.T) {
+	principal := &models.Principal{Role: models.RoleAdmin, APIKeyID: 1, Scopes: []string{"courses:read", "courses:write"}}
+
+	scope, ok := RequiredScope(http.MethodPut, "/courses/:course_id/sessions/:session_id/attendances")
+	if !ok {
+		t.Fatal("attendance route must be available to api keys")
+	}
+	if HasScope(principal, scope) {
+		t.Errorf("courses:write must not allow %s", scope)
+	}
+
+	scope, _ = RequiredScope(http.MethodGet, "/courses/:course_id/sessions/:session_id/attendances")
+	if HasScope(principal, scope) {
+		t.Errorf("courses:read must not allow %s", scope)
+	}
+
+	principal.Scopes = []string{"attendances:write"}
+	scope, _ = RequiredScope(http.MethodPut, "/courses/:course_id/sessions/:session_id/attendances")
+	if !HasScope(principal, scope) {
+		t.Errorf("attendances:write must allow %s", scope)
+	}
+	// 出席の書き込みスコープでは授業回を変更できない
+	scope, _ = RequiredScope(http.MethodPut, "/courses/:course_id/sessions/:session_id")
+	if HasScope(principal, scope) {
+		t.Errorf("attendances:write must not allow %s", scope)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	principal := &models.Principal{Role: models.RoleAdmin, APIKeyID: 1, Scopes: []string{"courses:read", "attendances:write"}}
+
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{"courses:read", true},
+		{"attendances:write", true},
+		{"courses:write", false},    // readはwriteを含まない
+		{"attendances:read", false}, // writeはreadを含まない
+		{"users:read", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			if got := HasScope(principal, tt.scope); got != tt.want {
+				t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+
+	if HasScope(&models.Principal{Role: models.RoleAdmin}, "courses:read") {
+		t.Error("principal without scopes must not have any scope")
+	}
+}
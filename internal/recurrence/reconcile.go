@@ -0,0 +1,76 @@
+package recurrence
+
+import (
+	"sort"
+	"time"
+)
+
+// Instance 保存済みの授業回（繰り返しを展開した発生日時との対応づけに使う）
+type Instance struct {
+	ID            int
+	OriginalStart time.Time // 展開した時点の開始日時（壁時計の時刻）
+	IsOverride    bool      // 日時の変更や休講を個別に行った回
+	HasAttendance bool
+}
+
+// SyncPlan 保存済みの授業回を展開した発生日時に合わせるための変更
+type SyncPlan struct {
+	Create []time.Time // 新しく作成する回の開始日時
+	Reset  []Instance  // 展開した日時・予定どおりの状態に戻す回
+	Cancel []int       // 展開から外れたが出席の記録があるため休講として残す回
+	Delete []int       // 展開から外れたため削除する回
+}
+
+// Reconcile 保存済みの授業回と展開した発生日時を突き合わせる
+// 個別に変更した回は展開に含まれる限りそのまま残し（移動・休講を上書きしない）、
+// 展開から外れた回は個別の変更の有無にかかわらず出席の記録があれば休講、なければ削除とする
+// from（壁時計の時刻）より前に始まる回は実施済みの記録として変更せず、fromより前の発生日時の回も作成しない
+func Reconcile(instances []Instance, occurrences []time.Time, from time.Time) SyncPlan {
+	from = WallClock(from)
+
+	existing := make(map[time.Time]Instance, len(instances))
+	for _, instance := range instances {
+		originalStart := WallClock(instance.OriginalStart)
+		if originalStart.Before(from) {
+			continue
+		}
+		existing[originalStart] = instance
+	}
+
+	var plan SyncPlan
+	for _, occurrence := range occurrences {
+		occurrence = WallClock(occurrence)
+		if occurrence.Before(from) {
+			continue
+		}
+		instance, ok := existing[occurrence]
+		if !ok {
+			plan.Create = append(plan.Create, occurrence)
+			continue
+		}
+		delete(existing, occurrence)
+
+		if !instance.IsOverride {
+			instance.OriginalStart = occurrence
+			plan.Reset = append(plan.Reset, instance)
+		}
+	}
+
+	// 展開から外れた回（結果を一定にするため元の開始日時順）
+	removed := make([]Instance, 0, len(existing))
+	for _, instance := range existing {
+		removed = append(removed, instance)
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].OriginalStart.Before(removed[j].OriginalStart)
+	})
+	for _, instance := range removed {
+		if instance.HasAttendance {
+			plan.Cancel = append(plan.Cancel, instance.ID)
+		} else {
+			plan.Delete = append(plan.Delete, instance.ID)
+		}
+	}
+
+	return plan
+}
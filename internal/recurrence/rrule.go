@@ -0,0 +1,398 @@
+// Package recurrence RFC 5545のRRULE（繰り返しルール）を解析して日時に展開する
+//
+// 授業の日時はタイムゾーンを持たない壁時計の時刻として保存しているため、展開も壁時計の時刻で行う
+// （毎週月曜9時の授業は夏時間の切り替えがあっても9時のまま）。
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPeriods 展開する期間（日・週・月）の上限（該当日のないルールで無限に探索しないため）
+const maxPeriods = 100000
+
+// Frequency 繰り返しの単位
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// weekdays RRULEの曜日の表記
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ByDay BYDAYの1要素（Nthが0の場合は該当するすべての曜日、MONTHLYでは2TU・-1FRのように第N曜日を指定できる）
+type ByDay struct {
+	Nth     int
+	Weekday time.Weekday
+}
+
+// Rule 解析済みのRRULE
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int        // 0の場合は未指定
+	Until      *time.Time // 壁時計の時刻（UTCとして保持）
+	ByDay      []ByDay
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+// Parse RRULEの文字列（"RRULE:"の接頭辞は省略可）を解析する
+// locは繰り返しの壁時計の時刻のタイムゾーンで、UTC（末尾にZ）で指定されたUNTILをこのタイムゾーンの時刻にする
+// 対応していない要素（BYSETPOS・BYHOURなど）を含む場合はエラーを返す
+func Parse(value string, loc *time.Location) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("rrule is empty")
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || name == "" || val == "" {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate rrule part %s", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch Frequency(val) {
+			case Daily, Weekly, Monthly:
+				rule.Freq = Frequency(val)
+			default:
+				return nil, fmt.Errorf("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval <= 0 {
+				return nil, fmt.Errorf("INTERVAL must be a positive integer")
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count <= 0 {
+				return nil, fmt.Errorf("COUNT must be a positive integer")
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseUntil(val, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(val, ",") {
+				byDay, err := parseByDay(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, byDay)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(val, ",") {
+				day, err := strconv.Atoi(item)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("BYMONTHDAY must be between 1 and 31 or -31 and -1")
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "BYMONTH":
+			for _, item := range strings.Split(val, ",") {
+				month, err := strconv.Atoi(item)
+				if err != nil || month < 1 || month > 12 {
+					return nil, fmt.Errorf("BYMONTH must be between 1 and 12")
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(month))
+			}
+		case "WKST":
+			weekday, ok := weekdays[val]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %q", val)
+			}
+			rule.WeekStart = weekday
+		default:
+			return nil, fmt.Errorf("rrule part %s is not supported", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL must not be used together")
+	}
+	for _, byDay := range rule.ByDay {
+		if byDay.Nth != 0 && rule.Freq != Monthly {
+			return nil, fmt.Errorf("BYDAY with an ordinal (e.g. 2TU) is only supported with FREQ=MONTHLY")
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq == Weekly {
+		return nil, fmt.Errorf("BYMONTHDAY must not be used with FREQ=WEEKLY")
+	}
+
+	return rule, nil
+}
+
+// IsBounded COUNTまたはUNTILで終わりが決まっているか
+func (r *Rule) IsBounded() bool {
+	return r.Count > 0 || r.Until != nil
+}
+
+// Expand dtstartを起点に発生日時を展開し、exdatesに一致する日時を除いて返す
+// RFC 5545と同様にdtstartは常に最初の発生日時として数える
+// 発生日時がlimit件を超える場合はエラーを返す（終わりのないルールは展開できない）
+func (r *Rule) Expand(dtstart time.Time, exdates []time.Time, limit int) ([]time.Time, error) {
+	if !r.IsBounded() {
+		return nil, fmt.Errorf("rrule must have COUNT or UNTIL")
+	}
+
+	dtstart = WallClock(dtstart)
+	excluded := map[time.Time]bool{}
+	for _, exdate := range exdates {
+		excluded[WallClock(exdate)] = true
+	}
+
+	occurrences := []time.Time{dtstart}
+	done := func() bool {
+		return r.Count > 0 && len(occurrences) >= r.Count
+	}
+
+	periodStart := r.firstPeriod(dtstart)
+	for i := 0; i < maxPeriods && !done(); i++ {
+		for _, candidate := range r.candidates(periodStart, dtstart) {
+			if !candidate.After(dtstart) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return r.finish(occurrences, excluded, limit)
+			}
+			occurrences = append(occurrences, candidate)
+			if done() {
+				break
+			}
+			if len(occurrences) > limit {
+				return nil, fmt.Errorf("rrule expands to more than %d occurrences", limit)
+			}
+		}
+		periodStart = r.nextPeriod(periodStart)
+
+		if r.Until != nil && periodStart.After(*r.Until) {
+			break
+		}
+	}
+
+	return r.finish(occurrences, excluded, limit)
+}
+
+// finish 除外日時を取り除き、件数の上限をチェックする
+func (r *Rule) finish(occurrences []time.Time, excluded map[time.Time]bool, limit int) ([]time.Time, error) {
+	if len(occurrences) > limit {
+		return nil, fmt.Errorf("rrule expands to more than %d occurrences", limit)
+	}
+
+	result := make([]time.Time, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		if !excluded[occurrence] {
+			result = append(result, occurrence)
+		}
+	}
+	return result, nil
+}
+
+// firstPeriod dtstartを含む期間の開始日（0時）を返す
+func (r *Rule) firstPeriod(dtstart time.Time) time.Time {
+	day := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, time.UTC)
+	switch r.Freq {
+	case Weekly:
+		offset := (int(day.Weekday()) - int(r.WeekStart) + 7) % 7
+		return day.AddDate(0, 0, -offset)
+	case Monthly:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// nextPeriod INTERVAL分進めた期間の開始日を返す
+func (r *Rule) nextPeriod(periodStart time.Time) time.Time {
+	switch r.Freq {
+	case Weekly:
+		return periodStart.AddDate(0, 0, 7*r.Interval)
+	case Monthly:
+		return periodStart.AddDate(0, r.Interval, 0)
+	default:
+		return periodStart.AddDate(0, 0, r.Interval)
+	}
+}
+
+// candidates 期間内でルールに一致する日時を昇順で返す（時刻はdtstartと同じ）
+func (r *Rule) candidates(periodStart time.Time, dtstart time.Time) []time.Time {
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{periodStart}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			offset := (int(dtstart.Weekday()) - int(periodStart.Weekday()) + 7) % 7
+			days = []time.Time{periodStart.AddDate(0, 0, offset)}
+		} else {
+			for i := 0; i < 7; i++ {
+				days = append(days, periodStart.AddDate(0, 0, i))
+			}
+		}
+	case Monthly:
+		lastDay := periodStart.AddDate(0, 1, -1).Day()
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			if dtstart.Day() <= lastDay {
+				days = []time.Time{periodStart.AddDate(0, 0, dtstart.Day()-1)}
+			}
+		} else {
+			for i := 0; i < lastDay; i++ {
+				days = append(days, periodStart.AddDate(0, 0, i))
+			}
+		}
+	}
+
+	var result []time.Time
+	for _, day := range days {
+		if !r.matches(day) {
+			continue
+		}
+		result = append(result, time.Date(day.Year(), day.Month(), day.Day(),
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, time.UTC))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Before(result[j])
+	})
+	return result
+}
+
+// matches 日付がBYMONTH・BYMONTHDAY・BYDAYの条件をすべて満たすか
+func (r *Rule) matches(day time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, day.Month()) {
+		return false
+	}
+
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	if len(r.ByMonthDay) > 0 {
+		matched := false
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay == day.Day() || (monthDay < 0 && lastDay+monthDay+1 == day.Day()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(r.ByDay) > 0 {
+		matched := false
+		for _, byDay := range r.ByDay {
+			if byDay.Weekday != day.Weekday() {
+				continue
+			}
+			// 月内で何回目・最後から何回目の曜日か
+			nth := (day.Day()-1)/7 + 1
+			nthFromEnd := -((lastDay-day.Day())/7 + 1)
+			if byDay.Nth == 0 || byDay.Nth == nth || byDay.Nth == nthFromEnd {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WallClock 日時をタイムゾーンを除いた壁時計の時刻（UTCとして保持）にする
+// DBに保存した値と比較できるよう、秒未満は切り捨てる
+func WallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// parseUntil UNTILの値（YYYYMMDDまたはYYYYMMDDTHHMMSS[Z]）を解析し、壁時計の時刻で返す
+// 末尾にZのあるUTCの日時はlocの時刻に変換し、Zのない日時はそのまま壁時計の時刻とする
+// 日付のみの場合はその日の終わりまでを含める
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if utc, ok := strings.CutSuffix(value, "Z"); ok {
+		until, err := time.Parse("20060102T150405", utc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+		}
+		if loc == nil {
+			loc = time.UTC
+		}
+		return WallClock(until.In(loc)), nil
+	}
+	if until, err := time.Parse("20060102T150405", value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse("20060102", value); err == nil {
+		return until.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+}
+
+// parseByDay BYDAYの1要素（MO・2TU・-1FRなど）を解析する
+func parseByDay(value string) (ByDay, error) {
+	if len(value) < 2 {
+		return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+
+	weekday, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+
+	byDay := ByDay{Weekday: weekday}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		nth, err := strconv.Atoi(prefix)
+		if err != nil || nth == 0 || nth < -5 || nth > 5 {
+			return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+		}
+		byDay.Nth = nth
+	}
+
+	return byDay, nil
+}
+
+// containsMonth 月の一覧に含まれるか
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,302 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tokyo 1951年以降は夏時間のないAsia/Tokyo
+var tokyo = time.FixedZone("Asia/Tokyo", 9*60*60)
+
+// at 壁時計の時刻を作成する
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name    string
+		rrule   string
+		dtstart time.Time
+		exdates []time.Time
+		want    []time.Time
+	}{
+		{
+			name:    "weekly count",
+			rrule:   "FREQ=WEEKLY;COUNT=3",
+			dtstart: at(2024, 1, 8, 9, 0),
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 15, 9, 0), at(2024, 1, 22, 9, 0)},
+		},
+		{
+			name:    "weekly byday until",
+			rrule:   "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20240117T090000",
+			dtstart: at(2024, 1, 8, 9, 0),
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 10, 9, 0), at(2024, 1, 15, 9, 0), at(2024, 1, 17, 9, 0)},
+		},
+		{
+			name:    "count includes dtstart outside byday",
+			rrule:   "FREQ=WEEKLY;BYDAY=TU;COUNT=2",
+			dtstart: at(2024, 1, 8, 9, 0),
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 9, 9, 0)},
+		},
+		{
+			name:    "monthly second tuesday",
+			rrule:   "FREQ=MONTHLY;BYDAY=2TU;COUNT=3",
+			dtstart: at(2024, 1, 9, 10, 0),
+			want:    []time.Time{at(2024, 1, 9, 10, 0), at(2024, 2, 13, 10, 0), at(2024, 3, 12, 10, 0)},
+		},
+		{
+			name:    "monthly last friday",
+			rrule:   "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			dtstart: at(2024, 1, 26, 13, 0),
+			want:    []time.Time{at(2024, 1, 26, 13, 0), at(2024, 2, 23, 13, 0), at(2024, 3, 29, 13, 0)},
+		},
+		{
+			name:    "monthly first and third monday",
+			rrule:   "FREQ=MONTHLY;BYDAY=1MO,3MO;UNTIL=20240220",
+			dtstart: at(2024, 1, 1, 9, 0),
+			want:    []time.Time{at(2024, 1, 1, 9, 0), at(2024, 1, 15, 9, 0), at(2024, 2, 5, 9, 0), at(2024, 2, 19, 9, 0)},
+		},
+		{
+			name:    "monthly 31st skips short months",
+			rrule:   "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3",
+			dtstart: at(2024, 1, 31, 9, 0),
+			want:    []time.Time{at(2024, 1, 31, 9, 0), at(2024, 3, 31, 9, 0), at(2024, 5, 31, 9, 0)},
+		},
+		{
+			name:    "biweekly",
+			rrule:   "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			dtstart: at(2024, 1, 8, 9, 0),
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 22, 9, 0), at(2024, 2, 5, 9, 0)},
+		},
+		{
+			name:    "date-only until includes that day",
+			rrule:   "FREQ=DAILY;UNTIL=20240110",
+			dtstart: at(2024, 1, 8, 18, 0),
+			want:    []time.Time{at(2024, 1, 8, 18, 0), at(2024, 1, 9, 18, 0), at(2024, 1, 10, 18, 0)},
+		},
+		{
+			name:    "floating until excludes later time",
+			rrule:   "FREQ=DAILY;UNTIL=20240110T000000",
+			dtstart: at(2024, 1, 8, 9, 0),
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 9, 9, 0)},
+		},
+		{
+			// 2024-01-10T00:00:00Zは東京の9時
+			name:    "utc until converted to series time zone",
+			rrule:   "FREQ=DAILY;UNTIL=20240110T000000Z",
+			dtstart: at(2024, 1, 8, 9, 0),
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 9, 9, 0), at(2024, 1, 10, 9, 0)},
+		},
+		{
+			name:    "utc until before occurrence",
+			rrule:   "FREQ=DAILY;UNTIL=20240109T235959Z",
+			dtstart: at(2024, 1, 8, 9, 0),
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 9, 9, 0)},
+		},
+		{
+			name:    "exdate removes occurrence but still counts",
+			rrule:   "FREQ=WEEKLY;COUNT=3",
+			dtstart: at(2024, 1, 8, 9, 0),
+			exdates: []time.Time{at(2024, 1, 15, 9, 0)},
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 22, 9, 0)},
+		},
+		{
+			name:    "exdate at a different time is ignored",
+			rrule:   "FREQ=WEEKLY;COUNT=2",
+			dtstart: at(2024, 1, 8, 9, 0),
+			exdates: []time.Time{at(2024, 1, 15, 10, 0)},
+			want:    []time.Time{at(2024, 1, 8, 9, 0), at(2024, 1, 15, 9, 0)},
+		},
+		{
+			name:    "exdate on dtstart",
+			rrule:   "FREQ=DAILY;COUNT=2",
+			dtstart: at(2024, 1, 8, 9, 0),
+			exdates: []time.Time{at(2024, 1, 8, 9, 0)},
+			want:    []time.Time{at(2024, 1, 9, 9, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rrule, tokyo)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.rrule, err)
+			}
+			got, err := rule.Expand(tt.dtstart, tt.exdates, 1000)
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUntil(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		loc   *time.Location
+		want  time.Time
+	}{
+		{"floating", "20240110T083000", tokyo, at(2024, 1, 10, 8, 30)},
+		{"utc in tokyo", "20240110T083000Z", tokyo, at(2024, 1, 10, 17, 30)},
+		{"utc crosses date", "20240110T200000Z", tokyo, at(2024, 1, 11, 5, 0)},
+		{"utc without location", "20240110T083000Z", nil, at(2024, 1, 10, 8, 30)},
+		{"date only", "20240110", tokyo, time.Date(2024, 1, 10, 23, 59, 59, 999999999, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse("FREQ=DAILY;UNTIL="+tt.value, tt.loc)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !rule.Until.Equal(tt.want) {
+				t.Errorf("Until = %v, want %v", rule.Until, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"COUNT=3",
+		"FREQ=YEARLY;COUNT=3",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20240110",
+		"FREQ=WEEKLY;COUNT=0",
+		"FREQ=WEEKLY;COUNT=3;COUNT=4",
+		"FREQ=WEEKLY;BYDAY=2TU;COUNT=3",
+		"FREQ=MONTHLY;BYDAY=6TU;COUNT=3",
+		"FREQ=MONTHLY;BYDAY=0TU;COUNT=3",
+		"FREQ=WEEKLY;BYMONTHDAY=1;COUNT=3",
+		"FREQ=DAILY;UNTIL=2024-01-10",
+		"FREQ=DAILY;UNTIL=20240110T0000Z",
+		"FREQ=DAILY;BYSETPOS=1;COUNT=3",
+	}
+
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			if _, err := Parse(value, tokyo); err == nil {
+				t.Errorf("Parse(%q) error = nil, want error", value)
+			}
+		})
+	}
+}
+
+func TestExpandUnbounded(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY", tokyo)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := rule.Expand(at(2024, 1, 8, 9, 0), nil, 1000); err == nil {
+		t.Error("Expand() of a rule without COUNT or UNTIL must fail")
+	}
+
+	rule, err = Parse("FREQ=DAILY;COUNT=10", tokyo)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := rule.Expand(at(2024, 1, 8, 9, 0), nil, 5); err == nil {
+		t.Error("Expand() over the limit must fail")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	jan8, jan15, jan22, jan29 := at(2024, 1, 8, 9, 0), at(2024, 1, 15, 9, 0), at(2024, 1, 22, 9, 0), at(2024, 1, 29, 9, 0)
+
+	tests := []struct {
+		name        string
+		instances   []Instance
+		occurrences []time.Time
+		from        time.Time
+		want        SyncPlan
+	}{
+		{
+			name:        "create all",
+			occurrences: []time.Time{jan8, jan15},
+			want:        SyncPlan{Create: []time.Time{jan8, jan15}},
+		},
+		{
+			name:        "reset unchanged sessions",
+			instances:   []Instance{{ID: 1, OriginalStart: jan8}, {ID: 2, OriginalStart: jan15}},
+			occurrences: []time.Time{jan8, jan15, jan22},
+			want: SyncPlan{
+				Create: []time.Time{jan22},
+				Reset:  []Instance{{ID: 1, OriginalStart: jan8}, {ID: 2, OriginalStart: jan15}},
+			},
+		},
+		{
+			// 1/15の回を別の日時に移動・休講にしていても、展開に含まれる限りそのまま
+			name:        "moved or cancelled override is kept",
+			instances:   []Instance{{ID: 1, OriginalStart: jan8}, {ID: 2, OriginalStart: jan15, IsOverride: true}, {ID: 3, OriginalStart: jan22, IsOverride: true, HasAttendance: true}},
+			occurrences: []time.Time{jan8, jan15, jan22},
+			want:        SyncPlan{Reset: []Instance{{ID: 1, OriginalStart: jan8}}},
+		},
+		{
+			name:        "removed sessions are cancelled or deleted",
+			instances:   []Instance{{ID: 4, OriginalStart: jan29}, {ID: 1, OriginalStart: jan8}, {ID: 3, OriginalStart: jan22, HasAttendance: true}, {ID: 2, OriginalStart: jan15}},
+			occurrences: []time.Time{jan8},
+			want: SyncPlan{
+				Reset:  []Instance{{ID: 1, OriginalStart: jan8}},
+				Cancel: []int{3},
+				Delete: []int{2, 4},
+			},
+		},
+		{
+			// 個別に変更した回も展開から外れた場合は出席の有無で休講・削除とする
+			name:        "override outside expansion",
+			instances:   []Instance{{ID: 1, OriginalStart: jan8, IsOverride: true, HasAttendance: true}, {ID: 2, OriginalStart: jan15, IsOverride: true}},
+			occurrences: []time.Time{jan22},
+			want: SyncPlan{
+				Create: []time.Time{jan22},
+				Cancel: []int{1},
+				Delete: []int{2},
+			},
+		},
+		{
+			// DBから読み出した日時のタイムゾーンや秒未満の違いで別の回として扱わない
+			name:        "matches wall-clock time",
+			instances:   []Instance{{ID: 1, OriginalStart: time.Date(2024, 1, 8, 9, 0, 0, 500, tokyo)}},
+			occurrences: []time.Time{jan8},
+			want:        SyncPlan{Reset: []Instance{{ID: 1, OriginalStart: jan8}}},
+		},
+		{
+			// 1/20時点で曜日を変更した場合、実施済みの回（1/8・1/15）は出席の有無や展開に含まれるかにかかわらずそのまま残す
+			name: "past sessions are not touched",
+			instances: []Instance{
+				{ID: 1, OriginalStart: jan8, HasAttendance: true},
+				{ID: 2, OriginalStart: jan15},
+				{ID: 3, OriginalStart: jan22},
+				{ID: 4, OriginalStart: jan29, HasAttendance: true},
+			},
+			occurrences: []time.Time{at(2024, 1, 10, 9, 0), at(2024, 1, 17, 9, 0), at(2024, 1, 24, 9, 0), at(2024, 1, 31, 9, 0)},
+			from:        at(2024, 1, 20, 0, 0),
+			want: SyncPlan{
+				Create: []time.Time{at(2024, 1, 24, 9, 0), at(2024, 1, 31, 9, 0)},
+				Cancel: []int{4},
+				Delete: []int{3},
+			},
+		},
+		{
+			// 開始時刻ちょうどの回は対象に含める
+			name:        "session starting at from",
+			instances:   []Instance{{ID: 1, OriginalStart: jan8}, {ID: 2, OriginalStart: jan15}},
+			occurrences: []time.Time{jan15},
+			from:        jan15,
+			want:        SyncPlan{Reset: []Instance{{ID: 2, OriginalStart: jan15}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Reconcile(tt.instances, tt.occurrences, tt.from); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Reconcile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// apiKeyTouchInterval 最終利用日時を更新する最小間隔（認証のたびに書き込まないため）
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyRepository APIキーリポジトリの構造体
+type APIKeyRepository struct {
+	DB DBTX
+}
+
+// NewAPIKeyRepository APIキーリポジトリのコンストラクタ
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *APIKeyRepository) WithTx(tx pgx.Tx) *APIKeyRepository {
+	return &APIKeyRepository{DB: tx}
+}
+
+// CreateAPIKey APIキーを登録する
+func (r *APIKeyRepository) CreateAPIKey(apiKey *models.APIKey) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING api_key_id
+	`
+
+	apiKey.CreatedAt = time.Now()
+	err := r.DB.QueryRow(ctx, query,
+		apiKey.UserID,
+		apiKey.Name,
+		apiKey.KeyPrefix,
+		apiKey.KeyHash,
+		apiKey.Scopes,
+		apiKey.ExpiresAt,
+		apiKey.CreatedBy,
+		apiKey.CreatedAt,
+	).Scan(&apiKey.APIKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetActiveAPIKeyByPrefix 識別子から有効なAPIキーと所有ユーザーの役割を取得する
+// 無効化済み・期限切れ・所有ユーザーが削除済みの場合はnilを返す
+func (r *APIKeyRepository) GetActiveAPIKeyByPrefix(keyPrefix string) (*models.APIKey, string, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT k.api_key_id, k.user_id, k.name, k.key_prefix, k.key_hash, k.scopes,
+			k.expires_at, k.last_used_at, k.created_by, k.created_at, u.role
+		FROM api_keys k
+		INNER JOIN users u ON k.user_id = u.user_id
+		WHERE k.key_prefix = $1
+			AND k.revoked_at IS NULL
+			AND (k.expires_at IS NULL OR k.expires_at > $2)
+			AND u.is_deleted = false
+	`
+
+	var apiKey models.APIKey
+	var role string
+	err := r.DB.QueryRow(ctx, query, keyPrefix, time.Now()).Scan(
+		&apiKey.APIKeyID,
+		&apiKey.UserID,
+		&apiKey.Name,
+		&apiKey.KeyPrefix,
+		&apiKey.KeyHash,
+		&apiKey.Scopes,
+		&apiKey.ExpiresAt,
+		&apiKey.LastUsedAt,
+		&apiKey.CreatedBy,
+		&apiKey.CreatedAt,
+		&role,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return &apiKey, role, nil
+}
+
+// TouchAPIKey 最終利用日時と接続元IPアドレスを記録する
+func (r *APIKeyRepository) TouchAPIKey(apiKeyID int, ip string) error {
+	ctx := context.Background()
+
+	now := time.Now()
+	query := `
+		UPDATE api_keys
+		SET last_used_at = $2, last_used_ip = $3
+		WHERE api_key_id = $1 AND (last_used_at IS NULL OR last_used_at < $4)
+	`
+
+	if _, err := r.DB.Exec(ctx, query, apiKeyID, now, ip, now.Add(-apiKeyTouchInterval)); err != nil {
+		return fmt.Errorf("failed to touch api key: %w", err)
+	}
+
+	return nil
+}
+
+// ListAPIKeys APIキーの一覧を取得する（userIDが0の場合は全ユーザー）
+func (r *APIKeyRepository) ListAPIKeys(userID int, includeRevoked bool) ([]models.APIKey, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT api_key_id, user_id, name, key_prefix, scopes, expires_at,
+			last_used_at, last_used_ip, created_by, created_at, revoked_at
+		FROM api_keys
+		WHERE ($1 = 0 OR user_id = $1)
+			AND ($2 OR revoked_at IS NULL)
+		ORDER BY api_key_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, userID, includeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var apiKeys []models.APIKey
+	for rows.Next() {
+		var apiKey models.APIKey
+		err := rows.Scan(
+			&apiKey.APIKeyID,
+			&apiKey.UserID,
+			&apiKey.Name,
+			&apiKey.KeyPrefix,
+			&apiKey.Scopes,
+			&apiKey.ExpiresAt,
+			&apiKey.LastUsedAt,
+			&apiKey.LastUsedIP,
+			&apiKey.CreatedBy,
+			&apiKey.CreatedAt,
+			&apiKey.RevokedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key row: %w", err)
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over api key rows: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+// RevokeAPIKey APIキーを無効化する（対象がない、または無効化済みの場合はエラー）
+func (r *APIKeyRepository) RevokeAPIKey(apiKeyID int) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE api_keys
+		SET revoked_at = $2
+		WHERE api_key_id = $1 AND revoked_at IS NULL
+	`
+
+	result, err := r.DB.Exec(ctx, query, apiKeyID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("api key not found")
+	}
+
+	return nil
+}
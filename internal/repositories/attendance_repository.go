@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// AttendanceRepository 出席リポジトリの構造体
+type AttendanceRepository struct {
+	DB DBTX
+}
+
+// NewAttendanceRepository 出席リポジトリのコンストラクタ
+func NewAttendanceRepository(db *pgxpool.Pool) *AttendanceRepository {
+	return &AttendanceRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *AttendanceRepository) WithTx(tx pgx.Tx) *AttendanceRepository {
+	return &AttendanceRepository{DB: tx}
+}
+
+// AttendanceFilter 出席一覧の検索条件
+type AttendanceFilter struct {
+	StudentUserID *int
+	CourseID      *int
+	Status        string
+	From          *time.Time // この日時以降
+	To            *time.Time // この日時より前
+	Limit         int
+	Offset        int
+}
+
+// ListAttendances 条件に一致する出席の一覧を取得する
+func (r *AttendanceRepository) ListAttendances(filter *AttendanceFilter) ([]models.AttendanceResponse, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT
+			a.attendance_id,
+			a.student_user_id,
+			u.name as student_name,
+			a.course_id,
+			c.title as course_title,
+			a.session_id,
+			a.status,
+			a.attended_at
+		FROM attendances a
+		JOIN users u ON a.student_user_id = u.user_id
+		JOIN courses c ON a.course_id = c.course_id
+		WHERE a.is_deleted = false
+			AND c.is_deleted = false
+			AND ($1::int IS NULL OR a.student_user_id = $1)
+			AND ($2::int IS NULL OR a.course_id = $2)
+			AND ($3 = '' OR a.status::text = $3)
+			AND ($4::timestamp IS NULL OR a.attended_at >= $4)
+			AND ($5::timestamp IS NULL OR a.attended_at < $5)
+		ORDER BY a.attended_at DESC, a.attendance_id DESC
+		LIMIT $6 OFFSET $7
+	`
+
+	rows, err := r.DB.Query(ctx, query,
+		filter.StudentUserID,
+		filter.CourseID,
+		filter.Status,
+		filter.From,
+		filter.To,
+		filter.Limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attendances: %w", err)
+	}
+	defer rows.Close()
+
+	var attendances []models.AttendanceResponse
+	for rows.Next() {
+		var attendance models.AttendanceResponse
+		err := rows.Scan(
+			&attendance.AttendanceID,
+			&attendance.StudentUserID,
+			&attendance.StudentName,
+			&attendance.CourseID,
+			&attendance.CourseTitle,
+			&attendance.SessionID,
+			&attendance.Status,
+			&attendance.AttendedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attendance row: %w", err)
+		}
+		attendances = append(attendances, attendance)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over attendance rows: %w", err)
+	}
+
+	return attendances, nil
+}
+
+// ListSessionAttendances 授業回の出席を受講者ごとに取得する
+// 受講中・修了の学生は未記録でも含め、受講を取りやめた学生は記録がある場合のみ含める
+func (r *AttendanceRepository) ListSessionAttendances(courseID int, sessionID int) ([]models.SessionAttendanceResponse, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT e.student_user_id, u.name, a.attendance_id, a.status::text
+		FROM enrollments e
+		JOIN users u ON e.student_user_id = u.user_id
+		LEFT JOIN attendances a
+			ON a.session_id = $2 AND a.student_user_id = e.student_user_id AND a.is_deleted = false
+		WHERE e.course_id = $1
+			AND (e.status IN ('active', 'completed') OR a.attendance_id IS NOT NULL)
+		ORDER BY u.name, e.student_user_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, courseID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session attendances: %w", err)
+	}
+	defer rows.Close()
+
+	var attendances []models.SessionAttendanceResponse
+	for rows.Next() {
+		var attendance models.SessionAttendanceResponse
+		err := rows.Scan(
+			&attendance.StudentUserID,
+			&attendance.StudentName,
+			&attendance.AttendanceID,
+			&attendance.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session attendance row: %w", err)
+		}
+		attendances = append(attendances, attendance)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over session attendance rows: %w", err)
+	}
+
+	return attendances, nil
+}
+
+// RecordSessionAttendances 授業回の出席を登録し、記録済みの学生は状態を更新する
+func (r *AttendanceRepository) RecordSessionAttendances(session *models.CourseSession, entries []models.SessionAttendanceEntry) error {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, entry := range entries {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO attendances (student_user_id, course_id, session_id, status, attended_at, is_deleted)
+			VALUES ($1, $2, $3, $4, $5, false)
+			ON CONFLICT (session_id, student_user_id) WHERE session_id IS NOT NULL AND is_deleted = false
+			DO UPDATE SET status = EXCLUDED.status
+		`, entry.StudentUserID, session.CourseID, session.SessionID, entry.Status, session.StartsAt)
+		if err != nil {
+			return fmt.Errorf("failed to record attendance: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
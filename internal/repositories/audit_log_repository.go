@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// AuditLogFilter 監査ログの検索条件（ゼロ値の条件は無視する）
+type AuditLogFilter struct {
+	ActorUserID int
+	EntityType  string
+	EntityID    string
+	Action      string
+	From        *time.Time
+	To          *time.Time
+}
+
+// AuditLogRepository 監査ログリポジトリの構造体
+type AuditLogRepository struct {
+	DB DBTX
+}
+
+// NewAuditLogRepository 監査ログリポジトリのコンストラクタ
+func NewAuditLogRepository(db *pgxpool.Pool) *AuditLogRepository {
+	return &AuditLogRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *AuditLogRepository) WithTx(tx pgx.Tx) *AuditLogRepository {
+	return &AuditLogRepository{DB: tx}
+}
+
+// CreateAuditLog 監査ログを追記する（WithTxで変更操作と同じトランザクションに追記する）
+func (r *AuditLogRepository) CreateAuditLog(auditLog *models.AuditLog) error {
+	return insertAuditLog(context.Background(), r.DB, auditLog)
+}
+
+// auditLogQuerier 監査ログを追記できる接続（プールまたはトランザクション）
+type auditLogQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertAuditLog 監査ログを追記する（トランザクションを渡した場合は変更操作と同時にコミットされる）
+func insertAuditLog(ctx context.Context, db auditLogQuerier, auditLog *models.AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (
+			actor_user_id, actor_api_key_id, action, entity_type, entity_id,
+			before_data, after_data, changes, request_id, ip_address, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING audit_log_id
+	`
+
+	auditLog.CreatedAt = time.Now()
+	err := db.QueryRow(ctx, query,
+		auditLog.ActorUserID,
+		auditLog.ActorAPIKeyID,
+		auditLog.Action,
+		auditLog.EntityType,
+		auditLog.EntityID,
+		nullableJSON(auditLog.BeforeData),
+		nullableJSON(auditLog.AfterData),
+		nullableJSON(auditLog.Changes),
+		auditLog.RequestID,
+		auditLog.IPAddress,
+		auditLog.CreatedAt,
+	).Scan(&auditLog.AuditLogID)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// ListAuditLogs 条件に一致する監査ログを新しい順に取得し、総件数も返す
+func (r *AuditLogRepository) ListAuditLogs(filter AuditLogFilter, limit int, offset int) ([]models.AuditLog, int, error) {
+	ctx := context.Background()
+
+	where := `
+		WHERE ($1 = 0 OR actor_user_id = $1)
+			AND ($2 = '' OR entity_type = $2)
+			AND ($3 = '' OR entity_id = $3)
+			AND ($4 = '' OR action = $4)
+			AND ($5::timestamp IS NULL OR created_at >= $5)
+			AND ($6::timestamp IS NULL OR created_at < $6)
+	`
+	args := []interface{}{filter.ActorUserID, filter.EntityType, filter.EntityID, filter.Action, filter.From, filter.To}
+
+	var total int
+	if err := r.DB.QueryRow(ctx, `SELECT COUNT(*) FROM audit_logs`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	query := `
+		SELECT audit_log_id, actor_user_id, actor_api_key_id, action, entity_type, entity_id,
+			before_data, after_data, changes, request_id, ip_address, created_at
+		FROM audit_logs
+	` + where + `
+		ORDER BY created_at DESC, audit_log_id DESC
+		LIMIT $7 OFFSET $8
+	`
+
+	rows, err := r.DB.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var auditLogs []models.AuditLog
+	for rows.Next() {
+		var auditLog models.AuditLog
+		var beforeData, afterData, changes []byte
+		err := rows.Scan(
+			&auditLog.AuditLogID,
+			&auditLog.ActorUserID,
+			&auditLog.ActorAPIKeyID,
+			&auditLog.Action,
+			&auditLog.EntityType,
+			&auditLog.EntityID,
+			&beforeData,
+			&afterData,
+			&changes,
+			&auditLog.RequestID,
+			&auditLog.IPAddress,
+			&auditLog.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit log row: %w", err)
+		}
+		auditLog.BeforeData = beforeData
+		auditLog.AfterData = afterData
+		auditLog.Changes = changes
+		auditLogs = append(auditLogs, auditLog)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over audit log rows: %w", err)
+	}
+
+	return auditLogs, total, nil
+}
+
+// nullableJSON 空のJSONをNULLとして保存する
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
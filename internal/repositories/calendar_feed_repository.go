@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// calendarFeedTouchInterval 最終利用日時を更新する最小間隔（カレンダーアプリの定期取得のたびに書き込まないため）
+const calendarFeedTouchInterval = time.Hour
+
+// calendarFeedCourses カレンダーに含める授業（$1: ユーザーID、$2: 役割）
+// 教師は担当授業（副担当・アシスタントを含む）、学生は受講中・修了の授業、保護者は子どもが受講中・修了の授業
+const calendarFeedCourses = `
+	WITH feed_courses AS (
+		SELECT st.course_id FROM course_staff st
+		WHERE $2 = 'teacher' AND st.user_id = $1
+		UNION
+		SELECT e.course_id FROM enrollments e
+		WHERE $2 = 'student' AND e.student_user_id = $1 AND e.status IN ('active', 'completed')
+		UNION
+		SELECT e.course_id FROM enrollments e
+		JOIN guardians g ON g.student_user_id = e.student_user_id AND g.is_deleted = false
+		WHERE $2 = 'parent' AND g.parent_user_id = $1 AND e.status IN ('active', 'completed')
+	)
+`
+
+// CalendarFeedRepository カレンダー購読リポジトリの構造体
+type CalendarFeedRepository struct {
+	DB DBTX
+}
+
+// NewCalendarFeedRepository カレンダー購読リポジトリのコンストラクタ
+func NewCalendarFeedRepository(db *pgxpool.Pool) *CalendarFeedRepository {
+	return &CalendarFeedRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *CalendarFeedRepository) WithTx(tx pgx.Tx) *CalendarFeedRepository {
+	return &CalendarFeedRepository{DB: tx}
+}
+
+// GetFeed ユーザーのカレンダー購読を取得する（発行していない場合はnil）
+func (r *CalendarFeedRepository) GetFeed(userID int) (*models.CalendarFeed, error) {
+	ctx := context.Background()
+
+	var feed models.CalendarFeed
+	err := r.DB.QueryRow(ctx, `
+		SELECT user_id, token_hash, created_at, last_used_at
+		FROM calendar_feeds
+		WHERE user_id = $1
+	`, userID).Scan(&feed.UserID, &feed.TokenHash, &feed.CreatedAt, &feed.LastUsedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	return &feed, nil
+}
+
+// SaveFeed カレンダー購読のトークンを登録する（発行済みの場合は置き換えて以前のURLを無効にする）
+func (r *CalendarFeedRepository) SaveFeed(feed *models.CalendarFeed) error {
+	ctx := context.Background()
+
+	feed.CreatedAt = time.Now()
+	feed.LastUsedAt = nil
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO calendar_feeds (user_id, token_hash, created_at, last_used_at)
+		VALUES ($1, $2, $3, NULL)
+		ON CONFLICT (user_id) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash, created_at = EXCLUDED.created_at, last_used_at = NULL
+	`, feed.UserID, feed.TokenHash, feed.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFeed カレンダー購読を削除する（削除した場合はtrueを返す）
+func (r *CalendarFeedRepository) DeleteFeed(userID int) (bool, error) {
+	ctx := context.Background()
+
+	tag, err := r.DB.Exec(ctx, `DELETE FROM calendar_feeds WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetFeedUserByTokenHash トークンのハッシュから購読ユーザーのIDと役割を取得する
+// 該当するトークンがない・ユーザーが削除済みの場合は0を返す
+func (r *CalendarFeedRepository) GetFeedUserByTokenHash(tokenHash string) (int, string, error) {
+	ctx := context.Background()
+
+	var userID int
+	var role string
+	err := r.DB.QueryRow(ctx, `
+		SELECT f.user_id, u.role
+		FROM calendar_feeds f
+		INNER JOIN users u ON f.user_id = u.user_id
+		WHERE f.token_hash = $1 AND u.is_deleted = false
+	`, tokenHash).Scan(&userID, &role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	return userID, role, nil
+}
+
+// TouchFeed 最終利用日時を記録する
+func (r *CalendarFeedRepository) TouchFeed(userID int) error {
+	ctx := context.Background()
+
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, `
+		UPDATE calendar_feeds SET last_used_at = $2
+		WHERE user_id = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`, userID, now, now.Add(-calendarFeedTouchInterval))
+	if err != nil {
+		return fmt.Errorf("failed to touch calendar feed: %w", err)
+	}
+
+	return nil
+}
+
+// ListFeedSessions カレンダーに含める授業の授業回を取得する（休講の回を含む）
+func (r *CalendarFeedRepository) ListFeedSessions(userID int, role string, from time.Time) ([]models.CalendarFeedSession, error) {
+	ctx := context.Background()
+
+	query := calendarFeedCourses + `
+		SELECT cs.session_id, c.course_id, c.title, c.description,
+			cs.original_starts_at, cs.starts_at, cs.ends_at, cs.status, cs.note
+		FROM course_sessions cs
+		JOIN feed_courses fc ON cs.course_id = fc.course_id
+		JOIN courses c ON cs.course_id = c.course_id
+		WHERE c.is_deleted = false AND cs.starts_at >= $3
+		ORDER BY cs.starts_at, cs.session_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, userID, role, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calendar sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.CalendarFeedSession
+	for rows.Next() {
+		var session models.CalendarFeedSession
+		err := rows.Scan(
+			&session.SessionID,
+			&session.CourseID,
+			&session.CourseTitle,
+			&session.CourseDescription,
+			&session.OriginalStartsAt,
+			&session.StartsAt,
+			&session.EndsAt,
+			&session.Status,
+			&session.Note,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calendar session row: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over calendar session rows: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// ListFeedTests カレンダーに含める授業のテストを取得する（includeDraftsがfalseの場合は下書きを除く）
+func (r *CalendarFeedRepository) ListFeedTests(userID int, role string, from time.Time, includeDrafts bool) ([]models.CalendarFeedTest, error) {
+	ctx := context.Background()
+
+	query := calendarFeedCourses + `
+		SELECT t.teacher_test_id, c.title, t.title, t.description,
+			t.duration_minutes, t.scheduled_at, t.is_draft
+		FROM teacher_tests t
+		JOIN feed_courses fc ON t.course_id = fc.course_id
+		JOIN courses c ON t.course_id = c.course_id
+		WHERE t.is_deleted = false AND c.is_deleted = false
+			AND t.scheduled_at >= $3
+			AND ($4 OR t.is_draft = false)
+		ORDER BY t.scheduled_at, t.teacher_test_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, userID, role, from, includeDrafts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calendar tests: %w", err)
+	}
+	defer rows.Close()
+
+	var tests []models.CalendarFeedTest
+	for rows.Next() {
+		var test models.CalendarFeedTest
+		err := rows.Scan(
+			&test.TeacherTestID,
+			&test.CourseTitle,
+			&test.Title,
+			&test.Description,
+			&test.DurationMinutes,
+			&test.ScheduledAt,
+			&test.IsDraft,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calendar test row: %w", err)
+		}
+		tests = append(tests, test)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over calendar test rows: %w", err)
+	}
+
+	return tests, nil
+}
@@ -2,16 +2,18 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/tomoki-den-uhd/go-study/internal/models"
 )
 
 // CourseRepository 授業リポジトリの構造体
 type CourseRepository struct {
-	DB *pgxpool.Pool
+	DB DBTX
 }
 
 // NewCourseRepository 授業リポジトリのコンストラクタ
@@ -21,22 +23,34 @@ func NewCourseRepository(db *pgxpool.Pool) *CourseRepository {
 	}
 }
 
-// CreateCourse 授業をデータベースに登録する
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *CourseRepository) WithTx(tx pgx.Tx) *CourseRepository {
+	return &CourseRepository{DB: tx}
+}
+
+// CreateCourse 授業をデータベースに登録し、作成した教師を主担当にする
 func (r *CourseRepository) CreateCourse(course *models.Course) (int, error) {
 	ctx := context.Background()
 	
 	// 現在時刻を取得
 	now := time.Now()
 	
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	
 	// SQLクエリを実行
 	query := `
-		INSERT INTO courses (title, description, teacher_user_id, subject_id, created_at, updated_at, scheduled_at, is_deleted)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO courses (title, description, teacher_user_id, subject_id, created_at, updated_at, scheduled_at, is_deleted,
+		                     recurrence_rule, recurrence_exdates, duration_minutes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING course_id
 	`
 	
 	var courseID int
-	err := r.DB.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		course.Title,
 		course.Description,
 		course.TeacherUserID,
@@ -45,12 +59,23 @@ func (r *CourseRepository) CreateCourse(course *models.Course) (int, error) {
 		now,
 		course.ScheduledAt,
 		false, // is_deleted
+		course.RecurrenceRule,
+		exDatesOrEmpty(course.ExDates),
+		course.DurationMinutes,
 	).Scan(&courseID)
 	
 	if err != nil {
 		return 0, fmt.Errorf("failed to create course: %w", err)
 	}
 	
+	if err := insertCourseOwner(ctx, tx, courseID, course.TeacherUserID, now); err != nil {
+		return 0, err
+	}
+	
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
 	return courseID, nil
 }
 
@@ -75,7 +100,8 @@ func (r *CourseRepository) GetCourseByID(courseID int) (*models.Course, error) {
 	
 	query := `
 		SELECT course_id, title, description, teacher_user_id, subject_id, 
-		       created_at, updated_at, scheduled_at, is_deleted
+		       created_at, updated_at, scheduled_at, is_deleted,
+		       recurrence_rule, recurrence_exdates, duration_minutes
 		FROM courses 
 		WHERE course_id = $1 AND is_deleted = false
 	`
@@ -91,6 +117,9 @@ func (r *CourseRepository) GetCourseByID(courseID int) (*models.Course, error) {
 		&course.UpdatedAt,
 		&course.ScheduledAt,
 		&course.IsDeleted,
+		&course.RecurrenceRule,
+		&course.ExDates,
+		&course.DurationMinutes,
 	)
 	
 	if err != nil {
@@ -111,7 +140,8 @@ func (r *CourseRepository) UpdateCourse(courseID int, course *models.Course) err
 	query := `
 		UPDATE courses 
 		SET title = $1, description = $2, subject_id = $3, 
-		    scheduled_at = $4, updated_at = $5
+		    scheduled_at = $4, updated_at = $5,
+		    recurrence_rule = $7, recurrence_exdates = $8, duration_minutes = $9
 		WHERE course_id = $6 AND is_deleted = false
 	`
 	
@@ -122,6 +152,9 @@ func (r *CourseRepository) UpdateCourse(courseID int, course *models.Course) err
 		course.ScheduledAt,
 		now,
 		courseID,
+		course.RecurrenceRule,
+		exDatesOrEmpty(course.ExDates),
+		course.DurationMinutes,
 	)
 	
 	if err != nil {
@@ -135,4 +168,389 @@ func (r *CourseRepository) UpdateCourse(courseID int, course *models.Course) err
 	}
 	
 	return nil
-} 
\ No newline at end of file
+} 
+
+// CourseFilter 授業一覧の検索条件
+type CourseFilter struct {
+	SubjectID         *int
+	TeacherUserID     *int       // 担当（副担当・アシスタントを含む）の授業に限定する
+	StudentUserID     *int       // 受講中の授業に限定する
+	From              *time.Time // scheduled_atがこの日時以降
+	To                *time.Time // scheduled_atがこの日時より前
+	Keyword           string
+	OrderBy           string // ORDER BY句（サービスで許可した値のみ指定すること）
+	IncludeDraftTests bool   // テスト数に下書きを含めるか
+	Limit             int
+	Offset            int
+}
+
+// courseDetailColumns 授業詳細として取得する列（教科名・教師名・動画数・テスト数を含む）
+const courseDetailColumns = `
+		c.course_id, c.teacher_user_id, c.title, c.description, c.subject_id, c.scheduled_at, c.updated_at,
+		c.recurrence_rule, c.recurrence_exdates, c.duration_minutes,
+		s.name, u.name,
+		(SELECT COUNT(*) FROM course_videos v WHERE v.course_id = c.course_id AND v.is_deleted = false),
+		(SELECT COUNT(*) FROM teacher_tests t WHERE t.course_id = c.course_id AND t.is_deleted = false),
+		(SELECT COUNT(*) FROM teacher_tests t WHERE t.course_id = c.course_id AND t.is_deleted = false AND t.is_draft = false)
+	FROM courses c
+	JOIN subjects s ON c.subject_id = s.subject_id
+	JOIN users u ON c.teacher_user_id = u.user_id
+`
+
+// scanCourseDetail 授業詳細の行を読み込む
+func scanCourseDetail(row pgx.Row, includeDraftTests bool) (*models.CourseDetailResponse, error) {
+	var course models.CourseDetailResponse
+	var testCount, publishedTestCount int
+	err := row.Scan(
+		&course.CourseID,
+		&course.TeacherUserID,
+		&course.Title,
+		&course.Description,
+		&course.SubjectID,
+		&course.ScheduledAt,
+		&course.UpdatedAt,
+		&course.RecurrenceRule,
+		&course.ExDates,
+		&course.DurationMinutes,
+		&course.SubjectName,
+		&course.TeacherName,
+		&course.VideoCount,
+		&testCount,
+		&publishedTestCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	course.TestCount = publishedTestCount
+	if includeDraftTests {
+		course.TestCount = testCount
+	}
+
+	return &course, nil
+}
+
+// ListCourses 条件に一致する授業の一覧と総件数を取得する
+func (r *CourseRepository) ListCourses(filter *CourseFilter) ([]models.CourseDetailResponse, int, error) {
+	ctx := context.Background()
+
+	where := `
+		WHERE c.is_deleted = false
+			AND ($1::int IS NULL OR c.subject_id = $1)
+			AND ($2::int IS NULL OR EXISTS (
+				SELECT 1 FROM course_staff cs WHERE cs.course_id = c.course_id AND cs.user_id = $2
+			))
+			AND ($3::int IS NULL OR EXISTS (
+				SELECT 1 FROM enrollments e
+				WHERE e.course_id = c.course_id AND e.student_user_id = $3 AND e.status IN ('active', 'completed')
+			))
+			AND ($4::timestamp IS NULL OR c.scheduled_at >= $4)
+			AND ($5::timestamp IS NULL OR c.scheduled_at < $5)
+			AND ($6 = '' OR c.title ILIKE '%' || $6 || '%' OR c.description ILIKE '%' || $6 || '%')
+	`
+	args := []interface{}{filter.SubjectID, filter.TeacherUserID, filter.StudentUserID, filter.From, filter.To, filter.Keyword}
+
+	var total int
+	if err := r.DB.QueryRow(ctx, `SELECT COUNT(*) FROM courses c`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count courses: %w", err)
+	}
+
+	query := `SELECT ` + courseDetailColumns + where + `
+		ORDER BY ` + filter.OrderBy + `, c.course_id
+		LIMIT $7 OFFSET $8
+	`
+
+	rows, err := r.DB.Query(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query courses: %w", err)
+	}
+	defer rows.Close()
+
+	var courses []models.CourseDetailResponse
+	for rows.Next() {
+		course, err := scanCourseDetail(rows, filter.IncludeDraftTests)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan course row: %w", err)
+		}
+		courses = append(courses, *course)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over course rows: %w", err)
+	}
+
+	return courses, total, nil
+}
+
+// GetCourseDetail 授業の詳細を取得する（存在しない場合はnilを返す）
+func (r *CourseRepository) GetCourseDetail(courseID int, includeDraftTests bool) (*models.CourseDetailResponse, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + courseDetailColumns + `WHERE c.course_id = $1 AND c.is_deleted = false`
+
+	course, err := scanCourseDetail(r.DB.QueryRow(ctx, query, courseID), includeDraftTests)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course detail: %w", err)
+	}
+
+	return course, nil
+}
+
+// IsEnrolled 学生が授業を受講しているか（受講中または修了）を返す
+func (r *CourseRepository) IsEnrolled(courseID int, studentUserID int) (bool, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM enrollments
+			WHERE course_id = $1 AND student_user_id = $2 AND status IN ('active', 'completed')
+		)
+	`
+
+	var enrolled bool
+	if err := r.DB.QueryRow(ctx, query, courseID, studentUserID).Scan(&enrolled); err != nil {
+		return false, fmt.Errorf("failed to check enrollment: %w", err)
+	}
+
+	return enrolled, nil
+}
+
+// GetStaffRole 教師の授業での担当を返す（担当でない場合は空文字を返す）
+func (r *CourseRepository) GetStaffRole(courseID int, userID int) (string, error) {
+	ctx := context.Background()
+
+	query := `SELECT role FROM course_staff WHERE course_id = $1 AND user_id = $2`
+
+	var role string
+	err := r.DB.QueryRow(ctx, query, courseID, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get course staff role: %w", err)
+	}
+
+	return role, nil
+}
+
+// ListStaffUserIDs 授業の担当（アシスタントを含む）のユーザーIDを取得する
+func (r *CourseRepository) ListStaffUserIDs(courseID int) ([]int, error) {
+	ctx := context.Background()
+
+	rows, err := r.DB.Query(ctx, `SELECT user_id FROM course_staff WHERE course_id = $1 ORDER BY user_id`, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query course staff: %w", err)
+	}
+
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan course staff row: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over course staff rows: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// GetCourseIncludingDeleted 論理削除済みを含めて授業を取得する（存在しない場合はnilを返す）
+func (r *CourseRepository) GetCourseIncludingDeleted(courseID int) (*models.Course, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT course_id, title, description, teacher_user_id, subject_id,
+		       created_at, updated_at, scheduled_at, is_deleted, deleted_at, deleted_by,
+		       recurrence_rule, recurrence_exdates, duration_minutes
+		FROM courses
+		WHERE course_id = $1
+	`
+
+	var course models.Course
+	err := r.DB.QueryRow(ctx, query, courseID).Scan(
+		&course.CourseID,
+		&course.Title,
+		&course.Description,
+		&course.TeacherUserID,
+		&course.SubjectID,
+		&course.CreatedAt,
+		&course.UpdatedAt,
+		&course.ScheduledAt,
+		&course.IsDeleted,
+		&course.DeletedAt,
+		&course.DeletedBy,
+		&course.RecurrenceRule,
+		&course.ExDates,
+		&course.DurationMinutes,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course: %w", err)
+	}
+
+	return &course, nil
+}
+
+// countRetainedRecords 授業の削除後も保持する成績・提出済み解答・出席の件数を取得する
+func countRetainedRecords(ctx context.Context, tx pgx.Tx, courseID int, result *models.CourseCascadeResult) error {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM grades WHERE course_id = $1 AND is_deleted = false),
+			(SELECT COUNT(*) FROM student_tests st
+				JOIN teacher_tests tt ON st.teacher_test_id = tt.teacher_test_id
+				WHERE tt.course_id = $1 AND st.is_deleted = false),
+			(SELECT COUNT(*) FROM attendances WHERE course_id = $1 AND is_deleted = false)
+	`
+
+	err := tx.QueryRow(ctx, query, courseID).Scan(&result.Grades, &result.Submissions, &result.Attendances)
+	if err != nil {
+		return fmt.Errorf("failed to count course records: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCourse 授業を論理削除し、テスト・動画を同じ削除日時で論理削除する
+// 成績・提出済み解答・出席は変更しない。forceがfalseで成績か提出済み解答がある場合は削除しない
+func (r *CourseRepository) DeleteCourse(courseID int, deletedBy int, force bool) (*models.CourseCascadeResult, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// 同時に実行された削除・復元と競合しないよう行ロックを取得
+	var exists bool
+	err = tx.QueryRow(ctx, `
+		SELECT true FROM courses WHERE course_id = $1 AND is_deleted = false FOR UPDATE
+	`, courseID).Scan(&exists)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("course not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock course: %w", err)
+	}
+
+	result := &models.CourseCascadeResult{CourseID: courseID}
+	if err := countRetainedRecords(ctx, tx, courseID, result); err != nil {
+		return nil, err
+	}
+
+	if !force && (result.Grades > 0 || result.Submissions > 0) {
+		return nil, fmt.Errorf("course has graded work: %d grades, %d submissions", result.Grades, result.Submissions)
+	}
+
+	// 復元時に削除日時で連動対象を照合するため、データベースの精度に揃える
+	now := time.Now().Truncate(time.Microsecond)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE teacher_tests SET is_deleted = true, deleted_at = $1, updated_at = $1
+		WHERE course_id = $2 AND is_deleted = false
+	`, now, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete course tests: %w", err)
+	}
+	result.Tests = int(tag.RowsAffected())
+
+	tag, err = tx.Exec(ctx, `
+		UPDATE course_videos SET is_deleted = true, deleted_at = $1
+		WHERE course_id = $2 AND is_deleted = false
+	`, now, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete course videos: %w", err)
+	}
+	result.Videos = int(tag.RowsAffected())
+
+	_, err = tx.Exec(ctx, `
+		UPDATE courses SET is_deleted = true, deleted_at = $1, deleted_by = $2, updated_at = $1
+		WHERE course_id = $3
+	`, now, deletedBy, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete course: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	result.DeletedAt = &now
+	return result, nil
+}
+
+// RestoreCourse 論理削除した授業を復元する
+// テスト・動画は授業と同じ削除日時のもの（授業の削除に連動したもの）だけを復元する
+func (r *CourseRepository) RestoreCourse(courseID int) (*models.CourseCascadeResult, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var deletedAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT deleted_at FROM courses WHERE course_id = $1 AND is_deleted = true FOR UPDATE
+	`, courseID).Scan(&deletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("course not deleted")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock course: %w", err)
+	}
+
+	result := &models.CourseCascadeResult{CourseID: courseID}
+	now := time.Now()
+
+	// deleted_atがない（このAPI以前に削除された）場合は授業のみ復元する
+	if deletedAt != nil {
+		tag, err := tx.Exec(ctx, `
+			UPDATE teacher_tests SET is_deleted = false, deleted_at = NULL, updated_at = $1
+			WHERE course_id = $2 AND is_deleted = true AND deleted_at = $3
+		`, now, courseID, *deletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore course tests: %w", err)
+		}
+		result.Tests = int(tag.RowsAffected())
+
+		tag, err = tx.Exec(ctx, `
+			UPDATE course_videos SET is_deleted = false, deleted_at = NULL
+			WHERE course_id = $1 AND is_deleted = true AND deleted_at = $2
+		`, courseID, *deletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore course videos: %w", err)
+		}
+		result.Videos = int(tag.RowsAffected())
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE courses SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $1
+		WHERE course_id = $2
+	`, now, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore course: %w", err)
+	}
+
+	if err := countRetainedRecords(ctx, tx, courseID, result); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,367 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/recurrence"
+)
+
+// CourseSessionRepository 授業回リポジトリの構造体
+type CourseSessionRepository struct {
+	DB DBTX
+}
+
+// NewCourseSessionRepository 授業回リポジトリのコンストラクタ
+func NewCourseSessionRepository(db *pgxpool.Pool) *CourseSessionRepository {
+	return &CourseSessionRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *CourseSessionRepository) WithTx(tx pgx.Tx) *CourseSessionRepository {
+	return &CourseSessionRepository{DB: tx}
+}
+
+// CourseSessionFilter 授業回一覧の検索条件
+type CourseSessionFilter struct {
+	From             *time.Time // starts_atがこの日時以降
+	To               *time.Time // starts_atがこの日時より前
+	IncludeCancelled bool
+}
+
+// courseSessionColumns 授業回として取得する列
+const courseSessionColumns = `
+		cs.session_id, cs.course_id, cs.original_starts_at, cs.starts_at, cs.ends_at,
+		cs.status, cs.is_override, cs.note, cs.created_at, cs.updated_at
+	FROM course_sessions cs
+`
+
+// scanCourseSession 授業回の行を読み込む
+func scanCourseSession(row pgx.Row) (*models.CourseSession, error) {
+	var session models.CourseSession
+	err := row.Scan(
+		&session.SessionID,
+		&session.CourseID,
+		&session.OriginalStartsAt,
+		&session.StartsAt,
+		&session.EndsAt,
+		&session.Status,
+		&session.IsOverride,
+		&session.Note,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// SyncSessions 繰り返しを展開した日時に授業回を合わせる（from以降の回のみ）
+func (r *CourseSessionRepository) SyncSessions(courseID int, occurrences []time.Time, duration time.Duration, from time.Time) error {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := syncCourseSessions(ctx, tx, courseID, occurrences, duration, from); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// syncCourseSessions 繰り返しを展開した日時に授業回を合わせる（対応づけはrecurrence.Reconcileを参照）
+// fromより前に始まる回は実施済みとして変更しない
+func syncCourseSessions(ctx context.Context, tx pgx.Tx, courseID int, occurrences []time.Time, duration time.Duration, from time.Time) error {
+	rows, err := tx.Query(ctx, `
+		SELECT cs.session_id, cs.original_starts_at, cs.is_override,
+			EXISTS(SELECT 1 FROM attendances a WHERE a.session_id = cs.session_id AND a.is_deleted = false)
+		FROM course_sessions cs
+		WHERE cs.course_id = $1
+		FOR UPDATE
+	`, courseID)
+	if err != nil {
+		return fmt.Errorf("failed to query course sessions: %w", err)
+	}
+
+	var instances []recurrence.Instance
+	for rows.Next() {
+		var instance recurrence.Instance
+		if err := rows.Scan(&instance.ID, &instance.OriginalStart, &instance.IsOverride, &instance.HasAttendance); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan course session row: %w", err)
+		}
+		instances = append(instances, instance)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over course session rows: %w", err)
+	}
+
+	plan := recurrence.Reconcile(instances, occurrences, from)
+
+	now := time.Now()
+	for _, startsAt := range plan.Create {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO course_sessions (course_id, original_starts_at, starts_at, ends_at, status, created_at, updated_at)
+			VALUES ($1, $2, $2, $3, $4, $5, $5)
+		`, courseID, startsAt, startsAt.Add(duration), models.CourseSessionStatusScheduled, now)
+		if err != nil {
+			return fmt.Errorf("failed to create course session: %w", err)
+		}
+	}
+
+	for _, instance := range plan.Reset {
+		startsAt := instance.OriginalStart
+		_, err := tx.Exec(ctx, `
+			UPDATE course_sessions SET starts_at = $1, ends_at = $2, status = $3, updated_at = $4
+			WHERE session_id = $5 AND (starts_at <> $1 OR ends_at <> $2 OR status <> $3)
+		`, startsAt, startsAt.Add(duration), models.CourseSessionStatusScheduled, now, instance.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update course session: %w", err)
+		}
+	}
+
+	// 展開から外れた回
+	for _, sessionID := range plan.Cancel {
+		_, err := tx.Exec(ctx, `
+			UPDATE course_sessions SET status = $1, updated_at = $2
+			WHERE session_id = $3 AND status <> $1
+		`, models.CourseSessionStatusCancelled, now, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to remove course session: %w", err)
+		}
+	}
+
+	for _, sessionID := range plan.Delete {
+		if _, err := tx.Exec(ctx, `DELETE FROM course_sessions WHERE session_id = $1`, sessionID); err != nil {
+			return fmt.Errorf("failed to remove course session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListSessions 授業の授業回を開始日時順で取得する
+func (r *CourseSessionRepository) ListSessions(courseID int, filter *CourseSessionFilter) ([]models.CourseSession, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + courseSessionColumns + `
+		WHERE cs.course_id = $1
+			AND ($2::timestamp IS NULL OR cs.starts_at >= $2)
+			AND ($3::timestamp IS NULL OR cs.starts_at < $3)
+			AND ($4 OR cs.status = 'scheduled')
+		ORDER BY cs.starts_at, cs.session_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, courseID, filter.From, filter.To, filter.IncludeCancelled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query course sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.CourseSession
+	for rows.Next() {
+		session, err := scanCourseSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan course session row: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over course session rows: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// GetSession 授業の授業回を取得する（存在しない場合はnilを返す）
+func (r *CourseSessionRepository) GetSession(courseID int, sessionID int) (*models.CourseSession, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + courseSessionColumns + `WHERE cs.course_id = $1 AND cs.session_id = $2`
+
+	session, err := scanCourseSession(r.DB.QueryRow(ctx, query, courseID, sessionID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course session: %w", err)
+	}
+
+	return session, nil
+}
+
+// UpdateSession 授業回を個別に変更する（以降の繰り返しの変更で上書きしない）
+func (r *CourseSessionRepository) UpdateSession(session *models.CourseSession) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE course_sessions
+		SET starts_at = $1, ends_at = $2, status = $3, note = $4, is_override = true, updated_at = $5
+		WHERE session_id = $6
+	`
+
+	_, err := r.DB.Exec(ctx, query,
+		session.StartsAt,
+		session.EndsAt,
+		session.Status,
+		session.Note,
+		time.Now(),
+		session.SessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update course session: %w", err)
+	}
+
+	return nil
+}
+
+// exDatesOrEmpty 除外日時が未指定の場合に空の配列にする（recurrence_exdatesはNOT NULL）
+func exDatesOrEmpty(exDates []time.Time) []time.Time {
+	if exDates == nil {
+		return []time.Time{}
+	}
+	return exDates
+}
+
+// LockSchedules 授業の担当（staffUserIDs）と受講中の学生の予定を、トランザクションの終了までロックする
+// 同じユーザーの予定を同時に変更する操作を直列化し、重複のチェックから登録までの間に別の予定が入らないようにする
+// トランザクション内（WithTx）で呼び出す。デッドロックを避けるためユーザーIDの昇順にロックする
+func (r *CourseSessionRepository) LockSchedules(courseID int, staffUserIDs []int) error {
+	ctx := context.Background()
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT user_id FROM unnest($1::int[]) AS staff(user_id)
+		UNION
+		SELECT student_user_id FROM enrollments WHERE course_id = $2 AND status = 'active'
+		ORDER BY 1
+	`, staffUserIDs, courseID)
+	if err != nil {
+		return fmt.Errorf("failed to query schedule users: %w", err)
+	}
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan schedule user row: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over schedule user rows: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		if _, err := r.DB.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('user_schedule'), $1)`, userID); err != nil {
+			return fmt.Errorf("failed to lock user schedule: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FindScheduleConflicts 時間帯に重なる他の授業の授業回・テストを取得する（休講の回・下書きのテストは除く）
+// 教師はstaffUserIDsの教師が担当（アシスタントを含む）する他の授業の授業回とテスト、
+// 学生はcourseIDを受講中の学生が受講中の他の授業の授業回とテストを対象とする
+func (r *CourseSessionRepository) FindScheduleConflicts(courseID int, staffUserIDs []int, slots []models.ScheduleSlot) ([]models.ScheduleConflict, error) {
+	ctx := context.Background()
+
+	if len(slots) == 0 {
+		return nil, nil
+	}
+
+	startsAt := make([]time.Time, 0, len(slots))
+	endsAt := make([]time.Time, 0, len(slots))
+	for _, slot := range slots {
+		startsAt = append(startsAt, slot.StartsAt)
+		endsAt = append(endsAt, slot.EndsAt)
+	}
+
+	// 時間が0分のテストは1分として重なりを判定する
+	query := `
+		WITH slots AS (
+			SELECT * FROM unnest($1::timestamp[], $2::timestamp[]) AS s(starts_at, ends_at)
+		),
+		items AS (
+			SELECT 'session' AS item_type, cs.session_id AS item_id, c.course_id, c.title AS course_title,
+				c.title, cs.starts_at, cs.ends_at
+			FROM course_sessions cs
+			JOIN courses c ON cs.course_id = c.course_id
+			WHERE cs.status = 'scheduled' AND c.is_deleted = false AND c.course_id <> $3
+			UNION ALL
+			SELECT 'test', t.teacher_test_id, c.course_id, c.title,
+				t.title, t.scheduled_at, t.scheduled_at + make_interval(mins => GREATEST(t.duration_minutes, 1))
+			FROM teacher_tests t
+			JOIN courses c ON t.course_id = c.course_id
+			WHERE t.is_deleted = false AND t.is_draft = false AND c.is_deleted = false AND c.course_id <> $3
+		)
+		SELECT 'teacher' AS kind, u.user_id, u.name, s.starts_at, s.ends_at,
+			i.item_type, i.item_id, i.course_id, i.course_title, i.title, i.starts_at, i.ends_at
+		FROM slots s
+		JOIN items i ON i.starts_at < s.ends_at AND i.ends_at > s.starts_at
+		JOIN course_staff st ON st.course_id = i.course_id AND st.user_id = ANY($4::int[])
+		JOIN users u ON u.user_id = st.user_id
+		UNION ALL
+		SELECT 'student', u.user_id, u.name, s.starts_at, s.ends_at,
+			i.item_type, i.item_id, i.course_id, i.course_title, i.title, i.starts_at, i.ends_at
+		FROM slots s
+		JOIN items i ON i.starts_at < s.ends_at AND i.ends_at > s.starts_at
+		JOIN enrollments other ON other.course_id = i.course_id AND other.status = 'active'
+		JOIN enrollments mine ON mine.student_user_id = other.student_user_id AND mine.course_id = $3 AND mine.status = 'active'
+		JOIN users u ON u.user_id = other.student_user_id
+		ORDER BY 4, 1, 3, 2, 11, 7
+	`
+
+	rows, err := r.DB.Query(ctx, query, startsAt, endsAt, courseID, staffUserIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedule conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	var conflicts []models.ScheduleConflict
+	for rows.Next() {
+		var conflict models.ScheduleConflict
+		err := rows.Scan(
+			&conflict.Kind,
+			&conflict.UserID,
+			&conflict.UserName,
+			&conflict.StartsAt,
+			&conflict.EndsAt,
+			&conflict.ItemType,
+			&conflict.ItemID,
+			&conflict.CourseID,
+			&conflict.CourseTitle,
+			&conflict.Title,
+			&conflict.ItemStartsAt,
+			&conflict.ItemEndsAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule conflict row: %w", err)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over schedule conflict rows: %w", err)
+	}
+
+	return conflicts, nil
+}
@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// CourseStaffRepository 授業の担当教師リポジトリの構造体
+type CourseStaffRepository struct {
+	DB DBTX
+}
+
+// NewCourseStaffRepository 授業の担当教師リポジトリのコンストラクタ
+func NewCourseStaffRepository(db *pgxpool.Pool) *CourseStaffRepository {
+	return &CourseStaffRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *CourseStaffRepository) WithTx(tx pgx.Tx) *CourseStaffRepository {
+	return &CourseStaffRepository{DB: tx}
+}
+
+// courseStaffDetailColumns 担当教師として取得する列（教師の氏名・メールアドレスを含む）
+const courseStaffDetailColumns = `
+		st.course_id, st.user_id, u.name, u.email, st.role, st.created_at, st.updated_at
+	FROM course_staff st
+	JOIN users u ON st.user_id = u.user_id
+`
+
+// scanCourseStaffDetail 担当教師の行を読み込む
+func scanCourseStaffDetail(row pgx.Row) (*models.CourseStaffResponse, error) {
+	var staff models.CourseStaffResponse
+	err := row.Scan(
+		&staff.CourseID,
+		&staff.UserID,
+		&staff.UserName,
+		&staff.UserEmail,
+		&staff.Role,
+		&staff.CreatedAt,
+		&staff.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &staff, nil
+}
+
+// ListStaff 授業の担当教師の一覧を主担当・副担当・アシスタントの順で取得する
+func (r *CourseStaffRepository) ListStaff(courseID int) ([]models.CourseStaffResponse, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + courseStaffDetailColumns + `
+		WHERE st.course_id = $1
+		ORDER BY CASE st.role WHEN 'owner' THEN 0 WHEN 'co_teacher' THEN 1 ELSE 2 END, u.name, st.user_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query course staff: %w", err)
+	}
+	defer rows.Close()
+
+	var staff []models.CourseStaffResponse
+	for rows.Next() {
+		member, err := scanCourseStaffDetail(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan course staff row: %w", err)
+		}
+		staff = append(staff, *member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over course staff rows: %w", err)
+	}
+
+	return staff, nil
+}
+
+// GetStaff 授業と教師の担当を取得する（存在しない場合はnilを返す）
+func (r *CourseStaffRepository) GetStaff(courseID int, userID int) (*models.CourseStaffResponse, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + courseStaffDetailColumns + `WHERE st.course_id = $1 AND st.user_id = $2`
+
+	staff, err := scanCourseStaffDetail(r.DB.QueryRow(ctx, query, courseID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course staff: %w", err)
+	}
+
+	return staff, nil
+}
+
+// AddStaff 教師を授業の担当に追加する（主担当の追加はTransferOwnershipを使うこと）
+func (r *CourseStaffRepository) AddStaff(courseID int, userID int, role string) error {
+	ctx := context.Background()
+
+	now := time.Now()
+
+	query := `
+		INSERT INTO course_staff (course_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.DB.Exec(ctx, query, courseID, userID, role, now, now)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("staff already exists")
+		}
+		return fmt.Errorf("failed to create course staff: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRole 主担当以外の担当を変更する
+func (r *CourseStaffRepository) UpdateRole(courseID int, userID int, role string) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE course_staff SET role = $1, updated_at = $2
+		WHERE course_id = $3 AND user_id = $4 AND role <> 'owner'
+	`
+
+	tag, err := r.DB.Exec(ctx, query, role, time.Now(), courseID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update course staff: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("course must have an owner")
+	}
+
+	return nil
+}
+
+// RemoveStaff 主担当以外の担当を授業から外す
+func (r *CourseStaffRepository) RemoveStaff(courseID int, userID int) error {
+	ctx := context.Background()
+
+	tag, err := r.DB.Exec(ctx, `DELETE FROM course_staff WHERE course_id = $1 AND user_id = $2 AND role <> 'owner'`, courseID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete course staff: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("course must have an owner")
+	}
+
+	return nil
+}
+
+// TransferOwnership 主担当を交代する（以前の主担当は副担当になる）
+func (r *CourseStaffRepository) TransferOwnership(courseID int, userID int) error {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setCourseOwner(ctx, tx, courseID, userID, models.CourseStaffRoleCoTeacher); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertCourseOwner 作成した授業の主担当を登録する
+func insertCourseOwner(ctx context.Context, tx pgx.Tx, courseID int, userID int, now time.Time) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO course_staff (course_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, 'owner', $3, $3)
+	`, courseID, userID, now)
+	if err != nil {
+		return fmt.Errorf("failed to create course owner: %w", err)
+	}
+	return nil
+}
+
+// setCourseOwner 授業の主担当をuserIDの教師にし、courses.teacher_user_idも合わせる
+// 以前の主担当はdemoteToの担当に変更する（空文字の場合は担当から外す）。すでに主担当の場合は何もしない
+func setCourseOwner(ctx context.Context, tx pgx.Tx, courseID int, userID int, demoteTo string) error {
+	now := time.Now()
+
+	var err error
+	if demoteTo == "" {
+		_, err = tx.Exec(ctx, `
+			DELETE FROM course_staff WHERE course_id = $1 AND role = 'owner' AND user_id <> $2
+		`, courseID, userID)
+	} else {
+		_, err = tx.Exec(ctx, `
+			UPDATE course_staff SET role = $3, updated_at = $4
+			WHERE course_id = $1 AND role = 'owner' AND user_id <> $2
+		`, courseID, userID, demoteTo, now)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to demote course owner: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO course_staff (course_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, 'owner', $3, $3)
+		ON CONFLICT (course_id, user_id) DO UPDATE SET role = 'owner', updated_at = EXCLUDED.updated_at
+		WHERE course_staff.role <> 'owner'
+	`, courseID, userID, now)
+	if err != nil {
+		return fmt.Errorf("failed to set course owner: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE courses SET teacher_user_id = $2, updated_at = $3
+		WHERE course_id = $1 AND teacher_user_id <> $2
+	`, courseID, userID, now)
+	if err != nil {
+		return fmt.Errorf("failed to update course teacher: %w", err)
+	}
+
+	return nil
+}
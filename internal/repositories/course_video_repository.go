@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// CourseVideoRepository 授業動画リポジトリの構造体
+type CourseVideoRepository struct {
+	DB DBTX
+}
+
+// NewCourseVideoRepository 授業動画リポジトリのコンストラクタ
+func NewCourseVideoRepository(db *pgxpool.Pool) *CourseVideoRepository {
+	return &CourseVideoRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *CourseVideoRepository) WithTx(tx pgx.Tx) *CourseVideoRepository {
+	return &CourseVideoRepository{DB: tx}
+}
+
+// courseVideoColumns 授業動画として取得する列（保存先のない既存の行は空として扱う）
+const courseVideoColumns = `
+		video_id, course_id, filename, url, uploaded_at, is_deleted,
+		COALESCE(storage_key, ''), COALESCE(content_type, ''), COALESCE(size_bytes, 0), uploaded_by,
+		duration_seconds
+	FROM course_videos
+`
+
+// scanCourseVideo 授業動画の行を読み込む
+func scanCourseVideo(row pgx.Row) (*models.CourseVideo, error) {
+	var video models.CourseVideo
+	err := row.Scan(
+		&video.VideoID,
+		&video.CourseID,
+		&video.Filename,
+		&video.URL,
+		&video.UploadedAt,
+		&video.IsDeleted,
+		&video.StorageKey,
+		&video.ContentType,
+		&video.SizeBytes,
+		&video.UploadedBy,
+		&video.DurationSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &video, nil
+}
+
+// CreateVideo 授業動画を登録し、配信エンドポイントのパスを設定する
+func (r *CourseVideoRepository) CreateVideo(video *models.CourseVideo) (int, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var videoID int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO course_videos (course_id, filename, url, uploaded_at, is_deleted, storage_key, content_type, size_bytes, uploaded_by, duration_seconds)
+		VALUES ($1, $2, '', $3, false, $4, $5, $6, $7, $8)
+		RETURNING video_id
+	`,
+		video.CourseID,
+		video.Filename,
+		time.Now(),
+		video.StorageKey,
+		video.ContentType,
+		video.SizeBytes,
+		video.UploadedBy,
+		video.DurationSeconds,
+	).Scan(&videoID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create course video: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE course_videos SET url = $1 WHERE video_id = $2`,
+		fmt.Sprintf(models.CourseVideoStreamPath, video.CourseID, videoID), videoID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set course video url: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return videoID, nil
+}
+
+// ListVideos 授業の動画一覧をアップロード順で取得する
+func (r *CourseVideoRepository) ListVideos(courseID int) ([]models.CourseVideo, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + courseVideoColumns + `
+		WHERE course_id = $1 AND is_deleted = false
+		ORDER BY uploaded_at, video_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query course videos: %w", err)
+	}
+	defer rows.Close()
+
+	var videos []models.CourseVideo
+	for rows.Next() {
+		video, err := scanCourseVideo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan course video row: %w", err)
+		}
+		videos = append(videos, *video)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over course video rows: %w", err)
+	}
+
+	return videos, nil
+}
+
+// GetVideo 授業の動画を取得する（存在しない場合はnilを返す）
+func (r *CourseVideoRepository) GetVideo(courseID int, videoID int) (*models.CourseVideo, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + courseVideoColumns + `WHERE course_id = $1 AND video_id = $2 AND is_deleted = false`
+
+	video, err := scanCourseVideo(r.DB.QueryRow(ctx, query, courseID, videoID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course video: %w", err)
+	}
+
+	return video, nil
+}
+
+// DeleteVideo 授業の動画を論理削除する（ストレージ上のファイルは残す）
+func (r *CourseVideoRepository) DeleteVideo(courseID int, videoID int) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE course_videos SET is_deleted = true, deleted_at = $1
+		WHERE course_id = $2 AND video_id = $3 AND is_deleted = false
+	`
+
+	tag, err := r.DB.Exec(ctx, query, time.Now(), courseID, videoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete course video: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("video not found")
+	}
+
+	return nil
+}
+
+// UpdateDuration 削除されていない動画の長さを設定する
+func (r *CourseVideoRepository) UpdateDuration(courseID int, videoID int, durationSeconds float64) error {
+	ctx := context.Background()
+
+	query := `UPDATE course_videos SET duration_seconds = $1 WHERE course_id = $2 AND video_id = $3 AND is_deleted = false`
+
+	tag, err := r.DB.Exec(ctx, query, durationSeconds, courseID, videoID)
+	if err != nil {
+		return fmt.Errorf("failed to update course video duration: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("video not found")
+	}
+
+	return nil
+}
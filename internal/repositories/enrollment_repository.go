@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// EnrollmentRepository 受講登録リポジトリの構造体
+type EnrollmentRepository struct {
+	DB DBTX
+}
+
+// NewEnrollmentRepository 受講登録リポジトリのコンストラクタ
+func NewEnrollmentRepository(db *pgxpool.Pool) *EnrollmentRepository {
+	return &EnrollmentRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *EnrollmentRepository) WithTx(tx pgx.Tx) *EnrollmentRepository {
+	return &EnrollmentRepository{DB: tx}
+}
+
+// enrollmentDetailColumns 受講登録として取得する列（学生の氏名・メールアドレスを含む）
+const enrollmentDetailColumns = `
+		e.enrollment_id, e.course_id, e.student_user_id, u.name, u.email, e.status, e.created_at, e.updated_at
+	FROM enrollments e
+	JOIN users u ON e.student_user_id = u.user_id
+`
+
+// scanEnrollmentDetail 受講登録の行を読み込む
+func scanEnrollmentDetail(row pgx.Row) (*models.EnrollmentResponse, error) {
+	var enrollment models.EnrollmentResponse
+	err := row.Scan(
+		&enrollment.EnrollmentID,
+		&enrollment.CourseID,
+		&enrollment.StudentUserID,
+		&enrollment.StudentName,
+		&enrollment.StudentEmail,
+		&enrollment.Status,
+		&enrollment.CreatedAt,
+		&enrollment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+// ListByCourse 授業の受講者一覧を氏名順で取得する（statusが空の場合はすべての状態）
+func (r *EnrollmentRepository) ListByCourse(courseID int, status string) ([]models.EnrollmentResponse, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + enrollmentDetailColumns + `
+		WHERE e.course_id = $1 AND ($2 = '' OR e.status = $2)
+		ORDER BY u.name, e.student_user_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, courseID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enrollments: %w", err)
+	}
+	defer rows.Close()
+
+	var enrollments []models.EnrollmentResponse
+	for rows.Next() {
+		enrollment, err := scanEnrollmentDetail(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan enrollment row: %w", err)
+		}
+		enrollments = append(enrollments, *enrollment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over enrollment rows: %w", err)
+	}
+
+	return enrollments, nil
+}
+
+// GetEnrollment 授業と学生の受講登録を取得する（存在しない場合はnilを返す）
+func (r *EnrollmentRepository) GetEnrollment(courseID int, studentUserID int) (*models.EnrollmentResponse, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + enrollmentDetailColumns + `WHERE e.course_id = $1 AND e.student_user_id = $2`
+
+	enrollment, err := scanEnrollmentDetail(r.DB.QueryRow(ctx, query, courseID, studentUserID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrollment: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+// CreateEnrollment 学生を授業に受講登録する
+func (r *EnrollmentRepository) CreateEnrollment(courseID int, studentUserID int) (int, error) {
+	ctx := context.Background()
+
+	now := time.Now()
+
+	query := `
+		INSERT INTO enrollments (course_id, student_user_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING enrollment_id
+	`
+
+	var enrollmentID int
+	err := r.DB.QueryRow(ctx, query, courseID, studentUserID, models.EnrollmentStatusActive, now, now).Scan(&enrollmentID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, fmt.Errorf("student already enrolled")
+		}
+		return 0, fmt.Errorf("failed to create enrollment: %w", err)
+	}
+
+	return enrollmentID, nil
+}
+
+// UpdateStatus 受講登録の状態を変更する
+func (r *EnrollmentRepository) UpdateStatus(enrollmentID int, status string) error {
+	ctx := context.Background()
+
+	query := `UPDATE enrollments SET status = $1, updated_at = $2 WHERE enrollment_id = $3`
+
+	tag, err := r.DB.Exec(ctx, query, status, time.Now(), enrollmentID)
+	if err != nil {
+		return fmt.Errorf("failed to update enrollment: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("enrollment not found")
+	}
+
+	return nil
+}
@@ -11,7 +11,7 @@ import (
 
 // GradeRepository 成績リポジトリの構造体
 type GradeRepository struct {
-	DB *pgxpool.Pool
+	DB DBTX
 }
 
 // NewGradeRepository 成績リポジトリのコンストラクタ
@@ -78,6 +78,7 @@ func (g *GradeRepository) getGradeSummary(ctx context.Context, gradeID int) (*gr
 		WHERE g.grade_id = $1 
 			AND g.is_deleted = false
 			AND st.is_deleted = false
+			AND c.is_deleted = false
 	`
 	
 	var summary gradeSummary
@@ -143,25 +144,26 @@ func (g *GradeRepository) GetGradeByID(gradeID int) (*models.Grade, error) {
 	return &grade, nil
 }
 
-// IsTeacherOfCourse 教師が指定されたコースの担当者かチェックする
-func (g *GradeRepository) IsTeacherOfCourse(teacherUserID int, courseID int) (bool, error) {
+// GetCourseStaffRole 教師の指定されたコースでの担当を取得する（担当でない場合は空文字を返す）
+func (g *GradeRepository) GetCourseStaffRole(teacherUserID int, courseID int) (string, error) {
 	ctx := context.Background()
 	
 	query := `
-		SELECT COUNT(*)
-		FROM courses
-		WHERE course_id = $1 
-			AND teacher_user_id = $2 
-			AND is_deleted = false
+		SELECT COALESCE(MAX(st.role), '')
+		FROM course_staff st
+		JOIN courses c ON st.course_id = c.course_id
+		WHERE st.course_id = $1 
+			AND st.user_id = $2 
+			AND c.is_deleted = false
 	`
 	
-	var count int
-	err := g.DB.QueryRow(ctx, query, courseID, teacherUserID).Scan(&count)
+	var role string
+	err := g.DB.QueryRow(ctx, query, courseID, teacherUserID).Scan(&role)
 	if err != nil {
-		return false, fmt.Errorf("failed to check teacher course access: %w", err)
+		return "", fmt.Errorf("failed to check teacher course access: %w", err)
 	}
 	
-	return count > 0, nil
+	return role, nil
 }
 
 // getQuestionDetails 問題詳細を取得する（設計書2対応）
@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// GuardianRepository 保護者紐付けリポジトリの構造体
+type GuardianRepository struct {
+	DB DBTX
+}
+
+// NewGuardianRepository 保護者紐付けリポジトリのコンストラクタ
+func NewGuardianRepository(db *pgxpool.Pool) *GuardianRepository {
+	return &GuardianRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *GuardianRepository) WithTx(tx pgx.Tx) *GuardianRepository {
+	return &GuardianRepository{DB: tx}
+}
+
+// CreateGuardian 保護者と学生を紐付ける
+func (r *GuardianRepository) CreateGuardian(guardian *models.Guardian) (int, error) {
+	ctx := context.Background()
+
+	now := time.Now()
+
+	query := `
+		INSERT INTO guardians (parent_user_id, student_user_id, relationship, created_at, updated_at, is_deleted)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING guardian_id
+	`
+
+	var guardianID int
+	err := r.DB.QueryRow(ctx, query,
+		guardian.ParentUserID,
+		guardian.StudentUserID,
+		guardian.Relationship,
+		now,
+		now,
+		false, // is_deleted
+	).Scan(&guardianID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, fmt.Errorf("guardian already linked")
+		}
+		return 0, fmt.Errorf("failed to create guardian: %w", err)
+	}
+
+	return guardianID, nil
+}
+
+// DeleteGuardian 保護者の紐付けを解除し、解除後の紐付けを返す（論理削除）
+func (r *GuardianRepository) DeleteGuardian(guardianID int) (*models.Guardian, error) {
+	ctx := context.Background()
+
+	query := `
+		UPDATE guardians SET is_deleted = true, updated_at = $1
+		WHERE guardian_id = $2 AND is_deleted = false
+		RETURNING guardian_id, parent_user_id, student_user_id, relationship, created_at, updated_at, is_deleted
+	`
+
+	var guardian models.Guardian
+	err := r.DB.QueryRow(ctx, query, time.Now(), guardianID).Scan(
+		&guardian.GuardianID,
+		&guardian.ParentUserID,
+		&guardian.StudentUserID,
+		&guardian.Relationship,
+		&guardian.CreatedAt,
+		&guardian.UpdatedAt,
+		&guardian.IsDeleted,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("guardian not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete guardian: %w", err)
+	}
+
+	return &guardian, nil
+}
+
+// IsGuardianOf 保護者が学生に紐付いているかチェックする
+func (r *GuardianRepository) IsGuardianOf(parentUserID int, studentUserID int) (bool, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM guardians
+			WHERE parent_user_id = $1 AND student_user_id = $2 AND is_deleted = false
+		)
+	`
+
+	var exists bool
+	err := r.DB.QueryRow(ctx, query, parentUserID, studentUserID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check guardian: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ListByParent 保護者に紐付く学生の一覧を取得する
+func (r *GuardianRepository) ListByParent(parentUserID int) ([]models.GuardianResponse, error) {
+	return r.list(`g.parent_user_id = $1`, parentUserID)
+}
+
+// ListByStudent 学生に紐付く保護者の一覧を取得する
+func (r *GuardianRepository) ListByStudent(studentUserID int) ([]models.GuardianResponse, error) {
+	return r.list(`g.student_user_id = $1`, studentUserID)
+}
+
+// list 条件に一致する有効な紐付けを氏名付きで取得する
+func (r *GuardianRepository) list(condition string, userID int) ([]models.GuardianResponse, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT
+			g.guardian_id,
+			g.parent_user_id,
+			p.name as parent_name,
+			g.student_user_id,
+			s.name as student_name,
+			g.relationship,
+			g.created_at
+		FROM guardians g
+		JOIN users p ON g.parent_user_id = p.user_id
+		JOIN users s ON g.student_user_id = s.user_id
+		WHERE ` + condition + `
+			AND g.is_deleted = false
+			AND p.is_deleted = false
+			AND s.is_deleted = false
+		ORDER BY g.guardian_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query guardians: %w", err)
+	}
+	defer rows.Close()
+
+	var guardians []models.GuardianResponse
+	for rows.Next() {
+		var guardian models.GuardianResponse
+		err := rows.Scan(
+			&guardian.GuardianID,
+			&guardian.ParentUserID,
+			&guardian.ParentName,
+			&guardian.StudentUserID,
+			&guardian.StudentName,
+			&guardian.Relationship,
+			&guardian.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan guardian row: %w", err)
+		}
+		guardians = append(guardians, guardian)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over guardian rows: %w", err)
+	}
+
+	return guardians, nil
+}
@@ -0,0 +1,254 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// LoginThrottleRepository ログイン試行制限リポジトリの構造体
+type LoginThrottleRepository struct {
+	DB DBTX
+}
+
+// NewLoginThrottleRepository ログイン試行制限リポジトリのコンストラクタ
+func NewLoginThrottleRepository(db *pgxpool.Pool) *LoginThrottleRepository {
+	return &LoginThrottleRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *LoginThrottleRepository) WithTx(tx pgx.Tx) *LoginThrottleRepository {
+	return &LoginThrottleRepository{DB: tx}
+}
+
+// GetThrottles 指定したキーのカウンターを取得する（存在しないキーは含まれない）
+func (r *LoginThrottleRepository) GetThrottles(keys []string) ([]models.LoginThrottle, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT throttle_key, failed_count, last_failed_at, locked_until
+		FROM login_throttles
+		WHERE throttle_key = ANY($1)
+	`
+
+	rows, err := r.DB.Query(ctx, query, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query login throttles: %w", err)
+	}
+	defer rows.Close()
+
+	var throttles []models.LoginThrottle
+	for rows.Next() {
+		var throttle models.LoginThrottle
+		err := rows.Scan(
+			&throttle.ThrottleKey,
+			&throttle.FailedCount,
+			&throttle.LastFailedAt,
+			&throttle.LockedUntil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan login throttle row: %w", err)
+		}
+		throttles = append(throttles, throttle)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over login throttle rows: %w", err)
+	}
+
+	return throttles, nil
+}
+
+// RecordFailure ログイン失敗を記録し、更新後の回数を返す
+// 最終失敗がwindowStartより前の場合はカウンターをリセットしてから数える
+func (r *LoginThrottleRepository) RecordFailure(key string, windowStart time.Time) (int, error) {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO login_throttles (throttle_key, failed_count, last_failed_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (throttle_key) DO UPDATE SET
+			failed_count = CASE
+				WHEN login_throttles.last_failed_at IS NULL OR login_throttles.last_failed_at < $3 THEN 1
+				ELSE login_throttles.failed_count + 1
+			END,
+			last_failed_at = $2
+		RETURNING failed_count
+	`
+
+	var failedCount int
+	err := r.DB.QueryRow(ctx, query, key, time.Now(), windowStart).Scan(&failedCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return failedCount, nil
+}
+
+// RecordRequest 要求回数のカウンター（request_counters）を増やし、更新後の回数を返す
+// ログイン失敗のカウンターとは別に数え、失敗回数・ロックは変更しない
+// 数え始めがwindowStartより前の場合は1から数え直す
+func (r *LoginThrottleRepository) RecordRequest(key string, windowStart time.Time) (int, error) {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO request_counters (counter_key, request_count, window_started_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (counter_key) DO UPDATE SET
+			request_count = CASE
+				WHEN request_counters.window_started_at < $3 THEN 1
+				ELSE request_counters.request_count + 1
+			END,
+			window_started_at = CASE
+				WHEN request_counters.window_started_at < $3 THEN $2
+				ELSE request_counters.window_started_at
+			END
+		RETURNING request_count
+	`
+
+	var requestCount int
+	err := r.DB.QueryRow(ctx, query, key, time.Now(), windowStart).Scan(&requestCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record request: %w", err)
+	}
+
+	return requestCount, nil
+}
+
+// Lock キーをロックし、ロックアウトを記録する（既にロック中の場合は記録しない）
+func (r *LoginThrottleRepository) Lock(key string, lockedUntil time.Time, event *models.LockoutEvent) error {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	result, err := tx.Exec(ctx, `
+		UPDATE login_throttles SET locked_until = $1
+		WHERE throttle_key = $2 AND (locked_until IS NULL OR locked_until <= $3)
+	`, lockedUntil, key, now)
+	if err != nil {
+		return fmt.Errorf("failed to lock login throttle: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO lockout_events (throttle_key, user_id, ip_address, failed_count, locked_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, key, event.UserID, event.IPAddress, event.FailedCount, lockedUntil, now)
+	if err != nil {
+		return fmt.Errorf("failed to record lockout event: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Reset キーのカウンターとロックを解除する
+func (r *LoginThrottleRepository) Reset(key string) error {
+	ctx := context.Background()
+
+	query := `DELETE FROM login_throttles WHERE throttle_key = $1`
+
+	if _, err := r.DB.Exec(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+
+	return nil
+}
+
+// Unlock 管理者によるロック解除（カウンターを削除し、未解除のロックアウト記録に解除者を記録する）
+func (r *LoginThrottleRepository) Unlock(key string, unlockedBy int) (bool, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	var lockedUntil *time.Time
+	err = tx.QueryRow(ctx, `
+		DELETE FROM login_throttles WHERE throttle_key = $1
+		RETURNING locked_until
+	`, key).Scan(&lockedUntil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete login throttle: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE lockout_events SET unlocked_at = $1, unlocked_by = $2
+		WHERE throttle_key = $3 AND unlocked_at IS NULL AND locked_until > $1
+	`, now, unlockedBy, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to update lockout events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return lockedUntil != nil && lockedUntil.After(now), nil
+}
+
+// ListLockoutEvents ロックアウト記録を新しい順に取得する
+func (r *LoginThrottleRepository) ListLockoutEvents(userID *int, limit int, offset int) ([]models.LockoutEvent, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT lockout_event_id, throttle_key, user_id, ip_address, failed_count,
+		       locked_until, created_at, unlocked_at, unlocked_by
+		FROM lockout_events
+		WHERE ($1::int IS NULL OR user_id = $1)
+		ORDER BY created_at DESC, lockout_event_id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.DB.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lockout events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.LockoutEvent
+	for rows.Next() {
+		var event models.LockoutEvent
+		err := rows.Scan(
+			&event.LockoutEventID,
+			&event.ThrottleKey,
+			&event.UserID,
+			&event.IPAddress,
+			&event.FailedCount,
+			&event.LockedUntil,
+			&event.CreatedAt,
+			&event.UnlockedAt,
+			&event.UnlockedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lockout event row: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over lockout event rows: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OIDCRepository OIDCログインリポジトリの構造体
+type OIDCRepository struct {
+	DB DBTX
+}
+
+// NewOIDCRepository OIDCログインリポジトリのコンストラクタ
+func NewOIDCRepository(db *pgxpool.Pool) *OIDCRepository {
+	return &OIDCRepository{DB: db}
+}
+
+// CreateLoginState ログイン開始時のstate・nonce・code_verifierを保存する
+func (r *OIDCRepository) CreateLoginState(stateHash string, nonce string, codeVerifier string, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	if _, err := r.DB.Exec(ctx, query, stateHash, nonce, codeVerifier, expiresAt, time.Now()); err != nil {
+		return fmt.Errorf("failed to create oidc login state: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeLoginState 有効なstateを使用済みにしてnonceとcode_verifierを返す
+// 存在しない・期限切れ・使用済みの場合はエラーを返す
+func (r *OIDCRepository) ConsumeLoginState(stateHash string) (string, string, error) {
+	ctx := context.Background()
+
+	now := time.Now()
+	query := `
+		UPDATE oidc_login_states
+		SET used_at = $2
+		WHERE state_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING nonce, code_verifier
+	`
+
+	var nonce, codeVerifier string
+	err := r.DB.QueryRow(ctx, query, stateHash, now).Scan(&nonce, &codeVerifier)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", fmt.Errorf("invalid oidc state")
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to consume oidc login state: %w", err)
+	}
+
+	return nonce, codeVerifier, nil
+}
+
+// GetUserIDByIdentity 外部IdPのアカウントに紐付くユーザーIDを取得する（紐付けがない場合は0を返す）
+func (r *OIDCRepository) GetUserIDByIdentity(issuer string, subject string) (int, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT user_id
+		FROM user_identities
+		WHERE issuer = $1 AND subject = $2
+	`
+
+	var userID int
+	err := r.DB.QueryRow(ctx, query, issuer, subject).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user identity: %w", err)
+	}
+
+	return userID, nil
+}
+
+// RecordIdentityLogin 外部IdPのアカウントをユーザーに紐付け、最終ログイン日時を記録する
+func (r *OIDCRepository) RecordIdentityLogin(userID int, issuer string, subject string) error {
+	ctx := context.Background()
+
+	now := time.Now()
+	query := `
+		INSERT INTO user_identities (user_id, issuer, subject, created_at, last_login_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (issuer, subject) DO UPDATE SET last_login_at = EXCLUDED.last_login_at
+	`
+
+	if _, err := r.DB.Exec(ctx, query, userID, issuer, subject, now); err != nil {
+		return fmt.Errorf("failed to record user identity: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,402 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// RosterRepository 名簿インポートリポジトリの構造体
+type RosterRepository struct {
+	DB DBTX
+}
+
+// NewRosterRepository 名簿インポートリポジトリのコンストラクタ
+func NewRosterRepository(db *pgxpool.Pool) *RosterRepository {
+	return &RosterRepository{DB: db}
+}
+
+// RosterTx 名簿インポートのトランザクション
+type RosterTx struct {
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+// RunImport fnを1つのトランザクション内で実行し、fnがtrueを返した場合のみコミットする
+// ドライランやエラーのある場合はfnがfalseを返してロールバックする
+// 同時に実行されたインポートは先に開始したものの完了を待つ
+func (r *RosterRepository) RunImport(fn func(tx *RosterTx) (bool, error)) (bool, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('roster_import'))`); err != nil {
+		return false, fmt.Errorf("failed to lock roster import: %w", err)
+	}
+
+	commit, err := fn(&RosterTx{ctx: ctx, tx: tx})
+	if err != nil || !commit {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit roster import: %w", err)
+	}
+
+	return true, nil
+}
+
+// rosterUserColumns 名簿インポートで参照するユーザーの列
+const rosterUserColumns = `user_id, name, email, COALESCE(phone, ''), role, is_deleted, is_service_account, sourced_id`
+
+// scanRosterUser ユーザーの行を読み込む（存在しない場合はnilを返す）
+func scanRosterUser(row pgx.Row) (*models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.UserID,
+		&user.Name,
+		&user.Email,
+		&user.Phone,
+		&user.Role,
+		&user.IsDeleted,
+		&user.IsServiceAccount,
+		&user.SourcedID,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserBySourcedID 外部IDでユーザーを取得する（無効化済みを含む、存在しない場合はnil）
+func (t *RosterTx) GetUserBySourcedID(sourcedID string) (*models.User, error) {
+	user, err := scanRosterUser(t.tx.QueryRow(t.ctx, `SELECT `+rosterUserColumns+` FROM users WHERE sourced_id = $1`, sourcedID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by sourced id: %w", err)
+	}
+	return user, nil
+}
+
+// GetActiveUserByEmail メールアドレスで有効なユーザーを取得する（存在しない場合はnil）
+func (t *RosterTx) GetActiveUserByEmail(email string) (*models.User, error) {
+	user, err := scanRosterUser(t.tx.QueryRow(t.ctx, `SELECT `+rosterUserColumns+` FROM users WHERE lower(email) = lower($1) AND is_deleted = false`, email))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	return user, nil
+}
+
+// CreateUser ユーザーを登録する（Passwordはハッシュ済みであること）
+func (t *RosterTx) CreateUser(user *models.User) (int, error) {
+	now := time.Now()
+
+	var userID int
+	err := t.tx.QueryRow(t.ctx, `
+		INSERT INTO users (name, email, password, phone, role, created_at, updated_at, is_deleted, is_service_account, sourced_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false, $9)
+		RETURNING user_id
+	`, user.Name, user.Email, user.Password, user.Phone, user.Role, now, now, user.IsDeleted, user.SourcedID).Scan(&userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return userID, nil
+}
+
+// UpdateUser ユーザーの氏名・メールアドレス・電話番号・役割・状態・外部IDを更新する
+func (t *RosterTx) UpdateUser(user *models.User) error {
+	_, err := t.tx.Exec(t.ctx, `
+		UPDATE users
+		SET name = $1, email = $2, phone = $3, role = $4, is_deleted = $5, sourced_id = $6, updated_at = $7
+		WHERE user_id = $8
+	`, user.Name, user.Email, user.Phone, user.Role, user.IsDeleted, user.SourcedID, time.Now(), user.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// GetCourseBySourcedID 外部IDで授業を取得する（削除済みを含む、存在しない場合はnil）
+func (t *RosterTx) GetCourseBySourcedID(sourcedID string) (*models.Course, error) {
+	var course models.Course
+	err := t.tx.QueryRow(t.ctx, `
+		SELECT course_id, title, description, teacher_user_id, subject_id,
+		       created_at, updated_at, scheduled_at, is_deleted, sourced_id,
+		       recurrence_rule, recurrence_exdates, duration_minutes
+		FROM courses
+		WHERE sourced_id = $1
+	`, sourcedID).Scan(
+		&course.CourseID,
+		&course.Title,
+		&course.Description,
+		&course.TeacherUserID,
+		&course.SubjectID,
+		&course.CreatedAt,
+		&course.UpdatedAt,
+		&course.ScheduledAt,
+		&course.IsDeleted,
+		&course.SourcedID,
+		&course.RecurrenceRule,
+		&course.ExDates,
+		&course.DurationMinutes,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course by sourced id: %w", err)
+	}
+	return &course, nil
+}
+
+// CreateCourse 授業を登録し、担当教師を主担当にする
+func (t *RosterTx) CreateCourse(course *models.Course) (int, error) {
+	now := time.Now()
+
+	var courseID int
+	err := t.tx.QueryRow(t.ctx, `
+		INSERT INTO courses (title, description, teacher_user_id, subject_id, created_at, updated_at, scheduled_at, is_deleted, sourced_id, duration_minutes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING course_id
+	`, course.Title, course.Description, course.TeacherUserID, course.SubjectID, now, now, course.ScheduledAt, course.IsDeleted, course.SourcedID, course.DurationMinutes).Scan(&courseID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create course: %w", err)
+	}
+
+	if err := insertCourseOwner(t.ctx, t.tx, courseID, course.TeacherUserID, now); err != nil {
+		return 0, err
+	}
+
+	return courseID, nil
+}
+
+// UpdateCourse 授業の内容・担当教師・状態を更新する
+// 担当教師が変わった場合は主担当を交代し、以前の主担当は担当から外す
+func (t *RosterTx) UpdateCourse(course *models.Course) error {
+	_, err := t.tx.Exec(t.ctx, `
+		UPDATE courses
+		SET title = $1, description = $2, teacher_user_id = $3, subject_id = $4, scheduled_at = $5, is_deleted = $6, updated_at = $7
+		WHERE course_id = $8
+	`, course.Title, course.Description, course.TeacherUserID, course.SubjectID, course.ScheduledAt, course.IsDeleted, time.Now(), course.CourseID)
+	if err != nil {
+		return fmt.Errorf("failed to update course: %w", err)
+	}
+	// APIで主担当を交代する場合と同様に、以前の主担当は副担当にする
+	return setCourseOwner(t.ctx, t.tx, course.CourseID, course.TeacherUserID, models.CourseStaffRoleCoTeacher)
+}
+
+// GetStaffRole 教師の授業での担当を返す（担当でない場合は空文字を返す）
+func (t *RosterTx) GetStaffRole(courseID int, userID int) (string, error) {
+	var role string
+	err := t.tx.QueryRow(t.ctx, `SELECT role FROM course_staff WHERE course_id = $1 AND user_id = $2`, courseID, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get course staff role: %w", err)
+	}
+	return role, nil
+}
+
+// SetStaffRole 教師を主担当以外の担当として登録・変更する（主担当の場合は変更しない）
+func (t *RosterTx) SetStaffRole(courseID int, userID int, role string) error {
+	_, err := t.tx.Exec(t.ctx, `
+		INSERT INTO course_staff (course_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (course_id, user_id) DO UPDATE SET role = EXCLUDED.role, updated_at = EXCLUDED.updated_at
+		WHERE course_staff.role <> 'owner'
+	`, courseID, userID, role, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set course staff: %w", err)
+	}
+	return nil
+}
+
+// RemoveStaff 主担当以外の担当を授業から外す
+func (t *RosterTx) RemoveStaff(courseID int, userID int) error {
+	_, err := t.tx.Exec(t.ctx, `DELETE FROM course_staff WHERE course_id = $1 AND user_id = $2 AND role <> 'owner'`, courseID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete course staff: %w", err)
+	}
+	return nil
+}
+
+// CreateAuditLog 取り込みの監査ログを同じトランザクションで追記する
+func (t *RosterTx) CreateAuditLog(auditLog *models.AuditLog) error {
+	return insertAuditLog(t.ctx, t.tx, auditLog)
+}
+
+// SyncCourseSessions 授業回を授業の繰り返しに合わせる（from以降の回のみ）
+func (t *RosterTx) SyncCourseSessions(courseID int, occurrences []time.Time, duration time.Duration, from time.Time) error {
+	return syncCourseSessions(t.ctx, t.tx, courseID, occurrences, duration, from)
+}
+
+// GetSubjectIDByName 教科名から有効な教科のIDを取得する（存在しない場合は0）
+func (t *RosterTx) GetSubjectIDByName(name string) (int, error) {
+	var subjectID int
+	err := t.tx.QueryRow(t.ctx, `SELECT subject_id FROM subjects WHERE name = $1 AND is_deleted = false ORDER BY subject_id LIMIT 1`, name).Scan(&subjectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get subject by name: %w", err)
+	}
+	return subjectID, nil
+}
+
+// GetEnrollmentBySourcedID 外部IDで受講登録を取得する（存在しない場合はnil）
+func (t *RosterTx) GetEnrollmentBySourcedID(sourcedID string) (*models.Enrollment, error) {
+	enrollment, err := scanEnrollment(t.tx.QueryRow(t.ctx, `SELECT `+enrollmentColumns+` FROM enrollments WHERE sourced_id = $1`, sourcedID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrollment by sourced id: %w", err)
+	}
+	return enrollment, nil
+}
+
+// GetEnrollmentByCourseStudent 授業と学生の組み合わせで受講登録を取得する（存在しない場合はnil）
+func (t *RosterTx) GetEnrollmentByCourseStudent(courseID int, studentUserID int) (*models.Enrollment, error) {
+	enrollment, err := scanEnrollment(t.tx.QueryRow(t.ctx, `SELECT `+enrollmentColumns+` FROM enrollments WHERE course_id = $1 AND student_user_id = $2`, courseID, studentUserID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrollment: %w", err)
+	}
+	return enrollment, nil
+}
+
+// enrollmentColumns 受講登録の列
+const enrollmentColumns = `enrollment_id, course_id, student_user_id, status, sourced_id, created_at, updated_at`
+
+// scanEnrollment 受講登録の行を読み込む（存在しない場合はnilを返す）
+func scanEnrollment(row pgx.Row) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := row.Scan(
+		&enrollment.EnrollmentID,
+		&enrollment.CourseID,
+		&enrollment.StudentUserID,
+		&enrollment.Status,
+		&enrollment.SourcedID,
+		&enrollment.CreatedAt,
+		&enrollment.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// CreateEnrollment 受講登録を作成する
+func (t *RosterTx) CreateEnrollment(enrollment *models.Enrollment) (int, error) {
+	now := time.Now()
+
+	var enrollmentID int
+	err := t.tx.QueryRow(t.ctx, `
+		INSERT INTO enrollments (course_id, student_user_id, status, sourced_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING enrollment_id
+	`, enrollment.CourseID, enrollment.StudentUserID, enrollment.Status, enrollment.SourcedID, now, now).Scan(&enrollmentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create enrollment: %w", err)
+	}
+
+	return enrollmentID, nil
+}
+
+// UpdateEnrollment 受講登録の授業・学生・状態・外部IDを更新する
+func (t *RosterTx) UpdateEnrollment(enrollment *models.Enrollment) error {
+	_, err := t.tx.Exec(t.ctx, `
+		UPDATE enrollments
+		SET course_id = $1, student_user_id = $2, status = $3, sourced_id = $4, updated_at = $5
+		WHERE enrollment_id = $6
+	`, enrollment.CourseID, enrollment.StudentUserID, enrollment.Status, enrollment.SourcedID, time.Now(), enrollment.EnrollmentID)
+	if err != nil {
+		return fmt.Errorf("failed to update enrollment: %w", err)
+	}
+	return nil
+}
+
+// GetGuardianBySourcedID 外部IDで紐付けを取得する（解除済みを含む、存在しない場合はnil）
+func (t *RosterTx) GetGuardianBySourcedID(sourcedID string) (*models.Guardian, error) {
+	guardian, err := scanRosterGuardian(t.tx.QueryRow(t.ctx, `SELECT `+rosterGuardianColumns+` FROM guardians WHERE sourced_id = $1`, sourcedID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guardian by sourced id: %w", err)
+	}
+	return guardian, nil
+}
+
+// GetActiveGuardianByPair 保護者と学生の組み合わせで有効な紐付けを取得する（存在しない場合はnil）
+func (t *RosterTx) GetActiveGuardianByPair(parentUserID int, studentUserID int) (*models.Guardian, error) {
+	guardian, err := scanRosterGuardian(t.tx.QueryRow(t.ctx, `SELECT `+rosterGuardianColumns+` FROM guardians WHERE parent_user_id = $1 AND student_user_id = $2 AND is_deleted = false`, parentUserID, studentUserID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guardian: %w", err)
+	}
+	return guardian, nil
+}
+
+// rosterGuardianColumns 名簿インポートで参照する紐付けの列
+const rosterGuardianColumns = `guardian_id, parent_user_id, student_user_id, relationship, created_at, updated_at, is_deleted, sourced_id`
+
+// scanRosterGuardian 紐付けの行を読み込む（存在しない場合はnilを返す）
+func scanRosterGuardian(row pgx.Row) (*models.Guardian, error) {
+	var guardian models.Guardian
+	err := row.Scan(
+		&guardian.GuardianID,
+		&guardian.ParentUserID,
+		&guardian.StudentUserID,
+		&guardian.Relationship,
+		&guardian.CreatedAt,
+		&guardian.UpdatedAt,
+		&guardian.IsDeleted,
+		&guardian.SourcedID,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &guardian, nil
+}
+
+// CreateGuardian 保護者と学生を紐付ける
+func (t *RosterTx) CreateGuardian(guardian *models.Guardian) (int, error) {
+	now := time.Now()
+
+	var guardianID int
+	err := t.tx.QueryRow(t.ctx, `
+		INSERT INTO guardians (parent_user_id, student_user_id, relationship, created_at, updated_at, is_deleted, sourced_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING guardian_id
+	`, guardian.ParentUserID, guardian.StudentUserID, guardian.Relationship, now, now, guardian.IsDeleted, guardian.SourcedID).Scan(&guardianID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create guardian: %w", err)
+	}
+
+	return guardianID, nil
+}
+
+// UpdateGuardian 紐付けの保護者・学生・続柄・状態・外部IDを更新する
+func (t *RosterTx) UpdateGuardian(guardian *models.Guardian) error {
+	_, err := t.tx.Exec(t.ctx, `
+		UPDATE guardians
+		SET parent_user_id = $1, student_user_id = $2, relationship = $3, is_deleted = $4, sourced_id = $5, updated_at = $6
+		WHERE guardian_id = $7
+	`, guardian.ParentUserID, guardian.StudentUserID, guardian.Relationship, guardian.IsDeleted, guardian.SourcedID, time.Now(), guardian.GuardianID)
+	if err != nil {
+		return fmt.Errorf("failed to update guardian: %w", err)
+	}
+	return nil
+}
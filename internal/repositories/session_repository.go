@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// セッション無効化の理由
+const (
+	RevokedReasonLogout        = "logout"
+	RevokedReasonTokenReuse    = "refresh_token_reuse"
+	RevokedReasonAdminRevoked  = "admin_revoked"
+	RevokedReasonPasswordReset = "password_reset"
+)
+
+// SessionRepository セッションリポジトリの構造体
+type SessionRepository struct {
+	DB DBTX
+}
+
+// NewSessionRepository セッションリポジトリのコンストラクタ
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *SessionRepository) WithTx(tx pgx.Tx) *SessionRepository {
+	return &SessionRepository{DB: tx}
+}
+
+// CreateSession セッションと最初のリフレッシュトークンを登録する
+func (r *SessionRepository) CreateSession(userID int, refreshTokenHash string, expiresAt time.Time) (int, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	var sessionID int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO auth_sessions (user_id, created_at)
+		VALUES ($1, $2)
+		RETURNING session_id
+	`, userID, now).Scan(&sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, sessionID, refreshTokenHash, expiresAt, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return sessionID, nil
+}
+
+// RotateRefreshToken リフレッシュトークンを使用済みにして新しいトークンを発行する
+// 使用済みトークンが再利用された場合はセッション（トークンファミリー）全体を無効化する
+func (r *SessionRepository) RotateRefreshToken(oldTokenHash string, newTokenHash string, expiresAt time.Time) (*models.AuthSession, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// トークンとセッションを行ロック付きで取得
+	var token models.RefreshToken
+	var session models.AuthSession
+	err = tx.QueryRow(ctx, `
+		SELECT rt.refresh_token_id, rt.expires_at, rt.used_at,
+		       s.session_id, s.user_id, s.created_at, s.revoked_at
+		FROM refresh_tokens rt
+		INNER JOIN auth_sessions s ON rt.session_id = s.session_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE
+	`, oldTokenHash).Scan(
+		&token.RefreshTokenID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&session.SessionID,
+		&session.UserID,
+		&session.CreatedAt,
+		&session.RevokedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if session.RevokedAt != nil {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	now := time.Now()
+
+	// 使用済みトークンの再利用を検知した場合はファミリー全体を無効化
+	if token.UsedAt != nil {
+		_, err = tx.Exec(ctx, `
+			UPDATE auth_sessions SET revoked_at = $1, revoked_reason = $2
+			WHERE session_id = $3
+		`, now, RevokedReasonTokenReuse, session.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil, fmt.Errorf("refresh token reuse detected")
+	}
+
+	if now.After(token.ExpiresAt) {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	// 旧トークンを使用済みにして新トークンを登録
+	_, err = tx.Exec(ctx, `
+		UPDATE refresh_tokens SET used_at = $1
+		WHERE refresh_token_id = $2
+	`, now, token.RefreshTokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark refresh token as used: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, session.SessionID, newTokenHash, expiresAt, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &session, nil
+}
+
+// IsSessionActive セッションが無効化されていないかチェック
+func (r *SessionRepository) IsSessionActive(sessionID int, userID int) (bool, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM auth_sessions
+			WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL
+		)
+	`
+
+	var active bool
+	err := r.DB.QueryRow(ctx, query, sessionID, userID).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return active, nil
+}
+
+// RevokeSession セッションを無効化する
+func (r *SessionRepository) RevokeSession(sessionID int, reason string) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE auth_sessions SET revoked_at = $1, revoked_reason = $2
+		WHERE session_id = $3 AND revoked_at IS NULL
+	`
+
+	_, err := r.DB.Exec(ctx, query, time.Now(), reason, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions ユーザーの有効なセッションをすべて無効化し、無効化した件数を返す
+func (r *SessionRepository) RevokeUserSessions(userID int, reason string) (int, error) {
+	ctx := context.Background()
+
+	query := `
+		UPDATE auth_sessions SET revoked_at = $1, revoked_reason = $2
+		WHERE user_id = $3 AND revoked_at IS NULL
+	`
+
+	result, err := r.DB.Exec(ctx, query, time.Now(), reason, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// SubjectRepository 教科リポジトリの構造体
+type SubjectRepository struct {
+	DB DBTX
+}
+
+// NewSubjectRepository 教科リポジトリのコンストラクタ
+func NewSubjectRepository(db *pgxpool.Pool) *SubjectRepository {
+	return &SubjectRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *SubjectRepository) WithTx(tx pgx.Tx) *SubjectRepository {
+	return &SubjectRepository{DB: tx}
+}
+
+// subjectColumns 教科として取得する列（参照している有効な授業数を含む）
+const subjectColumns = `
+		s.subject_id, s.name, s.is_deleted, s.created_at, s.updated_at,
+		(SELECT COUNT(*) FROM courses c WHERE c.subject_id = s.subject_id AND c.is_deleted = false)
+	FROM subjects s
+`
+
+// scanSubject 教科の行を読み込む
+func scanSubject(row pgx.Row) (*models.SubjectResponse, error) {
+	var subject models.SubjectResponse
+	err := row.Scan(
+		&subject.SubjectID,
+		&subject.Name,
+		&subject.IsDeleted,
+		&subject.CreatedAt,
+		&subject.UpdatedAt,
+		&subject.CourseCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subject, nil
+}
+
+// ListSubjects 教科の一覧を名前順で取得する
+func (r *SubjectRepository) ListSubjects(includeDeleted bool) ([]models.SubjectResponse, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + subjectColumns + `
+		WHERE ($1 OR s.is_deleted = false)
+		ORDER BY s.name, s.subject_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, includeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subjects: %w", err)
+	}
+	defer rows.Close()
+
+	var subjects []models.SubjectResponse
+	for rows.Next() {
+		subject, err := scanSubject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subject row: %w", err)
+		}
+		subjects = append(subjects, *subject)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over subject rows: %w", err)
+	}
+
+	return subjects, nil
+}
+
+// GetSubjectByID 論理削除済みを含めて教科を取得する（存在しない場合はnilを返す）
+func (r *SubjectRepository) GetSubjectByID(subjectID int) (*models.SubjectResponse, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + subjectColumns + `WHERE s.subject_id = $1`
+
+	subject, err := scanSubject(r.DB.QueryRow(ctx, query, subjectID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subject: %w", err)
+	}
+
+	return subject, nil
+}
+
+// CreateSubject 教科を登録する
+func (r *SubjectRepository) CreateSubject(name string) (int, error) {
+	ctx := context.Background()
+
+	now := time.Now()
+
+	query := `
+		INSERT INTO subjects (name, is_deleted, created_at, updated_at)
+		VALUES ($1, false, $2, $3)
+		RETURNING subject_id
+	`
+
+	var subjectID int
+	if err := r.DB.QueryRow(ctx, query, name, now, now).Scan(&subjectID); err != nil {
+		return 0, subjectWriteError("create", err)
+	}
+
+	return subjectID, nil
+}
+
+// RenameSubject 有効な教科の名前を変更する
+func (r *SubjectRepository) RenameSubject(subjectID int, name string) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE subjects SET name = $1, updated_at = $2
+		WHERE subject_id = $3 AND is_deleted = false
+	`
+
+	tag, err := r.DB.Exec(ctx, query, name, time.Now(), subjectID)
+	if err != nil {
+		return subjectWriteError("rename", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("subject not found")
+	}
+
+	return nil
+}
+
+// DeleteSubject 教科を論理削除する（有効な授業が参照している場合は削除しない）
+func (r *SubjectRepository) DeleteSubject(subjectID int) error {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// 授業の登録・更新と競合しないよう行ロックを取得
+	var exists bool
+	err = tx.QueryRow(ctx, `
+		SELECT true FROM subjects WHERE subject_id = $1 AND is_deleted = false FOR UPDATE
+	`, subjectID).Scan(&exists)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("subject not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock subject: %w", err)
+	}
+
+	var courseCount int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM courses WHERE subject_id = $1 AND is_deleted = false
+	`, subjectID).Scan(&courseCount)
+	if err != nil {
+		return fmt.Errorf("failed to count subject courses: %w", err)
+	}
+
+	if courseCount > 0 {
+		return fmt.Errorf("subject in use: referenced by %d active courses", courseCount)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE subjects SET is_deleted = true, updated_at = $1 WHERE subject_id = $2
+	`, time.Now(), subjectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete subject: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RestoreSubject 論理削除した教科を復元する
+func (r *SubjectRepository) RestoreSubject(subjectID int) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE subjects SET is_deleted = false, updated_at = $1
+		WHERE subject_id = $2 AND is_deleted = true
+	`
+
+	tag, err := r.DB.Exec(ctx, query, time.Now(), subjectID)
+	if err != nil {
+		return subjectWriteError("restore", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("subject not deleted")
+	}
+
+	return nil
+}
+
+// subjectWriteError 教科名の一意制約違反を判別できるエラーに変換する
+func subjectWriteError(operation string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("subject name already exists")
+	}
+	return fmt.Errorf("failed to %s subject: %w", operation, err)
+}
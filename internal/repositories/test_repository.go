@@ -10,7 +10,7 @@ import (
 
 // TestRepository テストリポジトリの構造体
 type TestRepository struct {
-	DB *pgxpool.Pool
+	DB DBTX
 }
 
 // NewTestRepository テストリポジトリのコンストラクタ
@@ -23,15 +23,12 @@ func NewTestRepository(db *pgxpool.Pool) *TestRepository {
 func (t *TestRepository) SelectTests(userID int, userRole string) ([]models.TestListResponse, error) {
 	ctx := context.Background()
 	
-	// デバッグ用ログ
-	fmt.Printf("SelectTests called with userID: %d, userRole: %s\n", userID, userRole)
-	
 	// ユーザーの役割に応じてクエリを変更
 	var query string
 	var args []interface{}
 	
 	if userRole == "teacher" {
-		// 教師の場合：自分が作成したテストを取得（コメントは最初の学生のものを取得）
+		// 教師の場合：担当授業（副担当・アシスタントを含む）のテストを取得（コメントは最初の学生のものを取得）
 		query = `
 			SELECT 
 				tt.teacher_test_id,
@@ -65,7 +62,7 @@ func (t *TestRepository) SelectTests(userID int, userRole string) ([]models.Test
 					AND student_user_id = $1
 				LIMIT 1
 			) st_score ON true
-			WHERE tt.created_by = $1 
+			WHERE EXISTS (SELECT 1 FROM course_staff cst WHERE cst.course_id = tt.course_id AND cst.user_id = $1) 
 				AND tt.is_deleted = false
 				AND c.is_deleted = false
 				AND s.is_deleted = false
@@ -73,10 +70,36 @@ func (t *TestRepository) SelectTests(userID int, userRole string) ([]models.Test
 			ORDER BY tt.created_at DESC
 		`
 		args = []interface{}{userID}
+	} else if userRole == "admin" {
+		// 管理者の場合：下書きを含む全てのテストを取得
+		query = `
+			SELECT 
+				tt.teacher_test_id,
+				tt.title,
+				tt.description,
+				tt.duration_minutes,
+				c.title as course_title,
+				s.name as subject_name,
+				u.name as teacher_name,
+				tt.scheduled_at,
+				tt.is_draft,
+				tt.created_at,
+				'' as comment,
+				NULL::int as score
+			FROM teacher_tests tt
+			JOIN courses c ON tt.course_id = c.course_id
+			JOIN subjects s ON c.subject_id = s.subject_id
+			JOIN users u ON c.teacher_user_id = u.user_id
+			WHERE tt.is_deleted = false
+				AND c.is_deleted = false
+				AND s.is_deleted = false
+			ORDER BY tt.scheduled_at ASC
+		`
+		args = []interface{}{}
 	} else {
-		// 学生やその他の役割の場合：学生が受講しているコースのテストのみ取得
+		// 学生やその他の役割の場合：受講登録（受講中または修了）している授業の公開済みテストのみ取得
 		query = `
-			SELECT DISTINCT
+			SELECT
 				tt.teacher_test_id,
 				tt.title,
 				tt.description,
@@ -93,23 +116,26 @@ func (t *TestRepository) SelectTests(userID int, userRole string) ([]models.Test
 			JOIN courses c ON tt.course_id = c.course_id
 			JOIN subjects s ON c.subject_id = s.subject_id
 			JOIN users u ON c.teacher_user_id = u.user_id
-			JOIN attendances a ON c.course_id = a.course_id
-			LEFT JOIN student_tests st ON tt.teacher_test_id = st.teacher_test_id AND st.student_user_id = $1
-			WHERE a.student_user_id = $1 
+			JOIN enrollments e ON c.course_id = e.course_id
+			LEFT JOIN LATERAL (
+				SELECT comment, score
+				FROM student_tests
+				WHERE teacher_test_id = tt.teacher_test_id
+					AND student_user_id = $1
+					AND is_deleted = false
+				ORDER BY submitted_at DESC
+				LIMIT 1
+			) st ON true
+			WHERE e.student_user_id = $1 
+				AND e.status IN ('active', 'completed')
 				AND tt.is_deleted = false
 				AND c.is_deleted = false
 				AND s.is_deleted = false
 				AND u.is_deleted = false
-				AND a.is_deleted = false
 				AND tt.is_draft = false
-			ORDER BY tt.scheduled_at ASC
+			ORDER BY tt.scheduled_at ASC, tt.teacher_test_id
 		`
 		args = []interface{}{userID}
-		
-		// デバッグ用：クエリ実行前のログ
-		fmt.Printf("Executing query for student/other role (userID: %d)\n", userID)
-		fmt.Printf("Query: %s\n", query)
-		fmt.Printf("Args: %v\n", args)
 	}
 
 	rows, err := t.DB.Query(ctx, query, args...)
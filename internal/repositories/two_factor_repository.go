@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// TwoFactorRepository 二要素認証リポジトリの構造体
+type TwoFactorRepository struct {
+	DB DBTX
+}
+
+// NewTwoFactorRepository 二要素認証リポジトリのコンストラクタ
+func NewTwoFactorRepository(db *pgxpool.Pool) *TwoFactorRepository {
+	return &TwoFactorRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *TwoFactorRepository) WithTx(tx pgx.Tx) *TwoFactorRepository {
+	return &TwoFactorRepository{DB: tx}
+}
+
+// GetTOTP ユーザーのTOTP設定を取得する（未登録の場合はnilを返す）
+func (r *TwoFactorRepository) GetTOTP(userID int) (*models.UserTOTP, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT user_id, secret, confirmed_at, last_used_step, created_at
+		FROM user_totp
+		WHERE user_id = $1
+	`
+
+	var totp models.UserTOTP
+	err := r.DB.QueryRow(ctx, query, userID).Scan(
+		&totp.UserID,
+		&totp.Secret,
+		&totp.ConfirmedAt,
+		&totp.LastUsedStep,
+		&totp.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user totp: %w", err)
+	}
+
+	return &totp, nil
+}
+
+// SavePendingTOTP 登録手続き中のシークレットを保存する（手続き中のものは置き換える）
+// 既に有効化済みの場合はエラーを返す
+func (r *TwoFactorRepository) SavePendingTOTP(userID int, secret string) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO user_totp (user_id, secret, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			last_used_step = 0,
+			created_at = EXCLUDED.created_at
+		WHERE user_totp.confirmed_at IS NULL
+	`
+
+	result, err := r.DB.Exec(ctx, query, userID, secret, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save pending totp: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("two-factor already enabled")
+	}
+
+	return nil
+}
+
+// ConfirmTOTP TOTPを有効化し、リカバリーコードを置き換える
+func (r *TwoFactorRepository) ConfirmTOTP(userID int, step int64, recoveryCodeHashes []string) error {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// 手続き中の設定のみ有効化する
+	result, err := tx.Exec(ctx, `
+		UPDATE user_totp
+		SET confirmed_at = $2, last_used_step = $3
+		WHERE user_id = $1 AND confirmed_at IS NULL
+	`, userID, time.Now(), step)
+	if err != nil {
+		return fmt.Errorf("failed to confirm totp: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("two-factor enrollment not found")
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ConsumeStep 使用したステップを記録する
+// 記録済みのステップ以前のコードは再利用とみなしfalseを返す
+func (r *TwoFactorRepository) ConsumeStep(userID int, step int64) (bool, error) {
+	ctx := context.Background()
+
+	query := `
+		UPDATE user_totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+	`
+
+	result, err := r.DB.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume totp step: %w", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode 未使用のリカバリーコードを使用済みにする（一致しない場合はfalseを返す）
+func (r *TwoFactorRepository) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	ctx := context.Background()
+
+	query := `
+		UPDATE totp_recovery_codes
+		SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	result, err := r.DB.Exec(ctx, query, userID, codeHash, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ReplaceRecoveryCodes リカバリーコードを再発行する（未使用のものも含めて無効化）
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID int, recoveryCodeHashes []string) error {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CountRemainingRecoveryCodes 未使用のリカバリーコード数を取得する
+func (r *TwoFactorRepository) CountRemainingRecoveryCodes(userID int) (int, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT COUNT(*)
+		FROM totp_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL
+	`
+
+	var count int
+	if err := r.DB.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteTOTP TOTP設定とリカバリーコードを削除する（削除対象がない場合はfalseを返す）
+func (r *TwoFactorRepository) DeleteTOTP(userID int) (bool, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return false, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete user totp: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// replaceRecoveryCodes トランザクション内でリカバリーコードを置き換える
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int, recoveryCodeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO totp_recovery_codes (user_id, code_hash, created_at)
+			VALUES ($1, $2, $3)
+		`, userID, codeHash, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to insert recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
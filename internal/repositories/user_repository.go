@@ -2,14 +2,19 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
 )
 
 // UserRepository ユーザーリポジトリの構造体
 type UserRepository struct {
-	DB *pgxpool.Pool
+	DB DBTX
 }
 
 // NewUserRepository UserRepositoryのコンストラクタ
@@ -19,6 +24,11 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 	}
 }
 
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *UserRepository) WithTx(tx pgx.Tx) *UserRepository {
+	return &UserRepository{DB: tx}
+}
+
 // ExistsUser ユーザーが存在するかチェック
 func (r *UserRepository) ExistsUser(userID int) (bool, error) {
 	ctx := context.Background()
@@ -56,4 +66,448 @@ func (r *UserRepository) GetUserRole(userID int) (string, error) {
 	}
 	
 	return role, nil
-} 
\ No newline at end of file
+}
+
+// GetUserByEmail メールアドレスからユーザーを取得（存在しない場合はnilを返す）
+func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
+	ctx := context.Background()
+	
+	query := `
+		SELECT user_id, name, email, password, COALESCE(phone, ''), role, is_service_account
+		FROM users
+		WHERE lower(email) = lower($1) AND is_deleted = false
+	`
+	
+	var user models.User
+	err := r.DB.QueryRow(ctx, query, email).Scan(
+		&user.UserID,
+		&user.Name,
+		&user.Email,
+		&user.Password,
+		&user.Phone,
+		&user.Role,
+		&user.IsServiceAccount,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	
+	return &user, nil
+}
+
+// EmailExists メールアドレスが有効なユーザーで使用済みかチェック
+func (r *UserRepository) EmailExists(email string) (bool, error) {
+	ctx := context.Background()
+	
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE lower(email) = lower($1) AND is_deleted = false)`
+	
+	var exists bool
+	err := r.DB.QueryRow(ctx, query, email).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email existence: %w", err)
+	}
+	
+	return exists, nil
+}
+
+// CreateUser ユーザーをデータベースに登録する（Passwordはハッシュ済みであること）
+func (r *UserRepository) CreateUser(user *models.User) (int, error) {
+	ctx := context.Background()
+	
+	// 現在時刻を取得
+	now := time.Now()
+	
+	query := `
+		INSERT INTO users (name, email, password, phone, role, created_at, updated_at, is_deleted, is_service_account)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING user_id
+	`
+	
+	var userID int
+	err := r.DB.QueryRow(ctx, query,
+		user.Name,
+		user.Email,
+		user.Password,
+		user.Phone,
+		user.Role,
+		now,
+		now,
+		false, // is_deleted
+		user.IsServiceAccount,
+	).Scan(&userID)
+	
+	if err != nil {
+		// 一意制約違反（同時登録による重複）
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, fmt.Errorf("email already registered")
+		}
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+	
+	return userID, nil
+}
+
+// GetUserByID ユーザーIDからユーザーを取得（存在しない場合はnilを返す）
+func (r *UserRepository) GetUserByID(userID int) (*models.User, error) {
+	ctx := context.Background()
+	
+	query := `
+		SELECT user_id, name, email, password, COALESCE(phone, ''), role, is_service_account
+		FROM users
+		WHERE user_id = $1 AND is_deleted = false
+	`
+	
+	var user models.User
+	err := r.DB.QueryRow(ctx, query, userID).Scan(
+		&user.UserID,
+		&user.Name,
+		&user.Email,
+		&user.Password,
+		&user.Phone,
+		&user.Role,
+		&user.IsServiceAccount,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+	}
+	
+	return &user, nil
+}
+
+// UpdateUserProfile ユーザーの氏名と電話番号を更新する
+func (r *UserRepository) UpdateUserProfile(userID int, name string, phone string) error {
+	ctx := context.Background()
+	
+	query := `
+		UPDATE users
+		SET name = $1, phone = $2, updated_at = $3
+		WHERE user_id = $4 AND is_deleted = false
+	`
+	
+	result, err := r.DB.Exec(ctx, query, name, phone, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user profile: %w", err)
+	}
+	
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	
+	return nil
+}
+
+// CreateEmailChangeRequest メールアドレス変更の確認トークンを登録する（未使用の既存トークンは無効化）
+func (r *UserRepository) CreateEmailChangeRequest(userID int, newEmail string, tokenHash string, expiresAt time.Time) error {
+	ctx := context.Background()
+	
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	
+	now := time.Now()
+	
+	// 未使用の変更リクエストを無効化
+	_, err = tx.Exec(ctx, `
+		UPDATE email_change_requests
+		SET used_at = $1
+		WHERE user_id = $2 AND used_at IS NULL
+	`, now, userID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate email change requests: %w", err)
+	}
+	
+	_, err = tx.Exec(ctx, `
+		INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, userID, newEmail, tokenHash, expiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create email change request: %w", err)
+	}
+	
+	return tx.Commit(ctx)
+}
+
+// GetPendingEmail 確認待ちの新しいメールアドレスを取得（存在しない場合は空文字）
+func (r *UserRepository) GetPendingEmail(userID int) (string, error) {
+	ctx := context.Background()
+	
+	query := `
+		SELECT new_email
+		FROM email_change_requests
+		WHERE user_id = $1 AND used_at IS NULL AND expires_at > now()
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	
+	var email string
+	err := r.DB.QueryRow(ctx, query, userID).Scan(&email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get pending email: %w", err)
+	}
+	
+	return email, nil
+}
+
+// ApplyEmailChange 確認トークンを消費してメールアドレスを変更し、ユーザーIDと変更前後のメールアドレスを返す
+// 無効なトークンの場合はユーザーIDに0を返す
+func (r *UserRepository) ApplyEmailChange(tokenHash string) (int, string, string, error) {
+	ctx := context.Background()
+	
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	
+	// 有効なトークンを行ロック付きで取得
+	var requestID, userID int
+	var newEmail string
+	err = tx.QueryRow(ctx, `
+		SELECT email_change_request_id, user_id, new_email
+		FROM email_change_requests
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		FOR UPDATE
+	`, tokenHash).Scan(&requestID, &userID, &newEmail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", "", nil
+	}
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to get email change request: %w", err)
+	}
+	
+	// 監査ログ用に変更前のメールアドレスを取得
+	var oldEmail string
+	err = tx.QueryRow(ctx, `SELECT email FROM users WHERE user_id = $1 FOR UPDATE`, userID).Scan(&oldEmail)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to get current email: %w", err)
+	}
+	
+	now := time.Now()
+	
+	_, err = tx.Exec(ctx, `
+		UPDATE users SET email = $1, updated_at = $2
+		WHERE user_id = $3 AND is_deleted = false
+	`, newEmail, now, userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, "", "", fmt.Errorf("email already registered")
+		}
+		return 0, "", "", fmt.Errorf("failed to update email: %w", err)
+	}
+	
+	_, err = tx.Exec(ctx, `
+		UPDATE email_change_requests SET used_at = $1
+		WHERE email_change_request_id = $2
+	`, now, requestID)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to mark email change request as used: %w", err)
+	}
+	
+	if err := tx.Commit(ctx); err != nil {
+		return 0, "", "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
+	return userID, oldEmail, newEmail, nil
+}
+
+// CreatePasswordResetToken パスワード再設定トークンを登録する（未使用の既存トークンは無効化）
+func (r *UserRepository) CreatePasswordResetToken(userID int, tokenHash string, expiresAt time.Time) error {
+	ctx := context.Background()
+	
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	
+	now := time.Now()
+	
+	// 未使用のトークンを無効化
+	_, err = tx.Exec(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = $1
+		WHERE user_id = $2 AND used_at IS NULL
+	`, now, userID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+	
+	_, err = tx.Exec(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, userID, tokenHash, expiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	
+	return tx.Commit(ctx)
+}
+
+// ResetPassword 再設定トークンを消費してパスワードを更新する（無効なトークンの場合は0を返す）
+func (r *UserRepository) ResetPassword(tokenHash string, passwordHash string) (int, error) {
+	ctx := context.Background()
+	
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	
+	// 有効なトークンを行ロック付きで取得
+	var tokenID, userID int
+	err = tx.QueryRow(ctx, `
+		SELECT password_reset_token_id, user_id
+		FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		FOR UPDATE
+	`, tokenHash).Scan(&tokenID, &userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+	
+	now := time.Now()
+	
+	result, err := tx.Exec(ctx, `
+		UPDATE users SET password = $1, updated_at = $2
+		WHERE user_id = $3 AND is_deleted = false
+	`, passwordHash, now, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update password: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return 0, nil
+	}
+	
+	_, err = tx.Exec(ctx, `
+		UPDATE password_reset_tokens SET used_at = $1
+		WHERE password_reset_token_id = $2
+	`, now, tokenID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark password reset token as used: %w", err)
+	}
+	
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
+	return userID, nil
+}
+
+// ListUsers 条件に一致するユーザーの一覧と総件数を取得する
+func (r *UserRepository) ListUsers(keyword string, role string, includeDeleted bool, limit int, offset int) ([]models.User, int, error) {
+	ctx := context.Background()
+	
+	// 検索条件を組み立て（空の条件は無視）
+	where := `
+		WHERE ($1 = '' OR name ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%')
+			AND ($2 = '' OR role = $2)
+			AND ($3 OR is_deleted = false)
+	`
+	
+	var total int
+	err := r.DB.QueryRow(ctx, `SELECT COUNT(*) FROM users`+where, keyword, role, includeDeleted).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	
+	query := `
+		SELECT user_id, name, email, COALESCE(phone, ''), role, is_deleted, is_service_account
+		FROM users
+	` + where + `
+		ORDER BY user_id
+		LIMIT $4 OFFSET $5
+	`
+	
+	rows, err := r.DB.Query(ctx, query, keyword, role, includeDeleted, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+	
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(
+			&user.UserID,
+			&user.Name,
+			&user.Email,
+			&user.Phone,
+			&user.Role,
+			&user.IsDeleted,
+			&user.IsServiceAccount,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, user)
+	}
+	
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over user rows: %w", err)
+	}
+	
+	return users, total, nil
+}
+
+// SetUserDeleted ユーザーを無効化（論理削除）または再有効化する
+func (r *UserRepository) SetUserDeleted(userID int, deleted bool) error {
+	ctx := context.Background()
+	
+	query := `
+		UPDATE users SET is_deleted = $1, updated_at = $2
+		WHERE user_id = $3
+	`
+	
+	result, err := r.DB.Exec(ctx, query, deleted, time.Now(), userID)
+	if err != nil {
+		// 再有効化時に同じメールアドレスの有効なユーザーが存在する
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("email already registered")
+		}
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+	
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	
+	return nil
+}
+
+// UpdateUserRole ユーザーの役割を変更する
+func (r *UserRepository) UpdateUserRole(userID int, role string) error {
+	ctx := context.Background()
+	
+	query := `
+		UPDATE users SET role = $1, updated_at = $2
+		WHERE user_id = $3 AND is_deleted = false
+	`
+	
+	result, err := r.DB.Exec(ctx, query, role, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+	
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	
+	return nil
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// watchIntervalGapSeconds ハートビートの送信間隔のずれとして、この秒数以内で隣り合う視聴区間は統合する
+const watchIntervalGapSeconds = 1
+
+// VideoProgressRepository 動画視聴状況リポジトリの構造体
+type VideoProgressRepository struct {
+	DB DBTX
+}
+
+// NewVideoProgressRepository 動画視聴状況リポジトリのコンストラクタ
+func NewVideoProgressRepository(db *pgxpool.Pool) *VideoProgressRepository {
+	return &VideoProgressRepository{DB: db}
+}
+
+// videoProgressColumns 動画視聴状況として取得する列
+const videoProgressColumns = `
+		p.video_id, p.student_user_id, p.intervals, p.watched_seconds, p.last_position,
+		p.last_watched_at, p.created_at, p.updated_at
+	FROM video_watch_progress p
+`
+
+// scanVideoProgress 動画視聴状況の行を読み込む
+func scanVideoProgress(row pgx.Row) (*models.VideoWatchProgress, error) {
+	var progress models.VideoWatchProgress
+	err := row.Scan(
+		&progress.VideoID,
+		&progress.StudentUserID,
+		&progress.Intervals,
+		&progress.WatchedSeconds,
+		&progress.LastPosition,
+		&progress.LastWatchedAt,
+		&progress.CreatedAt,
+		&progress.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &progress, nil
+}
+
+// GetProgress 学生の動画視聴状況を取得する（未視聴の場合はnilを返す）
+func (r *VideoProgressRepository) GetProgress(videoID int, studentUserID int) (*models.VideoWatchProgress, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + videoProgressColumns + `WHERE p.video_id = $1 AND p.student_user_id = $2`
+
+	progress, err := scanVideoProgress(r.DB.QueryRow(ctx, query, videoID, studentUserID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video watch progress: %w", err)
+	}
+
+	return progress, nil
+}
+
+// RecordProgress 視聴した区間を既存の区間と統合し、再生位置とあわせて記録する
+// 同じ学生のハートビートが同時に届いても区間が失われないよう、行をロックしてから統合する
+// maxSecondsは前回の記録からの経過時間（初回はnil）から、今回記録できる区間の長さを返す。超える分は切り詰める
+func (r *VideoProgressRepository) RecordProgress(videoID int, studentUserID int, interval *models.WatchInterval, position float64, maxSeconds func(sinceLastWatched *time.Duration) float64) (*models.VideoWatchProgress, error) {
+	ctx := context.Background()
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO video_watch_progress (video_id, student_user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (video_id, student_user_id) DO NOTHING
+	`, videoID, studentUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create video watch progress: %w", err)
+	}
+
+	// 経過時間は記録時と同じ形式の日時でDB上で計算する
+	now := time.Now()
+	var intervals []models.WatchInterval
+	var elapsedSeconds float64
+	err = tx.QueryRow(ctx, `
+		SELECT intervals, EXTRACT(EPOCH FROM ($3::timestamp - last_watched_at))::float8
+		FROM video_watch_progress
+		WHERE video_id = $1 AND student_user_id = $2
+		FOR UPDATE
+	`, videoID, studentUserID, now).Scan(&intervals, &elapsedSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock video watch progress: %w", err)
+	}
+
+	// 今回作成した行の場合は前回の記録がない
+	var sinceLastWatched *time.Duration
+	if tag.RowsAffected() == 0 {
+		elapsed := time.Duration(elapsedSeconds * float64(time.Second))
+		sinceLastWatched = &elapsed
+	}
+
+	if interval != nil {
+		if clamped := models.ClampWatchInterval(*interval, maxSeconds(sinceLastWatched)); clamped != nil {
+			intervals = append(intervals, *clamped)
+		}
+	}
+	intervals = models.MergeWatchIntervals(intervals, watchIntervalGapSeconds)
+
+	encoded, err := json.Marshal(intervals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode watch intervals: %w", err)
+	}
+
+	query := `
+		UPDATE video_watch_progress p
+		SET intervals = $1, watched_seconds = $2, last_position = $3, last_watched_at = $4, updated_at = $4
+		WHERE p.video_id = $5 AND p.student_user_id = $6
+		RETURNING p.video_id, p.student_user_id, p.intervals, p.watched_seconds, p.last_position,
+			p.last_watched_at, p.created_at, p.updated_at
+	`
+
+	progress, err := scanVideoProgress(tx.QueryRow(ctx, query,
+		string(encoded),
+		models.TotalWatchedSeconds(intervals),
+		position,
+		now,
+		videoID,
+		studentUserID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update video watch progress: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return progress, nil
+}
+
+// ListByCourse 授業の（削除されていない）動画の視聴状況をすべて取得する
+func (r *VideoProgressRepository) ListByCourse(courseID int) ([]models.VideoWatchProgress, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + videoProgressColumns + `
+		JOIN course_videos v ON p.video_id = v.video_id
+		WHERE v.course_id = $1 AND v.is_deleted = false
+		ORDER BY p.student_user_id, p.video_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query video watch progress: %w", err)
+	}
+	defer rows.Close()
+
+	var progresses []models.VideoWatchProgress
+	for rows.Next() {
+		progress, err := scanVideoProgress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan video watch progress row: %w", err)
+		}
+		progresses = append(progresses, *progress)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over video watch progress rows: %w", err)
+	}
+
+	return progresses, nil
+}
@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// VideoUploadRepository 再開可能な動画アップロードリポジトリの構造体
+type VideoUploadRepository struct {
+	DB DBTX
+}
+
+// NewVideoUploadRepository 再開可能な動画アップロードリポジトリのコンストラクタ
+func NewVideoUploadRepository(db *pgxpool.Pool) *VideoUploadRepository {
+	return &VideoUploadRepository{DB: db}
+}
+
+// WithTx トランザクション内で操作するリポジトリを返す
+func (r *VideoUploadRepository) WithTx(tx pgx.Tx) *VideoUploadRepository {
+	return &VideoUploadRepository{DB: tx}
+}
+
+// CreateUpload アップロードを登録する
+func (r *VideoUploadRepository) CreateUpload(upload *models.VideoUpload) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO video_uploads (upload_id, course_id, user_id, filename, content_type, upload_length, upload_offset, metadata, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $9, $9)
+	`
+
+	_, err := r.DB.Exec(ctx, query,
+		upload.UploadID,
+		upload.CourseID,
+		upload.UserID,
+		upload.Filename,
+		upload.ContentType,
+		upload.UploadLength,
+		upload.Metadata,
+		upload.ExpiresAt,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create video upload: %w", err)
+	}
+
+	return nil
+}
+
+// GetUpload アップロードを取得する（存在しない場合はnilを返す）
+func (r *VideoUploadRepository) GetUpload(uploadID string) (*models.VideoUpload, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT upload_id, course_id, user_id, filename, content_type, upload_length, upload_offset,
+			metadata, video_id, expires_at, completed_at, created_at, updated_at
+		FROM video_uploads
+		WHERE upload_id = $1
+	`
+
+	var upload models.VideoUpload
+	err := r.DB.QueryRow(ctx, query, uploadID).Scan(
+		&upload.UploadID,
+		&upload.CourseID,
+		&upload.UserID,
+		&upload.Filename,
+		&upload.ContentType,
+		&upload.UploadLength,
+		&upload.UploadOffset,
+		&upload.Metadata,
+		&upload.VideoID,
+		&upload.ExpiresAt,
+		&upload.CompletedAt,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video upload: %w", err)
+	}
+
+	return &upload, nil
+}
+
+// AcquireLease 書き込み中のリースの取得を試みる（他のリクエストのリースが有効な場合はfalse）
+// 取得・延長・解放は短いUPDATEだけで行い、書き込みの間も接続を保持しない
+func (r *VideoUploadRepository) AcquireLease(uploadID string, token string, until time.Time) (bool, error) {
+	ctx := context.Background()
+
+	query := `
+		UPDATE video_uploads SET lock_token = $1, locked_until = $2
+		WHERE upload_id = $3 AND (locked_until IS NULL OR locked_until < $4)
+	`
+
+	result, err := r.DB.Exec(ctx, query, token, until, uploadID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire video upload lease: %w", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// RenewLease 保持しているリースの期限を延長する（リースを失っていた場合はfalse）
+func (r *VideoUploadRepository) RenewLease(uploadID string, token string, until time.Time) (bool, error) {
+	ctx := context.Background()
+
+	result, err := r.DB.Exec(ctx, `
+		UPDATE video_uploads SET locked_until = $1
+		WHERE upload_id = $2 AND lock_token = $3
+	`, until, uploadID, token)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew video upload lease: %w", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// ReleaseLease 保持しているリースを解放する
+func (r *VideoUploadRepository) ReleaseLease(uploadID string, token string) error {
+	ctx := context.Background()
+
+	_, err := r.DB.Exec(ctx, `
+		UPDATE video_uploads SET lock_token = NULL, locked_until = NULL
+		WHERE upload_id = $1 AND lock_token = $2
+	`, uploadID, token)
+	if err != nil {
+		return fmt.Errorf("failed to release video upload lease: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateOffset 受信済みのバイト数をfromからoffsetに更新し、有効期限を延ばす
+// 記録済みのバイト数がfromでない場合やリースを失っていた場合（他のリクエストが書き込んだ可能性がある）は更新しない
+func (r *VideoUploadRepository) UpdateOffset(uploadID string, token string, from int64, offset int64, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE video_uploads SET upload_offset = $1, expires_at = $2, updated_at = $3
+		WHERE upload_id = $4 AND upload_offset = $5 AND lock_token = $6
+	`
+
+	result, err := r.DB.Exec(ctx, query, offset, expiresAt, time.Now(), uploadID, from, token)
+	if err != nil {
+		return fmt.Errorf("failed to update video upload offset: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("upload offset mismatch: offset was changed by another request")
+	}
+
+	return nil
+}
+
+// CompleteUpload 登録した動画を記録してアップロードを完了にする
+func (r *VideoUploadRepository) CompleteUpload(uploadID string, videoID int) error {
+	ctx := context.Background()
+
+	now := time.Now()
+	query := `
+		UPDATE video_uploads SET video_id = $1, completed_at = $2, updated_at = $2
+		WHERE upload_id = $3
+	`
+
+	_, err := r.DB.Exec(ctx, query, videoID, now, uploadID)
+	if err != nil {
+		return fmt.Errorf("failed to complete video upload: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUpload アップロードを削除する
+func (r *VideoUploadRepository) DeleteUpload(uploadID string) error {
+	ctx := context.Background()
+
+	_, err := r.DB.Exec(ctx, `DELETE FROM video_uploads WHERE upload_id = $1`, uploadID)
+	if err != nil {
+		return fmt.Errorf("failed to delete video upload: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired 有効期限切れの未完了のアップロードを削除し、削除したアップロードIDを返す
+// 書き込み中（リースが有効）のアップロードは削除しない
+func (r *VideoUploadRepository) DeleteExpired(now time.Time) ([]string, error) {
+	ctx := context.Background()
+
+	query := `
+		DELETE FROM video_uploads
+		WHERE expires_at < $1 AND completed_at IS NULL AND (locked_until IS NULL OR locked_until < $1)
+		RETURNING upload_id
+	`
+
+	rows, err := r.DB.Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired video uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var uploadIDs []string
+	for rows.Next() {
+		var uploadID string
+		if err := rows.Scan(&uploadID); err != nil {
+			return nil, fmt.Errorf("failed to scan video upload row: %w", err)
+		}
+		uploadIDs = append(uploadIDs, uploadID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over video upload rows: %w", err)
+	}
+
+	return uploadIDs, nil
+}
@@ -0,0 +1,87 @@
+package roster
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// utf8BOM Excelで保存したCSVの先頭に付くBOM
+const utf8BOM = "\ufeff"
+
+// record CSVの1行（ヘッダー名で値を参照する）
+type record struct {
+	columns map[string]int
+	values  []string
+}
+
+// get 列の値を前後の空白を除いて返す（列がない場合は空）
+func (r record) get(column string) string {
+	index, ok := r.columns[strings.ToLower(column)]
+	if !ok || index >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[index])
+}
+
+// readTable CSVを読み込み、データ行ごとにfnを呼び出す
+// 必須列がない場合はファイル全体のエラー（Lineが0）、行単位の形式エラーはその行のエラーとして返す
+func readTable(file string, r io.Reader, required []string, fn func(line int, row record) error) []models.RosterImportError {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []models.RosterImportError{{File: file, Message: "file is empty"}}
+	}
+	if err != nil {
+		return []models.RosterImportError{{File: file, Message: fmt.Sprintf("invalid csv header: %v", err)}}
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, utf8BOM)
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var missing []string
+	for _, column := range required {
+		if _, ok := columns[strings.ToLower(column)]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return []models.RosterImportError{{File: file, Message: "missing required columns: " + strings.Join(missing, ", ")}}
+	}
+
+	var rowErrors []models.RosterImportError
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// 形式エラーの行は読み飛ばして続行する
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, models.RosterImportError{File: file, Line: parseErr.Line, Message: parseErr.Err.Error()})
+				continue
+			}
+			return append(rowErrors, models.RosterImportError{File: file, Message: fmt.Sprintf("failed to read csv: %v", err)})
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := record{columns: columns, values: values}
+		if err := fn(line, row); err != nil {
+			rowErrors = append(rowErrors, models.RosterImportError{File: file, Line: line, SourcedID: row.get("sourcedId"), Message: err.Error()})
+		}
+	}
+
+	return rowErrors
+}
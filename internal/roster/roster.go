@@ -0,0 +1,329 @@
+// Package roster はOneRoster 1.1形式に準じた名簿CSV（users, classes, enrollments, guardians）を読み込む
+//
+// 各ファイルの列（大文字・小文字は区別しない）
+//
+//	users.csv:       sourcedId, status, enabledUser, role, givenName, familyName, email, phone
+//	classes.csv:     sourcedId, status, title, description, subjects, scheduledAt
+//	enrollments.csv: sourcedId, status, classSourcedId, userSourcedId, role, primary
+//	guardians.csv:   sourcedId, status, parentSourcedId, studentSourcedId, relationship
+//
+// classes.csvのdescription・scheduledAtとguardians.csvは本システム独自の拡張
+package roster
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/recurrence"
+)
+
+// 行の状態（OneRosterのstatus、空の場合はactive）
+const (
+	StatusActive      = "active"
+	StatusToBeDeleted = "tobedeleted"
+)
+
+// 受講登録の役割
+const (
+	EnrollmentRoleStudent = "student"
+	EnrollmentRoleTeacher = "teacher"
+)
+
+// userRoles OneRosterの役割と本システムの役割の対応
+var userRoles = map[string]string{
+	"student":       models.RoleStudent,
+	"teacher":       models.RoleTeacher,
+	"parent":        models.RoleParent,
+	"guardian":      models.RoleParent,
+	"relative":      models.RoleParent,
+	"administrator": models.RoleAdmin,
+}
+
+// scheduledAtLayouts classes.csvのscheduledAtとして受け付ける形式（タイムゾーンの有無によらず記載した壁時計の時刻）
+var scheduledAtLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04"}
+
+// Files 読み込むCSV（nilのファイルは読み込まない）
+type Files struct {
+	Users       io.Reader
+	Classes     io.Reader
+	Enrollments io.Reader
+	Guardians   io.Reader
+}
+
+// Roster 読み込んだ名簿（エラーのあった行は含まない）
+type Roster struct {
+	Users       []User
+	Classes     []Class
+	Enrollments []Enrollment
+	Guardians   []Guardian
+}
+
+// User users.csvの行
+type User struct {
+	Line      int
+	SourcedID string
+	Deleted   bool   // status=tobedeleted または enabledUser=false
+	Role      string // 本システムの役割
+	Name      string // 姓 名
+	Email     string
+	Phone     string // 空の場合は既存の値を変更しない
+}
+
+// Class classes.csvの行（本システムの授業）
+type Class struct {
+	Line        int
+	SourcedID   string
+	Deleted     bool
+	Title       string
+	Description string     // 空の場合は既存の値を変更しない
+	Subject     string     // 教科名
+	ScheduledAt *time.Time // 未指定の場合は既存の値を変更しない（新規作成時は必須）
+}
+
+// Enrollment enrollments.csvの行
+type Enrollment struct {
+	Line           int
+	SourcedID      string
+	Deleted        bool
+	ClassSourcedID string
+	UserSourcedID  string
+	Role           string // EnrollmentRoleStudent または EnrollmentRoleTeacher
+	Primary        bool   // 主担当の教師
+}
+
+// Guardian guardians.csvの行
+type Guardian struct {
+	Line             int
+	SourcedID        string
+	Deleted          bool
+	ParentSourcedID  string
+	StudentSourcedID string
+	Relationship     string
+}
+
+// Parse 名簿CSVを読み込む
+// 行単位のエラーはその行を除外してエラー一覧に追加し、残りの行の読み込みを続ける
+func Parse(files Files) (*Roster, []models.RosterImportError) {
+	roster := &Roster{}
+	var rowErrors []models.RosterImportError
+
+	if files.Users != nil {
+		rowErrors = append(rowErrors, parseUsers(files.Users, roster)...)
+	}
+	if files.Classes != nil {
+		rowErrors = append(rowErrors, parseClasses(files.Classes, roster)...)
+	}
+	if files.Enrollments != nil {
+		rowErrors = append(rowErrors, parseEnrollments(files.Enrollments, roster)...)
+	}
+	if files.Guardians != nil {
+		rowErrors = append(rowErrors, parseGuardians(files.Guardians, roster)...)
+	}
+
+	return roster, rowErrors
+}
+
+// parseUsers users.csvを読み込む
+func parseUsers(r io.Reader, roster *Roster) []models.RosterImportError {
+	seen := make(map[string]bool)
+	return readTable(models.RosterFileUsers, r, []string{"sourcedId", "role", "givenName", "familyName", "email"}, func(line int, row record) error {
+		sourcedID, deleted, err := parseCommon(row, seen)
+		if err != nil {
+			return err
+		}
+
+		if enabled := row.get("enabledUser"); enabled != "" {
+			value, err := parseBool(enabled)
+			if err != nil {
+				return fmt.Errorf("enabledUser must be true or false")
+			}
+			deleted = deleted || !value
+		}
+
+		role, ok := userRoles[strings.ToLower(row.get("role"))]
+		if !ok {
+			return fmt.Errorf("unsupported role: %q", row.get("role"))
+		}
+
+		name := strings.TrimSpace(row.get("familyName") + " " + row.get("givenName"))
+		if name == "" {
+			return fmt.Errorf("givenName or familyName is required")
+		}
+
+		email := row.get("email")
+		if email == "" {
+			return fmt.Errorf("email is required")
+		}
+
+		roster.Users = append(roster.Users, User{
+			Line:      line,
+			SourcedID: sourcedID,
+			Deleted:   deleted,
+			Role:      role,
+			Name:      name,
+			Email:     email,
+			Phone:     row.get("phone"),
+		})
+		return nil
+	})
+}
+
+// parseClasses classes.csvを読み込む
+func parseClasses(r io.Reader, roster *Roster) []models.RosterImportError {
+	seen := make(map[string]bool)
+	return readTable(models.RosterFileClasses, r, []string{"sourcedId", "title", "subjects"}, func(line int, row record) error {
+		sourcedID, deleted, err := parseCommon(row, seen)
+		if err != nil {
+			return err
+		}
+
+		title := row.get("title")
+		if title == "" {
+			return fmt.Errorf("title is required")
+		}
+
+		// OneRosterでは複数の教科を列挙できるが、授業には教科を1つだけ設定する
+		subjects := strings.Split(row.get("subjects"), ",")
+		subject := strings.TrimSpace(subjects[0])
+		if subject == "" {
+			return fmt.Errorf("subjects is required")
+		}
+		if len(subjects) > 1 {
+			return fmt.Errorf("subjects must contain exactly one subject")
+		}
+
+		class := Class{
+			Line:        line,
+			SourcedID:   sourcedID,
+			Deleted:     deleted,
+			Title:       title,
+			Description: row.get("description"),
+			Subject:     subject,
+		}
+
+		if value := row.get("scheduledAt"); value != "" {
+			scheduledAt, err := parseScheduledAt(value)
+			if err != nil {
+				return err
+			}
+			class.ScheduledAt = &scheduledAt
+		}
+
+		roster.Classes = append(roster.Classes, class)
+		return nil
+	})
+}
+
+// parseEnrollments enrollments.csvを読み込む
+func parseEnrollments(r io.Reader, roster *Roster) []models.RosterImportError {
+	seen := make(map[string]bool)
+	return readTable(models.RosterFileEnrollments, r, []string{"sourcedId", "classSourcedId", "userSourcedId", "role"}, func(line int, row record) error {
+		sourcedID, deleted, err := parseCommon(row, seen)
+		if err != nil {
+			return err
+		}
+
+		enrollment := Enrollment{
+			Line:           line,
+			SourcedID:      sourcedID,
+			Deleted:        deleted,
+			ClassSourcedID: row.get("classSourcedId"),
+			UserSourcedID:  row.get("userSourcedId"),
+			Role:           strings.ToLower(row.get("role")),
+		}
+
+		if enrollment.ClassSourcedID == "" || enrollment.UserSourcedID == "" {
+			return fmt.Errorf("classSourcedId and userSourcedId are required")
+		}
+
+		if enrollment.Role != EnrollmentRoleStudent && enrollment.Role != EnrollmentRoleTeacher {
+			return fmt.Errorf("unsupported role: %q", row.get("role"))
+		}
+
+		if primary := row.get("primary"); primary != "" {
+			enrollment.Primary, err = parseBool(primary)
+			if err != nil {
+				return fmt.Errorf("primary must be true or false")
+			}
+		}
+
+		roster.Enrollments = append(roster.Enrollments, enrollment)
+		return nil
+	})
+}
+
+// parseGuardians guardians.csvを読み込む
+func parseGuardians(r io.Reader, roster *Roster) []models.RosterImportError {
+	seen := make(map[string]bool)
+	return readTable(models.RosterFileGuardians, r, []string{"sourcedId", "parentSourcedId", "studentSourcedId"}, func(line int, row record) error {
+		sourcedID, deleted, err := parseCommon(row, seen)
+		if err != nil {
+			return err
+		}
+
+		guardian := Guardian{
+			Line:             line,
+			SourcedID:        sourcedID,
+			Deleted:          deleted,
+			ParentSourcedID:  row.get("parentSourcedId"),
+			StudentSourcedID: row.get("studentSourcedId"),
+			Relationship:     row.get("relationship"),
+		}
+
+		if guardian.ParentSourcedID == "" || guardian.StudentSourcedID == "" {
+			return fmt.Errorf("parentSourcedId and studentSourcedId are required")
+		}
+
+		roster.Guardians = append(roster.Guardians, guardian)
+		return nil
+	})
+}
+
+// parseCommon 全ファイル共通のsourcedIdとstatusを読み込む（ファイル内でのsourcedIdの重複はエラー）
+func parseCommon(row record, seen map[string]bool) (string, bool, error) {
+	sourcedID := row.get("sourcedId")
+	if sourcedID == "" {
+		return "", false, fmt.Errorf("sourcedId is required")
+	}
+	if len(sourcedID) > 255 {
+		return "", false, fmt.Errorf("sourcedId must be at most 255 characters")
+	}
+	if seen[sourcedID] {
+		return "", false, fmt.Errorf("duplicate sourcedId")
+	}
+	seen[sourcedID] = true
+
+	switch strings.ToLower(row.get("status")) {
+	case "", StatusActive:
+		return sourcedID, false, nil
+	case StatusToBeDeleted:
+		return sourcedID, true, nil
+	default:
+		return "", false, fmt.Errorf("status must be active or tobedeleted")
+	}
+}
+
+// parseBool true/falseを読み込む
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean: %q", value)
+	}
+}
+
+// parseScheduledAt 授業の日時を読み込み、壁時計の時刻（UTCとして保持）で返す
+func parseScheduledAt(value string) (time.Time, error) {
+	for _, layout := range scheduledAtLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return recurrence.WallClock(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("scheduledAt must be RFC3339 or YYYY-MM-DD HH:MM")
+}
@@ -0,0 +1,290 @@
+package roster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+func TestReadTableHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		message string // ファイル全体のエラー（空の場合はエラーなし）
+	}{
+		{"empty file", "", "file is empty"},
+		{"missing columns", "sourcedId,role,email\n", "missing required columns: givenName, familyName"},
+		{"case-insensitive header", "SOURCEDID, Role ,GivenName,familyname,EMAIL\nu1,student,Taro,Yamada,taro@example.com\n", ""},
+		{"utf-8 bom", utf8BOM + "sourcedId,role,givenName,familyName,email\nu1,student,Taro,Yamada,taro@example.com\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roster, rowErrors := Parse(Files{Users: strings.NewReader(tt.csv)})
+
+			if tt.message == "" {
+				if len(rowErrors) != 0 {
+					t.Fatalf("Parse() errors = %+v, want none", rowErrors)
+				}
+				if len(roster.Users) != 1 || roster.Users[0].SourcedID != "u1" {
+					t.Errorf("Parse() users = %+v, want u1", roster.Users)
+				}
+				return
+			}
+
+			want := models.RosterImportError{File: models.RosterFileUsers, Message: tt.message}
+			if len(rowErrors) != 1 || rowErrors[0] != want {
+				t.Errorf("Parse() errors = %+v, want %+v", rowErrors, want)
+			}
+			if len(roster.Users) != 0 {
+				t.Errorf("Parse() users = %+v, want none", roster.Users)
+			}
+		})
+	}
+}
+
+// TestParseUsersRowErrors 行単位のエラーはその行だけを除外し、行番号とsourcedIdを返すこと
+func TestParseUsersRowErrors(t *testing.T) {
+	csv := strings.Join([]string{
+		"sourcedId,status,enabledUser,role,givenName,familyName,email",
+		"u1,active,true,student,Taro,Yamada,taro@example.com",
+		",active,true,student,Jiro,Yamada,jiro@example.com",
+		"u1,active,true,student,Taro,Yamada,taro2@example.com",
+		"u3,inactive,true,student,Hanako,Sato,hanako@example.com",
+		"u4,active,maybe,student,Ichiro,Suzuki,ichiro@example.com",
+		"u5,active,true,principal,Ken,Tanaka,ken@example.com",
+		"u6,active,true,teacher,,,nobody@example.com",
+		"u7,active,true,teacher,Ken,Tanaka,",
+		`u8,active,true,teacher,"Ken,Tanaka,ken@example.com`,
+	}, "\n") + "\n"
+
+	roster, rowErrors := Parse(Files{Users: strings.NewReader(csv)})
+
+	want := []models.RosterImportError{
+		{File: models.RosterFileUsers, Line: 3, Message: "sourcedId is required"},
+		{File: models.RosterFileUsers, Line: 4, SourcedID: "u1", Message: "duplicate sourcedId"},
+		{File: models.RosterFileUsers, Line: 5, SourcedID: "u3", Message: "status must be active or tobedeleted"},
+		{File: models.RosterFileUsers, Line: 6, SourcedID: "u4", Message: "enabledUser must be true or false"},
+		{File: models.RosterFileUsers, Line: 7, SourcedID: "u5", Message: `unsupported role: "principal"`},
+		{File: models.RosterFileUsers, Line: 8, SourcedID: "u6", Message: "givenName or familyName is required"},
+		{File: models.RosterFileUsers, Line: 9, SourcedID: "u7", Message: "email is required"},
+	}
+
+	// 閉じていない引用符は形式エラーとしてその行を読み飛ばす
+	if len(rowErrors) != len(want)+1 {
+		t.Fatalf("Parse() errors = %+v, want %d errors", rowErrors, len(want)+1)
+	}
+	for i, w := range want {
+		if rowErrors[i] != w {
+			t.Errorf("errors[%d] = %+v, want %+v", i, rowErrors[i], w)
+		}
+	}
+	if last := rowErrors[len(want)]; last.File != models.RosterFileUsers || last.Line != 10 || last.Message == "" {
+		t.Errorf("errors[%d] = %+v, want a csv error on line 10", len(want), last)
+	}
+
+	if len(roster.Users) != 1 {
+		t.Fatalf("Parse() users = %+v, want only u1", roster.Users)
+	}
+	user := roster.Users[0]
+	if user.Line != 2 || user.SourcedID != "u1" || user.Name != "Yamada Taro" || user.Role != models.RoleStudent || user.Deleted {
+		t.Errorf("Parse() user = %+v", user)
+	}
+}
+
+func TestParseUsersDeleted(t *testing.T) {
+	csv := strings.Join([]string{
+		"sourcedId,status,enabledUser,role,givenName,familyName,email",
+		"u1,active,true,student,Taro,Yamada,taro@example.com",
+		"u2,tobedeleted,true,student,Jiro,Yamada,jiro@example.com",
+		"u3,active,false,student,Saburo,Yamada,saburo@example.com",
+		"u4,,,student,Shiro,Yamada,shiro@example.com",
+		"u5,ToBeDeleted,TRUE,student,Goro,Yamada,goro@example.com",
+	}, "\n") + "\n"
+
+	roster, rowErrors := Parse(Files{Users: strings.NewReader(csv)})
+	if len(rowErrors) != 0 {
+		t.Fatalf("Parse() errors = %+v", rowErrors)
+	}
+
+	want := map[string]bool{"u1": false, "u2": true, "u3": true, "u4": false, "u5": true}
+	for _, user := range roster.Users {
+		if user.Deleted != want[user.SourcedID] {
+			t.Errorf("%s Deleted = %v, want %v", user.SourcedID, user.Deleted, want[user.SourcedID])
+		}
+	}
+	if len(roster.Users) != len(want) {
+		t.Errorf("Parse() users = %d, want %d", len(roster.Users), len(want))
+	}
+}
+
+// TestParseUsersRole OneRosterの役割を本システムの役割に変換すること
+func TestParseUsersRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want string
+	}{
+		{"student", models.RoleStudent},
+		{"Student", models.RoleStudent},
+		{"teacher", models.RoleTeacher},
+		{"parent", models.RoleParent},
+		{"guardian", models.RoleParent},
+		{"relative", models.RoleParent},
+		{"administrator", models.RoleAdmin},
+		{"aide", ""},
+		{"admin", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			csv := "sourcedId,role,givenName,familyName,email\nu1," + tt.role + ",Taro,Yamada,taro@example.com\n"
+			roster, rowErrors := Parse(Files{Users: strings.NewReader(csv)})
+
+			if tt.want == "" {
+				if len(rowErrors) != 1 || !strings.HasPrefix(rowErrors[0].Message, "unsupported role") {
+					t.Errorf("Parse() errors = %+v, want unsupported role", rowErrors)
+				}
+				return
+			}
+
+			if len(rowErrors) != 0 || len(roster.Users) != 1 {
+				t.Fatalf("Parse() users = %+v, errors = %+v", roster.Users, rowErrors)
+			}
+			if roster.Users[0].Role != tt.want {
+				t.Errorf("Role = %q, want %q", roster.Users[0].Role, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClasses(t *testing.T) {
+	csv := strings.Join([]string{
+		"sourcedId,status,title,description,subjects,scheduledAt",
+		"c1,active,Algebra,Weekly,Math,2025-04-07 09:00",
+		"c2,active,Physics,,Science,",
+		"c3,active,,,Math,2025-04-07 09:00",
+		"c4,active,Mixed,,\"Math,Science\",2025-04-07 09:00",
+		"c5,active,Empty,,,2025-04-07 09:00",
+		"c6,active,Bad date,,Math,04/07/2025",
+	}, "\n") + "\n"
+
+	roster, rowErrors := Parse(Files{Classes: strings.NewReader(csv)})
+
+	want := []models.RosterImportError{
+		{File: models.RosterFileClasses, Line: 4, SourcedID: "c3", Message: "title is required"},
+		{File: models.RosterFileClasses, Line: 5, SourcedID: "c4", Message: "subjects must contain exactly one subject"},
+		{File: models.RosterFileClasses, Line: 6, SourcedID: "c5", Message: "subjects is required"},
+		{File: models.RosterFileClasses, Line: 7, SourcedID: "c6", Message: "scheduledAt must be RFC3339 or YYYY-MM-DD HH:MM"},
+	}
+	if len(rowErrors) != len(want) {
+		t.Fatalf("Parse() errors = %+v, want %+v", rowErrors, want)
+	}
+	for i, w := range want {
+		if rowErrors[i] != w {
+			t.Errorf("errors[%d] = %+v, want %+v", i, rowErrors[i], w)
+		}
+	}
+
+	if len(roster.Classes) != 2 {
+		t.Fatalf("Parse() classes = %+v, want c1 and c2", roster.Classes)
+	}
+	c1, c2 := roster.Classes[0], roster.Classes[1]
+	if c1.Subject != "Math" || c1.Description != "Weekly" || c1.ScheduledAt == nil || !c1.ScheduledAt.Equal(time.Date(2025, 4, 7, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("c1 = %+v", c1)
+	}
+	// scheduledAtが空の場合は既存の値を変更しない
+	if c2.ScheduledAt != nil {
+		t.Errorf("c2 ScheduledAt = %v, want nil", c2.ScheduledAt)
+	}
+}
+
+func TestParseEnrollments(t *testing.T) {
+	csv := strings.Join([]string{
+		"sourcedId,status,classSourcedId,userSourcedId,role,primary",
+		"e1,active,c1,u1,student,",
+		"e2,active,c1,t1,Teacher,true",
+		"e3,active,c1,,student,",
+		"e4,active,c1,u2,aide,",
+		"e5,active,c1,t2,teacher,yes",
+	}, "\n") + "\n"
+
+	roster, rowErrors := Parse(Files{Enrollments: strings.NewReader(csv)})
+
+	want := []models.RosterImportError{
+		{File: models.RosterFileEnrollments, Line: 4, SourcedID: "e3", Message: "classSourcedId and userSourcedId are required"},
+		{File: models.RosterFileEnrollments, Line: 5, SourcedID: "e4", Message: `unsupported role: "aide"`},
+		{File: models.RosterFileEnrollments, Line: 6, SourcedID: "e5", Message: "primary must be true or false"},
+	}
+	if len(rowErrors) != len(want) {
+		t.Fatalf("Parse() errors = %+v, want %+v", rowErrors, want)
+	}
+	for i, w := range want {
+		if rowErrors[i] != w {
+			t.Errorf("errors[%d] = %+v, want %+v", i, rowErrors[i], w)
+		}
+	}
+
+	if len(roster.Enrollments) != 2 {
+		t.Fatalf("Parse() enrollments = %+v, want e1 and e2", roster.Enrollments)
+	}
+	if e := roster.Enrollments[1]; e.Role != EnrollmentRoleTeacher || !e.Primary {
+		t.Errorf("e2 = %+v, want primary teacher", e)
+	}
+}
+
+// TestParseFilesIndependently sourcedIdの重複はファイルごとに判定し、各ファイルのエラーを合わせて返すこと
+func TestParseFilesIndependently(t *testing.T) {
+	roster, rowErrors := Parse(Files{
+		Users:     strings.NewReader("sourcedId,role,givenName,familyName,email\nx1,parent,Hanako,Yamada,hanako@example.com\n"),
+		Guardians: strings.NewReader("sourcedId,parentSourcedId,studentSourcedId,relationship\nx1,x1,u1,mother\nx2,x1,,\n"),
+	})
+
+	want := models.RosterImportError{File: models.RosterFileGuardians, Line: 3, SourcedID: "x2", Message: "parentSourcedId and studentSourcedId are required"}
+	if len(rowErrors) != 1 || rowErrors[0] != want {
+		t.Fatalf("Parse() errors = %+v, want %+v", rowErrors, want)
+	}
+	if len(roster.Users) != 1 || len(roster.Guardians) != 1 || roster.Guardians[0].Relationship != "mother" {
+		t.Errorf("Parse() = %+v", roster)
+	}
+}
+
+// TestParseScheduledAt タイムゾーンの有無によらず記載した壁時計の時刻として読み込むこと
+func TestParseScheduledAt(t *testing.T) {
+	want := time.Date(2025, 4, 7, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"2025-04-07 09:30", true},
+		{"2025-04-07T09:30", true},
+		{"2025-04-07T09:30:00Z", true},
+		{"2025-04-07T09:30:00+09:00", true},
+		{"2025-04-07T09:30:00-05:00", true},
+		{"2025-04-07", false},
+		{"2025/04/07 09:30", false},
+		{"2025-13-07 09:30", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseScheduledAt(tt.value)
+			if !tt.ok {
+				if err == nil {
+					t.Errorf("parseScheduledAt(%q) = %v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseScheduledAt(%q) error = %v", tt.value, err)
+			}
+			if !got.Equal(want) || got.Location() != time.UTC {
+				t.Errorf("parseScheduledAt(%q) = %v, want %v", tt.value, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// APIKeyPrefix APIキーの接頭辞（Authorizationヘッダーでアクセストークンと区別する）
+// キーの形式は "gs_<識別子>_<秘密部分>" で、識別子はDBに平文で保存する
+const APIKeyPrefix = "gs_"
+
+// APIKeyService APIキーサービスの構造体
+type APIKeyService struct {
+	apiKeyRepo   *repositories.APIKeyRepository
+	userRepo     *repositories.UserRepository
+	userService  *UserService
+	auditService *AuditService
+}
+
+// NewAPIKeyService APIキーサービスのコンストラクタ
+func NewAPIKeyService(apiKeyRepo *repositories.APIKeyRepository, userRepo *repositories.UserRepository, userService *UserService, auditService *AuditService) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:   apiKeyRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		auditService: auditService,
+	}
+}
+
+// CreateAPIKey ユーザーまたはサービスアカウントにAPIキーを発行する（管理者のみ）
+// キーの平文はこのレスポンスでのみ返す
+func (s *APIKeyService) CreateAPIKey(userID string, request *models.APIKeyCreateRequest, audit models.AuditContext) (*models.APIKeyCreateResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionCreate, policy.Resource{Type: policy.ResourceAPIKey}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	// リクエストのバリデーション
+	name := strings.TrimSpace(request.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return nil, fmt.Errorf("入力値エラーがあります: name is required and must be at most 100 characters")
+	}
+
+	scopes, err := normalizeScopes(request.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("入力値エラーがあります: expires_at must be in the future")
+	}
+
+	user, err := s.userRepo.GetUserByID(request.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	// キーを生成
+	key, keyPrefix, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &models.APIKey{
+		UserID:    user.UserID,
+		Name:      name,
+		KeyPrefix: keyPrefix,
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: request.ExpiresAt,
+		CreatedBy: principal.UserID,
+	}
+
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.apiKeyRepo.WithTx(tx).CreateAPIKey(apiKey); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録（キーの平文・ハッシュは含まない）
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceAPIKey, apiKey.APIKeyID, nil, apiKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.APIKeyCreateResponse{
+		APIKey: *apiKey,
+		Key:    key,
+	}, nil
+}
+
+// ListAPIKeys APIキーの一覧を取得する（管理者のみ）
+func (s *APIKeyService) ListAPIKeys(userID string, request *models.APIKeyListRequest) (*models.APIKeyListResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionList, policy.Resource{Type: policy.ResourceAPIKey}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	if request.UserID < 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid user ID")
+	}
+
+	apiKeys, err := s.apiKeyRepo.ListAPIKeys(request.UserID, request.IncludeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if apiKeys == nil {
+		apiKeys = []models.APIKey{}
+	}
+
+	return &models.APIKeyListResponse{
+		Count:   len(apiKeys),
+		APIKeys: apiKeys,
+	}, nil
+}
+
+// RevokeAPIKey APIキーを無効化する（管理者のみ）
+func (s *APIKeyService) RevokeAPIKey(userID string, apiKeyID string, audit models.AuditContext) error {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionDelete, policy.Resource{Type: policy.ResourceAPIKey}); err != nil {
+		return fmt.Errorf("access denied: %w", err)
+	}
+
+	apiKeyIDInt, err := strconv.Atoi(apiKeyID)
+	if err != nil || apiKeyIDInt <= 0 {
+		return fmt.Errorf("入力値エラーがあります: invalid API key ID")
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.apiKeyRepo.WithTx(tx).RevokeAPIKey(apiKeyIDInt); err != nil {
+			if strings.Contains(err.Error(), "api key not found") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionRevoke, policy.ResourceAPIKey, apiKeyIDInt, map[string]bool{"revoked": false}, map[string]bool{"revoked": true})
+	})
+}
+
+// Authenticate APIキーを検証し、所有ユーザーとして振る舞うプリンシパルを取得する
+// 操作できる範囲は所有ユーザーの役割とキーのスコープの両方で制限される
+func (s *APIKeyService) Authenticate(key string, ip string) (*models.Principal, error) {
+	keyPrefix, ok := parseAPIKeyPrefix(key)
+	if !ok {
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	apiKey, role, err := s.apiKeyRepo.GetActiveAPIKeyByPrefix(keyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if apiKey == nil || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashToken(key))) != 1 {
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	if err := s.apiKeyRepo.TouchAPIKey(apiKey.APIKeyID, ip); err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return &models.Principal{
+		UserID:   apiKey.UserID,
+		Role:     role,
+		APIKeyID: apiKey.APIKeyID,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
+
+// generateAPIKey APIキーとその識別子を生成する
+func generateAPIKey() (string, string, error) {
+	prefix := make([]byte, 6)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	keyPrefix := APIKeyPrefix + hex.EncodeToString(prefix)
+	return keyPrefix + "_" + hex.EncodeToString(secret), keyPrefix, nil
+}
+
+// parseAPIKeyPrefix APIキーから識別子を取り出す
+func parseAPIKeyPrefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+
+	return APIKeyPrefix + id, true
+}
+
+// normalizeScopes スコープを検証し、重複を除いて整列する
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: at least one scope is required")
+	}
+
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !policy.ValidScope(scope) {
+			return nil, fmt.Errorf("入力値エラーがあります: invalid scope %q", scope)
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
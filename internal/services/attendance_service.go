@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// 出席一覧の取得件数
+const (
+	defaultAttendanceListLimit = 50
+	maxAttendanceListLimit     = 200
+)
+
+// attendanceStatuses 出席ステータスの一覧
+var attendanceStatuses = map[string]bool{
+	"present": true,
+	"absent":  true,
+	"late":    true,
+}
+
+// AttendanceService 出席サービスの構造体
+type AttendanceService struct {
+	attendanceRepo *repositories.AttendanceRepository
+	courseRepo     *repositories.CourseRepository
+	guardianRepo   *repositories.GuardianRepository
+	userService    *UserService
+}
+
+// NewAttendanceService 出席サービスのコンストラクタ
+func NewAttendanceService(attendanceRepo *repositories.AttendanceRepository, courseRepo *repositories.CourseRepository, guardianRepo *repositories.GuardianRepository, userService *UserService) *AttendanceService {
+	return &AttendanceService{
+		attendanceRepo: attendanceRepo,
+		courseRepo:     courseRepo,
+		guardianRepo:   guardianRepo,
+		userService:    userService,
+	}
+}
+
+// ListAttendances 出席の一覧を取得する
+// 学生は自分の出席、保護者は紐付く学生の出席、教師は担当授業（アシスタントを含む）の出席のみ取得できる
+func (s *AttendanceService) ListAttendances(userID string, request *models.AttendanceListRequest) (*models.AttendanceListResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 検索条件のバリデーション
+	filter, err := buildAttendanceFilter(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// 役割に応じて参照範囲を決定
+	resource := policy.Resource{Type: policy.ResourceAttendance}
+	switch principal.Role {
+	case models.RoleStudent:
+		// 学生は自分の出席のみ
+		if filter.StudentUserID != nil && *filter.StudentUserID != principal.UserID {
+			return nil, fmt.Errorf("access denied: %s: students can only read their own attendance", models.ErrorMessageForbidden)
+		}
+		filter.StudentUserID = &principal.UserID
+		resource.StudentUserID = principal.UserID
+	case models.RoleParent:
+		// 保護者は紐付く学生を指定する
+		if filter.StudentUserID == nil {
+			return nil, fmt.Errorf("入力値エラーがあります: student_user_id is required")
+		}
+		resource.StudentUserID = *filter.StudentUserID
+		resource.IsGuardian, err = s.guardianRepo.IsGuardianOf(principal.UserID, *filter.StudentUserID)
+		if err != nil {
+			return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+	case models.RoleTeacher:
+		// 教師は担当授業を指定する
+		if filter.CourseID == nil {
+			return nil, fmt.Errorf("入力値エラーがあります: course_id is required")
+		}
+		if _, err := s.courseRepo.GetCourseByID(*filter.CourseID); err != nil {
+			return nil, fmt.Errorf("course not found: %w", err)
+		}
+		resource.CourseRole, err = s.courseRepo.GetStaffRole(*filter.CourseID, principal.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+	}
+
+	if err := policy.Authorize(principal, policy.ActionRead, resource); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	attendances, err := s.attendanceRepo.ListAttendances(filter)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if attendances == nil {
+		attendances = []models.AttendanceResponse{}
+	}
+
+	return &models.AttendanceListResponse{
+		Count:       len(attendances),
+		Attendances: attendances,
+	}, nil
+}
+
+// buildAttendanceFilter リクエストから出席一覧の検索条件を作成する
+func buildAttendanceFilter(request *models.AttendanceListRequest) (*repositories.AttendanceFilter, error) {
+	filter := &repositories.AttendanceFilter{
+		StudentUserID: request.StudentUserID,
+		CourseID:      request.CourseID,
+		Status:        request.Status,
+		Limit:         request.Limit,
+		Offset:        request.Offset,
+	}
+
+	if filter.Status != "" && !attendanceStatuses[filter.Status] {
+		return nil, fmt.Errorf("入力値エラーがあります: status must be one of present, absent, late")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAttendanceListLimit
+	}
+	if filter.Limit > maxAttendanceListLimit {
+		filter.Limit = maxAttendanceListLimit
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: offset must not be negative")
+	}
+
+	// 日付はYYYY-MM-DD形式で壁時計の日付として扱う（終了日は当日を含む）
+	if request.StartDate != "" {
+		from, err := time.Parse("2006-01-02", request.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("入力値エラーがあります: start_date must be YYYY-MM-DD")
+		}
+		filter.From = &from
+	}
+	if request.EndDate != "" {
+		to, err := time.Parse("2006-01-02", request.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("入力値エラーがあります: end_date must be YYYY-MM-DD")
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	return filter, nil
+}
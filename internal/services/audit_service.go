@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/netip"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// AuditService 監査ログサービスの構造体
+type AuditService struct {
+	auditRepo *repositories.AuditLogRepository
+	userRepo  *repositories.UserRepository
+}
+
+// NewAuditService 監査ログサービスのコンストラクタ
+func NewAuditService(auditRepo *repositories.AuditLogRepository, userRepo *repositories.UserRepository) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// 監査ログの列の長さの上限（migrations/010_audit_logs.sql）
+const (
+	maxAuditRequestIDLength = 64
+	maxAuditEntityIDLength  = 64
+)
+
+// Transact fnを1つのトランザクション内で実行する
+// 変更操作とその監査ログ（Record）を同じトランザクションで書き込み、どちらかに失敗した場合は両方とも取り消す
+// fnが返したエラーはそのまま返す
+func (s *AuditService) Transact(fn func(tx pgx.Tx) error) error {
+	var fnErr error
+	err := repositories.RunInTx(context.Background(), s.auditRepo.DB, func(tx pgx.Tx) error {
+		fnErr = fn(tx)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return nil
+}
+
+// Record 変更操作を同じトランザクション（Transact）で監査ログに記録する
+// before・afterは変更前後のエンティティ（作成時のbefore、削除時のafterはnil）
+// 記録できない変更操作を残さないため、記録に失敗した場合はエラーを返す（呼び出し側はトランザクションをロールバックする）
+func (s *AuditService) Record(tx pgx.Tx, audit models.AuditContext, action string, entityType policy.ResourceType, entityID interface{}, before interface{}, after interface{}) error {
+	auditLog := newAuditLog(audit, action, entityType, entityID, before, after)
+
+	if err := s.auditRepo.WithTx(tx).CreateAuditLog(auditLog); err != nil {
+		entry, _ := json.Marshal(auditLog)
+		log.Printf("failed to record audit log: %v: %s", err, entry)
+		return fmt.Errorf("データベースエラーが発生しました: failed to record audit log: %v", err)
+	}
+
+	return nil
+}
+
+// newAuditLog 変更操作の監査ログを作成する
+// リクエスト由来の値は列に収まるよう検証する（IPアドレスとして解析できない値は記録しない）
+func newAuditLog(audit models.AuditContext, action string, entityType policy.ResourceType, entityID interface{}, before interface{}, after interface{}) *models.AuditLog {
+	auditLog := &models.AuditLog{
+		Action:     action,
+		EntityType: string(entityType),
+		EntityID:   truncateRunes(fmt.Sprint(entityID), maxAuditEntityIDLength),
+	}
+
+	if audit.ActorUserID > 0 {
+		auditLog.ActorUserID = &audit.ActorUserID
+	}
+	if audit.APIKeyID > 0 {
+		auditLog.ActorAPIKeyID = &audit.APIKeyID
+	}
+	if requestID := truncateRunes(strings.ToValidUTF8(audit.RequestID, ""), maxAuditRequestIDLength); requestID != "" {
+		auditLog.RequestID = &requestID
+	}
+	if addr, err := netip.ParseAddr(audit.IPAddress); err == nil {
+		ipAddress := addr.Unmap().WithZone("").String()
+		auditLog.IPAddress = &ipAddress
+	}
+
+	beforeData, beforeFields := snapshot(before)
+	afterData, afterFields := snapshot(after)
+	auditLog.BeforeData = beforeData
+	auditLog.AfterData = afterData
+	auditLog.Changes = diffFields(beforeFields, afterFields)
+
+	return auditLog
+}
+
+// truncateRunes 文字列を先頭から最大n文字にする
+func truncateRunes(value string, n int) string {
+	runes := []rune(value)
+	if len(runes) <= n {
+		return value
+	}
+	return string(runes[:n])
+}
+
+// ListAuditLogs 監査ログを検索する（管理者のみ）
+func (s *AuditService) ListAuditLogs(userID string, request *models.AuditLogListRequest) (*models.AuditLogListResponse, error) {
+	userIDInt, err := parseTargetUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.userRepo.GetUserRole(userIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user role: %w", err)
+	}
+
+	principal := &models.Principal{UserID: userIDInt, Role: role}
+	if err := policy.Authorize(principal, policy.ActionList, policy.Resource{Type: policy.ResourceAuditLog}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	// ページングのバリデーション
+	if request.Limit <= 0 {
+		request.Limit = defaultUserListLimit
+	}
+	if request.Limit > maxUserListLimit {
+		request.Limit = maxUserListLimit
+	}
+	if request.Offset < 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: offset must not be negative")
+	}
+	if request.ActorUserID < 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid actor_user_id")
+	}
+
+	filter := repositories.AuditLogFilter{
+		ActorUserID: request.ActorUserID,
+		EntityType:  strings.TrimSpace(request.EntityType),
+		EntityID:    strings.TrimSpace(request.EntityID),
+		Action:      strings.TrimSpace(request.Action),
+	}
+
+	if filter.From, err = parseFilterTime(request.From, "from", false); err != nil {
+		return nil, err
+	}
+	if filter.To, err = parseFilterTime(request.To, "to", true); err != nil {
+		return nil, err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("入力値エラーがあります: from must be before to")
+	}
+
+	auditLogs, total, err := s.auditRepo.ListAuditLogs(filter, request.Limit, request.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if auditLogs == nil {
+		auditLogs = []models.AuditLog{}
+	}
+
+	return &models.AuditLogListResponse{
+		Count:     len(auditLogs),
+		Total:     total,
+		AuditLogs: auditLogs,
+	}, nil
+}
+
+// snapshot エンティティをJSONとフィールドごとの値に変換する（nilの場合は空）
+func snapshot(entity interface{}) (json.RawMessage, map[string]interface{}) {
+	if entity == nil || (reflect.ValueOf(entity).Kind() == reflect.Ptr && reflect.ValueOf(entity).IsNil()) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// オブジェクト以外はフィールド単位の差分を取らない
+		return data, map[string]interface{}{"value": json.RawMessage(data)}
+	}
+
+	return data, fields
+}
+
+// diffFields 変更前後で値が異なるフィールドを {"before": ..., "after": ...} の形で返す
+func diffFields(before map[string]interface{}, after map[string]interface{}) json.RawMessage {
+	changes := make(map[string]map[string]interface{})
+
+	for key, beforeValue := range before {
+		afterValue, ok := after[key]
+		if !ok || !reflect.DeepEqual(beforeValue, afterValue) {
+			changes[key] = map[string]interface{}{"before": beforeValue, "after": afterValue}
+		}
+	}
+	for key, afterValue := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = map[string]interface{}{"before": nil, "after": afterValue}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil
+	}
+	return data
+}
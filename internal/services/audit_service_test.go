@@ -0,0 +1,83 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+)
+
+func TestNewAuditLogIPAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want string // 空文字は記録しない
+	}{
+		{"ipv4", "192.0.2.1", "192.0.2.1"},
+		{"ipv6", "2001:db8::1", "2001:db8::1"},
+		{"ipv4 mapped", "::ffff:192.0.2.1", "192.0.2.1"},
+		{"zone", "fe80::1%eth0", "fe80::1"},
+		{"empty", "", ""},
+		{"forwarded list", "192.0.2.1, 198.51.100.1", ""},
+		{"overlong", strings.Repeat("1", 400), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog := newAuditLog(models.AuditContext{IPAddress: tt.ip}, models.AuditActionUpdate, policy.ResourceUser, 1, nil, nil)
+
+			got := ""
+			if auditLog.IPAddress != nil {
+				got = *auditLog.IPAddress
+			}
+			if got != tt.want {
+				t.Errorf("IPAddress = %q, want %q", got, tt.want)
+			}
+			if len(got) > 45 {
+				t.Errorf("IPAddress %q does not fit ip_address VARCHAR(45)", got)
+			}
+		})
+	}
+}
+
+func TestNewAuditLogRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		want      string
+	}{
+		{"normal", "abc123", "abc123"},
+		{"empty", "", ""},
+		{"overlong", strings.Repeat("あ", 100), strings.Repeat("あ", maxAuditRequestIDLength)},
+		{"invalid utf8", "ab\xffcd", "abcd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog := newAuditLog(models.AuditContext{RequestID: tt.requestID}, models.AuditActionUpdate, policy.ResourceUser, 1, nil, nil)
+
+			got := ""
+			if auditLog.RequestID != nil {
+				got = *auditLog.RequestID
+			}
+			if got != tt.want {
+				t.Errorf("RequestID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAuditLogActor(t *testing.T) {
+	auditLog := newAuditLog(models.AuditContext{}, models.AuditActionCreate, policy.ResourceUser, 42, nil, map[string]string{"name": "a"})
+
+	if auditLog.ActorUserID != nil || auditLog.ActorAPIKeyID != nil {
+		t.Errorf("actor must be NULL when unknown, got user=%v api_key=%v", auditLog.ActorUserID, auditLog.ActorAPIKeyID)
+	}
+	if auditLog.EntityID != "42" {
+		t.Errorf("EntityID = %q, want 42", auditLog.EntityID)
+	}
+	if auditLog.BeforeData != nil {
+		t.Errorf("BeforeData = %s, want NULL on create", auditLog.BeforeData)
+	}
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthService 認証サービスの構造体
+type AuthService struct {
+	userRepo        *repositories.UserRepository
+	sessionRepo     *repositories.SessionRepository
+	throttleService *LoginThrottleService
+	twoFactor       *TwoFactorService
+	auditService    *AuditService
+	jwtSecret       []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+// NewAuthService 認証サービスのコンストラクタ
+func NewAuthService(userRepo *repositories.UserRepository, sessionRepo *repositories.SessionRepository, throttleService *LoginThrottleService, twoFactor *TwoFactorService, auditService *AuditService, jwtSecret string, accessTokenTTL time.Duration, refreshTokenTTL time.Duration) *AuthService {
+	return &AuthService{
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		throttleService: throttleService,
+		twoFactor:       twoFactor,
+		auditService:    auditService,
+		jwtSecret:       []byte(jwtSecret),
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+// トークンの用途
+const (
+	tokenPurposeAccess = "access" // アクセストークン
+	tokenPurposeMFA    = "mfa"    // 二要素認証待ちのログイン（認証コードの送信にのみ使用）
+)
+
+// mfaTokenTTL 二要素認証待ちトークンの有効期限
+const mfaTokenTTL = 5 * time.Minute
+
+// tokenClaims アクセストークンのクレーム
+type tokenClaims struct {
+	Purpose   string `json:"pur"`
+	Role      string `json:"role,omitempty"`
+	SessionID int    `json:"sid,omitempty"`
+	// 二要素認証の登録が必須だが未登録（登録以外の操作は拒否される）
+	MFAEnrollmentRequired bool `json:"mfa_enroll,omitempty"`
+	jwt.StandardClaims
+}
+
+// Login メールアドレスとパスワードで認証し、署名済みトークンを発行する
+// ipはログイン試行制限に使用する接続元IPアドレス
+func (s *AuthService) Login(request *models.UserLoginRequest, ip string) (*models.UserLoginResponse, error) {
+	// リクエストのバリデーション
+	request.Email = normalizeEmail(request.Email)
+	if request.Email == "" || request.Password == "" {
+		return nil, fmt.Errorf("入力値エラーがあります: email and password are required")
+	}
+	if len(request.Email) > maxEmailLength {
+		return nil, fmt.Errorf("入力値エラーがあります: email is too long")
+	}
+
+	// ログイン試行制限のチェック（制限中はパスワードを検証しない）
+	if err := s.throttleService.Check(request.Email, ip); err != nil {
+		return nil, err
+	}
+
+	// メールアドレスからユーザーを取得
+	user, err := s.userRepo.GetUserByEmail(request.Email)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	// ユーザーが存在しない、またはパスワードが一致しない場合は同じエラーを返す
+	if user == nil {
+		if err := s.throttleService.RecordFailure(request.Email, ip, nil); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	// サービスアカウントはパスワードでログインできない
+	if user.IsServiceAccount || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)) != nil {
+		if err := s.throttleService.RecordFailure(request.Email, ip, &user.UserID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	return s.LoginVerifiedUser(user)
+}
+
+// LoginVerifiedUser 本人確認済みのユーザーのログインを進める（外部IdPでのログインからも使用する）
+// 二要素認証が有効な場合は認証コードの確認を待ち、試行制限のカウンターは確認後にリセットする
+func (s *AuthService) LoginVerifiedUser(user *models.User) (*models.UserLoginResponse, error) {
+	enabled, err := s.twoFactor.IsEnabled(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		mfaToken, err := s.issueMFAToken(user.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to issue token: %w", err)
+		}
+
+		return &models.UserLoginResponse{
+			UserID:      user.UserID,
+			Name:        user.Name,
+			Email:       user.Email,
+			Role:        user.Role,
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			Message:     "認証コードを入力してください",
+		}, nil
+	}
+
+	return s.completeLogin(user)
+}
+
+// VerifyTwoFactorLogin 二要素認証待ちトークンと認証コードを検証し、署名済みトークンを発行する
+// 認証コードの失敗もログイン試行制限の対象にする
+func (s *AuthService) VerifyTwoFactorLogin(request *models.TwoFactorLoginRequest, ip string) (*models.UserLoginResponse, error) {
+	if request.MFAToken == "" || (request.Code == "") == (request.RecoveryCode == "") {
+		return nil, fmt.Errorf("入力値エラーがあります: mfa_token and either code or recovery_code are required")
+	}
+
+	claims, err := s.parseClaims(request.MFAToken, tokenPurposeMFA)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || userID <= 0 {
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil {
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	if err := s.throttleService.Check(user.Email, ip); err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactor.VerifyLogin(user.UserID, request.Code, request.RecoveryCode); err != nil {
+		if err.Error() != models.ErrorMessageUnauthorized {
+			return nil, err
+		}
+		if err := s.throttleService.RecordFailure(user.Email, ip, &user.UserID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	return s.completeLogin(user)
+}
+
+// completeLogin 試行制限のカウンターをリセットし、セッションを開始してログインレスポンスを作成する
+func (s *AuthService) completeLogin(user *models.User) (*models.UserLoginResponse, error) {
+	if err := s.throttleService.RecordSuccess(user.Email); err != nil {
+		return nil, err
+	}
+
+	// セッションを開始してトークンを発行
+	tokens, enrollmentRequired, err := s.startSession(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// レスポンスを作成
+	response := &models.UserLoginResponse{
+		UserID:                user.UserID,
+		Name:                  user.Name,
+		Email:                 user.Email,
+		Role:                  user.Role,
+		Token:                 tokens.Token,
+		RefreshToken:          tokens.RefreshToken,
+		ExpiresIn:             tokens.ExpiresIn,
+		MFAEnrollmentRequired: enrollmentRequired,
+		Message:               "ログインに成功しました",
+	}
+
+	return response, nil
+}
+
+// Refresh リフレッシュトークンをローテーションしてアクセストークンを再発行する
+func (s *AuthService) Refresh(request *models.RefreshTokenRequest) (*models.TokenResponse, error) {
+	if request.RefreshToken == "" {
+		return nil, fmt.Errorf("入力値エラーがあります: refresh_token is required")
+	}
+
+	// 新しいリフレッシュトークンを生成
+	refreshToken, refreshTokenHash, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// 旧トークンを使用済みにして新トークンに置き換える
+	session, err := s.sessionRepo.RotateRefreshToken(hashToken(request.RefreshToken), refreshTokenHash, time.Now().Add(s.refreshTokenTTL))
+	if err != nil {
+		errorMsg := err.Error()
+		if strings.Contains(errorMsg, "invalid refresh token") || strings.Contains(errorMsg, "refresh token reuse detected") {
+			return nil, errors.New(models.ErrorMessageUnauthorized)
+		}
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	// 最新の役割でアクセストークンを発行（削除済みユーザーは拒否）
+	user, err := s.userRepo.GetUserByID(session.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil {
+		if err := s.sessionRepo.RevokeSession(session.SessionID, repositories.RevokedReasonAdminRevoked); err != nil {
+			return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	// 二要素認証の登録状況も最新の状態を反映する
+	enrollmentRequired, err := s.enrollmentRequired(user)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.issueToken(user.UserID, user.Role, session.SessionID, enrollmentRequired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue token: %w", err)
+	}
+
+	return &models.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Logout 現在のセッションを無効化する
+func (s *AuthService) Logout(principal *models.Principal) error {
+	if err := s.sessionRepo.RevokeSession(principal.SessionID, repositories.RevokedReasonLogout); err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+	return nil
+}
+
+// RevokeUserSessions 指定したユーザーの全セッションを無効化する（管理者のみ）
+func (s *AuthService) RevokeUserSessions(principal *models.Principal, targetUserID string, audit models.AuditContext) (*models.RevokeSessionsResponse, error) {
+	// 管理者のみが実行可能
+	if err := policy.Authorize(principal, policy.ActionUpdate, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	// 対象ユーザーIDの型変換とバリデーション
+	targetUserIDInt, err := strconv.Atoi(targetUserID)
+	if err != nil || targetUserIDInt <= 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid user ID")
+	}
+
+	var revoked int
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		revoked, err = s.sessionRepo.WithTx(tx).RevokeUserSessions(targetUserIDInt, repositories.RevokedReasonAdminRevoked)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionRevokeSessions, policy.ResourceUser, targetUserIDInt, nil, map[string]int{"revoked_sessions": revoked})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RevokeSessionsResponse{
+		Status:          "OK",
+		UserID:          targetUserIDInt,
+		RevokedSessions: revoked,
+	}, nil
+}
+
+// ParseToken トークンを検証し、プリンシパルを取得する
+func (s *AuthService) ParseToken(tokenString string) (*models.Principal, error) {
+	claims, err := s.parseClaims(tokenString, tokenPurposeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	// サブジェクトからユーザーIDを取得
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || userID <= 0 || claims.SessionID <= 0 {
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	// ログアウト・無効化済みのセッションは拒否
+	active, err := s.sessionRepo.IsSessionActive(claims.SessionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if !active {
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	return &models.Principal{
+		UserID:                userID,
+		Role:                  claims.Role,
+		SessionID:             claims.SessionID,
+		MFAEnrollmentRequired: claims.MFAEnrollmentRequired,
+	}, nil
+}
+
+// parseClaims トークンの署名・有効期限・用途を検証し、クレームを取得する
+func (s *AuthService) parseClaims(tokenString string, purpose string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// HMAC以外の署名方式は受け付けない
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil || !token.Valid || claims.Purpose != purpose {
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	return claims, nil
+}
+
+// startSession セッションを開始し、アクセストークンとリフレッシュトークンを発行する
+// 二要素認証の登録が必須かどうかも返す
+func (s *AuthService) startSession(user *models.User) (*models.TokenResponse, bool, error) {
+	enrollmentRequired, err := s.enrollmentRequired(user)
+	if err != nil {
+		return nil, false, err
+	}
+
+	refreshToken, refreshTokenHash, err := generateToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	sessionID, err := s.sessionRepo.CreateSession(user.UserID, refreshTokenHash, time.Now().Add(s.refreshTokenTTL))
+	if err != nil {
+		return nil, false, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	token, err := s.issueToken(user.UserID, user.Role, sessionID, enrollmentRequired)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to issue token: %w", err)
+	}
+
+	return &models.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+	}, enrollmentRequired, nil
+}
+
+// enrollmentRequired 役割により二要素認証が必須だが未登録かどうかを返す
+func (s *AuthService) enrollmentRequired(user *models.User) (bool, error) {
+	if !s.twoFactor.IsRequired(user.Role) {
+		return false, nil
+	}
+
+	enabled, err := s.twoFactor.IsEnabled(user.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	return !enabled, nil
+}
+
+// issueMFAToken 二要素認証待ちのトークンを生成する（セッションは開始しない）
+func (s *AuthService) issueMFAToken(userID int) (string, error) {
+	now := time.Now()
+	claims := tokenClaims{
+		Purpose: tokenPurposeMFA,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(mfaTokenTTL).Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.jwtSecret)
+}
+
+// issueToken ユーザーID・役割・セッションIDから署名済みアクセストークンを生成する
+func (s *AuthService) issueToken(userID int, role string, sessionID int, enrollmentRequired bool) (string, error) {
+	now := time.Now()
+	claims := tokenClaims{
+		Purpose:               tokenPurposeAccess,
+		Role:                  role,
+		SessionID:             sessionID,
+		MFAEnrollmentRequired: enrollmentRequired,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(s.accessTokenTTL).Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.jwtSecret)
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/ical"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// カレンダー購読の出力内容
+const (
+	calendarProdID          = "-//go-study//Calendar Feed//JA"
+	calendarName            = "go-study"
+	calendarUIDDomain       = "go-study"
+	calendarRefreshInterval = time.Hour
+	calendarPastDays        = 180 // この日数より前の予定は出力しない
+)
+
+// calendarTokenPattern カレンダー購読のトークンの形式（generateTokenで生成した64文字の16進数）
+var calendarTokenPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// CalendarFeedService カレンダー購読サービスの構造体
+type CalendarFeedService struct {
+	feedRepo     *repositories.CalendarFeedRepository
+	userService  *UserService
+	auditService *AuditService
+}
+
+// NewCalendarFeedService カレンダー購読サービスのコンストラクタ
+func NewCalendarFeedService(feedRepo *repositories.CalendarFeedRepository, userService *UserService, auditService *AuditService) *CalendarFeedService {
+	return &CalendarFeedService{
+		feedRepo:     feedRepo,
+		userService:  userService,
+		auditService: auditService,
+	}
+}
+
+// GetFeed 自分のカレンダー購読の発行状況を取得する
+func (s *CalendarFeedService) GetFeed(userID string) (*models.CalendarFeedResponse, error) {
+	principal, err := s.authorize(userID, policy.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	feed, err := s.feedRepo.GetFeed(principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if feed == nil {
+		return &models.CalendarFeedResponse{Enabled: false}, nil
+	}
+
+	return &models.CalendarFeedResponse{
+		Enabled:    true,
+		CreatedAt:  &feed.CreatedAt,
+		LastUsedAt: feed.LastUsedAt,
+	}, nil
+}
+
+// CreateFeed 自分のカレンダー購読のURLを発行する（発行済みの場合は再発行して以前のURLを無効にする）
+// トークンの平文はこのレスポンスでのみ返す
+func (s *CalendarFeedService) CreateFeed(userID string, audit models.AuditContext) (*models.CalendarFeedCreateResponse, error) {
+	principal, err := s.authorize(userID, policy.ActionCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &models.CalendarFeed{UserID: principal.UserID, TokenHash: tokenHash}
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		feedRepo := s.feedRepo.WithTx(tx)
+
+		before, err := feedRepo.GetFeed(principal.UserID)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		if err := feedRepo.SaveFeed(feed); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		action := models.AuditActionCreate
+		if before != nil {
+			action = models.AuditActionUpdate
+		}
+		return s.auditService.Record(tx, audit, action, policy.ResourceCalendar, principal.UserID, before, feed)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CalendarFeedCreateResponse{
+		Path:      fmt.Sprintf(models.CalendarFeedPath, token),
+		Token:     token,
+		CreatedAt: feed.CreatedAt,
+	}, nil
+}
+
+// RevokeFeed 自分のカレンダー購読を削除する（以前のURLは使えなくなる）
+func (s *CalendarFeedService) RevokeFeed(userID string, audit models.AuditContext) error {
+	principal, err := s.authorize(userID, policy.ActionDelete)
+	if err != nil {
+		return err
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		feedRepo := s.feedRepo.WithTx(tx)
+
+		before, err := feedRepo.GetFeed(principal.UserID)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		deleted, err := feedRepo.DeleteFeed(principal.UserID)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		if !deleted {
+			return fmt.Errorf("calendar feed not found")
+		}
+
+		return s.auditService.Record(tx, audit, models.AuditActionRevoke, policy.ResourceCalendar, principal.UserID, before, nil)
+	})
+}
+
+// RenderFeed トークンに対応するユーザーの授業回・テストをiCalendar形式で出力する
+// 学生・保護者には下書きのテストを含めない
+func (s *CalendarFeedService) RenderFeed(token string) ([]byte, error) {
+	if !calendarTokenPattern.MatchString(token) {
+		return nil, fmt.Errorf("calendar feed not found")
+	}
+
+	userID, role, err := s.feedRepo.GetFeedUserByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if userID == 0 {
+		return nil, fmt.Errorf("calendar feed not found")
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -calendarPastDays)
+
+	sessions, err := s.feedRepo.ListFeedSessions(userID, role, from)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	tests, err := s.feedRepo.ListFeedTests(userID, role, from, role == models.RoleTeacher)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if err := s.feedRepo.TouchFeed(userID); err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	calendar := &ical.Calendar{
+		ProdID:          calendarProdID,
+		Name:            calendarName,
+		RefreshInterval: calendarRefreshInterval,
+		Events:          make([]ical.Event, 0, len(sessions)+len(tests)),
+	}
+	for _, session := range sessions {
+		calendar.Events = append(calendar.Events, sessionEvent(&session))
+	}
+	for _, test := range tests {
+		calendar.Events = append(calendar.Events, testEvent(&test))
+	}
+
+	return calendar.Encode(now), nil
+}
+
+// authorize 自分のカレンダー購読に対する操作の権限をチェックする
+func (s *CalendarFeedService) authorize(userID string, action policy.Action) (*models.Principal, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, action, policy.Resource{Type: policy.ResourceCalendar}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	return principal, nil
+}
+
+// sessionEvent 授業回を予定に変換する
+// UIDは授業と繰り返しから展開した日時で決まるため、移動・休講しても同じ予定として更新される
+func sessionEvent(session *models.CalendarFeedSession) ical.Event {
+	description := session.CourseDescription
+	if session.Note != "" {
+		description = session.Note + "\n\n" + description
+	}
+
+	status := ical.StatusConfirmed
+	if session.Status == models.CourseSessionStatusCancelled {
+		status = ical.StatusCancelled
+	}
+
+	return ical.Event{
+		UID:         fmt.Sprintf("course-%d-%s@%s", session.CourseID, session.OriginalStartsAt.Format("20060102T150405"), calendarUIDDomain),
+		Start:       session.StartsAt,
+		End:         session.EndsAt,
+		Summary:     session.CourseTitle,
+		Description: strings.TrimSpace(description),
+		Status:      status,
+	}
+}
+
+// testEvent テストを予定に変換する（下書きは仮の予定として出力する）
+func testEvent(test *models.CalendarFeedTest) ical.Event {
+	event := ical.Event{
+		UID:         fmt.Sprintf("test-%d@%s", test.TeacherTestID, calendarUIDDomain),
+		Start:       test.ScheduledAt,
+		Summary:     fmt.Sprintf("テスト: %s（%s）", test.Title, test.CourseTitle),
+		Description: test.Description,
+		Status:      ical.StatusConfirmed,
+	}
+
+	if test.DurationMinutes > 0 {
+		event.End = test.ScheduledAt.Add(time.Duration(test.DurationMinutes) * time.Minute)
+	}
+
+	if test.IsDraft {
+		event.Summary = "[下書き] " + event.Summary
+		event.Status = ical.StatusTentative
+	}
+
+	return event
+}
@@ -3,28 +3,53 @@ package services
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
 	"github.com/tomoki-den-uhd/go-study/internal/repositories"
 )
 
+// 授業一覧の取得件数
+const (
+	defaultCourseListLimit = 50
+	maxCourseListLimit     = 200
+)
+
+// courseSortOrders 授業一覧で指定できる並び順（先頭に-で降順）
+var courseSortOrders = map[string]string{
+	"scheduled_at":  "c.scheduled_at ASC",
+	"-scheduled_at": "c.scheduled_at DESC",
+	"title":         "c.title ASC",
+	"-title":        "c.title DESC",
+	"created_at":    "c.created_at ASC",
+	"-created_at":   "c.created_at DESC",
+	"updated_at":    "c.updated_at ASC",
+	"-updated_at":   "c.updated_at DESC",
+}
+
 // CourseService 授業サービスの構造体
 type CourseService struct {
 	courseRepo *repositories.CourseRepository
+	sessionRepo *repositories.CourseSessionRepository
 	userService *UserService
+	auditService *AuditService
 }
 
 // NewCourseService 授業サービスのコンストラクタ
-func NewCourseService(courseRepo *repositories.CourseRepository, userService *UserService) *CourseService {
+func NewCourseService(courseRepo *repositories.CourseRepository, sessionRepo *repositories.CourseSessionRepository, userService *UserService, auditService *AuditService) *CourseService {
 	return &CourseService{
 		courseRepo: courseRepo,
+		sessionRepo: sessionRepo,
 		userService: userService,
+		auditService: auditService,
 	}
 }
 
 // CreateCourse 授業を登録する
-func (s *CourseService) CreateCourse(request *models.CreateCourseRequest, userID string) (*models.CreateCourseResponse, error) {
+func (s *CourseService) CreateCourse(request *models.CreateCourseRequest, userID string, audit models.AuditContext) (*models.CreateCourseResponse, error) {
 	// ユーザーIDのバリデーション
 	userIDInt, err := s.userService.ValidateUser(userID)
 	if err != nil {
@@ -37,9 +62,17 @@ func (s *CourseService) CreateCourse(request *models.CreateCourseRequest, userID
 		return nil, fmt.Errorf("failed to get user role: %w", err)
 	}
 
-	// 教師のみが授業を作成可能
-	if userRole != "teacher" {
-		return nil, fmt.Errorf("only teachers can create courses")
+	// 授業の作成権限をチェック
+	principal := &models.Principal{UserID: userIDInt, Role: userRole}
+	if err := policy.Authorize(principal, policy.ActionCreate, policy.Resource{Type: policy.ResourceCourse}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	// 予定の重複を無視できるのは管理者のみ
+	if request.OverrideConflicts {
+		if err := authorizeConflictOverride(principal, policy.ResourceCourse); err != nil {
+			return nil, err
+		}
 	}
 
 	// リクエストのバリデーション（エラーNo. 201）
@@ -72,18 +105,53 @@ func (s *CourseService) CreateCourse(request *models.CreateCourseRequest, userID
 		TeacherUserID: userIDInt,
 		SubjectID:     request.SubjectID,
 		ScheduledAt:   request.ScheduledAt,
+		RecurrenceRule:  &request.RecurrenceRule,
+		ExDates:         request.ExDates,
+		DurationMinutes: request.DurationMinutes,
 	}
 
-	// リポジトリを呼び出して授業を登録
-	courseID, err := s.courseRepo.CreateCourse(courseData)
+	// 繰り返しのバリデーションと授業回への展開
+	occurrences, err := applyCourseRecurrence(courseData)
 	if err != nil {
-		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+		return nil, err
+	}
+
+	// 重複のチェック・授業の登録・授業回の作成・監査ログの記録を1つのトランザクションで行う
+	var courseID int
+	var overridden int
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		// 担当教師の他の授業・テストとの重複をチェック
+		slots := courseScheduleSlots(occurrences, courseSessionDuration(courseData), nil)
+		overridden, err = checkScheduleConflicts(sessionRepo, 0, []int{userIDInt}, slots, request.OverrideConflicts)
+		if err != nil {
+			return err
+		}
+
+		// リポジトリを呼び出して授業を登録
+		courseID, err = s.courseRepo.WithTx(tx).CreateCourse(courseData)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 授業回を作成
+		if err := sessionRepo.SyncSessions(courseID, occurrences, courseSessionDuration(courseData), time.Time{}); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		courseData.CourseID = courseID
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceCourse, courseID, nil, courseData)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// レスポンスを作成
 	response := &models.CreateCourseResponse{
 		Status: "OK",
-		Info:   map[string]interface{}{},
+		Info:   scheduleConflictInfo(overridden),
 		Data: models.CourseData{
 			CourseID:      courseID,
 			TeacherUserID: userIDInt,
@@ -92,6 +160,9 @@ func (s *CourseService) CreateCourse(request *models.CreateCourseRequest, userID
 			SubjectID:     request.SubjectID,
 			ScheduledAt:   request.ScheduledAt,
 			UpdatedAt:     time.Now(),
+			RecurrenceRule:  courseData.RecurrenceRule,
+			ExDates:         courseData.ExDates,
+			DurationMinutes: courseData.DurationMinutes,
 		},
 	}
 
@@ -99,7 +170,7 @@ func (s *CourseService) CreateCourse(request *models.CreateCourseRequest, userID
 }
 
 // UpdateCourse 授業を更新する
-func (s *CourseService) UpdateCourse(courseID string, request *models.UpdateCourseRequest, userID string) (*models.UpdateCourseResponse, error) {
+func (s *CourseService) UpdateCourse(courseID string, request *models.UpdateCourseRequest, userID string, audit models.AuditContext) (*models.UpdateCourseResponse, error) {
 	// ユーザーIDのバリデーション
 	userIDInt, err := s.userService.ValidateUser(userID)
 	if err != nil {
@@ -112,11 +183,6 @@ func (s *CourseService) UpdateCourse(courseID string, request *models.UpdateCour
 		return nil, fmt.Errorf("failed to get user role: %w", err)
 	}
 
-	// 教師のみが授業を更新可能
-	if userRole != "teacher" {
-		return nil, fmt.Errorf("only teachers can update courses")
-	}
-
 	// 授業IDの型変換とバリデーション
 	courseIDInt, err := strconv.Atoi(courseID)
 	if err != nil {
@@ -133,9 +199,21 @@ func (s *CourseService) UpdateCourse(courseID string, request *models.UpdateCour
 		return nil, fmt.Errorf("failed to get course: %w", err)
 	}
 
-	// 授業の所有者かチェック
-	if existingCourse.TeacherUserID != userIDInt {
-		return nil, fmt.Errorf("you can only update your own courses")
+	// 授業の更新権限をチェック（主担当・副担当または管理者）
+	principal := &models.Principal{UserID: userIDInt, Role: userRole}
+	resource, err := courseResource(s.courseRepo, principal, policy.ResourceCourse, existingCourse.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Authorize(principal, policy.ActionUpdate, resource); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	// 予定の重複を無視できるのは管理者のみ
+	if request.OverrideConflicts {
+		if err := authorizeConflictOverride(principal, policy.ResourceCourse); err != nil {
+			return nil, err
+		}
 	}
 
 	// リクエストのバリデーション（エラーNo. 201）
@@ -168,24 +246,80 @@ func (s *CourseService) UpdateCourse(courseID string, request *models.UpdateCour
 		TeacherUserID: userIDInt,
 		SubjectID:     request.SubjectID,
 		ScheduledAt:   request.ScheduledAt,
+		RecurrenceRule:  existingCourse.RecurrenceRule,
+		ExDates:         existingCourse.ExDates,
+		DurationMinutes: existingCourse.DurationMinutes,
 	}
 
-	// リポジトリを呼び出して授業を更新
-	err = s.courseRepo.UpdateCourse(courseIDInt, courseData)
+	// 繰り返しの項目は指定されたもののみ変更する
+	if request.RecurrenceRule != nil {
+		courseData.RecurrenceRule = request.RecurrenceRule
+	}
+	if request.ExDates != nil {
+		courseData.ExDates = *request.ExDates
+	}
+	if request.DurationMinutes != nil {
+		if err := validateCourseDuration(*request.DurationMinutes); err != nil {
+			return nil, err
+		}
+		courseData.DurationMinutes = *request.DurationMinutes
+	}
+
+	// 繰り返しのバリデーションと授業回への展開
+	occurrences, err := applyCourseRecurrence(courseData)
 	if err != nil {
-		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+		return nil, err
 	}
 
-	// 更新後の授業データを取得
-	updatedCourse, err := s.courseRepo.GetCourseByID(courseIDInt)
+	// 重複のチェック・授業の更新・授業回の同期・監査ログの記録を1つのトランザクションで行う
+	var updatedCourse *models.Course
+	var overridden int
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		courseRepo := s.courseRepo.WithTx(tx)
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		// 日時が変わる回について、授業の担当・受講中の学生の他の授業・テストとの重複をチェック
+		staffUserIDs, err := courseRepo.ListStaffUserIDs(courseIDInt)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+		existingSessions, err := sessionRepo.ListSessions(courseIDInt, &repositories.CourseSessionFilter{IncludeCancelled: true})
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+		slots := courseScheduleSlots(occurrences, courseSessionDuration(courseData), existingSessions)
+		overridden, err = checkScheduleConflicts(sessionRepo, courseIDInt, staffUserIDs, slots, request.OverrideConflicts)
+		if err != nil {
+			return err
+		}
+
+		// リポジトリを呼び出して授業を更新
+		if err := courseRepo.UpdateCourse(courseIDInt, courseData); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 授業回を繰り返しに合わせる（個別に変更した回と実施済みの回はそのまま残す）
+		if err := sessionRepo.SyncSessions(courseIDInt, occurrences, courseSessionDuration(courseData), wallClockNow()); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 更新後の授業データを取得
+		updatedCourse, err = courseRepo.GetCourseByID(courseIDInt)
+		if err != nil {
+			return fmt.Errorf("failed to get updated course: %w", err)
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceCourse, courseIDInt, existingCourse, updatedCourse)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get updated course: %w", err)
+		return nil, err
 	}
 
 	// レスポンスを作成
 	response := &models.UpdateCourseResponse{
 		Status: "OK",
-		Info:   map[string]interface{}{},
+		Info:   scheduleConflictInfo(overridden),
 		Data: models.CourseData{
 			CourseID:      updatedCourse.CourseID,
 			TeacherUserID: updatedCourse.TeacherUserID,
@@ -194,8 +328,261 @@ func (s *CourseService) UpdateCourse(courseID string, request *models.UpdateCour
 			SubjectID:     updatedCourse.SubjectID,
 			ScheduledAt:   updatedCourse.ScheduledAt,
 			UpdatedAt:     updatedCourse.UpdatedAt,
+			RecurrenceRule:  updatedCourse.RecurrenceRule,
+			ExDates:         updatedCourse.ExDates,
+			DurationMinutes: updatedCourse.DurationMinutes,
 		},
 	}
 
 	return response, nil
-} 
\ No newline at end of file
+}
+
+// ListCourses 授業の一覧を取得する
+// 管理者はすべての授業、教師は担当授業（副担当・アシスタントを含む）、学生は受講中の授業のみ取得できる
+func (s *CourseService) ListCourses(userID string, request *models.CourseListRequest) (*models.CourseListResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionList, policy.Resource{Type: policy.ResourceCourse}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	// 検索条件のバリデーション
+	filter, err := buildCourseFilter(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// 役割に応じて参照範囲を決定
+	switch principal.Role {
+	case models.RoleTeacher:
+		if filter.TeacherUserID != nil && *filter.TeacherUserID != principal.UserID {
+			return nil, fmt.Errorf("access denied: %s: teachers can only list their own courses", models.ErrorMessageForbidden)
+		}
+		filter.TeacherUserID = &principal.UserID
+	case models.RoleStudent:
+		filter.StudentUserID = &principal.UserID
+	}
+	filter.IncludeDraftTests = principal.Role != models.RoleStudent
+
+	courses, total, err := s.courseRepo.ListCourses(filter)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if courses == nil {
+		courses = []models.CourseDetailResponse{}
+	}
+
+	return &models.CourseListResponse{
+		Count:   len(courses),
+		Total:   total,
+		Courses: courses,
+	}, nil
+}
+
+// GetCourse 授業の詳細を取得する
+func (s *CourseService) GetCourse(userID string, courseID string) (*models.CourseDetailResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	courseIDInt, err := strconv.Atoi(courseID)
+	if err != nil || courseIDInt <= 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid course ID")
+	}
+
+	course, err := s.courseRepo.GetCourseDetail(courseIDInt, principal.Role != models.RoleStudent)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if course == nil {
+		return nil, fmt.Errorf("course not found")
+	}
+
+	// 授業の担当・受講中の学生のみ参照できる
+	resource, err := courseResource(s.courseRepo, principal, policy.ResourceCourse, courseIDInt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionRead, resource); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	return course, nil
+}
+
+// buildCourseFilter リクエストから授業一覧の検索条件を作成する
+func buildCourseFilter(request *models.CourseListRequest) (*repositories.CourseFilter, error) {
+	filter := &repositories.CourseFilter{
+		SubjectID:     request.SubjectID,
+		TeacherUserID: request.TeacherUserID,
+		Keyword:       strings.TrimSpace(request.Keyword),
+		Limit:         request.Limit,
+		Offset:        request.Offset,
+	}
+
+	sort := request.Sort
+	if sort == "" {
+		sort = "scheduled_at"
+	}
+	orderBy, ok := courseSortOrders[sort]
+	if !ok {
+		return nil, fmt.Errorf("入力値エラーがあります: sort must be one of scheduled_at, title, created_at, updated_at (prefix - for descending)")
+	}
+	filter.OrderBy = orderBy
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultCourseListLimit
+	}
+	if filter.Limit > maxCourseListLimit {
+		filter.Limit = maxCourseListLimit
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: offset must not be negative")
+	}
+
+	var err error
+	if filter.From, err = parseFilterTime(request.From, "from", false); err != nil {
+		return nil, err
+	}
+	if filter.To, err = parseFilterTime(request.To, "to", true); err != nil {
+		return nil, err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("入力値エラーがあります: from must be before to")
+	}
+
+	return filter, nil
+}
+
+// DeleteCourse 授業を論理削除する（主担当または管理者）
+// テスト・動画は連動して論理削除し、成績・出席は保持する
+// 成績か提出済みの解答がある場合は、forceを指定しない限り削除しない
+func (s *CourseService) DeleteCourse(userID string, courseID string, request *models.DeleteCourseRequest, audit models.AuditContext) (*models.CourseCascadeResponse, error) {
+	principal, course, err := s.getCourseForCascade(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if course.IsDeleted {
+		return nil, fmt.Errorf("course not found")
+	}
+
+	if err := s.authorizeCourse(principal, policy.ActionDelete, course); err != nil {
+		return nil, err
+	}
+
+	var result *models.CourseCascadeResult
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		result, err = s.courseRepo.WithTx(tx).DeleteCourse(course.CourseID, principal.UserID, request.Force)
+		if err != nil {
+			if strings.Contains(err.Error(), "course not found") || strings.Contains(err.Error(), "course has graded work") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionDelete, policy.ResourceCourse, course.CourseID, course, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CourseCascadeResponse{
+		Status: "OK",
+		Data:   *result,
+	}, nil
+}
+
+// RestoreCourse 論理削除した授業を復元する（主担当または管理者）
+// 授業の削除に連動して削除したテスト・動画も復元する
+func (s *CourseService) RestoreCourse(userID string, courseID string, audit models.AuditContext) (*models.CourseCascadeResponse, error) {
+	principal, course, err := s.getCourseForCascade(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeCourse(principal, policy.ActionRestore, course); err != nil {
+		return nil, err
+	}
+
+	if !course.IsDeleted {
+		return nil, fmt.Errorf("course not deleted")
+	}
+
+	// 削除済みの教科を参照する授業は復元しない
+	subjectExists, err := s.courseRepo.SubjectExists(course.SubjectID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if !subjectExists {
+		return nil, fmt.Errorf("教科情報が存在しません")
+	}
+
+	var result *models.CourseCascadeResult
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		result, err = s.courseRepo.WithTx(tx).RestoreCourse(course.CourseID)
+		if err != nil {
+			if strings.Contains(err.Error(), "course not deleted") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionRestore, policy.ResourceCourse, course.CourseID, course, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CourseCascadeResponse{
+		Status: "OK",
+		Data:   *result,
+	}, nil
+}
+
+// getCourseForCascade 削除・復元の対象授業を論理削除済みを含めて取得する
+func (s *CourseService) getCourseForCascade(userID string, courseID string) (*models.Principal, *models.Course, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	courseIDInt, err := strconv.Atoi(courseID)
+	if err != nil || courseIDInt <= 0 {
+		return nil, nil, fmt.Errorf("入力値エラーがあります: invalid course ID")
+	}
+
+	course, err := s.courseRepo.GetCourseIncludingDeleted(courseIDInt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if course == nil {
+		return nil, nil, fmt.Errorf("course not found")
+	}
+
+	return principal, course, nil
+}
+
+// authorizeCourse 授業に対する操作の権限をチェックする
+func (s *CourseService) authorizeCourse(principal *models.Principal, action policy.Action, course *models.Course) error {
+	resource, err := courseResource(s.courseRepo, principal, policy.ResourceCourse, course.CourseID)
+	if err != nil {
+		return err
+	}
+	if err := policy.Authorize(principal, action, resource); err != nil {
+		return fmt.Errorf("access denied: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/ical"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/recurrence"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// maxCourseSessions 1つの授業で展開できる授業回の上限
+const maxCourseSessions = 500
+
+// 授業1回あたりの時間（分）
+const (
+	defaultCourseDurationMinutes = 60
+	maxCourseDurationMinutes     = 24 * 60
+)
+
+// CourseSessionService 授業回サービスの構造体
+type CourseSessionService struct {
+	sessionRepo    *repositories.CourseSessionRepository
+	courseRepo     *repositories.CourseRepository
+	attendanceRepo *repositories.AttendanceRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+	userService    *UserService
+	auditService   *AuditService
+}
+
+// NewCourseSessionService 授業回サービスのコンストラクタ
+func NewCourseSessionService(sessionRepo *repositories.CourseSessionRepository, courseRepo *repositories.CourseRepository, attendanceRepo *repositories.AttendanceRepository, enrollmentRepo *repositories.EnrollmentRepository, userService *UserService, auditService *AuditService) *CourseSessionService {
+	return &CourseSessionService{
+		sessionRepo:    sessionRepo,
+		courseRepo:     courseRepo,
+		attendanceRepo: attendanceRepo,
+		enrollmentRepo: enrollmentRepo,
+		userService:    userService,
+		auditService:   auditService,
+	}
+}
+
+// ListSessions 授業の授業回を開始日時順で取得する（担当教師・受講中の学生・管理者）
+func (s *CourseSessionService) ListSessions(userID string, courseID string, request *models.CourseSessionListRequest) (*models.CourseSessionListResponse, error) {
+	_, course, err := s.authorize(userID, courseID, policy.ResourceSession, policy.ActionList)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &repositories.CourseSessionFilter{IncludeCancelled: request.IncludeCancelled}
+	if filter.From, err = parseFilterTime(request.From, "from", false); err != nil {
+		return nil, err
+	}
+	if filter.To, err = parseFilterTime(request.To, "to", true); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.ListSessions(course.CourseID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if sessions == nil {
+		sessions = []models.CourseSession{}
+	}
+
+	return &models.CourseSessionListResponse{
+		Count:    len(sessions),
+		Sessions: sessions,
+	}, nil
+}
+
+// UpdateSession 授業回を個別に移動・休講する（担当教師または管理者）
+// 変更した回は繰り返しの対象から外れず、以降の繰り返しの変更でも日時・状態を上書きしない
+func (s *CourseSessionService) UpdateSession(userID string, courseID string, sessionID string, request *models.CourseSessionUpdateRequest, audit models.AuditContext) (*models.CourseSession, error) {
+	principal, course, err := s.authorize(userID, courseID, policy.ResourceSession, policy.ActionUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	// 予定の重複を無視できるのは管理者のみ
+	if request.OverrideConflicts {
+		if err := authorizeConflictOverride(principal, policy.ResourceSession); err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := s.getSession(s.sessionRepo, course.CourseID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.StartsAt == nil && request.DurationMinutes == nil && request.Status == nil && request.Note == nil {
+		return nil, fmt.Errorf("入力値エラーがあります: at least one of starts_at, duration_minutes, status or note is required")
+	}
+
+	session := *existing
+	duration := existing.EndsAt.Sub(existing.StartsAt)
+	if request.DurationMinutes != nil {
+		if err := validateCourseDuration(*request.DurationMinutes); err != nil {
+			return nil, err
+		}
+		duration = time.Duration(*request.DurationMinutes) * time.Minute
+	}
+	if request.StartsAt != nil {
+		session.StartsAt = recurrence.WallClock(*request.StartsAt)
+	}
+	session.EndsAt = session.StartsAt.Add(duration)
+
+	if request.Status != nil {
+		switch *request.Status {
+		case models.CourseSessionStatusScheduled, models.CourseSessionStatusCancelled:
+			session.Status = *request.Status
+		default:
+			return nil, fmt.Errorf("入力値エラーがあります: status must be one of scheduled, cancelled")
+		}
+	}
+	if request.Note != nil {
+		session.Note = strings.TrimSpace(*request.Note)
+	}
+
+	var updated *models.CourseSession
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		// 日時を変更する・休講を取り消す場合は、授業の担当・受講中の学生の他の授業・テストとの重複をチェック
+		unchanged := existing.Status == models.CourseSessionStatusScheduled && session.StartsAt.Equal(existing.StartsAt) && session.EndsAt.Equal(existing.EndsAt)
+		if session.Status == models.CourseSessionStatusScheduled && !unchanged {
+			staffUserIDs, err := s.courseRepo.WithTx(tx).ListStaffUserIDs(course.CourseID)
+			if err != nil {
+				return fmt.Errorf("データベースエラーが発生しました: %v", err)
+			}
+			slots := []models.ScheduleSlot{{StartsAt: session.StartsAt, EndsAt: session.EndsAt}}
+			if _, err := checkScheduleConflicts(sessionRepo, course.CourseID, staffUserIDs, slots, request.OverrideConflicts); err != nil {
+				return err
+			}
+		}
+
+		if err := sessionRepo.UpdateSession(&session); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		updated, err = s.getSession(sessionRepo, course.CourseID, sessionID)
+		if err != nil {
+			return err
+		}
+
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceSession, updated.SessionID, existing, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// ListSessionAttendances 授業回の出席を受講者ごとに取得する（担当教師・管理者）
+func (s *CourseSessionService) ListSessionAttendances(userID string, courseID string, sessionID string) (*models.SessionAttendanceListResponse, error) {
+	_, course, err := s.authorize(userID, courseID, policy.ResourceAttendance, policy.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.getSession(s.sessionRepo, course.CourseID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildSessionAttendanceList(s.attendanceRepo, session)
+}
+
+// RecordSessionAttendances 授業回の出席を記録する（担当教師のみ）
+// 指定した学生の出席を登録し、記録済みの学生は状態を更新する
+func (s *CourseSessionService) RecordSessionAttendances(userID string, courseID string, sessionID string, request *models.SessionAttendanceRequest, audit models.AuditContext) (*models.SessionAttendanceListResponse, error) {
+	_, course, err := s.authorize(userID, courseID, policy.ResourceAttendance, policy.ActionCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.getSession(s.sessionRepo, course.CourseID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status == models.CourseSessionStatusCancelled {
+		return nil, fmt.Errorf("session cancelled")
+	}
+
+	if len(request.Attendances) == 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: attendances is required")
+	}
+
+	// 出席を記録できるのは受講中・修了の学生のみ
+	enrollments, err := s.enrollmentRepo.ListByCourse(course.CourseID, "")
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+	enrolled := map[int]bool{}
+	for _, enrollment := range enrollments {
+		if enrollment.Status != models.EnrollmentStatusDropped {
+			enrolled[enrollment.StudentUserID] = true
+		}
+	}
+
+	seen := map[int]bool{}
+	for i, entry := range request.Attendances {
+		if !enrolled[entry.StudentUserID] {
+			return nil, fmt.Errorf("入力値エラーがあります: attendances[%d]: student %d is not enrolled in the course", i, entry.StudentUserID)
+		}
+		if seen[entry.StudentUserID] {
+			return nil, fmt.Errorf("入力値エラーがあります: attendances[%d]: duplicate student %d", i, entry.StudentUserID)
+		}
+		seen[entry.StudentUserID] = true
+		if !attendanceStatuses[entry.Status] {
+			return nil, fmt.Errorf("入力値エラーがあります: attendances[%d]: status must be one of present, absent, late", i)
+		}
+	}
+
+	var after *models.SessionAttendanceListResponse
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		attendanceRepo := s.attendanceRepo.WithTx(tx)
+
+		before, err := buildSessionAttendanceList(attendanceRepo, session)
+		if err != nil {
+			return err
+		}
+
+		if err := attendanceRepo.RecordSessionAttendances(session, request.Attendances); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		after, err = buildSessionAttendanceList(attendanceRepo, session)
+		if err != nil {
+			return err
+		}
+
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceAttendance, session.SessionID, before.Attendances, after.Attendances)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// buildSessionAttendanceList 授業回の出席一覧のレスポンスを作成する
+func buildSessionAttendanceList(attendanceRepo *repositories.AttendanceRepository, session *models.CourseSession) (*models.SessionAttendanceListResponse, error) {
+	attendances, err := attendanceRepo.ListSessionAttendances(session.CourseID, session.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if attendances == nil {
+		attendances = []models.SessionAttendanceResponse{}
+	}
+
+	return &models.SessionAttendanceListResponse{
+		Session:     *session,
+		Count:       len(attendances),
+		Attendances: attendances,
+	}, nil
+}
+
+// getSession 授業の授業回を取得する
+func (s *CourseSessionService) getSession(sessionRepo *repositories.CourseSessionRepository, courseID int, sessionID string) (*models.CourseSession, error) {
+	sessionIDInt, err := strconv.Atoi(sessionID)
+	if err != nil || sessionIDInt <= 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid session ID")
+	}
+
+	session, err := sessionRepo.GetSession(courseID, sessionIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if session == nil {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	return session, nil
+}
+
+// authorize 授業回・出席に対する操作の権限をチェックし、プリンシパルと授業を返す
+func (s *CourseSessionService) authorize(userID string, courseID string, resourceType policy.ResourceType, action policy.Action) (*models.Principal, *models.Course, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	courseIDInt, err := strconv.Atoi(courseID)
+	if err != nil || courseIDInt <= 0 {
+		return nil, nil, fmt.Errorf("入力値エラーがあります: invalid course ID")
+	}
+
+	course, err := s.courseRepo.GetCourseIncludingDeleted(courseIDInt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if course == nil || course.IsDeleted {
+		return nil, nil, fmt.Errorf("course not found")
+	}
+
+	resource, err := courseResource(s.courseRepo, principal, resourceType, courseIDInt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := policy.Authorize(principal, action, resource); err != nil {
+		return nil, nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	return principal, course, nil
+}
+
+// courseOccurrences 授業の繰り返しを展開した日時を返す（繰り返しがない場合はscheduled_atの1回のみ）
+func courseOccurrences(course *models.Course) ([]time.Time, error) {
+	dtstart := recurrence.WallClock(course.ScheduledAt)
+	if course.RecurrenceRule == nil {
+		return []time.Time{dtstart}, nil
+	}
+
+	rule, err := recurrence.Parse(*course.RecurrenceRule, ical.Location)
+	if err != nil {
+		return nil, fmt.Errorf("入力値エラーがあります: recurrence_rule: %v", err)
+	}
+
+	exDates := make([]time.Time, 0, len(course.ExDates))
+	for _, exDate := range course.ExDates {
+		exDates = append(exDates, recurrence.WallClock(exDate))
+	}
+
+	occurrences, err := rule.Expand(dtstart, exDates, maxCourseSessions)
+	if err != nil {
+		return nil, fmt.Errorf("入力値エラーがあります: recurrence_rule: %v", err)
+	}
+	if len(occurrences) == 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: recurrence_rule produces no sessions")
+	}
+
+	return occurrences, nil
+}
+
+// wallClockNow 授業の日時と比較できるよう、授業のタイムゾーンでの現在の壁時計の時刻を返す
+func wallClockNow() time.Time {
+	return recurrence.WallClock(time.Now().In(ical.Location))
+}
+
+// applyCourseRecurrence 授業の繰り返しの項目を正規化して検証し、展開した日時を返す
+// 空のRRULEは繰り返しなし、未指定の時間は既定値として扱う
+func applyCourseRecurrence(course *models.Course) ([]time.Time, error) {
+	if course.RecurrenceRule != nil {
+		rule := strings.TrimPrefix(strings.TrimSpace(*course.RecurrenceRule), "RRULE:")
+		if rule == "" {
+			course.RecurrenceRule = nil
+		} else {
+			course.RecurrenceRule = &rule
+		}
+	}
+	if course.RecurrenceRule == nil {
+		course.ExDates = nil
+	}
+
+	if course.DurationMinutes == 0 {
+		course.DurationMinutes = defaultCourseDurationMinutes
+	}
+	if err := validateCourseDuration(course.DurationMinutes); err != nil {
+		return nil, err
+	}
+
+	return courseOccurrences(course)
+}
+
+// validateCourseDuration 授業1回あたりの時間（分）をチェックする
+func validateCourseDuration(minutes int) error {
+	if minutes < 1 || minutes > maxCourseDurationMinutes {
+		return fmt.Errorf("入力値エラーがあります: duration_minutes must be between 1 and %d", maxCourseDurationMinutes)
+	}
+	return nil
+}
+
+// courseSessionDuration 授業1回あたりの時間
+func courseSessionDuration(course *models.Course) time.Duration {
+	return time.Duration(course.DurationMinutes) * time.Minute
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// CourseStaffService 授業の担当教師サービスの構造体
+type CourseStaffService struct {
+	staffRepo    *repositories.CourseStaffRepository
+	courseRepo   *repositories.CourseRepository
+	userRepo     *repositories.UserRepository
+	userService  *UserService
+	auditService *AuditService
+}
+
+// NewCourseStaffService 授業の担当教師サービスのコンストラクタ
+func NewCourseStaffService(staffRepo *repositories.CourseStaffRepository, courseRepo *repositories.CourseRepository, userRepo *repositories.UserRepository, userService *UserService, auditService *AuditService) *CourseStaffService {
+	return &CourseStaffService{
+		staffRepo:    staffRepo,
+		courseRepo:   courseRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		auditService: auditService,
+	}
+}
+
+// ListStaff 授業の担当教師の一覧を取得する（授業の担当または管理者）
+func (s *CourseStaffService) ListStaff(userID string, courseID string) (*models.CourseStaffListResponse, error) {
+	courseIDInt, err := s.authorize(userID, courseID, policy.ActionList)
+	if err != nil {
+		return nil, err
+	}
+
+	staff, err := s.staffRepo.ListStaff(courseIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if staff == nil {
+		staff = []models.CourseStaffResponse{}
+	}
+
+	return &models.CourseStaffListResponse{
+		Count: len(staff),
+		Staff: staff,
+	}, nil
+}
+
+// AddStaff 教師を副担当またはアシスタントとして授業に追加する（主担当または管理者）
+func (s *CourseStaffService) AddStaff(userID string, courseID string, request *models.CourseStaffCreateRequest, audit models.AuditContext) (*models.CourseStaffResponse, error) {
+	courseIDInt, err := s.authorize(userID, courseID, policy.ActionCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.UserID <= 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: user_id is required")
+	}
+
+	if request.Role != models.CourseStaffRoleCoTeacher && request.Role != models.CourseStaffRoleAssistant {
+		return nil, fmt.Errorf("入力値エラーがあります: role must be one of co_teacher, assistant")
+	}
+
+	if err := s.validateTeacher(request.UserID); err != nil {
+		return nil, err
+	}
+
+	var staff *models.CourseStaffResponse
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		staffRepo := s.staffRepo.WithTx(tx)
+
+		if err := staffRepo.AddStaff(courseIDInt, request.UserID, request.Role); err != nil {
+			if strings.Contains(err.Error(), "staff already exists") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		staff, err = getStaff(staffRepo, courseIDInt, request.UserID)
+		if err != nil {
+			return err
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceStaff, courseIDInt, nil, staff)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return staff, nil
+}
+
+// UpdateStaff 担当を変更する（主担当または管理者）
+// ownerを指定した場合は主担当を交代し、以前の主担当は副担当になる。主担当を直接変更することはできない
+func (s *CourseStaffService) UpdateStaff(userID string, courseID string, staffUserID string, request *models.CourseStaffUpdateRequest, audit models.AuditContext) (*models.CourseStaffResponse, error) {
+	courseIDInt, err := s.authorize(userID, courseID, policy.ActionUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	switch request.Role {
+	case models.CourseStaffRoleOwner, models.CourseStaffRoleCoTeacher, models.CourseStaffRoleAssistant:
+	default:
+		return nil, fmt.Errorf("入力値エラーがあります: role must be one of owner, co_teacher, assistant")
+	}
+
+	staffUserIDInt, err := parseTargetUserID(staffUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := getStaff(s.staffRepo, courseIDInt, staffUserIDInt)
+	if err != nil {
+		return nil, err
+	}
+
+	if before.Role == request.Role {
+		return before, nil
+	}
+
+	if before.Role == models.CourseStaffRoleOwner {
+		return nil, fmt.Errorf("course must have an owner: transfer ownership to another staff member first")
+	}
+
+	if request.Role == models.CourseStaffRoleOwner {
+		// 主担当は教師の役割を持つユーザーのみ
+		if err := s.validateTeacher(staffUserIDInt); err != nil {
+			return nil, err
+		}
+	}
+
+	var after *models.CourseStaffResponse
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		staffRepo := s.staffRepo.WithTx(tx)
+
+		if request.Role == models.CourseStaffRoleOwner {
+			err = staffRepo.TransferOwnership(courseIDInt, staffUserIDInt)
+		} else {
+			err = staffRepo.UpdateRole(courseIDInt, staffUserIDInt, request.Role)
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "course must have an owner") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		after, err = getStaff(staffRepo, courseIDInt, staffUserIDInt)
+		if err != nil {
+			return err
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceStaff, courseIDInt, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// RemoveStaff 担当を授業から外す（主担当または管理者）。主担当は外せない
+func (s *CourseStaffService) RemoveStaff(userID string, courseID string, staffUserID string, audit models.AuditContext) error {
+	courseIDInt, err := s.authorize(userID, courseID, policy.ActionDelete)
+	if err != nil {
+		return err
+	}
+
+	staffUserIDInt, err := parseTargetUserID(staffUserID)
+	if err != nil {
+		return err
+	}
+
+	before, err := getStaff(s.staffRepo, courseIDInt, staffUserIDInt)
+	if err != nil {
+		return err
+	}
+
+	if before.Role == models.CourseStaffRoleOwner {
+		return fmt.Errorf("course must have an owner: transfer ownership to another staff member first")
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.staffRepo.WithTx(tx).RemoveStaff(courseIDInt, staffUserIDInt); err != nil {
+			if strings.Contains(err.Error(), "course must have an owner") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionDelete, policy.ResourceStaff, courseIDInt, before, nil)
+	})
+}
+
+// authorize 授業の担当教師に対する操作の権限をチェックし、授業IDを返す
+func (s *CourseStaffService) authorize(userID string, courseID string, action policy.Action) (int, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	courseIDInt, err := strconv.Atoi(courseID)
+	if err != nil || courseIDInt <= 0 {
+		return 0, fmt.Errorf("入力値エラーがあります: invalid course ID")
+	}
+
+	course, err := s.courseRepo.GetCourseIncludingDeleted(courseIDInt)
+	if err != nil {
+		return 0, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if course == nil || course.IsDeleted {
+		return 0, fmt.Errorf("course not found")
+	}
+
+	resource, err := courseResource(s.courseRepo, principal, policy.ResourceStaff, courseIDInt)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := policy.Authorize(principal, action, resource); err != nil {
+		return 0, fmt.Errorf("access denied: %w", err)
+	}
+
+	return courseIDInt, nil
+}
+
+// validateTeacher 担当にするユーザーが有効な教師かチェックする
+func (s *CourseStaffService) validateTeacher(userID int) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil || user.Role != models.RoleTeacher {
+		return fmt.Errorf("入力値エラーがあります: user_id must be an active teacher")
+	}
+
+	return nil
+}
+
+// getStaff 授業と教師の担当を取得する
+func getStaff(staffRepo *repositories.CourseStaffRepository, courseID int, userID int) (*models.CourseStaffResponse, error) {
+	staff, err := staffRepo.GetStaff(courseID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if staff == nil {
+		return nil, fmt.Errorf("staff not found")
+	}
+
+	return staff, nil
+}
+
+// courseResource 授業に対する認可判定のリソースを作成する
+// 教師は授業での担当、学生は受講しているかを設定する
+func courseResource(courseRepo *repositories.CourseRepository, principal *models.Principal, resourceType policy.ResourceType, courseID int) (policy.Resource, error) {
+	resource := policy.Resource{Type: resourceType}
+
+	var err error
+	switch principal.Role {
+	case models.RoleTeacher:
+		resource.CourseRole, err = courseRepo.GetStaffRole(courseID, principal.UserID)
+	case models.RoleStudent:
+		resource.IsEnrolled, err = courseRepo.IsEnrolled(courseID, principal.UserID)
+	}
+	if err != nil {
+		return resource, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return resource, nil
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"github.com/tomoki-den-uhd/go-study/internal/storage"
+)
+
+// videoContentTypes アップロードできる動画の拡張子とContent-Type
+var videoContentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/x-m4v",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".ogv":  "video/ogg",
+}
+
+// maxVideoDurationSeconds 設定できる動画の長さの上限（秒）
+const maxVideoDurationSeconds = 24 * 60 * 60
+
+// VideoStream 配信する動画とその内容（呼び出し側でContentを閉じること）
+type VideoStream struct {
+	Video   *models.CourseVideo
+	ModTime time.Time
+	Content io.ReadSeekCloser
+}
+
+// CourseVideoService 授業動画サービスの構造体
+type CourseVideoService struct {
+	videoRepo      *repositories.CourseVideoRepository
+	courseRepo     *repositories.CourseRepository
+	store          storage.Storage
+	userService    *UserService
+	auditService   *AuditService
+	maxUploadBytes int64
+}
+
+// NewCourseVideoService 授業動画サービスのコンストラクタ
+func NewCourseVideoService(videoRepo *repositories.CourseVideoRepository, courseRepo *repositories.CourseRepository, store storage.Storage, userService *UserService, auditService *AuditService, maxUploadBytes int64) *CourseVideoService {
+	return &CourseVideoService{
+		videoRepo:      videoRepo,
+		courseRepo:     courseRepo,
+		store:          store,
+		userService:    userService,
+		auditService:   auditService,
+		maxUploadBytes: maxUploadBytes,
+	}
+}
+
+// MaxUploadBytes アップロードできる動画の最大サイズ
+func (s *CourseVideoService) MaxUploadBytes() int64 {
+	return s.maxUploadBytes
+}
+
+// UploadVideo 授業に動画をアップロードする（担当教師のみ）
+// durationは動画の長さ（秒）で、受講者の視聴率の計算に使う
+func (s *CourseVideoService) UploadVideo(ctx context.Context, userID string, courseID string, filename string, duration string, content io.Reader, size int64, audit models.AuditContext) (*models.CourseVideo, error) {
+	principal, courseIDInt, err := s.authorize(userID, courseID, policy.ActionCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	filename, contentType, err := s.validateVideoFile(filename, size)
+	if err != nil {
+		return nil, err
+	}
+
+	durationSeconds, err := parseVideoDuration(duration)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveVideo(ctx, principal, courseIDInt, filename, contentType, durationSeconds, content, size, audit, nil)
+}
+
+// validateVideoFile ファイル名とサイズをチェックし、保存するファイル名とContent-Typeを返す
+func (s *CourseVideoService) validateVideoFile(filename string, size int64) (string, string, error) {
+	filename = filepath.Base(strings.TrimSpace(filename))
+	contentType, ok := videoContentTypes[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return "", "", fmt.Errorf("入力値エラーがあります: file must be a video (.mp4, .m4v, .mov, .webm, .ogv)")
+	}
+
+	if size <= 0 {
+		return "", "", fmt.Errorf("入力値エラーがあります: file is empty")
+	}
+	if size > s.maxUploadBytes {
+		return "", "", fmt.Errorf("video too large: maximum size is %d bytes", s.maxUploadBytes)
+	}
+
+	return filename, contentType, nil
+}
+
+// saveVideo ストレージに保存してから登録し、登録に失敗した場合は保存したファイルを削除する
+// onCreatedを指定した場合は、動画の登録と同じトランザクションで呼び出す
+func (s *CourseVideoService) saveVideo(ctx context.Context, principal *models.Principal, courseID int, filename string, contentType string, durationSeconds float64, content io.Reader, size int64, audit models.AuditContext, onCreated func(tx pgx.Tx, video *models.CourseVideo) error) (*models.CourseVideo, error) {
+	token, _, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("courses/%d/videos/%s%s", courseID, token[:32], strings.ToLower(filepath.Ext(filename)))
+
+	if err := s.store.Put(ctx, key, content, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store video: %w", err)
+	}
+
+	video := &models.CourseVideo{
+		CourseID:        courseID,
+		Filename:        filename,
+		StorageKey:      key,
+		ContentType:     contentType,
+		SizeBytes:       size,
+		UploadedBy:      &principal.UserID,
+		DurationSeconds: &durationSeconds,
+	}
+
+	var created *models.CourseVideo
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		videoRepo := s.videoRepo.WithTx(tx)
+
+		videoID, err := videoRepo.CreateVideo(video)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		created, err = s.getVideo(videoRepo, courseID, videoID)
+		if err != nil {
+			return err
+		}
+
+		if onCreated != nil {
+			if err := onCreated(tx, created); err != nil {
+				return err
+			}
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceVideo, videoID, nil, created)
+	})
+	if err != nil {
+		s.store.Delete(context.Background(), key)
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// ListVideos 授業の動画一覧を取得する（担当教師・受講中の学生・管理者）
+func (s *CourseVideoService) ListVideos(userID string, courseID string) (*models.CourseVideoListResponse, error) {
+	_, courseIDInt, err := s.authorize(userID, courseID, policy.ActionList)
+	if err != nil {
+		return nil, err
+	}
+
+	videos, err := s.videoRepo.ListVideos(courseIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if videos == nil {
+		videos = []models.CourseVideo{}
+	}
+
+	return &models.CourseVideoListResponse{
+		Count:  len(videos),
+		Videos: videos,
+	}, nil
+}
+
+// OpenVideo 配信する動画を開く（担当教師・受講中の学生・管理者）
+func (s *CourseVideoService) OpenVideo(ctx context.Context, userID string, courseID string, videoID string) (*VideoStream, error) {
+	_, courseIDInt, err := s.authorize(userID, courseID, policy.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	videoIDInt, err := parseVideoID(videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := s.getVideo(s.videoRepo, courseIDInt, videoIDInt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 保存先のない（アップロード機能以前に登録された）動画は配信できない
+	if video.StorageKey == "" {
+		return nil, fmt.Errorf("video not found")
+	}
+
+	info, err := s.store.Stat(ctx, video.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("video not found: stored file is missing")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open video: %w", err)
+	}
+
+	return &VideoStream{
+		Video:   video,
+		ModTime: info.ModTime,
+		Content: storage.NewReader(ctx, s.store, video.StorageKey, info.Size),
+	}, nil
+}
+
+// UpdateVideo 授業の動画の長さを設定する（担当教師または管理者）
+// 長さが不明なまま登録された動画や、誤って登録した長さを修正するために使う
+func (s *CourseVideoService) UpdateVideo(userID string, courseID string, videoID string, request *models.CourseVideoUpdateRequest, audit models.AuditContext) (*models.CourseVideo, error) {
+	_, courseIDInt, err := s.authorize(userID, courseID, policy.ActionUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	videoIDInt, err := parseVideoID(videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.DurationSeconds == nil {
+		return nil, fmt.Errorf("入力値エラーがあります: duration_seconds is required")
+	}
+	if err := validateVideoDuration(*request.DurationSeconds); err != nil {
+		return nil, err
+	}
+
+	before, err := s.getVideo(s.videoRepo, courseIDInt, videoIDInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *models.CourseVideo
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		videoRepo := s.videoRepo.WithTx(tx)
+
+		if err := videoRepo.UpdateDuration(courseIDInt, videoIDInt, *request.DurationSeconds); err != nil {
+			if strings.Contains(err.Error(), "video not found") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		after, err = s.getVideo(videoRepo, courseIDInt, videoIDInt)
+		if err != nil {
+			return err
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceVideo, videoIDInt, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// DeleteVideo 授業の動画を論理削除する（担当教師または管理者）
+// 授業の復元と同様に戻せるよう、ストレージ上のファイルは削除しない
+func (s *CourseVideoService) DeleteVideo(userID string, courseID string, videoID string, audit models.AuditContext) error {
+	_, courseIDInt, err := s.authorize(userID, courseID, policy.ActionDelete)
+	if err != nil {
+		return err
+	}
+
+	videoIDInt, err := parseVideoID(videoID)
+	if err != nil {
+		return err
+	}
+
+	video, err := s.getVideo(s.videoRepo, courseIDInt, videoIDInt)
+	if err != nil {
+		return err
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.videoRepo.WithTx(tx).DeleteVideo(courseIDInt, videoIDInt); err != nil {
+			if strings.Contains(err.Error(), "video not found") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		deleted := *video
+		deleted.IsDeleted = true
+		return s.auditService.Record(tx, audit, models.AuditActionDelete, policy.ResourceVideo, videoIDInt, video, &deleted)
+	})
+}
+
+// getVideo 授業の動画を取得する
+func (s *CourseVideoService) getVideo(videoRepo *repositories.CourseVideoRepository, courseID int, videoID int) (*models.CourseVideo, error) {
+	video, err := videoRepo.GetVideo(courseID, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if video == nil {
+		return nil, fmt.Errorf("video not found")
+	}
+
+	return video, nil
+}
+
+// authorize 授業の動画に対する操作の権限をチェックし、プリンシパルと授業IDを返す
+func (s *CourseVideoService) authorize(userID string, courseID string, action policy.Action) (*models.Principal, int, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	courseIDInt, err := strconv.Atoi(courseID)
+	if err != nil || courseIDInt <= 0 {
+		return nil, 0, fmt.Errorf("入力値エラーがあります: invalid course ID")
+	}
+
+	course, err := s.courseRepo.GetCourseIncludingDeleted(courseIDInt)
+	if err != nil {
+		return nil, 0, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if course == nil || course.IsDeleted {
+		return nil, 0, fmt.Errorf("course not found")
+	}
+
+	resource, err := courseResource(s.courseRepo, principal, policy.ResourceVideo, courseIDInt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := policy.Authorize(principal, action, resource); err != nil {
+		return nil, 0, fmt.Errorf("access denied: %w", err)
+	}
+
+	return principal, courseIDInt, nil
+}
+
+// parseVideoDuration アップロード時に指定された動画の長さ（秒）を解析する
+func parseVideoDuration(value string) (float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, fmt.Errorf("入力値エラーがあります: duration_seconds is required")
+	}
+
+	durationSeconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("入力値エラーがあります: duration_seconds must be a number")
+	}
+
+	if err := validateVideoDuration(durationSeconds); err != nil {
+		return 0, err
+	}
+
+	return durationSeconds, nil
+}
+
+// validateVideoDuration 動画の長さ（秒）が正の数で上限以内かチェックする
+func validateVideoDuration(durationSeconds float64) error {
+	if !validSeconds(durationSeconds) || durationSeconds == 0 || durationSeconds > maxVideoDurationSeconds {
+		return fmt.Errorf("入力値エラーがあります: duration_seconds must be greater than 0 and at most %d", maxVideoDurationSeconds)
+	}
+	return nil
+}
+
+// parseVideoID パスパラメータの動画IDを解析する
+func parseVideoID(videoID string) (int, error) {
+	videoIDInt, err := strconv.Atoi(videoID)
+	if err != nil || videoIDInt <= 0 {
+		return 0, fmt.Errorf("入力値エラーがあります: invalid video ID")
+	}
+	return videoIDInt, nil
+}
@@ -0,0 +1,19 @@
+package services
+
+import "reflect"
+
+// testRow テスト用のDBTXが返すQueryRowの結果
+type testRow struct {
+	values []any
+	err    error
+}
+
+func (r testRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i := range dest {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(r.values[i]))
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// enrollmentStatuses 受講登録の状態の一覧
+var enrollmentStatuses = map[string]bool{
+	models.EnrollmentStatusActive:    true,
+	models.EnrollmentStatusDropped:   true,
+	models.EnrollmentStatusCompleted: true,
+}
+
+// EnrollmentService 受講登録サービスの構造体
+type EnrollmentService struct {
+	enrollmentRepo *repositories.EnrollmentRepository
+	courseRepo     *repositories.CourseRepository
+	userRepo       *repositories.UserRepository
+	userService    *UserService
+	auditService   *AuditService
+}
+
+// NewEnrollmentService 受講登録サービスのコンストラクタ
+func NewEnrollmentService(enrollmentRepo *repositories.EnrollmentRepository, courseRepo *repositories.CourseRepository, userRepo *repositories.UserRepository, userService *UserService, auditService *AuditService) *EnrollmentService {
+	return &EnrollmentService{
+		enrollmentRepo: enrollmentRepo,
+		courseRepo:     courseRepo,
+		userRepo:       userRepo,
+		userService:    userService,
+		auditService:   auditService,
+	}
+}
+
+// ListEnrollments 授業の受講者一覧を取得する（担当教師または管理者）
+func (s *EnrollmentService) ListEnrollments(userID string, courseID string, request *models.EnrollmentListRequest) (*models.EnrollmentListResponse, error) {
+	courseIDInt, err := s.authorize(userID, courseID, policy.ActionList)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != "" && !enrollmentStatuses[request.Status] {
+		return nil, fmt.Errorf("入力値エラーがあります: status must be one of active, dropped, completed")
+	}
+
+	enrollments, err := s.enrollmentRepo.ListByCourse(courseIDInt, request.Status)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if enrollments == nil {
+		enrollments = []models.EnrollmentResponse{}
+	}
+
+	return &models.EnrollmentListResponse{
+		Count:       len(enrollments),
+		Enrollments: enrollments,
+	}, nil
+}
+
+// Enroll 学生を授業に受講登録する（担当教師または管理者）
+// 取消・修了済みの受講登録がある場合は受講中に戻す
+func (s *EnrollmentService) Enroll(userID string, courseID string, request *models.EnrollmentCreateRequest, audit models.AuditContext) (*models.EnrollmentResponse, error) {
+	courseIDInt, err := s.authorize(userID, courseID, policy.ActionCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.StudentUserID <= 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: student_user_id is required")
+	}
+
+	student, err := s.userRepo.GetUserByID(request.StudentUserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if student == nil || student.Role != models.RoleStudent {
+		return nil, fmt.Errorf("入力値エラーがあります: student_user_id must be an active student")
+	}
+
+	existing, err := s.enrollmentRepo.GetEnrollment(courseIDInt, request.StudentUserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if existing != nil {
+		if existing.Status == models.EnrollmentStatusActive {
+			return nil, fmt.Errorf("student already enrolled")
+		}
+		return s.changeStatus(existing, models.EnrollmentStatusActive, audit)
+	}
+
+	var enrollment *models.EnrollmentResponse
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		enrollmentRepo := s.enrollmentRepo.WithTx(tx)
+
+		enrollmentID, err := enrollmentRepo.CreateEnrollment(courseIDInt, request.StudentUserID)
+		if err != nil {
+			if strings.Contains(err.Error(), "student already enrolled") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		enrollment, err = s.getEnrollment(enrollmentRepo, courseIDInt, request.StudentUserID)
+		if err != nil {
+			return err
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceEnrollment, enrollmentID, nil, enrollment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// UpdateEnrollment 受講登録の状態を変更する（担当教師または管理者）
+func (s *EnrollmentService) UpdateEnrollment(userID string, courseID string, studentUserID string, request *models.EnrollmentUpdateRequest, audit models.AuditContext) (*models.EnrollmentResponse, error) {
+	courseIDInt, err := s.authorize(userID, courseID, policy.ActionUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enrollmentStatuses[request.Status] {
+		return nil, fmt.Errorf("入力値エラーがあります: status must be one of active, dropped, completed")
+	}
+
+	studentUserIDInt, err := parseTargetUserID(studentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := s.getEnrollment(s.enrollmentRepo, courseIDInt, studentUserIDInt)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrollment.Status == request.Status {
+		return enrollment, nil
+	}
+
+	return s.changeStatus(enrollment, request.Status, audit)
+}
+
+// Unenroll 学生の受講登録を取り消す（担当教師または管理者）
+// 受講登録は削除せず取消（dropped）として残す
+func (s *EnrollmentService) Unenroll(userID string, courseID string, studentUserID string, audit models.AuditContext) (*models.EnrollmentResponse, error) {
+	courseIDInt, err := s.authorize(userID, courseID, policy.ActionDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	studentUserIDInt, err := parseTargetUserID(studentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := s.getEnrollment(s.enrollmentRepo, courseIDInt, studentUserIDInt)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrollment.Status == models.EnrollmentStatusDropped {
+		return nil, fmt.Errorf("enrollment not found")
+	}
+
+	return s.changeStatus(enrollment, models.EnrollmentStatusDropped, audit)
+}
+
+// changeStatus 受講登録の状態を変更し、監査ログを記録する
+func (s *EnrollmentService) changeStatus(before *models.EnrollmentResponse, status string, audit models.AuditContext) (*models.EnrollmentResponse, error) {
+	var after *models.EnrollmentResponse
+	err := s.auditService.Transact(func(tx pgx.Tx) error {
+		enrollmentRepo := s.enrollmentRepo.WithTx(tx)
+
+		if err := enrollmentRepo.UpdateStatus(before.EnrollmentID, status); err != nil {
+			if strings.Contains(err.Error(), "enrollment not found") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		var err error
+		after, err = s.getEnrollment(enrollmentRepo, before.CourseID, before.StudentUserID)
+		if err != nil {
+			return err
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceEnrollment, before.EnrollmentID, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// getEnrollment 授業と学生の受講登録を取得する
+func (s *EnrollmentService) getEnrollment(enrollmentRepo *repositories.EnrollmentRepository, courseID int, studentUserID int) (*models.EnrollmentResponse, error) {
+	enrollment, err := enrollmentRepo.GetEnrollment(courseID, studentUserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if enrollment == nil {
+		return nil, fmt.Errorf("enrollment not found")
+	}
+
+	return enrollment, nil
+}
+
+// authorize 授業の受講登録に対する操作の権限をチェックし、授業IDを返す
+func (s *EnrollmentService) authorize(userID string, courseID string, action policy.Action) (int, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	courseIDInt, err := strconv.Atoi(courseID)
+	if err != nil || courseIDInt <= 0 {
+		return 0, fmt.Errorf("入力値エラーがあります: invalid course ID")
+	}
+
+	course, err := s.courseRepo.GetCourseIncludingDeleted(courseIDInt)
+	if err != nil {
+		return 0, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if course == nil || course.IsDeleted {
+		return 0, fmt.Errorf("course not found")
+	}
+
+	resource, err := courseResource(s.courseRepo, principal, policy.ResourceEnrollment, courseIDInt)
+	if err != nil {
+		return 0, err
+	}
+	if err := policy.Authorize(principal, action, resource); err != nil {
+		return 0, fmt.Errorf("access denied: %w", err)
+	}
+
+	return courseIDInt, nil
+}
@@ -5,20 +5,23 @@ import (
 	"strconv"
 
 	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
 	"github.com/tomoki-den-uhd/go-study/internal/repositories"
 )
 
 // GradeService 成績サービスの構造体
 type GradeService struct {
-	gradeRepo   *repositories.GradeRepository
-	userService *UserService
+	gradeRepo    *repositories.GradeRepository
+	guardianRepo *repositories.GuardianRepository
+	userService  *UserService
 }
 
 // NewGradeService 成績サービスのコンストラクタ
-func NewGradeService(gradeRepo *repositories.GradeRepository, userService *UserService) *GradeService {
+func NewGradeService(gradeRepo *repositories.GradeRepository, guardianRepo *repositories.GuardianRepository, userService *UserService) *GradeService {
 	return &GradeService{
-		gradeRepo:   gradeRepo,
-		userService: userService,
+		gradeRepo:    gradeRepo,
+		guardianRepo: guardianRepo,
+		userService:  userService,
 	}
 }
 
@@ -46,19 +49,11 @@ func (s *GradeService) GetGradeDetail(gradeID string, userID string) (*models.Gr
 		return nil, fmt.Errorf("failed to get user role: %w", err)
 	}
 
-	// 権限チェック（学生は自分の成績のみ、教師は自分のコースの成績のみ）
-	if userRole == "student" {
-		// 学生の場合：自分の成績のみアクセス可能
-		if err := s.validateStudentAccess(gradeIDInt, userIDInt); err != nil {
-			return nil, fmt.Errorf("access denied: %w", err)
-		}
-	} else if userRole == "teacher" {
-		// 教師の場合：自分のコースの成績のみアクセス可能
-		if err := s.validateTeacherAccess(gradeIDInt, userIDInt); err != nil {
-			return nil, fmt.Errorf("access denied: %w", err)
-		}
+	// 権限チェック（学生は自分の成績のみ、教師は自分のコースの成績のみ、保護者は紐付く学生の成績のみ）
+	principal := &models.Principal{UserID: userIDInt, Role: userRole}
+	if err := s.authorizeGrade(principal, policy.ActionRead, gradeIDInt); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
 	}
-	// その他の役割（admin等）は全ての成績にアクセス可能
 
 	// 成績詳細を取得
 	gradeDetail, err := s.gradeRepo.GetGradeDetail(gradeIDInt)
@@ -69,38 +64,35 @@ func (s *GradeService) GetGradeDetail(gradeID string, userID string) (*models.Gr
 	return gradeDetail, nil
 }
 
-// validateStudentAccess 学生のアクセス権限をチェック
-func (s *GradeService) validateStudentAccess(gradeID int, userID int) error {
-	// 成績が自分のものかチェック
+// authorizeGrade 成績に対する操作の権限をチェック
+func (s *GradeService) authorizeGrade(principal *models.Principal, action policy.Action, gradeID int) error {
+	// 成績を取得
 	grade, err := s.gradeRepo.GetGradeByID(gradeID)
 	if err != nil {
 		return fmt.Errorf("failed to get grade: %w", err)
 	}
-	
-	if grade.StudentUserID != userID {
-		return fmt.Errorf("access denied: you can only access your own grades")
-	}
-	
-	return nil
-}
 
-// validateTeacherAccess 教師のアクセス権限をチェック
-func (s *GradeService) validateTeacherAccess(gradeID int, userID int) error {
-	// 成績が自分のコースのものかチェック
-	grade, err := s.gradeRepo.GetGradeByID(gradeID)
-	if err != nil {
-		return fmt.Errorf("failed to get grade: %w", err)
-	}
-	
-	// 教師がそのコースの担当者かチェック
-	isCourseTeacher, err := s.gradeRepo.IsTeacherOfCourse(userID, grade.CourseID)
+	// 操作ユーザーのそのコースでの担当を取得
+	courseRole, err := s.gradeRepo.GetCourseStaffRole(principal.UserID, grade.CourseID)
 	if err != nil {
 		return fmt.Errorf("failed to check teacher access: %w", err)
 	}
-	
-	if !isCourseTeacher {
-		return fmt.Errorf("access denied: you can only access grades from your courses")
+
+	// 保護者の場合は対象学生に紐付いているかチェック
+	isGuardian := false
+	if principal.Role == models.RoleParent {
+		isGuardian, err = s.guardianRepo.IsGuardianOf(principal.UserID, grade.StudentUserID)
+		if err != nil {
+			return fmt.Errorf("failed to check guardian access: %w", err)
+		}
 	}
-	
-	return nil
-} 
\ No newline at end of file
+
+	resource := policy.Resource{
+		Type:          policy.ResourceGrade,
+		CourseRole:    courseRole,
+		IsGuardian:    isGuardian,
+		StudentUserID: grade.StudentUserID,
+	}
+
+	return policy.Authorize(principal, action, resource)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// GuardianService 保護者紐付けサービスの構造体
+type GuardianService struct {
+	guardianRepo *repositories.GuardianRepository
+	userRepo     *repositories.UserRepository
+	userService  *UserService
+	auditService *AuditService
+}
+
+// NewGuardianService 保護者紐付けサービスのコンストラクタ
+func NewGuardianService(guardianRepo *repositories.GuardianRepository, userRepo *repositories.UserRepository, userService *UserService, auditService *AuditService) *GuardianService {
+	return &GuardianService{
+		guardianRepo: guardianRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		auditService: auditService,
+	}
+}
+
+// CreateGuardian 保護者と学生を紐付ける（管理者のみ）
+func (s *GuardianService) CreateGuardian(userID string, request *models.GuardianCreateRequest, audit models.AuditContext) (*models.GuardianResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionCreate, policy.Resource{Type: policy.ResourceGuardian}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	// 紐付け対象の役割をチェック
+	if err := s.requireRole(request.ParentUserID, models.RoleParent, "parent_user_id"); err != nil {
+		return nil, err
+	}
+
+	if err := s.requireRole(request.StudentUserID, models.RoleStudent, "student_user_id"); err != nil {
+		return nil, err
+	}
+
+	guardian := &models.Guardian{
+		ParentUserID:  request.ParentUserID,
+		StudentUserID: request.StudentUserID,
+		Relationship:  strings.TrimSpace(request.Relationship),
+	}
+
+	var guardianID int
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		guardianID, err = s.guardianRepo.WithTx(tx).CreateGuardian(guardian)
+		if err != nil {
+			if strings.Contains(err.Error(), "guardian already linked") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		guardian.GuardianID = guardianID
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceGuardian, guardianID, nil, guardian)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 氏名付きの紐付け情報を返す
+	guardians, err := s.guardianRepo.ListByStudent(request.StudentUserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	for _, g := range guardians {
+		if g.GuardianID == guardianID {
+			return &g, nil
+		}
+	}
+
+	return nil, fmt.Errorf("guardian not found")
+}
+
+// DeleteGuardian 保護者の紐付けを解除する（管理者のみ）
+func (s *GuardianService) DeleteGuardian(userID string, guardianID string, audit models.AuditContext) error {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionDelete, policy.Resource{Type: policy.ResourceGuardian}); err != nil {
+		return fmt.Errorf("access denied: %w", err)
+	}
+
+	guardianIDInt, err := strconv.Atoi(guardianID)
+	if err != nil || guardianIDInt <= 0 {
+		return fmt.Errorf("入力値エラーがあります: invalid guardian ID")
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		deleted, err := s.guardianRepo.WithTx(tx).DeleteGuardian(guardianIDInt)
+		if err != nil {
+			if strings.Contains(err.Error(), "guardian not found") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		before := *deleted
+		before.IsDeleted = false
+		return s.auditService.Record(tx, audit, models.AuditActionDelete, policy.ResourceGuardian, guardianIDInt, &before, deleted)
+	})
+}
+
+// ListStudentGuardians 学生に紐付く保護者の一覧を取得する（管理者のみ）
+func (s *GuardianService) ListStudentGuardians(userID string, studentUserID string) (*models.GuardianListResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionRead, policy.Resource{Type: policy.ResourceGuardian}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	studentUserIDInt, err := parseTargetUserID(studentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	guardians, err := s.guardianRepo.ListByStudent(studentUserIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return toGuardianListResponse(guardians), nil
+}
+
+// ListMyChildren 保護者本人に紐付く学生の一覧を取得する
+func (s *GuardianService) ListMyChildren(userID string) (*models.GuardianListResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionList, policy.Resource{Type: policy.ResourceGuardian}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	guardians, err := s.guardianRepo.ListByParent(principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return toGuardianListResponse(guardians), nil
+}
+
+// requireRole ユーザーが指定した役割を持つかチェックする
+func (s *GuardianService) requireRole(userID int, role string, field string) error {
+	if userID <= 0 {
+		return fmt.Errorf("入力値エラーがあります: %s is required", field)
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil || user.Role != role {
+		return fmt.Errorf("入力値エラーがあります: %s must be an active %s", field, role)
+	}
+
+	return nil
+}
+
+// toGuardianListResponse 紐付け一覧をレスポンスに変換する
+func toGuardianListResponse(guardians []models.GuardianResponse) *models.GuardianListResponse {
+	if guardians == nil {
+		guardians = []models.GuardianResponse{}
+	}
+	return &models.GuardianListResponse{
+		Count:     len(guardians),
+		Guardians: guardians,
+	}
+}
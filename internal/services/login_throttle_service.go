@@ -0,0 +1,265 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// ログイン試行制限の設定
+const (
+	loginFailureWindow    = 15 * time.Minute // 失敗回数を数える期間
+	loginLockDuration     = 15 * time.Minute // ロックアウトの期間
+	accountDelayThreshold = 3                // この回数を超えると段階的に待機時間を課す
+	accountLockThreshold  = 10               // アカウント単位でロックする失敗回数
+	ipLockThreshold       = 100              // IP単位でロックする失敗回数（校内NATを考慮して多め）
+	maxLoginDelay         = 30 * time.Second // 段階的な待機時間の上限
+)
+
+// パスワード再設定メールの送信制限の設定
+const (
+	passwordResetWindow       = 15 * time.Minute // 送信要求を数える期間
+	passwordResetAccountLimit = 3                // メールアドレスごとに送信する上限（超えた分は送信しない）
+	passwordResetIPLimit      = 30               // IP単位で受け付ける上限（超えると429を返す）
+)
+
+// ThrottleError ログイン試行やパスワード再設定の要求が制限されている場合のエラー
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+// Error エラーメッセージを返す
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("too many attempts: retry after %d seconds", int(e.RetryAfter.Seconds()))
+}
+
+// LoginThrottleService ログイン試行制限サービスの構造体
+type LoginThrottleService struct {
+	throttleRepo *repositories.LoginThrottleRepository
+	userRepo     *repositories.UserRepository
+	userService  *UserService
+	auditService *AuditService
+}
+
+// NewLoginThrottleService ログイン試行制限サービスのコンストラクタ
+func NewLoginThrottleService(throttleRepo *repositories.LoginThrottleRepository, userRepo *repositories.UserRepository, userService *UserService, auditService *AuditService) *LoginThrottleService {
+	return &LoginThrottleService{
+		throttleRepo: throttleRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		auditService: auditService,
+	}
+}
+
+// Check ログイン試行が許可されるかチェックし、制限中の場合はThrottleErrorを返す
+func (s *LoginThrottleService) Check(email string, ip string) error {
+	throttles, err := s.throttleRepo.GetThrottles([]string{accountThrottleKey(email), ipThrottleKey(ip)})
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, throttle := range throttles {
+		// ロック中
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			retryAfter = max(retryAfter, throttle.LockedUntil.Sub(now))
+			continue
+		}
+
+		// アカウント単位の段階的な待機時間
+		if strings.HasPrefix(throttle.ThrottleKey, "account:") && throttle.LastFailedAt != nil && throttle.LastFailedAt.After(now.Add(-loginFailureWindow)) {
+			nextAttempt := throttle.LastFailedAt.Add(loginDelay(throttle.FailedCount))
+			if nextAttempt.After(now) {
+				retryAfter = max(retryAfter, nextAttempt.Sub(now))
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return &ThrottleError{RetryAfter: retryAfter.Round(time.Second) + time.Second}
+	}
+
+	return nil
+}
+
+// RecordFailure ログイン失敗を記録し、閾値を超えた場合はロックする
+// userIDはメールアドレスに一致するユーザーが存在する場合のみ指定する
+func (s *LoginThrottleService) RecordFailure(email string, ip string, userID *int) error {
+	windowStart := time.Now().Add(-loginFailureWindow)
+
+	// アカウント単位
+	accountKey := accountThrottleKey(email)
+	accountFailures, err := s.throttleRepo.RecordFailure(accountKey, windowStart)
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if accountFailures >= accountLockThreshold {
+		event := &models.LockoutEvent{UserID: userID, IPAddress: ip, FailedCount: accountFailures}
+		if err := s.throttleRepo.Lock(accountKey, time.Now().Add(loginLockDuration), event); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+	}
+
+	// IP単位
+	ipKey := ipThrottleKey(ip)
+	ipFailures, err := s.throttleRepo.RecordFailure(ipKey, windowStart)
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if ipFailures >= ipLockThreshold {
+		event := &models.LockoutEvent{IPAddress: ip, FailedCount: ipFailures}
+		if err := s.throttleRepo.Lock(ipKey, time.Now().Add(loginLockDuration), event); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// CheckPasswordReset パスワード再設定メールの送信要求を記録し、メールを送信してよいかを返す
+// IP単位の上限を超えた場合はThrottleErrorを返す。メールアドレス単位の上限を超えた場合は、
+// 登録有無や制限中であることが分からないようエラーにせず送信だけを見送る
+func (s *LoginThrottleService) CheckPasswordReset(email string, ip string) (bool, error) {
+	windowStart := time.Now().Add(-passwordResetWindow)
+
+	// IP単位
+	ipRequests, err := s.throttleRepo.RecordRequest(passwordResetIPKey(ip), windowStart)
+	if err != nil {
+		return false, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if ipRequests > passwordResetIPLimit {
+		return false, &ThrottleError{RetryAfter: passwordResetWindow}
+	}
+
+	// メールアドレス単位
+	accountRequests, err := s.throttleRepo.RecordRequest(passwordResetAccountKey(email), windowStart)
+	if err != nil {
+		return false, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return accountRequests <= passwordResetAccountLimit, nil
+}
+
+// RecordSuccess ログイン成功時にアカウント単位のカウンターをリセットする
+func (s *LoginThrottleService) RecordSuccess(email string) error {
+	if err := s.throttleRepo.Reset(accountThrottleKey(email)); err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+	return nil
+}
+
+// UnlockUser ユーザーのアカウントロックを解除する（管理者のみ）
+func (s *LoginThrottleService) UnlockUser(userID string, targetUserID string, audit models.AuditContext) (bool, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionUpdate, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return false, fmt.Errorf("access denied: %w", err)
+	}
+
+	targetUserIDInt, err := parseTargetUserID(targetUserID)
+	if err != nil {
+		return false, err
+	}
+
+	user, err := s.userRepo.GetUserByID(targetUserIDInt)
+	if err != nil {
+		return false, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil {
+		return false, fmt.Errorf("user not found")
+	}
+
+	var wasLocked bool
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		wasLocked, err = s.throttleRepo.WithTx(tx).Unlock(accountThrottleKey(user.Email), principal.UserID)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionUnlock, policy.ResourceUser, targetUserIDInt, map[string]bool{"locked": wasLocked}, map[string]bool{"locked": false})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return wasLocked, nil
+}
+
+// ListLockoutEvents ロックアウト記録の一覧を取得する（管理者のみ）
+func (s *LoginThrottleService) ListLockoutEvents(userID string, request *models.LockoutEventListRequest) (*models.LockoutEventListResponse, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionRead, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	if request.Limit <= 0 {
+		request.Limit = defaultUserListLimit
+	}
+	if request.Limit > maxUserListLimit {
+		request.Limit = maxUserListLimit
+	}
+	if request.Offset < 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: offset must not be negative")
+	}
+
+	events, err := s.throttleRepo.ListLockoutEvents(request.UserID, request.Limit, request.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if events == nil {
+		events = []models.LockoutEvent{}
+	}
+
+	return &models.LockoutEventListResponse{
+		Count:  len(events),
+		Events: events,
+	}, nil
+}
+
+// loginDelay 失敗回数に応じた次の試行までの待機時間（閾値を超えるごとに倍増）
+func loginDelay(failedCount int) time.Duration {
+	if failedCount <= accountDelayThreshold {
+		return 0
+	}
+	delay := time.Second << min(failedCount-accountDelayThreshold-1, 5)
+	return min(delay, maxLoginDelay)
+}
+
+// accountThrottleKey アカウント単位のカウンターのキー
+func accountThrottleKey(email string) string {
+	return "account:" + normalizeEmail(email)
+}
+
+// ipThrottleKey IP単位のカウンターのキー
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// passwordResetAccountKey パスワード再設定のメールアドレス単位のカウンターのキー
+func passwordResetAccountKey(email string) string {
+	return "reset:" + accountThrottleKey(email)
+}
+
+// passwordResetIPKey パスワード再設定のIP単位のカウンターのキー
+func passwordResetIPKey(ip string) string {
+	return "reset:" + ipThrottleKey(ip)
+}
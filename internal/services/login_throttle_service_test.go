@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// requestCounterTestDB request_countersのメモリ上の代わり（repositories.DBTX）
+// ログイン失敗のカウンター（login_throttles）への操作はエラーにする
+type requestCounterTestDB struct {
+	counts map[string]int
+}
+
+func (db *requestCounterTestDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (db *requestCounterTestDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, fmt.Errorf("unexpected exec: %s", sql)
+}
+
+func (db *requestCounterTestDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, fmt.Errorf("unexpected query: %s", sql)
+}
+
+func (db *requestCounterTestDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if strings.Contains(sql, "INSERT INTO request_counters") && !strings.Contains(sql, "login_throttles") {
+		key := args[0].(string)
+		db.counts[key]++
+		return testRow{values: []any{db.counts[key]}}
+	}
+	return testRow{err: fmt.Errorf("unexpected query: %s", sql)}
+}
+
+// TestCheckPasswordReset 送信要求は専用のカウンターで数え、メールアドレス単位・IP単位の上限を適用すること
+func TestCheckPasswordReset(t *testing.T) {
+	db := &requestCounterTestDB{counts: map[string]int{}}
+	service := NewLoginThrottleService(&repositories.LoginThrottleRepository{DB: db}, nil, nil, nil)
+
+	// メールアドレス単位の上限までは送信し、超えた分はエラーにせず送信だけを見送る
+	for i := 1; i <= passwordResetAccountLimit+2; i++ {
+		allowed, err := service.CheckPasswordReset("Taro@Example.com", "203.0.113.5")
+		if err != nil {
+			t.Fatalf("request %d error = %v", i, err)
+		}
+		if want := i <= passwordResetAccountLimit; allowed != want {
+			t.Errorf("request %d allowed = %v, want %v", i, allowed, want)
+		}
+	}
+
+	// 大文字・小文字の違いは同じメールアドレスとして数える
+	if got := db.counts[passwordResetAccountKey("taro@example.com")]; got != passwordResetAccountLimit+2 {
+		t.Errorf("account counter = %d, want %d", got, passwordResetAccountLimit+2)
+	}
+
+	// 他のメールアドレスは別に数える
+	if allowed, err := service.CheckPasswordReset("hanako@example.com", "203.0.113.5"); err != nil || !allowed {
+		t.Errorf("other email = (%v, %v), want allowed", allowed, err)
+	}
+
+	// IP単位の上限を超えるとThrottleErrorを返す
+	db.counts[passwordResetIPKey("198.51.100.1")] = passwordResetIPLimit
+	_, err := service.CheckPasswordReset("jiro@example.com", "198.51.100.1")
+	var throttleErr *ThrottleError
+	if !errors.As(err, &throttleErr) || throttleErr.RetryAfter != passwordResetWindow {
+		t.Errorf("over ip limit error = %v, want ThrottleError", err)
+	}
+	if got := db.counts[passwordResetAccountKey("jiro@example.com")]; got != 0 {
+		t.Errorf("account counter after ip limit = %d, want 0", got)
+	}
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/oidc"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// oidcStateTTL OIDCログイン開始からコールバックまでの有効期限
+const oidcStateTTL = 10 * time.Minute
+
+// OIDCService OpenID Connectによるシングルサインオンのサービスの構造体
+// IdPのアカウントは既存のユーザーに紐付け、ユーザーの自動作成は行わない
+type OIDCService struct {
+	provider    *oidc.Provider // 未設定の場合はnil
+	oidcRepo    *repositories.OIDCRepository
+	userRepo    *repositories.UserRepository
+	authService *AuthService
+	roleClaim   string            // 役割・グループを表すクレーム名（空の場合は照合しない）
+	roleMapping map[string]string // クレームの値 → 役割
+}
+
+// NewOIDCService OIDCサービスのコンストラクタ
+func NewOIDCService(provider *oidc.Provider, oidcRepo *repositories.OIDCRepository, userRepo *repositories.UserRepository, authService *AuthService, roleClaim string, roleMapping map[string]string) *OIDCService {
+	return &OIDCService{
+		provider:    provider,
+		oidcRepo:    oidcRepo,
+		userRepo:    userRepo,
+		authService: authService,
+		roleClaim:   roleClaim,
+		roleMapping: roleMapping,
+	}
+}
+
+// ParseRoleMapping "グループ=役割" をカンマ区切りで並べた設定を解析する
+func ParseRoleMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		group, role, ok := strings.Cut(entry, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || !assignableRoles[role] {
+			return nil, fmt.Errorf("invalid role mapping %q", entry)
+		}
+		mapping[group] = role
+	}
+	return mapping, nil
+}
+
+// StartLogin OIDCログインを開始し、IdPの認可エンドポイントのURLとstateを返す
+// stateはログインを開始したブラウザに紐付ける（ハンドラーでCookieに保存する）
+func (s *OIDCService) StartLogin() (*models.OIDCAuthorizeResponse, string, error) {
+	if s.provider == nil {
+		return nil, "", fmt.Errorf("oidc is not configured")
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return nil, "", err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return nil, "", err
+	}
+	codeVerifier, err := oidc.RandomString()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// stateはハッシュのみ保存する
+	if err := s.oidcRepo.CreateLoginState(hashToken(state), nonce, codeVerifier, time.Now().Add(oidcStateTTL)); err != nil {
+		return nil, "", fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	authorizationURL, err := s.provider.AuthCodeURL(state, nonce, codeVerifier)
+	if err != nil {
+		return nil, "", fmt.Errorf("oidc provider error: %v", err)
+	}
+
+	return &models.OIDCAuthorizeResponse{
+		AuthorizationURL: authorizationURL,
+		ExpiresIn:        int(oidcStateTTL.Seconds()),
+	}, state, nil
+}
+
+// CompleteLogin 認可コードをトークンと交換し、IDトークンのユーザーでログインする
+// boundStateはログインを開始したブラウザのCookieのstateで、コールバックのstateと一致する必要がある
+// （攻撃者が開始したログインのコールバックを他人のブラウザで完了させるログインCSRFを防ぐ）
+func (s *OIDCService) CompleteLogin(request *models.OIDCCallbackRequest, boundState string) (*models.UserLoginResponse, error) {
+	if s.provider == nil {
+		return nil, fmt.Errorf("oidc is not configured")
+	}
+
+	if request.Code == "" || request.State == "" {
+		return nil, fmt.Errorf("入力値エラーがあります: code and state are required")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(request.State), []byte(boundState)) != 1 {
+		return nil, errors.New(models.ErrorMessageUnauthorized)
+	}
+
+	// stateを検証（1回限り）
+	nonce, codeVerifier, err := s.oidcRepo.ConsumeLoginState(hashToken(request.State))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid oidc state") {
+			return nil, errors.New(models.ErrorMessageUnauthorized)
+		}
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	// 認可コードをトークンと交換してIDトークンを検証
+	token, err := s.provider.Exchange(request.Code, codeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("oidc login failed: %v", err)
+	}
+
+	idToken, err := s.provider.VerifyIDToken(token.IDToken, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("oidc login failed: %v", err)
+	}
+
+	user, err := s.resolveUser(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.oidcRepo.RecordIdentityLogin(user.UserID, s.provider.Issuer(), idToken.Subject); err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return s.authService.LoginVerifiedUser(user)
+}
+
+// resolveUser IDトークンのクレームから既存のユーザーを特定する
+// 紐付け済みのアカウントはsubjectで、初回は確認済みのメールアドレスで照合する
+func (s *OIDCService) resolveUser(idToken *oidc.IDToken) (*models.User, error) {
+	userID, err := s.oidcRepo.GetUserIDByIdentity(s.provider.Issuer(), idToken.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	var user *models.User
+	if userID != 0 {
+		user, err = s.userRepo.GetUserByID(userID)
+	} else {
+		// email_verifiedがないトークンは未確認として扱う（未確認のメールアドレスでアカウントを乗っ取られないようにする）
+		if idToken.Email == "" || idToken.EmailVerified == nil || !*idToken.EmailVerified {
+			return nil, fmt.Errorf("oidc login failed: verified email claim is required")
+		}
+		user, err = s.userRepo.GetUserByEmail(normalizeEmail(idToken.Email))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil || user.IsServiceAccount {
+		return nil, fmt.Errorf("oidc login failed: no matching user")
+	}
+
+	// IdPが役割・グループを示す場合はユーザーの役割と一致する必要がある（役割の自動変更は行わない）
+	if s.roleClaim != "" {
+		values := idToken.StringValues(s.roleClaim)
+		if len(values) > 0 && !s.matchesRole(values, user.Role) {
+			return nil, fmt.Errorf("oidc login failed: role claim does not match user role %s", user.Role)
+		}
+	}
+
+	return user, nil
+}
+
+// matchesRole クレームの値のいずれかがユーザーの役割に対応するかどうかを返す
+// 対応表に定義のない値は役割名そのものとして扱う
+func (s *OIDCService) matchesRole(values []string, role string) bool {
+	for _, value := range values {
+		mapped, ok := s.roleMapping[value]
+		if !ok {
+			mapped = value
+		}
+		if mapped == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/oidc"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+const testOIDCClientID = "go-study"
+
+// oidcTestIdP テスト用のIdP（認可リクエストのnonce・code_challengeでIDトークンを発行する）
+type oidcTestIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu            sync.Mutex
+	nonce         string
+	codeChallenge string
+	claims        jwt.MapClaims // IDトークンに追加するクレーム
+}
+
+func newOIDCTestIdP(t *testing.T) *oidcTestIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &oidcTestIdP{key: key, claims: jwt.MapClaims{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != idp.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   idp.server.URL,
+			"aud":   testOIDCClientID,
+			"nonce": idp.nonce,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}
+		for name, value := range idp.claims {
+			claims[name] = value
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// authorize 認可URLのnonceとcode_challengeを受け取り、IdPでの認可を完了したことにする
+func (idp *oidcTestIdP) authorize(t *testing.T, authorizationURL string) (state string) {
+	t.Helper()
+
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.nonce = u.Query().Get("nonce")
+	idp.codeChallenge = u.Query().Get("code_challenge")
+
+	return u.Query().Get("state")
+}
+
+// oidcTestState oidc_login_statesの行
+type oidcTestState struct {
+	nonce        string
+	codeVerifier string
+	expiresAt    time.Time
+	usedAt       *time.Time
+}
+
+// oidcTestDB OIDCログインで使うテーブルのメモリ上の代わり（repositories.DBTX）
+type oidcTestDB struct {
+	states     map[string]*oidcTestState // state_hash → 行
+	identities map[string]int            // issuer + " " + subject → user_id
+	users      []models.User
+}
+
+func newOIDCTestDB() *oidcTestDB {
+	return &oidcTestDB{states: map[string]*oidcTestState{}, identities: map[string]int{}}
+}
+
+func (db *oidcTestDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (db *oidcTestDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if strings.Contains(sql, "INSERT INTO oidc_login_states") {
+		db.states[args[0].(string)] = &oidcTestState{
+			nonce:        args[1].(string),
+			codeVerifier: args[2].(string),
+			expiresAt:    args[3].(time.Time),
+		}
+		return pgconn.NewCommandTag("INSERT 0 1"), nil
+	}
+	return pgconn.CommandTag{}, fmt.Errorf("unexpected exec: %s", sql)
+}
+
+func (db *oidcTestDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, fmt.Errorf("unexpected query: %s", sql)
+}
+
+func (db *oidcTestDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	switch {
+	case strings.Contains(sql, "UPDATE oidc_login_states"):
+		// used_at IS NULL AND expires_at > now の行のみ使用済みにする
+		state, ok := db.states[args[0].(string)]
+		now := args[1].(time.Time)
+		if !ok || state.usedAt != nil || !state.expiresAt.After(now) {
+			return testRow{err: pgx.ErrNoRows}
+		}
+		state.usedAt = &now
+		return testRow{values: []any{state.nonce, state.codeVerifier}}
+	case strings.Contains(sql, "FROM user_identities"):
+		userID, ok := db.identities[args[0].(string)+" "+args[1].(string)]
+		if !ok {
+			return testRow{err: pgx.ErrNoRows}
+		}
+		return testRow{values: []any{userID}}
+	case strings.Contains(sql, "FROM users"):
+		for _, user := range db.users {
+			if (strings.Contains(sql, "lower(email)") && strings.EqualFold(user.Email, args[0].(string))) ||
+				(strings.Contains(sql, "user_id = $1") && user.UserID == args[0]) {
+				return testRow{values: []any{user.UserID, user.Name, user.Email, user.Password, user.Phone, user.Role, user.IsServiceAccount}}
+			}
+		}
+		return testRow{err: pgx.ErrNoRows}
+	}
+	return testRow{err: fmt.Errorf("unexpected query: %s", sql)}
+}
+
+// newTestOIDCService テスト用のIdPとメモリ上のテーブルでOIDCサービスを作成する
+func newTestOIDCService(idp *oidcTestIdP, db *oidcTestDB, roleClaim string, roleMapping map[string]string) *OIDCService {
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:      idp.server.URL,
+		ClientID:    testOIDCClientID,
+		RedirectURL: "https://app.example.com/callback",
+	})
+	return NewOIDCService(provider, &repositories.OIDCRepository{DB: db}, &repositories.UserRepository{DB: db}, nil, roleClaim, roleMapping)
+}
+
+func TestOIDCCompleteLoginState(t *testing.T) {
+	idp := newOIDCTestIdP(t)
+	// 一致するユーザーがいないため、state・PKCE・IDトークンの検証を通過した後に失敗する
+	idp.claims = jwt.MapClaims{"sub": "unknown", "email": "unknown@example.com", "email_verified": true}
+
+	tests := []struct {
+		name       string
+		setup      func(db *oidcTestDB, state string)
+		boundState func(state string) string
+		wantErr    string
+	}{
+		{
+			name:       "valid state",
+			boundState: func(state string) string { return state },
+			wantErr:    "oidc login failed: no matching user",
+		},
+		{
+			name:       "missing state cookie",
+			boundState: func(state string) string { return "" },
+			wantErr:    models.ErrorMessageUnauthorized,
+		},
+		{
+			name:       "state cookie from another login",
+			boundState: func(state string) string { return state + "x" },
+			wantErr:    models.ErrorMessageUnauthorized,
+		},
+		{
+			name: "expired state",
+			setup: func(db *oidcTestDB, state string) {
+				db.states[hashToken(state)].expiresAt = time.Now().Add(-time.Second)
+			},
+			boundState: func(state string) string { return state },
+			wantErr:    models.ErrorMessageUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newOIDCTestDB()
+			service := newTestOIDCService(idp, db, "", nil)
+
+			response, state, err := service.StartLogin()
+			if err != nil {
+				t.Fatalf("StartLogin() error = %v", err)
+			}
+			if got := idp.authorize(t, response.AuthorizationURL); got != state {
+				t.Fatalf("authorization URL state = %q, want %q", got, state)
+			}
+
+			// stateは平文で保存しない
+			stored, ok := db.states[hashToken(state)]
+			if !ok || len(db.states) != 1 {
+				t.Fatalf("state hash was not stored: %v", db.states)
+			}
+			if ttl := time.Until(stored.expiresAt); ttl <= 0 || ttl > oidcStateTTL {
+				t.Errorf("state expires in %v, want within %v", ttl, oidcStateTTL)
+			}
+
+			if tt.setup != nil {
+				tt.setup(db, state)
+			}
+
+			_, err = service.CompleteLogin(&models.OIDCCallbackRequest{Code: "code", State: state}, tt.boundState(state))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("CompleteLogin() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestOIDCCompleteLoginReplay 一度使ったstateは再利用できないこと
+func TestOIDCCompleteLoginReplay(t *testing.T) {
+	idp := newOIDCTestIdP(t)
+	idp.claims = jwt.MapClaims{"sub": "unknown", "email": "unknown@example.com", "email_verified": true}
+
+	db := newOIDCTestDB()
+	service := newTestOIDCService(idp, db, "", nil)
+
+	response, state, err := service.StartLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.authorize(t, response.AuthorizationURL)
+
+	request := &models.OIDCCallbackRequest{Code: "code", State: state}
+	if _, err := service.CompleteLogin(request, state); err == nil || !strings.HasPrefix(err.Error(), "oidc login failed") {
+		t.Fatalf("first CompleteLogin() error = %v, want to pass state validation", err)
+	}
+
+	if _, err := service.CompleteLogin(request, state); err == nil || err.Error() != models.ErrorMessageUnauthorized {
+		t.Errorf("replayed CompleteLogin() error = %v, want %q", err, models.ErrorMessageUnauthorized)
+	}
+}
+
+func TestOIDCResolveUser(t *testing.T) {
+	idp := newOIDCTestIdP(t)
+	issuer := idp.server.URL
+
+	verified, unverified := true, false
+	users := []models.User{
+		{UserID: 1, Name: "学生", Email: "Student@example.com", Role: "student"},
+		{UserID: 2, Name: "教師", Email: "teacher@example.com", Role: "teacher"},
+		{UserID: 3, Name: "連携", Email: "service@example.com", Role: "teacher", IsServiceAccount: true},
+	}
+
+	tests := []struct {
+		name       string
+		idToken    *oidc.IDToken
+		identities map[string]int
+		wantUserID int
+		wantErr    string
+	}{
+		{
+			name:       "verified email",
+			idToken:    &oidc.IDToken{Subject: "s1", Email: "student@EXAMPLE.com", EmailVerified: &verified},
+			wantUserID: 1,
+		},
+		{
+			name:    "unverified email",
+			idToken: &oidc.IDToken{Subject: "s1", Email: "student@example.com", EmailVerified: &unverified},
+			wantErr: "verified email claim is required",
+		},
+		{
+			name:    "email_verified claim missing",
+			idToken: &oidc.IDToken{Subject: "s1", Email: "student@example.com"},
+			wantErr: "verified email claim is required",
+		},
+		{
+			name:    "email claim missing",
+			idToken: &oidc.IDToken{Subject: "s1", EmailVerified: &verified},
+			wantErr: "verified email claim is required",
+		},
+		{
+			name:       "linked identity ignores email",
+			idToken:    &oidc.IDToken{Subject: "s2", Email: "student@example.com"},
+			identities: map[string]int{issuer + " s2": 2},
+			wantUserID: 2,
+		},
+		{
+			name:    "no matching user",
+			idToken: &oidc.IDToken{Subject: "s1", Email: "nobody@example.com", EmailVerified: &verified},
+			wantErr: "no matching user",
+		},
+		{
+			name:    "service account",
+			idToken: &oidc.IDToken{Subject: "s1", Email: "service@example.com", EmailVerified: &verified},
+			wantErr: "no matching user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newOIDCTestDB()
+			db.users = users
+			for key, userID := range tt.identities {
+				db.identities[key] = userID
+			}
+
+			user, err := newTestOIDCService(idp, db, "", nil).resolveUser(tt.idToken)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveUser() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveUser() error = %v", err)
+			}
+			if user.UserID != tt.wantUserID {
+				t.Errorf("resolveUser() user = %d, want %d", user.UserID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+func TestOIDCResolveUserRoleClaim(t *testing.T) {
+	idp := newOIDCTestIdP(t)
+
+	roleMapping, err := ParseRoleMapping("staff=teacher, pupils=student")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified := true
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		ok     bool
+	}{
+		{"no role claim", jwt.MapClaims{}, true},
+		{"mapped group", jwt.MapClaims{"groups": []interface{}{"library", "staff"}}, true},
+		{"role name", jwt.MapClaims{"groups": "teacher"}, true},
+		{"other role", jwt.MapClaims{"groups": []interface{}{"pupils"}}, false},
+		{"unmapped group", jwt.MapClaims{"groups": "library"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newOIDCTestDB()
+			db.users = []models.User{{UserID: 2, Email: "teacher@example.com", Role: "teacher"}}
+
+			idToken := &oidc.IDToken{Subject: "s1", Email: "teacher@example.com", EmailVerified: &verified, Claims: tt.claims}
+			_, err := newTestOIDCService(idp, db, "groups", roleMapping).resolveUser(idToken)
+			if tt.ok && err != nil {
+				t.Errorf("resolveUser() error = %v", err)
+			}
+			if !tt.ok && (err == nil || !strings.Contains(err.Error(), "role claim does not match")) {
+				t.Errorf("resolveUser() error = %v, want role mismatch", err)
+			}
+		})
+	}
+}
+
+func TestParseRoleMapping(t *testing.T) {
+	mapping, err := ParseRoleMapping(" staff = teacher ,, admins=admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mapping) != 2 || mapping["staff"] != "teacher" || mapping["admins"] != "admin" {
+		t.Errorf("ParseRoleMapping() = %v", mapping)
+	}
+
+	for _, value := range []string{"staff", "staff=", "=teacher", "staff=owner"} {
+		if _, err := ParseRoleMapping(value); err == nil {
+			t.Errorf("ParseRoleMapping(%q) error = nil, want error", value)
+		}
+	}
+}
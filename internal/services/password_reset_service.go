@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/mail"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// passwordResetTokenTTL パスワード再設定トークンの有効期間
+const passwordResetTokenTTL = 30 * time.Minute
+
+// PasswordResetService パスワード再設定サービスの構造体
+type PasswordResetService struct {
+	userRepo        *repositories.UserRepository
+	sessionRepo     *repositories.SessionRepository
+	throttleService *LoginThrottleService
+	mailer          mail.Mailer
+	auditService    *AuditService
+}
+
+// NewPasswordResetService パスワード再設定サービスのコンストラクタ
+func NewPasswordResetService(userRepo *repositories.UserRepository, sessionRepo *repositories.SessionRepository, throttleService *LoginThrottleService, mailer mail.Mailer, auditService *AuditService) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		throttleService: throttleService,
+		mailer:          mailer,
+		auditService:    auditService,
+	}
+}
+
+// RequestReset パスワード再設定トークンを発行してメールで送信する（ipは送信要求の制限に使う）
+// 登録有無を推測されないよう、存在しないメールアドレスでもエラーにしない
+// 登録済みの場合にだけ発生するエラー（トークンの保存・メール送信の失敗）もログに記録して成功として扱う
+func (s *PasswordResetService) RequestReset(request *models.ForgotPasswordRequest, ip string) error {
+	request.Email = normalizeEmail(request.Email)
+	if !isValidEmail(request.Email) {
+		return fmt.Errorf("入力値エラーがあります: valid email is required")
+	}
+
+	// 送信要求の回数を制限する
+	allowed, err := s.throttleService.CheckPasswordReset(request.Email, ip)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return nil
+	}
+
+	// メールアドレスからユーザーを取得
+	user, err := s.userRepo.GetUserByEmail(request.Email)
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	// サービスアカウントはパスワードを持たないため再設定を受け付けない
+	if user == nil || user.IsServiceAccount {
+		return nil
+	}
+
+	// トークンを発行
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		log.Printf("failed to generate password reset token for user %d: %v", user.UserID, err)
+		return nil
+	}
+
+	if err := s.userRepo.CreatePasswordResetToken(user.UserID, tokenHash, time.Now().Add(passwordResetTokenTTL)); err != nil {
+		log.Printf("failed to create password reset token for user %d: %v", user.UserID, err)
+		return nil
+	}
+
+	// 登録済みのメールアドレスに送信
+	err = s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "パスワード再設定のご案内",
+		Body:    fmt.Sprintf("%s 様\n\nパスワードを再設定するには、次の再設定トークンを送信してください。\n\n%s\n\nこのトークンの有効期限は30分です。心当たりがない場合はこのメールを破棄してください。", user.Name, token),
+	})
+	if err != nil {
+		log.Printf("failed to send password reset mail to user %d: %v", user.UserID, err)
+	}
+
+	return nil
+}
+
+// ResetPassword 再設定トークンを検証してパスワードを更新する
+// 操作者はトークンを受け取ったユーザー本人として監査ログに記録する
+func (s *PasswordResetService) ResetPassword(request *models.ResetPasswordRequest, audit models.AuditContext) error {
+	if request.Token == "" {
+		return fmt.Errorf("入力値エラーがあります: token is required")
+	}
+
+	if err := validatePassword(request.NewPassword); err != nil {
+		return err
+	}
+
+	// 新しいパスワードをハッシュ化
+	hashedPassword, err := hashPassword(request.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		// トークンを消費してパスワードを更新
+		userID, err := s.userRepo.WithTx(tx).ResetPassword(hashToken(request.Token), hashedPassword)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		if userID == 0 {
+			return fmt.Errorf("入力値エラーがあります: invalid or expired token")
+		}
+
+		// 既存のセッションはすべて無効化
+		revoked, err := s.sessionRepo.WithTx(tx).RevokeUserSessions(userID, repositories.RevokedReasonPasswordReset)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録（パスワードのハッシュは記録しない）
+		audit.ActorUserID = userID
+		return s.auditService.Record(tx, audit, models.AuditActionResetPassword, policy.ResourceUser, userID, nil, map[string]int{"revoked_sessions": revoked})
+	})
+}
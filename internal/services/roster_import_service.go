@@ -0,0 +1,950 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"github.com/tomoki-den-uhd/go-study/internal/roster"
+)
+
+// maxRelationshipLength 続柄の最大文字数（guardians.relationship）
+const maxRelationshipLength = 50
+
+// rosterTimeLayout 差分レポートに表示する日時の形式（DBにはタイムゾーンなしで保存される）
+const rosterTimeLayout = "2006-01-02T15:04:05"
+
+// RosterImportService 名簿インポートサービスの構造体
+type RosterImportService struct {
+	rosterRepo   *repositories.RosterRepository
+	sessionRepo  *repositories.SessionRepository
+	userService  *UserService
+	auditService *AuditService
+}
+
+// NewRosterImportService 名簿インポートサービスのコンストラクタ
+func NewRosterImportService(rosterRepo *repositories.RosterRepository, sessionRepo *repositories.SessionRepository, userService *UserService, auditService *AuditService) *RosterImportService {
+	return &RosterImportService{
+		rosterRepo:   rosterRepo,
+		sessionRepo:  sessionRepo,
+		userService:  userService,
+		auditService: auditService,
+	}
+}
+
+// rosterAuditEntry コミット後に記録する監査ログ
+type rosterAuditEntry struct {
+	action     string
+	entityType policy.ResourceType
+	entityID   int
+	before     interface{}
+	after      interface{}
+}
+
+// rosterImport 1回のインポートの処理状態
+type rosterImport struct {
+	tx             *repositories.RosterTx
+	dryRun         bool
+	actorUserID    int
+	report         *models.RosterImportReport
+	classTeachers  map[string]roster.Enrollment // classSourcedId → 主担当の教師の受講登録行
+	teacherErrors  map[string]string            // 担当教師を決められない授業のclassSourcedId → 理由
+	audits         []rosterAuditEntry
+	revokeSessions []int // 無効化・役割変更したユーザー（コミット後にセッションを終了する）
+}
+
+// ImportRoster 名簿CSVをインポートする（管理者のみ）
+func (s *RosterImportService) ImportRoster(userID string, files roster.Files, dryRun bool, audit models.AuditContext) (*models.RosterImportReport, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionCreate, policy.Resource{Type: policy.ResourceRoster}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	return s.Import(files, dryRun, audit)
+}
+
+// Import 名簿CSVをインポートする
+// 認可チェックを行わないため、CLIなど信頼できる呼び出し元からのみ使用する
+// 全行を1つのトランザクションで処理し、ドライランまたはエラーのある行が1つでもある場合はロールバックする
+// 新規作成したユーザーはパスワード未設定のため、パスワード再設定かOIDCでログインする
+func (s *RosterImportService) Import(files roster.Files, dryRun bool, audit models.AuditContext) (*models.RosterImportReport, error) {
+	parsed, parseErrors := roster.Parse(files)
+
+	report := &models.RosterImportReport{
+		DryRun:  dryRun,
+		Summary: make(map[string]*models.RosterImportSummary),
+		Changes: []models.RosterImportChange{},
+		Errors:  []models.RosterImportError{},
+	}
+
+	run := &rosterImport{
+		dryRun:      dryRun,
+		actorUserID: audit.ActorUserID,
+		report:      report,
+	}
+	for _, rowError := range parseErrors {
+		run.addError(rowError)
+	}
+	run.resolveClassTeachers(parsed.Enrollments)
+
+	applied, err := s.rosterRepo.RunImport(func(tx *repositories.RosterTx) (bool, error) {
+		run.tx = tx
+		if err := run.importAll(parsed); err != nil {
+			return false, err
+		}
+		if dryRun || len(report.Errors) > 0 {
+			return false, nil
+		}
+
+		// 監査ログを同じトランザクションで記録する（記録できない場合は取り込みもロールバックする）
+		for _, entry := range run.audits {
+			if err := tx.CreateAuditLog(newAuditLog(audit, entry.action, entry.entityType, entry.entityID, entry.before, entry.after)); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+	report.Applied = applied
+
+	if !applied {
+		return report, nil
+	}
+
+	// 無効化・役割変更したユーザーの旧いトークンを使えないようにする
+	for _, userID := range run.revokeSessions {
+		if _, err := s.sessionRepo.RevokeUserSessions(userID, repositories.RevokedReasonAdminRevoked); err != nil {
+			return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+	}
+
+	return report, nil
+}
+
+// importAll 参照関係の順（ユーザー、授業、受講登録、保護者）に取り込む
+func (r *rosterImport) importAll(parsed *roster.Roster) error {
+	for _, user := range parsed.Users {
+		if err := r.importUser(user); err != nil {
+			return err
+		}
+	}
+	for _, class := range parsed.Classes {
+		if err := r.importClass(class); err != nil {
+			return err
+		}
+	}
+	for _, enrollment := range parsed.Enrollments {
+		if err := r.importEnrollment(enrollment); err != nil {
+			return err
+		}
+	}
+	for _, guardian := range parsed.Guardians {
+		if err := r.importGuardian(guardian); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveClassTeachers 受講登録の教師行から授業ごとの主担当を決める
+// 1人だけの場合はその教師、複数の場合はprimary=trueの1人を主担当とし、残りは副担当にする
+func (r *rosterImport) resolveClassTeachers(enrollments []roster.Enrollment) {
+	r.classTeachers = make(map[string]roster.Enrollment)
+	r.teacherErrors = make(map[string]string)
+
+	teachers := make(map[string][]roster.Enrollment)
+	for _, enrollment := range enrollments {
+		if enrollment.Role == roster.EnrollmentRoleTeacher && !enrollment.Deleted {
+			teachers[enrollment.ClassSourcedID] = append(teachers[enrollment.ClassSourcedID], enrollment)
+		}
+	}
+
+	for classSourcedID, candidates := range teachers {
+		if len(candidates) == 1 {
+			r.classTeachers[classSourcedID] = candidates[0]
+			continue
+		}
+
+		var primaries []roster.Enrollment
+		for _, candidate := range candidates {
+			if candidate.Primary {
+				primaries = append(primaries, candidate)
+			}
+		}
+		if len(primaries) == 1 {
+			r.classTeachers[classSourcedID] = primaries[0]
+			continue
+		}
+		r.teacherErrors[classSourcedID] = "multiple teachers for the class: mark exactly one as primary"
+	}
+}
+
+// importUser users.csvの1行を取り込む
+func (r *rosterImport) importUser(row roster.User) error {
+	email := normalizeEmail(row.Email)
+	if !isValidEmail(email) {
+		r.rowError(models.RosterFileUsers, row.Line, row.SourcedID, "valid email is required")
+		return nil
+	}
+
+	existing, err := r.tx.GetUserBySourcedID(row.SourcedID)
+	if err != nil {
+		return err
+	}
+
+	byEmail, err := r.tx.GetActiveUserByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	// 外部IDが未登録の既存ユーザーはメールアドレスで紐付ける
+	if existing == nil && byEmail != nil {
+		if byEmail.SourcedID != nil {
+			r.rowError(models.RosterFileUsers, row.Line, row.SourcedID, "email already registered to another user")
+			return nil
+		}
+		existing = byEmail
+	}
+
+	if existing == nil {
+		if row.Deleted {
+			r.skip(models.RosterFileUsers, row.Line, row.SourcedID, "user not found: nothing to delete")
+			return nil
+		}
+
+		user := &models.User{
+			Name:      row.Name,
+			Email:     email,
+			Password:  unusablePassword,
+			Phone:     row.Phone,
+			Role:      row.Role,
+			SourcedID: &row.SourcedID,
+		}
+		user.UserID, err = r.tx.CreateUser(user)
+		if err != nil {
+			return err
+		}
+
+		r.change(models.RosterFileUsers, row.Line, row.SourcedID, models.RosterActionCreate, user.UserID, rosterUserFields(nil), rosterUserFields(user))
+		r.audit(models.AuditActionCreate, policy.ResourceUser, user.UserID, nil, toAdminUserResponse(user))
+		return nil
+	}
+
+	if existing.IsServiceAccount {
+		r.rowError(models.RosterFileUsers, row.Line, row.SourcedID, "service accounts cannot be imported")
+		return nil
+	}
+
+	if !row.Deleted && byEmail != nil && byEmail.UserID != existing.UserID {
+		r.rowError(models.RosterFileUsers, row.Line, row.SourcedID, "email already registered to another user")
+		return nil
+	}
+
+	desired := *existing
+	desired.Name = row.Name
+	desired.Email = email
+	desired.Role = row.Role
+	desired.IsDeleted = row.Deleted
+	desired.SourcedID = &row.SourcedID
+	if row.Phone != "" {
+		desired.Phone = row.Phone
+	}
+
+	deactivated := !existing.IsDeleted && desired.IsDeleted
+	roleChanged := existing.Role != desired.Role
+
+	// 自分自身の無効化・役割変更は管理画面と同様に禁止する
+	if existing.UserID == r.actorUserID && (deactivated || roleChanged) {
+		r.rowError(models.RosterFileUsers, row.Line, row.SourcedID, "you cannot change your own account status or role")
+		return nil
+	}
+
+	action, changed := r.change(models.RosterFileUsers, row.Line, row.SourcedID, models.RosterActionUpdate, existing.UserID, rosterUserFields(existing), rosterUserFields(&desired))
+	if !changed {
+		return nil
+	}
+
+	if err := r.tx.UpdateUser(&desired); err != nil {
+		return err
+	}
+
+	if deactivated || roleChanged {
+		r.revokeSessions = append(r.revokeSessions, existing.UserID)
+	}
+
+	auditAction := models.AuditActionUpdate
+	if action == models.RosterActionDelete {
+		auditAction = models.AuditActionDeactivate
+	}
+	r.audit(auditAction, policy.ResourceUser, existing.UserID, toAdminUserResponse(existing), toAdminUserResponse(&desired))
+	return nil
+}
+
+// importClass classes.csvの1行を授業として取り込む
+// 担当教師はenrollments.csvの教師行から決め、新規作成時は必須とする
+func (r *rosterImport) importClass(row roster.Class) error {
+	existing, err := r.tx.GetCourseBySourcedID(row.SourcedID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil && row.Deleted {
+		r.skip(models.RosterFileClasses, row.Line, row.SourcedID, "class not found: nothing to delete")
+		return nil
+	}
+
+	subjectID, err := r.tx.GetSubjectIDByName(row.Subject)
+	if err != nil {
+		return err
+	}
+	if subjectID == 0 {
+		r.rowError(models.RosterFileClasses, row.Line, row.SourcedID, fmt.Sprintf("subject not found: %q", row.Subject))
+		return nil
+	}
+
+	if existing != nil {
+		desired := *existing
+		desired.Title = row.Title
+		desired.SubjectID = subjectID
+		desired.IsDeleted = row.Deleted
+		if row.Description != "" {
+			desired.Description = row.Description
+		}
+		if row.ScheduledAt != nil {
+			desired.ScheduledAt = *row.ScheduledAt
+		}
+
+		action, changed := r.change(models.RosterFileClasses, row.Line, row.SourcedID, models.RosterActionUpdate, existing.CourseID, rosterCourseFields(existing), rosterCourseFields(&desired))
+		if !changed {
+			return nil
+		}
+
+		if err := r.tx.UpdateCourse(&desired); err != nil {
+			return err
+		}
+		if err := r.syncCourseSessions(&desired, wallClockNow()); err != nil {
+			return err
+		}
+
+		auditAction := models.AuditActionUpdate
+		if action == models.RosterActionDelete {
+			auditAction = models.AuditActionDelete
+		}
+		r.audit(auditAction, policy.ResourceCourse, existing.CourseID, existing, &desired)
+		return nil
+	}
+
+	if row.ScheduledAt == nil {
+		r.rowError(models.RosterFileClasses, row.Line, row.SourcedID, "scheduledAt is required for a new class")
+		return nil
+	}
+
+	if message, ok := r.teacherErrors[row.SourcedID]; ok {
+		r.rowError(models.RosterFileClasses, row.Line, row.SourcedID, message)
+		return nil
+	}
+
+	teacherRow, ok := r.classTeachers[row.SourcedID]
+	if !ok {
+		r.rowError(models.RosterFileClasses, row.Line, row.SourcedID, "a teacher enrollment is required for a new class")
+		return nil
+	}
+
+	teacherID, message, err := r.resolveUser(teacherRow.UserSourcedID, models.RoleTeacher)
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		r.rowError(models.RosterFileClasses, row.Line, row.SourcedID, "teacher "+message)
+		return nil
+	}
+
+	course := &models.Course{
+		Title:           row.Title,
+		Description:     row.Description,
+		TeacherUserID:   teacherID,
+		SubjectID:       subjectID,
+		ScheduledAt:     *row.ScheduledAt,
+		SourcedID:       &row.SourcedID,
+		DurationMinutes: defaultCourseDurationMinutes,
+	}
+	course.CourseID, err = r.tx.CreateCourse(course)
+	if err != nil {
+		return err
+	}
+	if err := r.syncCourseSessions(course, time.Time{}); err != nil {
+		return err
+	}
+
+	r.change(models.RosterFileClasses, row.Line, row.SourcedID, models.RosterActionCreate, course.CourseID, rosterCourseFields(nil), rosterCourseFields(course))
+	r.audit(models.AuditActionCreate, policy.ResourceCourse, course.CourseID, nil, course)
+	return nil
+}
+
+// importEnrollment enrollments.csvの1行を取り込む
+// 学生行は受講登録、教師行は授業の担当教師として反映する
+func (r *rosterImport) importEnrollment(row roster.Enrollment) error {
+	if row.Role == roster.EnrollmentRoleTeacher {
+		return r.importTeacherEnrollment(row)
+	}
+
+	course, err := r.tx.GetCourseBySourcedID(row.ClassSourcedID)
+	if err != nil {
+		return err
+	}
+	if course == nil || (course.IsDeleted && !row.Deleted) {
+		r.rowError(models.RosterFileEnrollments, row.Line, row.SourcedID, fmt.Sprintf("class not found: %q", row.ClassSourcedID))
+		return nil
+	}
+
+	studentID, message, err := r.resolveUser(row.UserSourcedID, models.RoleStudent)
+	if err != nil {
+		return err
+	}
+	if message != "" && !row.Deleted {
+		r.rowError(models.RosterFileEnrollments, row.Line, row.SourcedID, "student "+message)
+		return nil
+	}
+
+	existing, err := r.tx.GetEnrollmentBySourcedID(row.SourcedID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil && studentID != 0 {
+		existing, err = r.tx.GetEnrollmentByCourseStudent(course.CourseID, studentID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.SourcedID != nil {
+			r.rowError(models.RosterFileEnrollments, row.Line, row.SourcedID, "student is already enrolled in the class under another sourcedId")
+			return nil
+		}
+	}
+
+	status := models.EnrollmentStatusActive
+	if row.Deleted {
+		status = models.EnrollmentStatusDropped
+	}
+
+	if existing == nil {
+		if row.Deleted {
+			r.skip(models.RosterFileEnrollments, row.Line, row.SourcedID, "enrollment not found: nothing to delete")
+			return nil
+		}
+
+		enrollment := &models.Enrollment{
+			CourseID:      course.CourseID,
+			StudentUserID: studentID,
+			Status:        status,
+			SourcedID:     &row.SourcedID,
+		}
+		enrollment.EnrollmentID, err = r.tx.CreateEnrollment(enrollment)
+		if err != nil {
+			return err
+		}
+
+		r.change(models.RosterFileEnrollments, row.Line, row.SourcedID, models.RosterActionCreate, enrollment.EnrollmentID, rosterEnrollmentFields(nil), rosterEnrollmentFields(enrollment))
+		r.audit(models.AuditActionCreate, policy.ResourceEnrollment, enrollment.EnrollmentID, nil, enrollment)
+		return nil
+	}
+
+	desired := *existing
+	desired.Status = status
+	desired.SourcedID = &row.SourcedID
+	// 修了した受講登録は名簿に残っていても受講中に戻さない
+	if !row.Deleted && existing.Status == models.EnrollmentStatusCompleted {
+		desired.Status = models.EnrollmentStatusCompleted
+	}
+	if !row.Deleted {
+		desired.CourseID = course.CourseID
+		desired.StudentUserID = studentID
+	}
+
+	// 授業・学生を付け替える場合は付け替え先の受講登録と重複しないこと
+	if desired.CourseID != existing.CourseID || desired.StudentUserID != existing.StudentUserID {
+		conflict, err := r.tx.GetEnrollmentByCourseStudent(desired.CourseID, desired.StudentUserID)
+		if err != nil {
+			return err
+		}
+		if conflict != nil {
+			r.rowError(models.RosterFileEnrollments, row.Line, row.SourcedID, "student is already enrolled in the class")
+			return nil
+		}
+	}
+
+	action, changed := r.change(models.RosterFileEnrollments, row.Line, row.SourcedID, models.RosterActionUpdate, existing.EnrollmentID, rosterEnrollmentFields(existing), rosterEnrollmentFields(&desired))
+	if !changed {
+		return nil
+	}
+
+	if err := r.tx.UpdateEnrollment(&desired); err != nil {
+		return err
+	}
+
+	auditAction := models.AuditActionUpdate
+	if action == models.RosterActionDelete {
+		auditAction = models.AuditActionDelete
+	}
+	r.audit(auditAction, policy.ResourceEnrollment, existing.EnrollmentID, existing, &desired)
+	return nil
+}
+
+// importTeacherEnrollment 教師の受講登録行を授業の担当として反映する
+// 主担当に選ばれた行は授業の主担当に、それ以外の行は副担当にし、削除行は担当から外す
+func (r *rosterImport) importTeacherEnrollment(row roster.Enrollment) error {
+	if message, ok := r.teacherErrors[row.ClassSourcedID]; ok && !row.Deleted {
+		r.rowError(models.RosterFileEnrollments, row.Line, row.SourcedID, message)
+		return nil
+	}
+
+	course, err := r.tx.GetCourseBySourcedID(row.ClassSourcedID)
+	if err != nil {
+		return err
+	}
+	if course == nil || (course.IsDeleted && !row.Deleted) {
+		r.rowError(models.RosterFileEnrollments, row.Line, row.SourcedID, fmt.Sprintf("class not found: %q", row.ClassSourcedID))
+		return nil
+	}
+
+	teacherID, message, err := r.resolveUser(row.UserSourcedID, models.RoleTeacher)
+	if err != nil {
+		return err
+	}
+	if message != "" && !row.Deleted {
+		r.rowError(models.RosterFileEnrollments, row.Line, row.SourcedID, "teacher "+message)
+		return nil
+	}
+
+	if row.Deleted {
+		return r.removeCoTeacher(row, course, teacherID)
+	}
+
+	if teacherRow := r.classTeachers[row.ClassSourcedID]; teacherRow.SourcedID != row.SourcedID {
+		return r.importCoTeacher(row, course, teacherID)
+	}
+
+	desired := *course
+	desired.TeacherUserID = teacherID
+
+	changes := diffRosterFields(map[string]interface{}{"teacher_user_id": course.TeacherUserID}, map[string]interface{}{"teacher_user_id": teacherID})
+	if len(changes) == 0 {
+		r.addChange(models.RosterImportChange{File: models.RosterFileEnrollments, Line: row.Line, SourcedID: row.SourcedID, Action: models.RosterActionUnchanged, EntityID: course.CourseID})
+		return nil
+	}
+
+	// 以前の主担当は副担当になる
+	if err := r.tx.UpdateCourse(&desired); err != nil {
+		return err
+	}
+
+	r.addChange(models.RosterImportChange{
+		File:      models.RosterFileEnrollments,
+		Line:      row.Line,
+		SourcedID: row.SourcedID,
+		Action:    models.RosterActionUpdate,
+		EntityID:  course.CourseID,
+		Changes:   changes,
+		Note:      "class teacher",
+	})
+	r.audit(models.AuditActionUpdate, policy.ResourceCourse, course.CourseID, course, &desired)
+	return nil
+}
+
+// importCoTeacher 主担当以外の教師の行を授業の副担当として反映する
+// 主担当の場合は変更しない（主担当の交代は主担当の行で行う）
+func (r *rosterImport) importCoTeacher(row roster.Enrollment, course *models.Course, teacherID int) error {
+	role, err := r.tx.GetStaffRole(course.CourseID, teacherID)
+	if err != nil {
+		return err
+	}
+
+	if role == models.CourseStaffRoleOwner || role == models.CourseStaffRoleCoTeacher {
+		r.addChange(models.RosterImportChange{File: models.RosterFileEnrollments, Line: row.Line, SourcedID: row.SourcedID, Action: models.RosterActionUnchanged, EntityID: course.CourseID})
+		return nil
+	}
+
+	if err := r.tx.SetStaffRole(course.CourseID, teacherID, models.CourseStaffRoleCoTeacher); err != nil {
+		return err
+	}
+
+	after := rosterStaffFields(teacherID, models.CourseStaffRoleCoTeacher)
+
+	if role == "" {
+		r.addChange(models.RosterImportChange{
+			File:      models.RosterFileEnrollments,
+			Line:      row.Line,
+			SourcedID: row.SourcedID,
+			Action:    models.RosterActionCreate,
+			EntityID:  course.CourseID,
+			Changes:   diffRosterFields(nil, after),
+			Note:      "co-teacher",
+		})
+		r.audit(models.AuditActionCreate, policy.ResourceStaff, course.CourseID, nil, after)
+		return nil
+	}
+
+	// アシスタントとして登録済みの場合は副担当に変更する
+	before := rosterStaffFields(teacherID, role)
+	r.addChange(models.RosterImportChange{
+		File:      models.RosterFileEnrollments,
+		Line:      row.Line,
+		SourcedID: row.SourcedID,
+		Action:    models.RosterActionUpdate,
+		EntityID:  course.CourseID,
+		Changes:   diffRosterFields(before, after),
+		Note:      "co-teacher",
+	})
+	r.audit(models.AuditActionUpdate, policy.ResourceStaff, course.CourseID, before, after)
+	return nil
+}
+
+// removeCoTeacher 教師の削除行で、主担当以外の担当を授業から外す
+// 主担当は別の教師を主担当として取り込んで交代させるため外さない
+func (r *rosterImport) removeCoTeacher(row roster.Enrollment, course *models.Course, teacherID int) error {
+	var role string
+	if teacherID != 0 {
+		var err error
+		role, err = r.tx.GetStaffRole(course.CourseID, teacherID)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch role {
+	case "":
+		r.skip(models.RosterFileEnrollments, row.Line, row.SourcedID, "teacher is not on the class staff: nothing to delete")
+		return nil
+	case models.CourseStaffRoleOwner:
+		r.skip(models.RosterFileEnrollments, row.Line, row.SourcedID, "the class teacher is replaced by importing another teacher, not removed")
+		return nil
+	}
+
+	if err := r.tx.RemoveStaff(course.CourseID, teacherID); err != nil {
+		return err
+	}
+
+	before := rosterStaffFields(teacherID, role)
+	r.addChange(models.RosterImportChange{
+		File:      models.RosterFileEnrollments,
+		Line:      row.Line,
+		SourcedID: row.SourcedID,
+		Action:    models.RosterActionDelete,
+		EntityID:  course.CourseID,
+		Changes:   diffRosterFields(before, rosterStaffFields(teacherID, "")),
+		Note:      "co-teacher",
+	})
+	r.audit(models.AuditActionDelete, policy.ResourceStaff, course.CourseID, before, nil)
+	return nil
+}
+
+// importGuardian guardians.csvの1行を取り込む
+func (r *rosterImport) importGuardian(row roster.Guardian) error {
+	if len([]rune(row.Relationship)) > maxRelationshipLength {
+		r.rowError(models.RosterFileGuardians, row.Line, row.SourcedID, fmt.Sprintf("relationship must be at most %d characters", maxRelationshipLength))
+		return nil
+	}
+
+	existing, err := r.tx.GetGuardianBySourcedID(row.SourcedID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil && row.Deleted {
+		r.skip(models.RosterFileGuardians, row.Line, row.SourcedID, "guardian not found: nothing to delete")
+		return nil
+	}
+
+	// 削除行は参照先のユーザーが無効化されていても紐付けを解除できるようにする
+	if existing != nil && row.Deleted {
+		desired := *existing
+		desired.IsDeleted = true
+		desired.SourcedID = &row.SourcedID
+		return r.updateGuardian(row, existing, &desired)
+	}
+
+	parentID, message, err := r.resolveUser(row.ParentSourcedID, models.RoleParent)
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		r.rowError(models.RosterFileGuardians, row.Line, row.SourcedID, "parent "+message)
+		return nil
+	}
+
+	studentID, message, err := r.resolveUser(row.StudentSourcedID, models.RoleStudent)
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		r.rowError(models.RosterFileGuardians, row.Line, row.SourcedID, "student "+message)
+		return nil
+	}
+
+	active, err := r.tx.GetActiveGuardianByPair(parentID, studentID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		// 外部IDが未登録の既存の紐付けは保護者と学生の組み合わせで紐付ける
+		if active != nil && active.SourcedID != nil {
+			r.rowError(models.RosterFileGuardians, row.Line, row.SourcedID, "guardian already linked under another sourcedId")
+			return nil
+		}
+		if active != nil {
+			desired := *active
+			desired.Relationship = row.Relationship
+			desired.SourcedID = &row.SourcedID
+			return r.updateGuardian(row, active, &desired)
+		}
+
+		guardian := &models.Guardian{
+			ParentUserID:  parentID,
+			StudentUserID: studentID,
+			Relationship:  row.Relationship,
+			SourcedID:     &row.SourcedID,
+		}
+		guardian.GuardianID, err = r.tx.CreateGuardian(guardian)
+		if err != nil {
+			return err
+		}
+
+		r.change(models.RosterFileGuardians, row.Line, row.SourcedID, models.RosterActionCreate, guardian.GuardianID, rosterGuardianFields(nil), rosterGuardianFields(guardian))
+		r.audit(models.AuditActionCreate, policy.ResourceGuardian, guardian.GuardianID, nil, guardian)
+		return nil
+	}
+
+	if active != nil && active.GuardianID != existing.GuardianID {
+		r.rowError(models.RosterFileGuardians, row.Line, row.SourcedID, "guardian already linked")
+		return nil
+	}
+
+	desired := *existing
+	desired.ParentUserID = parentID
+	desired.StudentUserID = studentID
+	desired.Relationship = row.Relationship
+	desired.IsDeleted = false
+	desired.SourcedID = &row.SourcedID
+	return r.updateGuardian(row, existing, &desired)
+}
+
+// updateGuardian 既存の紐付けを更新する（変更がない場合は何もしない）
+func (r *rosterImport) updateGuardian(row roster.Guardian, existing *models.Guardian, desired *models.Guardian) error {
+	action, changed := r.change(models.RosterFileGuardians, row.Line, row.SourcedID, models.RosterActionUpdate, existing.GuardianID, rosterGuardianFields(existing), rosterGuardianFields(desired))
+	if !changed {
+		return nil
+	}
+
+	if err := r.tx.UpdateGuardian(desired); err != nil {
+		return err
+	}
+
+	auditAction := models.AuditActionUpdate
+	if action == models.RosterActionDelete {
+		auditAction = models.AuditActionDelete
+	}
+	r.audit(auditAction, policy.ResourceGuardian, existing.GuardianID, existing, desired)
+	return nil
+}
+
+// resolveUser 外部IDから有効なユーザーを取得し、役割を確認する
+// 入力値の問題は2番目の戻り値にメッセージとして返す
+func (r *rosterImport) resolveUser(sourcedID string, role string) (int, string, error) {
+	user, err := r.tx.GetUserBySourcedID(sourcedID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if user == nil || user.IsDeleted {
+		return 0, fmt.Sprintf("not found: %q", sourcedID), nil
+	}
+
+	if user.Role != role {
+		return user.UserID, fmt.Sprintf("%q must have role %s", sourcedID, role), nil
+	}
+
+	return user.UserID, "", nil
+}
+
+// syncCourseSessions 授業回を授業の日時・繰り返しに合わせる（fromより前に始まる回は変更しない）
+func (r *rosterImport) syncCourseSessions(course *models.Course, from time.Time) error {
+	occurrences, err := courseOccurrences(course)
+	if err != nil {
+		return err
+	}
+	return r.tx.SyncCourseSessions(course.CourseID, occurrences, courseSessionDuration(course), from)
+}
+
+// change 変更前後の項目を比較して行の処理内容を記録し、処理内容と変更の有無を返す
+// 更新で状態が有効から削除に変わる場合は削除として記録する
+func (r *rosterImport) change(file string, line int, sourcedID string, action string, entityID int, before map[string]interface{}, after map[string]interface{}) (string, bool) {
+	changes := diffRosterFields(before, after)
+	if len(changes) == 0 {
+		r.addChange(models.RosterImportChange{File: file, Line: line, SourcedID: sourcedID, Action: models.RosterActionUnchanged, EntityID: entityID})
+		return models.RosterActionUnchanged, false
+	}
+
+	if action == models.RosterActionUpdate {
+		if deleted, ok := changes["is_deleted"]; ok && deleted.After == true {
+			action = models.RosterActionDelete
+		}
+		if status, ok := changes["status"]; ok && status.After == models.EnrollmentStatusDropped {
+			action = models.RosterActionDelete
+		}
+	}
+
+	// ドライランで新規作成したIDはロールバックされるため返さない
+	if action == models.RosterActionCreate && r.dryRun {
+		entityID = 0
+	}
+
+	r.addChange(models.RosterImportChange{File: file, Line: line, SourcedID: sourcedID, Action: action, EntityID: entityID, Changes: changes})
+	return action, true
+}
+
+// skip 反映しない行を記録する
+func (r *rosterImport) skip(file string, line int, sourcedID string, note string) {
+	r.addChange(models.RosterImportChange{File: file, Line: line, SourcedID: sourcedID, Action: models.RosterActionSkip, Note: note})
+}
+
+// addChange 行の処理内容をレポートに追加し、件数を集計する
+func (r *rosterImport) addChange(change models.RosterImportChange) {
+	r.report.Changes = append(r.report.Changes, change)
+
+	summary := r.summary(change.File)
+	switch change.Action {
+	case models.RosterActionCreate:
+		summary.Create++
+	case models.RosterActionUpdate:
+		summary.Update++
+	case models.RosterActionDelete:
+		summary.Delete++
+	case models.RosterActionUnchanged:
+		summary.Unchanged++
+	case models.RosterActionSkip:
+		summary.Skip++
+	}
+}
+
+// rowError 行のエラーをレポートに追加する
+func (r *rosterImport) rowError(file string, line int, sourcedID string, message string) {
+	r.addError(models.RosterImportError{File: file, Line: line, SourcedID: sourcedID, Message: message})
+}
+
+// addError エラーをレポートに追加し、件数を集計する
+func (r *rosterImport) addError(rowError models.RosterImportError) {
+	r.report.Errors = append(r.report.Errors, rowError)
+	r.summary(rowError.File).Error++
+}
+
+// summary ファイルごとの件数を取得する
+func (r *rosterImport) summary(file string) *models.RosterImportSummary {
+	summary, ok := r.report.Summary[file]
+	if !ok {
+		summary = &models.RosterImportSummary{}
+		r.report.Summary[file] = summary
+	}
+	return summary
+}
+
+// audit コミット後に記録する監査ログを追加する
+func (r *rosterImport) audit(action string, entityType policy.ResourceType, entityID int, before interface{}, after interface{}) {
+	r.audits = append(r.audits, rosterAuditEntry{action: action, entityType: entityType, entityID: entityID, before: before, after: after})
+}
+
+// rosterUserFields 差分レポートに表示するユーザーの項目（nilの場合は空）
+func rosterUserFields(user *models.User) map[string]interface{} {
+	if user == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"name":       user.Name,
+		"email":      user.Email,
+		"phone":      user.Phone,
+		"role":       user.Role,
+		"is_deleted": user.IsDeleted,
+		"sourced_id": stringValue(user.SourcedID),
+	}
+}
+
+// rosterCourseFields 差分レポートに表示する授業の項目（nilの場合は空）
+func rosterCourseFields(course *models.Course) map[string]interface{} {
+	if course == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"title":           course.Title,
+		"description":     course.Description,
+		"teacher_user_id": course.TeacherUserID,
+		"subject_id":      course.SubjectID,
+		"scheduled_at":    course.ScheduledAt.Format(rosterTimeLayout),
+		"is_deleted":      course.IsDeleted,
+		"sourced_id":      stringValue(course.SourcedID),
+	}
+}
+
+// rosterEnrollmentFields 差分レポートに表示する受講登録の項目（nilの場合は空）
+func rosterEnrollmentFields(enrollment *models.Enrollment) map[string]interface{} {
+	if enrollment == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"course_id":       enrollment.CourseID,
+		"student_user_id": enrollment.StudentUserID,
+		"status":          enrollment.Status,
+		"sourced_id":      stringValue(enrollment.SourcedID),
+	}
+}
+
+// rosterStaffFields 差分レポートに表示する授業の担当の項目（担当でない場合はroleがnull）
+func rosterStaffFields(userID int, role string) map[string]interface{} {
+	fields := map[string]interface{}{"user_id": userID, "role": nil}
+	if role != "" {
+		fields["role"] = role
+	}
+	return fields
+}
+
+// rosterGuardianFields 差分レポートに表示する紐付けの項目（nilの場合は空）
+func rosterGuardianFields(guardian *models.Guardian) map[string]interface{} {
+	if guardian == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"parent_user_id":  guardian.ParentUserID,
+		"student_user_id": guardian.StudentUserID,
+		"relationship":    guardian.Relationship,
+		"is_deleted":      guardian.IsDeleted,
+		"sourced_id":      stringValue(guardian.SourcedID),
+	}
+}
+
+// diffRosterFields 変更前後で値が異なる項目を返す（beforeが空の場合は全項目）
+func diffRosterFields(before map[string]interface{}, after map[string]interface{}) map[string]models.RosterFieldChange {
+	changes := make(map[string]models.RosterFieldChange)
+	for key, afterValue := range after {
+		beforeValue, ok := before[key]
+		if !ok || !reflect.DeepEqual(beforeValue, afterValue) {
+			changes[key] = models.RosterFieldChange{Before: beforeValue, After: afterValue}
+		}
+	}
+	return changes
+}
+
+// stringValue NULL許容の文字列をレポート用の値に変換する
+func stringValue(value *string) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"github.com/tomoki-den-uhd/go-study/internal/roster"
+)
+
+// rosterTestDB usersテーブルのメモリ上の代わり（repositories.DBTX）
+// トランザクション内の変更はコミットした場合のみ反映する
+type rosterTestDB struct {
+	users     map[int]models.User
+	nextID    int
+	auditLogs int
+}
+
+func (db *rosterTestDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	users := make(map[int]models.User, len(db.users))
+	for id, user := range db.users {
+		users[id] = user
+	}
+	return &rosterTestTx{db: db, users: users, nextID: db.nextID}, nil
+}
+
+func (db *rosterTestDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, fmt.Errorf("unexpected exec outside transaction: %s", sql)
+}
+
+func (db *rosterTestDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, fmt.Errorf("unexpected query outside transaction: %s", sql)
+}
+
+func (db *rosterTestDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return testRow{err: fmt.Errorf("unexpected query outside transaction: %s", sql)}
+}
+
+// rosterTestTx 名簿インポートのトランザクション（使用しないメソッドは埋め込んだnilのpgx.Txを呼び出す）
+type rosterTestTx struct {
+	pgx.Tx
+	db        *rosterTestDB
+	users     map[int]models.User
+	nextID    int
+	auditLogs int
+}
+
+func (tx *rosterTestTx) Commit(ctx context.Context) error {
+	tx.db.users = tx.users
+	tx.db.nextID = tx.nextID
+	tx.db.auditLogs += tx.auditLogs
+	return nil
+}
+
+func (tx *rosterTestTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
+func (tx *rosterTestTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	switch {
+	case strings.Contains(sql, "pg_advisory_xact_lock"):
+		return pgconn.NewCommandTag("SELECT 1"), nil
+	case strings.Contains(sql, "UPDATE users"):
+		userID := args[7].(int)
+		user := tx.users[userID]
+		user.Name = args[0].(string)
+		user.Email = args[1].(string)
+		user.Phone = args[2].(string)
+		user.Role = args[3].(string)
+		user.IsDeleted = args[4].(bool)
+		user.SourcedID = args[5].(*string)
+		tx.users[userID] = user
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	}
+	return pgconn.CommandTag{}, fmt.Errorf("unexpected exec: %s", sql)
+}
+
+func (tx *rosterTestTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	switch {
+	case strings.Contains(sql, "FROM users WHERE sourced_id"):
+		return tx.findUser(func(user models.User) bool {
+			return user.SourcedID != nil && *user.SourcedID == args[0].(string)
+		})
+	case strings.Contains(sql, "FROM users WHERE lower(email)"):
+		return tx.findUser(func(user models.User) bool {
+			return strings.EqualFold(user.Email, args[0].(string)) && !user.IsDeleted
+		})
+	case strings.Contains(sql, "INSERT INTO users"):
+		tx.nextID++
+		tx.users[tx.nextID] = models.User{
+			UserID:    tx.nextID,
+			Name:      args[0].(string),
+			Email:     args[1].(string),
+			Phone:     args[3].(string),
+			Role:      args[4].(string),
+			IsDeleted: args[7].(bool),
+			SourcedID: args[8].(*string),
+		}
+		return testRow{values: []any{tx.nextID}}
+	case strings.Contains(sql, "INSERT INTO audit_logs"):
+		tx.auditLogs++
+		return testRow{values: []any{int64(tx.db.auditLogs + tx.auditLogs)}}
+	}
+	return testRow{err: fmt.Errorf("unexpected query: %s", sql)}
+}
+
+// findUser 条件に一致するユーザーをrosterUserColumnsの順で返す
+func (tx *rosterTestTx) findUser(match func(user models.User) bool) pgx.Row {
+	for _, user := range tx.users {
+		if match(user) {
+			return testRow{values: []any{user.UserID, user.Name, user.Email, user.Phone, user.Role, user.IsDeleted, user.IsServiceAccount, user.SourcedID}}
+		}
+	}
+	return testRow{err: pgx.ErrNoRows}
+}
+
+const rosterTestUsers = "sourcedId,status,role,givenName,familyName,email,phone\n" +
+	"u1,active,student,Taro,Yamada,Taro.Yamada@Example.com,\n" +
+	"u2,active,parent,Hanako,Yamada,hanako@example.com,090-0000-0000\n"
+
+// importTestUsers users.csvを取り込み、エラーがないことを確認してレポートを返す
+func importTestUsers(t *testing.T, service *RosterImportService, csv string, dryRun bool) *models.RosterImportReport {
+	t.Helper()
+
+	report, err := service.Import(roster.Files{Users: strings.NewReader(csv)}, dryRun, models.AuditContext{ActorUserID: 100})
+	if err != nil {
+		t.Fatalf("Import(dryRun=%v) error = %v", dryRun, err)
+	}
+	if len(report.Errors) != 0 {
+		t.Fatalf("Import(dryRun=%v) errors = %+v", dryRun, report.Errors)
+	}
+	return report
+}
+
+// TestRosterImportIdempotent 取り込み済みのファイルを再度取り込んでも変更がないこと
+func TestRosterImportIdempotent(t *testing.T) {
+	db := &rosterTestDB{users: map[int]models.User{}}
+	service := NewRosterImportService(&repositories.RosterRepository{DB: db}, nil, nil, nil)
+
+	// ドライランは差分を返すだけで反映しない
+	report := importTestUsers(t, service, rosterTestUsers, true)
+	if report.Applied || report.Summary[models.RosterFileUsers].Create != 2 {
+		t.Fatalf("dry run report = %+v, summary = %+v", report, report.Summary[models.RosterFileUsers])
+	}
+	for _, change := range report.Changes {
+		if change.EntityID != 0 {
+			t.Errorf("dry run create EntityID = %d, want 0", change.EntityID)
+		}
+	}
+	if len(db.users) != 0 || db.auditLogs != 0 {
+		t.Fatalf("dry run wrote %d users, %d audit logs", len(db.users), db.auditLogs)
+	}
+
+	report = importTestUsers(t, service, rosterTestUsers, false)
+	if !report.Applied || report.Summary[models.RosterFileUsers].Create != 2 {
+		t.Fatalf("import report = %+v, summary = %+v", report, report.Summary[models.RosterFileUsers])
+	}
+	if len(db.users) != 2 || db.auditLogs != 2 {
+		t.Fatalf("import wrote %d users, %d audit logs, want 2 and 2", len(db.users), db.auditLogs)
+	}
+
+	// 同じファイルのドライラン・取り込みは全行が変更なしになる
+	for _, dryRun := range []bool{true, false} {
+		report = importTestUsers(t, service, rosterTestUsers, dryRun)
+
+		summary := report.Summary[models.RosterFileUsers]
+		if *summary != (models.RosterImportSummary{Unchanged: 2}) {
+			t.Errorf("reimport(dryRun=%v) summary = %+v, want 2 unchanged", dryRun, summary)
+		}
+		for _, change := range report.Changes {
+			if change.Action != models.RosterActionUnchanged || len(change.Changes) != 0 || change.EntityID == 0 {
+				t.Errorf("reimport(dryRun=%v) change = %+v, want unchanged with the existing id", dryRun, change)
+			}
+		}
+	}
+	if db.auditLogs != 2 {
+		t.Errorf("reimport wrote audit logs: %d, want 2", db.auditLogs)
+	}
+
+	// 変更した行だけが差分になる
+	changed := strings.Replace(rosterTestUsers, "Hanako,Yamada", "Hanako,Suzuki", 1)
+	report = importTestUsers(t, service, changed, true)
+	if summary := report.Summary[models.RosterFileUsers]; *summary != (models.RosterImportSummary{Update: 1, Unchanged: 1}) {
+		t.Fatalf("changed summary = %+v, want 1 update and 1 unchanged", summary)
+	}
+	for _, change := range report.Changes {
+		if change.SourcedID != "u2" {
+			continue
+		}
+		want := models.RosterFieldChange{Before: "Yamada Hanako", After: "Suzuki Hanako"}
+		if len(change.Changes) != 1 || change.Changes["name"] != want {
+			t.Errorf("u2 changes = %+v, want only name %+v", change.Changes, want)
+		}
+	}
+}
+
+func TestDiffRosterFields(t *testing.T) {
+	sourcedID := "u1"
+	user := &models.User{UserID: 1, Name: "Yamada Taro", Email: "taro@example.com", Role: models.RoleStudent, SourcedID: &sourcedID}
+
+	// 同じ値のポインターが異なっても変更なし
+	same := *user
+	other := "u1"
+	same.SourcedID = &other
+	if changes := diffRosterFields(rosterUserFields(user), rosterUserFields(&same)); len(changes) != 0 {
+		t.Errorf("diffRosterFields(same) = %+v, want none", changes)
+	}
+
+	// 新規作成は全項目
+	if changes := diffRosterFields(rosterUserFields(nil), rosterUserFields(user)); len(changes) != len(rosterUserFields(user)) {
+		t.Errorf("diffRosterFields(nil, user) = %+v, want all fields", changes)
+	}
+
+	deleted := *user
+	deleted.IsDeleted = true
+	want := map[string]models.RosterFieldChange{"is_deleted": {Before: false, After: true}}
+	if changes := diffRosterFields(rosterUserFields(user), rosterUserFields(&deleted)); len(changes) != 1 || changes["is_deleted"] != want["is_deleted"] {
+		t.Errorf("diffRosterFields(deleted) = %+v, want %+v", changes, want)
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// maxScheduleConflicts 予定の重複エラーで返す重複の件数の上限
+const maxScheduleConflicts = 100
+
+// ScheduleConflictError 教師・学生の予定が他の授業回・テストと重複する場合のエラー
+type ScheduleConflictError struct {
+	Total     int
+	Conflicts []models.ScheduleConflict // 先頭のmaxScheduleConflicts件
+}
+
+// Error エラーメッセージを返す
+func (e *ScheduleConflictError) Error() string {
+	return fmt.Sprintf("schedule conflict: %d conflicting items", e.Total)
+}
+
+// authorizeConflictOverride 予定の重複を無視して登録・変更する権限をチェックする（管理者のみ）
+func authorizeConflictOverride(principal *models.Principal, resourceType policy.ResourceType) error {
+	if err := policy.Authorize(principal, policy.ActionOverride, policy.Resource{Type: resourceType}); err != nil {
+		return fmt.Errorf("access denied: %w: only admins can override schedule conflicts", err)
+	}
+	return nil
+}
+
+// checkScheduleConflicts 時間帯が授業の担当（staffUserIDs）・受講中の学生の他の予定と重複しないか調べる
+// 重複がある場合はScheduleConflictErrorを返し、overrideがtrueの場合はエラーにせず重複の件数を返す
+// 終了済みの時間帯は対象にしない
+// sessionRepoは予定を登録するトランザクションのもの（WithTx）を渡す。対象のユーザーの予定をコミットまでロックし、
+// 同時に登録された予定との重複を見落とさないようにする
+func checkScheduleConflicts(sessionRepo *repositories.CourseSessionRepository, courseID int, staffUserIDs []int, slots []models.ScheduleSlot, override bool) (int, error) {
+	if err := sessionRepo.LockSchedules(courseID, staffUserIDs); err != nil {
+		return 0, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	now := wallClockNow()
+	upcoming := make([]models.ScheduleSlot, 0, len(slots))
+	for _, slot := range slots {
+		if slot.EndsAt.After(now) {
+			upcoming = append(upcoming, slot)
+		}
+	}
+
+	conflicts, err := sessionRepo.FindScheduleConflicts(courseID, staffUserIDs, upcoming)
+	if err != nil {
+		return 0, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if len(conflicts) == 0 || override {
+		return len(conflicts), nil
+	}
+
+	conflictErr := &ScheduleConflictError{Total: len(conflicts), Conflicts: conflicts}
+	if len(conflicts) > maxScheduleConflicts {
+		conflictErr.Conflicts = conflicts[:maxScheduleConflicts]
+	}
+	return 0, conflictErr
+}
+
+// courseScheduleSlots 繰り返しを展開した日時を時間帯にする
+// existingに同じ時間帯の授業回がある回と、個別に変更した回（繰り返しの変更で上書きしない）は除く
+func courseScheduleSlots(occurrences []time.Time, duration time.Duration, existing []models.CourseSession) []models.ScheduleSlot {
+	unchanged := map[[2]int64]bool{}
+	overridden := map[int64]bool{}
+	for _, session := range existing {
+		if session.IsOverride {
+			overridden[session.OriginalStartsAt.Unix()] = true
+		}
+		if session.Status == models.CourseSessionStatusScheduled {
+			unchanged[[2]int64{session.StartsAt.Unix(), session.EndsAt.Unix()}] = true
+		}
+	}
+
+	slots := make([]models.ScheduleSlot, 0, len(occurrences))
+	for _, startsAt := range occurrences {
+		endsAt := startsAt.Add(duration)
+		if overridden[startsAt.Unix()] || unchanged[[2]int64{startsAt.Unix(), endsAt.Unix()}] {
+			continue
+		}
+		slots = append(slots, models.ScheduleSlot{StartsAt: startsAt, EndsAt: endsAt})
+	}
+
+	return slots
+}
+
+// scheduleConflictInfo 重複を無視して登録・変更した場合にレスポンスのinfoへ件数を設定する
+func scheduleConflictInfo(overridden int) map[string]interface{} {
+	info := map[string]interface{}{}
+	if overridden > 0 {
+		info["overridden_conflicts"] = overridden
+	}
+	return info
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// maxSubjectNameLength 教科名の最大文字数
+const maxSubjectNameLength = 100
+
+// SubjectService 教科サービスの構造体
+type SubjectService struct {
+	subjectRepo  *repositories.SubjectRepository
+	userService  *UserService
+	auditService *AuditService
+}
+
+// NewSubjectService 教科サービスのコンストラクタ
+func NewSubjectService(subjectRepo *repositories.SubjectRepository, userService *UserService, auditService *AuditService) *SubjectService {
+	return &SubjectService{
+		subjectRepo:  subjectRepo,
+		userService:  userService,
+		auditService: auditService,
+	}
+}
+
+// ListSubjects 教科の一覧を取得する（管理者のみ）
+func (s *SubjectService) ListSubjects(userID string, request *models.SubjectListRequest) (*models.SubjectListResponse, error) {
+	if err := s.authorize(userID, policy.ActionList); err != nil {
+		return nil, err
+	}
+
+	subjects, err := s.subjectRepo.ListSubjects(request.IncludeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if subjects == nil {
+		subjects = []models.SubjectResponse{}
+	}
+
+	return &models.SubjectListResponse{
+		Count:    len(subjects),
+		Subjects: subjects,
+	}, nil
+}
+
+// CreateSubject 教科を登録する（管理者のみ）
+func (s *SubjectService) CreateSubject(userID string, request *models.SubjectRequest, audit models.AuditContext) (*models.SubjectResponse, error) {
+	if err := s.authorize(userID, policy.ActionCreate); err != nil {
+		return nil, err
+	}
+
+	name, err := validateSubjectName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject *models.SubjectResponse
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		subjectRepo := s.subjectRepo.WithTx(tx)
+		subjectID, err := subjectRepo.CreateSubject(name)
+		if err != nil {
+			return subjectError(err)
+		}
+
+		subject, err = getSubject(subjectRepo, subjectID)
+		if err != nil {
+			return err
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceSubject, subjectID, nil, &subject.Subject)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subject, nil
+}
+
+// RenameSubject 教科の名前を変更する（管理者のみ）
+func (s *SubjectService) RenameSubject(userID string, subjectID string, request *models.SubjectRequest, audit models.AuditContext) (*models.SubjectResponse, error) {
+	if err := s.authorize(userID, policy.ActionUpdate); err != nil {
+		return nil, err
+	}
+
+	subjectIDInt, err := parseSubjectID(subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := validateSubjectName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *models.SubjectResponse
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		subjectRepo := s.subjectRepo.WithTx(tx)
+		before, err := getSubject(subjectRepo, subjectIDInt)
+		if err != nil {
+			return err
+		}
+
+		if err := subjectRepo.RenameSubject(subjectIDInt, name); err != nil {
+			return subjectError(err)
+		}
+
+		after, err = getSubject(subjectRepo, subjectIDInt)
+		if err != nil {
+			return err
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceSubject, subjectIDInt, &before.Subject, &after.Subject)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// DeleteSubject 教科を論理削除する（管理者のみ）
+// 有効な授業が参照している教科は削除できない
+func (s *SubjectService) DeleteSubject(userID string, subjectID string, audit models.AuditContext) error {
+	if err := s.authorize(userID, policy.ActionDelete); err != nil {
+		return err
+	}
+
+	subjectIDInt, err := parseSubjectID(subjectID)
+	if err != nil {
+		return err
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		subjectRepo := s.subjectRepo.WithTx(tx)
+		before, err := getSubject(subjectRepo, subjectIDInt)
+		if err != nil {
+			return err
+		}
+
+		if err := subjectRepo.DeleteSubject(subjectIDInt); err != nil {
+			return subjectError(err)
+		}
+
+		// 監査ログを記録
+		after := before.Subject
+		after.IsDeleted = true
+		return s.auditService.Record(tx, audit, models.AuditActionDelete, policy.ResourceSubject, subjectIDInt, &before.Subject, &after)
+	})
+}
+
+// RestoreSubject 論理削除した教科を復元する（管理者のみ）
+// 同じ名前の有効な教科がある場合は復元できない
+func (s *SubjectService) RestoreSubject(userID string, subjectID string, audit models.AuditContext) (*models.SubjectResponse, error) {
+	if err := s.authorize(userID, policy.ActionRestore); err != nil {
+		return nil, err
+	}
+
+	subjectIDInt, err := parseSubjectID(subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *models.SubjectResponse
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		subjectRepo := s.subjectRepo.WithTx(tx)
+		before, err := getSubject(subjectRepo, subjectIDInt)
+		if err != nil {
+			return err
+		}
+
+		if err := subjectRepo.RestoreSubject(subjectIDInt); err != nil {
+			return subjectError(err)
+		}
+
+		after, err = getSubject(subjectRepo, subjectIDInt)
+		if err != nil {
+			return err
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionRestore, policy.ResourceSubject, subjectIDInt, &before.Subject, &after.Subject)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// authorize 教科に対する操作の権限をチェックする
+func (s *SubjectService) authorize(userID string, action policy.Action) error {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := policy.Authorize(principal, action, policy.Resource{Type: policy.ResourceSubject}); err != nil {
+		return fmt.Errorf("access denied: %w", err)
+	}
+
+	return nil
+}
+
+// getSubject 論理削除済みを含めて教科を取得する
+func getSubject(subjectRepo *repositories.SubjectRepository, subjectID int) (*models.SubjectResponse, error) {
+	subject, err := subjectRepo.GetSubjectByID(subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if subject == nil {
+		return nil, fmt.Errorf("subject not found")
+	}
+
+	return subject, nil
+}
+
+// parseSubjectID パスパラメータの教科IDを解析する
+func parseSubjectID(subjectID string) (int, error) {
+	subjectIDInt, err := strconv.Atoi(subjectID)
+	if err != nil || subjectIDInt <= 0 {
+		return 0, fmt.Errorf("入力値エラーがあります: invalid subject ID")
+	}
+	return subjectIDInt, nil
+}
+
+// validateSubjectName 教科名をチェックし、前後の空白を除いた名前を返す
+func validateSubjectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("入力値エラーがあります: name is required")
+	}
+	if utf8.RuneCountInString(name) > maxSubjectNameLength {
+		return "", fmt.Errorf("入力値エラーがあります: name must be at most %d characters", maxSubjectNameLength)
+	}
+	return name, nil
+}
+
+// subjectError リポジトリのエラーのうち判別できるものはそのまま返す
+func subjectError(err error) error {
+	errorMsg := err.Error()
+	if strings.Contains(errorMsg, "subject name already exists") ||
+		strings.Contains(errorMsg, "subject not found") ||
+		strings.Contains(errorMsg, "subject not deleted") ||
+		strings.Contains(errorMsg, "subject in use") {
+		return err
+	}
+	return fmt.Errorf("データベースエラーが発生しました: %v", err)
+}
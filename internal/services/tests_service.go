@@ -5,25 +5,29 @@ import (
 	"strconv"
 
 	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
 	"github.com/tomoki-den-uhd/go-study/internal/repositories"
 )
 
 // TestService テストサービスの構造体
 type TestService struct {
-	testRepo   *repositories.TestRepository
-	userService *UserService
+	testRepo     *repositories.TestRepository
+	guardianRepo *repositories.GuardianRepository
+	userService  *UserService
 }
 
 // NewTestService テストサービスのコンストラクタ
-func NewTestService(testRepo *repositories.TestRepository, userService *UserService) *TestService {
+func NewTestService(testRepo *repositories.TestRepository, guardianRepo *repositories.GuardianRepository, userService *UserService) *TestService {
 	return &TestService{
-		testRepo:   testRepo,
-		userService: userService,
+		testRepo:     testRepo,
+		guardianRepo: guardianRepo,
+		userService:  userService,
 	}
 }
 
 // GetTests 小テストの一覧を取得する
-func (s *TestService) GetTests(userID string) ([]models.TestListResponse, error) {
+// 保護者の場合はstudentUserIDで紐付く学生を指定し、その学生のテスト一覧を取得する
+func (s *TestService) GetTests(userID string, studentUserID string) ([]models.TestListResponse, error) {
 	// ユーザーIDの型変換
 	userIDInt, err := strconv.Atoi(userID)
 	if err != nil {
@@ -36,8 +40,34 @@ func (s *TestService) GetTests(userID string) ([]models.TestListResponse, error)
 		return nil, fmt.Errorf("failed to get user role: %w", err)
 	}
 
+	principal := &models.Principal{UserID: userIDInt, Role: userRole}
+	resource := policy.Resource{Type: policy.ResourceTest}
+
+	// 保護者の場合：紐付く学生として一覧を取得する
+	targetUserID, targetRole := userIDInt, userRole
+	if userRole == models.RoleParent {
+		studentUserIDInt, err := strconv.Atoi(studentUserID)
+		if err != nil || studentUserIDInt <= 0 {
+			return nil, fmt.Errorf("入力値エラーがあります: valid student_user_id is required")
+		}
+
+		isGuardian, err := s.guardianRepo.IsGuardianOf(userIDInt, studentUserIDInt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check guardian access: %w", err)
+		}
+
+		resource.IsGuardian = isGuardian
+		resource.StudentUserID = studentUserIDInt
+		targetUserID, targetRole = studentUserIDInt, models.RoleStudent
+	}
+
+	// 一覧取得の権限をチェック
+	if err := policy.Authorize(principal, policy.ActionList, resource); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
 	// DBアクセス関数を呼ぶ
-	tests, err := s.testRepo.SelectTests(userIDInt, userRole)
+	tests, err := s.testRepo.SelectTests(targetUserID, targetRole)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tests: %w", err)
 	}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"github.com/tomoki-den-uhd/go-study/internal/totp"
+)
+
+// 二要素認証の設定
+const (
+	recoveryCodeCount  = 10 // 発行するリカバリーコードの数
+	recoveryCodeLength = 10 // リカバリーコードの文字数（区切り文字を除く）
+)
+
+// recoveryCodeEncoding リカバリーコードのエンコーディング（小文字のBase32）
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// TwoFactorService 二要素認証サービスの構造体
+type TwoFactorService struct {
+	twoFactorRepo *repositories.TwoFactorRepository
+	userRepo      *repositories.UserRepository
+	userService   *UserService
+	auditService  *AuditService
+	issuer        string
+	requiredRoles map[string]bool
+}
+
+// NewTwoFactorService 二要素認証サービスのコンストラクタ
+// requiredRolesに指定した役割のユーザーは二要素認証の登録が必須になる
+func NewTwoFactorService(twoFactorRepo *repositories.TwoFactorRepository, userRepo *repositories.UserRepository, userService *UserService, auditService *AuditService, issuer string, requiredRoles []string) *TwoFactorService {
+	required := make(map[string]bool)
+	for _, role := range requiredRoles {
+		role = strings.TrimSpace(role)
+		if role != "" {
+			required[role] = true
+		}
+	}
+
+	return &TwoFactorService{
+		twoFactorRepo: twoFactorRepo,
+		userRepo:      userRepo,
+		userService:   userService,
+		auditService:  auditService,
+		issuer:        issuer,
+		requiredRoles: required,
+	}
+}
+
+// IsRequired 役割に二要素認証が必須かどうかを返す
+func (s *TwoFactorService) IsRequired(role string) bool {
+	return s.requiredRoles[role]
+}
+
+// IsEnabled ユーザーの二要素認証が有効化されているかどうかを返す
+func (s *TwoFactorService) IsEnabled(userID int) (bool, error) {
+	userTOTP, err := s.twoFactorRepo.GetTOTP(userID)
+	if err != nil {
+		return false, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+	return userTOTP != nil && userTOTP.ConfirmedAt != nil, nil
+}
+
+// GetStatus 自分の二要素認証の状態を取得する
+func (s *TwoFactorService) GetStatus(userID string) (*models.TwoFactorStatusResponse, error) {
+	user, err := s.currentUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.IsEnabled(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := 0
+	if enabled {
+		remaining, err = s.twoFactorRepo.CountRemainingRecoveryCodes(user.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+	}
+
+	return &models.TwoFactorStatusResponse{
+		Enabled:                enabled,
+		Required:               s.IsRequired(user.Role),
+		RemainingRecoveryCodes: remaining,
+	}, nil
+}
+
+// Enroll 二要素認証の登録を開始し、認証アプリ用のシークレットを返す
+// 確認が完了するまでは有効化されない
+func (s *TwoFactorService) Enroll(userID string, audit models.AuditContext) (*models.TwoFactorEnrollResponse, error) {
+	user, err := s.currentUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.twoFactorRepo.WithTx(tx).SavePendingTOTP(user.UserID, secret); err != nil {
+			if strings.Contains(err.Error(), "two-factor already enabled") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録（シークレットは記録しない）
+		return s.auditService.Record(tx, audit, models.AuditActionEnroll2FA, policy.ResourceUser, user.UserID, nil, map[string]bool{"two_factor_pending": true})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm 認証コードを確認して二要素認証を有効化し、リカバリーコードを発行する
+func (s *TwoFactorService) Confirm(userID string, request *models.TwoFactorCodeRequest, audit models.AuditContext) (*models.TwoFactorConfirmResponse, error) {
+	user, err := s.currentUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	userTOTP, err := s.twoFactorRepo.GetTOTP(user.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if userTOTP == nil {
+		return nil, fmt.Errorf("two-factor enrollment not found")
+	}
+	if userTOTP.ConfirmedAt != nil {
+		return nil, fmt.Errorf("two-factor already enabled")
+	}
+
+	step, ok := totp.Validate(userTOTP.Secret, request.Code, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid two-factor code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.twoFactorRepo.WithTx(tx).ConfirmTOTP(user.UserID, step, hashes); err != nil {
+			if strings.Contains(err.Error(), "two-factor enrollment not found") {
+				return fmt.Errorf("two-factor enrollment not found")
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録（リカバリーコードは記録しない）
+		return s.auditService.Record(tx, audit, models.AuditActionEnable2FA, policy.ResourceUser, user.UserID, map[string]bool{"two_factor_enabled": false}, map[string]bool{"two_factor_enabled": true})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorConfirmResponse{
+		Status:        "OK",
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// RegenerateRecoveryCodes 認証コードを確認してリカバリーコードを再発行する
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID string, request *models.TwoFactorCodeRequest, audit models.AuditContext) (*models.TwoFactorConfirmResponse, error) {
+	user, err := s.currentUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyEnabledCode(user.UserID, request.Code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.twoFactorRepo.WithTx(tx).ReplaceRecoveryCodes(user.UserID, hashes); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録（リカバリーコードは件数のみ記録する）
+		return s.auditService.Record(tx, audit, models.AuditActionRegenerateRecoveryCodes, policy.ResourceUser, user.UserID, nil, map[string]int{"recovery_codes": len(codes)})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorConfirmResponse{
+		Status:        "OK",
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// Disable 認証コードを確認して自分の二要素認証を無効化する
+// 役割により必須の場合は無効化できない
+func (s *TwoFactorService) Disable(userID string, request *models.TwoFactorCodeRequest, audit models.AuditContext) error {
+	user, err := s.currentUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if s.IsRequired(user.Role) {
+		return fmt.Errorf("access denied: %s: two-factor authentication is required for role %s", models.ErrorMessageForbidden, user.Role)
+	}
+
+	if err := s.verifyEnabledCode(user.UserID, request.Code, ""); err != nil {
+		return err
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		if _, err := s.twoFactorRepo.WithTx(tx).DeleteTOTP(user.UserID); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionDisable2FA, policy.ResourceUser, user.UserID, map[string]bool{"two_factor_enabled": true}, map[string]bool{"two_factor_enabled": false})
+	})
+}
+
+// AdminReset ユーザーの二要素認証をリセットする（管理者のみ）
+// 端末を紛失したユーザーは次回ログイン時に再登録する
+func (s *TwoFactorService) AdminReset(userID string, targetUserID string, audit models.AuditContext) error {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionUpdate, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return fmt.Errorf("access denied: %w", err)
+	}
+
+	targetUserIDInt, err := parseTargetUserID(targetUserID)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetUserByID(targetUserIDInt)
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil {
+		return fmt.Errorf("user not found")
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		deleted, err := s.twoFactorRepo.WithTx(tx).DeleteTOTP(targetUserIDInt)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		if !deleted {
+			return fmt.Errorf("two-factor not enabled")
+		}
+
+		// 監査ログを記録
+		return s.auditService.Record(tx, audit, models.AuditActionReset2FA, policy.ResourceUser, targetUserIDInt, map[string]bool{"two_factor_enabled": true}, map[string]bool{"two_factor_enabled": false})
+	})
+}
+
+// VerifyLogin ログイン時の認証コードまたはリカバリーコードを検証する
+// 一致しない場合はErrorMessageUnauthorizedを返す
+func (s *TwoFactorService) VerifyLogin(userID int, code string, recoveryCode string) error {
+	err := s.verifyEnabledCode(userID, code, recoveryCode)
+	if err != nil && (strings.HasPrefix(err.Error(), models.ErrorMessageInvalidInput) || err.Error() == "two-factor not enabled") {
+		return fmt.Errorf("%s", models.ErrorMessageUnauthorized)
+	}
+	return err
+}
+
+// verifyEnabledCode 有効化済みのTOTPに対して認証コードまたはリカバリーコードを検証する
+// 使用したステップ・リカバリーコードは再利用できない
+func (s *TwoFactorService) verifyEnabledCode(userID int, code string, recoveryCode string) error {
+	userTOTP, err := s.twoFactorRepo.GetTOTP(userID)
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if userTOTP == nil || userTOTP.ConfirmedAt == nil {
+		return fmt.Errorf("two-factor not enabled")
+	}
+
+	// リカバリーコード
+	if recoveryCode != "" {
+		used, err := s.twoFactorRepo.UseRecoveryCode(userID, hashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+		if !used {
+			return fmt.Errorf("入力値エラーがあります: invalid recovery code")
+		}
+		return nil
+	}
+
+	// TOTPコード
+	step, ok := totp.Validate(userTOTP.Secret, code, time.Now())
+	if !ok {
+		return fmt.Errorf("入力値エラーがあります: invalid two-factor code")
+	}
+
+	consumed, err := s.twoFactorRepo.ConsumeStep(userID, step)
+	if err != nil {
+		return fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+	if !consumed {
+		return fmt.Errorf("入力値エラーがあります: two-factor code already used")
+	}
+
+	return nil
+}
+
+// currentUser 認証済みユーザーを取得する
+func (s *TwoFactorService) currentUser(userID string) (*models.User, error) {
+	userIDInt, err := s.userService.ValidateUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	user, err := s.userRepo.GetUserByID(userIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return user, nil
+}
+
+// generateRecoveryCodes リカバリーコードとその保存用ハッシュを生成する
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, recoveryCodeLength*5/8)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := recoveryCodeEncoding.EncodeToString(buf)
+		codes = append(codes, raw[:recoveryCodeLength/2]+"-"+raw[recoveryCodeLength/2:])
+		hashes = append(hashes, hashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode 入力されたリカバリーコードを正規化する（大文字小文字・区切り文字を無視）
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
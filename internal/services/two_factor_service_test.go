@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"github.com/tomoki-den-uhd/go-study/internal/totp"
+)
+
+// twoFactorTestDB 有効化済みのTOTP設定（user_totp・totp_recovery_codes）のメモリ上の代わり（repositories.DBTX）
+type twoFactorTestDB struct {
+	secret        string
+	lastUsedStep  int64
+	recoveryCodes map[string]bool // code_hash → 使用済み
+}
+
+func (db *twoFactorTestDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (db *twoFactorTestDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	switch {
+	case strings.Contains(sql, "SET last_used_step"):
+		// last_used_step < $2 の場合のみ更新する
+		step := args[1].(int64)
+		if db.lastUsedStep >= step {
+			return pgconn.NewCommandTag("UPDATE 0"), nil
+		}
+		db.lastUsedStep = step
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	case strings.Contains(sql, "UPDATE totp_recovery_codes"):
+		// used_at IS NULL の場合のみ使用済みにする
+		used, ok := db.recoveryCodes[args[1].(string)]
+		if !ok || used {
+			return pgconn.NewCommandTag("UPDATE 0"), nil
+		}
+		db.recoveryCodes[args[1].(string)] = true
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	}
+	return pgconn.CommandTag{}, fmt.Errorf("unexpected exec: %s", sql)
+}
+
+func (db *twoFactorTestDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, fmt.Errorf("unexpected query: %s", sql)
+}
+
+func (db *twoFactorTestDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if strings.Contains(sql, "FROM user_totp") {
+		confirmedAt := time.Now().Add(-time.Hour)
+		return testRow{values: []any{args[0], db.secret, &confirmedAt, db.lastUsedStep, confirmedAt}}
+	}
+	return testRow{err: fmt.Errorf("unexpected query: %s", sql)}
+}
+
+func newTestTwoFactorService(t *testing.T) (*TwoFactorService, *twoFactorTestDB, []string) {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := &twoFactorTestDB{secret: secret, recoveryCodes: map[string]bool{}}
+	for _, hash := range hashes {
+		db.recoveryCodes[hash] = false
+	}
+
+	service := NewTwoFactorService(&repositories.TwoFactorRepository{DB: db}, nil, nil, nil, "go-study", nil)
+	return service, db, codes
+}
+
+// TestTwoFactorCodeReplay 使用済みのステップ（last_used_step）以前のコードは受け付けないこと
+func TestTwoFactorCodeReplay(t *testing.T) {
+	service, db, _ := newTestTwoFactorService(t)
+
+	current := totp.Step(time.Now())
+	code, err := totp.Code(db.secret, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := totp.Code(db.secret, current-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.verifyEnabledCode(1, code, ""); err != nil {
+		t.Fatalf("first use error = %v", err)
+	}
+	if db.lastUsedStep < current {
+		t.Fatalf("last_used_step = %d, want at least %d", db.lastUsedStep, current)
+	}
+
+	// 同じコードの再利用
+	if err := service.verifyEnabledCode(1, code, ""); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Errorf("replayed code error = %v, want already used", err)
+	}
+
+	// 許容範囲内でも使用済みのステップより前のコードは使えない
+	if err := service.verifyEnabledCode(1, previous, ""); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Errorf("older code error = %v, want already used", err)
+	}
+
+	// ログイン時は理由を区別せず認証エラーにする
+	if err := service.VerifyLogin(1, code, ""); err == nil || err.Error() != models.ErrorMessageUnauthorized {
+		t.Errorf("VerifyLogin(replayed) error = %v, want %q", err, models.ErrorMessageUnauthorized)
+	}
+}
+
+func TestTwoFactorInvalidCode(t *testing.T) {
+	service, db, _ := newTestTwoFactorService(t)
+
+	far, err := totp.Code(db.secret, totp.Step(time.Now())+5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, code := range []string{far, "", "12345"} {
+		if err := service.verifyEnabledCode(1, code, ""); err == nil || !strings.Contains(err.Error(), "invalid two-factor code") {
+			t.Errorf("verifyEnabledCode(%q) error = %v, want invalid code", code, err)
+		}
+	}
+	if db.lastUsedStep != 0 {
+		t.Errorf("last_used_step = %d, want unchanged", db.lastUsedStep)
+	}
+}
+
+// TestTwoFactorRecoveryCodeSingleUse リカバリーコードは1回だけ使えること
+func TestTwoFactorRecoveryCodeSingleUse(t *testing.T) {
+	service, db, codes := newTestTwoFactorService(t)
+
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("generateRecoveryCodes() = %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+	if len(db.recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("generateRecoveryCodes() hashes are not unique")
+	}
+
+	// 大文字・区切り文字・前後の空白は無視する
+	entered := " " + strings.ToUpper(strings.ReplaceAll(codes[0], "-", " ")) + " "
+	if err := service.VerifyLogin(1, "", entered); err != nil {
+		t.Fatalf("first use error = %v", err)
+	}
+
+	if err := service.verifyEnabledCode(1, "", codes[0]); err == nil || !strings.Contains(err.Error(), "invalid recovery code") {
+		t.Errorf("second use error = %v, want invalid recovery code", err)
+	}
+	if err := service.VerifyLogin(1, "", codes[0]); err == nil || err.Error() != models.ErrorMessageUnauthorized {
+		t.Errorf("VerifyLogin(second use) error = %v, want %q", err, models.ErrorMessageUnauthorized)
+	}
+
+	// 他のコードは使える
+	if err := service.VerifyLogin(1, "", codes[1]); err != nil {
+		t.Errorf("other code error = %v", err)
+	}
+
+	if err := service.VerifyLogin(1, "", "aaaaa-bbbbb"); err == nil {
+		t.Error("unknown recovery code error = nil")
+	}
+
+	used := 0
+	for _, u := range db.recoveryCodes {
+		if u {
+			used++
+		}
+	}
+	if used != 2 {
+		t.Errorf("used recovery codes = %d, want 2", used)
+	}
+}
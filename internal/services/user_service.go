@@ -3,19 +3,64 @@ package services
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/mail"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
 	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// パスワード長の制約（bcryptは72バイトまでしか扱えない）
+const (
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+)
+
+// emailChangeTokenTTL メールアドレス変更確認トークンの有効期間
+const emailChangeTokenTTL = 24 * time.Hour
+
+// registrableRoles 自己登録で選択可能な役割（教師・管理者は管理者が作成または役割を変更する）
+var registrableRoles = map[string]bool{
+	models.RoleStudent: true,
+	models.RoleParent:  true,
+}
+
+// unusablePassword サービスアカウントに設定するパスワード（bcryptハッシュとして不正なため常に照合に失敗する）
+const unusablePassword = "!"
+
+// assignableRoles 管理者が設定可能な役割
+var assignableRoles = map[string]bool{
+	models.RoleStudent: true,
+	models.RoleTeacher: true,
+	models.RoleParent:  true,
+	models.RoleAdmin:   true,
+}
+
+// ユーザー一覧の取得件数
+const (
+	defaultUserListLimit = 50
+	maxUserListLimit     = 200
 )
 
 // UserService ユーザーサービスの構造体
 type UserService struct {
-	userRepo *repositories.UserRepository
+	userRepo     *repositories.UserRepository
+	sessionRepo  *repositories.SessionRepository
+	mailer       mail.Mailer
+	auditService *AuditService
 }
 
 // NewUserService ユーザーサービスのコンストラクタ
-func NewUserService(userRepo *repositories.UserRepository) *UserService {
+func NewUserService(userRepo *repositories.UserRepository, sessionRepo *repositories.SessionRepository, mailer mail.Mailer, auditService *AuditService) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		mailer:       mailer,
+		auditService: auditService,
 	}
 }
 
@@ -65,4 +110,522 @@ func (s *UserService) ValidateUser(userID string) (int, error) {
 	}
 
 	return userIDInt, nil
-} 
\ No newline at end of file
+}
+
+// RegisterUser ユーザーを新規登録する（操作者は登録したユーザー本人として監査ログに記録する）
+func (s *UserService) RegisterUser(request *models.UserRegistrationRequest, audit models.AuditContext) (*models.UserResponse, error) {
+	// 自己登録では教師・管理者を選択できない
+	if !registrableRoles[request.Role] {
+		return nil, fmt.Errorf("入力値エラーがあります: role must be one of student, parent")
+	}
+
+	var response *models.UserResponse
+	_, err := s.createUser(request.Name, request.Email, request.Password, request.Phone, request.Role, false, func(tx pgx.Tx, user *models.User) error {
+		// レスポンスを作成
+		response = &models.UserResponse{
+			UserID: user.UserID,
+			Name:   user.Name,
+			Email:  user.Email,
+			Phone:  user.Phone,
+			Role:   user.Role,
+		}
+
+		// 監査ログを記録
+		audit.ActorUserID = user.UserID
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceUser, user.UserID, nil, response)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// createUser 入力値を検証してユーザーを登録する
+// サービスアカウントの場合はpasswordを使用せず、ログインできないパスワードを設定する
+// onCreatedは登録と同じトランザクション内で呼び出す（監査ログの記録に使う）
+func (s *UserService) createUser(name string, email string, password string, phone string, role string, serviceAccount bool, onCreated func(tx pgx.Tx, user *models.User) error) (*models.User, error) {
+	// リクエストのバリデーション
+	name = strings.TrimSpace(name)
+	email = normalizeEmail(email)
+
+	if name == "" {
+		return nil, fmt.Errorf("入力値エラーがあります: name is required")
+	}
+
+	if !isValidEmail(email) {
+		return nil, fmt.Errorf("入力値エラーがあります: valid email is required")
+	}
+
+	if !serviceAccount {
+		if err := validatePassword(password); err != nil {
+			return nil, err
+		}
+	}
+
+	if !assignableRoles[role] {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid role")
+	}
+
+	// メールアドレスの重複チェック
+	exists, err := s.userRepo.EmailExists(email)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if exists {
+		return nil, fmt.Errorf("email already registered")
+	}
+
+	// パスワードをハッシュ化
+	hashedPassword := unusablePassword
+	if !serviceAccount {
+		hashedPassword, err = hashPassword(password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// ユーザーデータを作成
+	user := &models.User{
+		Name:             name,
+		Email:            email,
+		Password:         hashedPassword,
+		Phone:            phone,
+		Role:             role,
+		IsServiceAccount: serviceAccount,
+	}
+
+	// リポジトリを呼び出してユーザーを登録
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		userID, err := s.userRepo.WithTx(tx).CreateUser(user)
+		if err != nil {
+			if strings.Contains(err.Error(), "email already registered") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+		user.UserID = userID
+
+		return onCreated(tx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// validatePassword パスワードの長さをチェックする
+func validatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return fmt.Errorf("入力値エラーがあります: password must be at least %d characters", minPasswordLength)
+	}
+
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("入力値エラーがあります: password must be at most %d bytes", maxPasswordBytes)
+	}
+
+	return nil
+}
+
+// hashPassword パスワードをbcryptでハッシュ化する
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// GetProfile 自分のプロフィールを取得する
+func (s *UserService) GetProfile(userID string) (*models.UserProfileResponse, error) {
+	// ユーザーIDのバリデーション
+	userIDInt, err := s.ValidateUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	return s.buildProfileResponse(userIDInt, "")
+}
+
+// UpdateProfile 自分のプロフィールを更新する（メールアドレスの変更は確認後に反映）
+func (s *UserService) UpdateProfile(userID string, request *models.UserProfileUpdateRequest, audit models.AuditContext) (*models.UserProfileResponse, error) {
+	// ユーザーIDのバリデーション
+	userIDInt, err := s.ValidateUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	// リクエストのバリデーション
+	request.Name = strings.TrimSpace(request.Name)
+	request.Email = normalizeEmail(request.Email)
+
+	if request.Name == "" {
+		return nil, fmt.Errorf("入力値エラーがあります: name is required")
+	}
+
+	if !isValidEmail(request.Email) {
+		return nil, fmt.Errorf("入力値エラーがあります: valid email is required")
+	}
+
+	// 現在のユーザー情報を取得
+	user, err := s.userRepo.GetUserByID(userIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		// 氏名と電話番号を更新
+		if err := s.userRepo.WithTx(tx).UpdateUserProfile(userIDInt, request.Name, request.Phone); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録（メールアドレスは確認後に反映されるため含めない）
+		updated := *user
+		updated.Name = request.Name
+		updated.Phone = request.Phone
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceUser, userIDInt, user, &updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// メールアドレスが変更されていなければここで終了
+	if request.Email == normalizeEmail(user.Email) {
+		return s.buildProfileResponse(userIDInt, "プロフィールを更新しました")
+	}
+
+	// 新しいメールアドレスの重複チェック
+	exists, err := s.userRepo.EmailExists(request.Email)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if exists {
+		return nil, fmt.Errorf("email already registered")
+	}
+
+	// 確認トークンを発行して新しいメールアドレスに送信
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.CreateEmailChangeRequest(userIDInt, request.Email, tokenHash, time.Now().Add(emailChangeTokenTTL)); err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	err = s.mailer.Send(&mail.Message{
+		To:      request.Email,
+		Subject: "メールアドレス変更の確認",
+		Body:    fmt.Sprintf("メールアドレスの変更を確定するには、次の確認トークンを送信してください。\n\n%s\n\nこのトークンの有効期限は24時間です。", token),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send verification mail: %w", err)
+	}
+
+	return s.buildProfileResponse(userIDInt, "プロフィールを更新しました。新しいメールアドレスは確認後に反映されます")
+}
+
+// VerifyEmailChange 確認トークンを検証してメールアドレスの変更を確定する
+// 操作者はトークンを受け取ったユーザー本人として監査ログに記録する
+func (s *UserService) VerifyEmailChange(request *models.EmailVerificationRequest, audit models.AuditContext) error {
+	if request.Token == "" {
+		return fmt.Errorf("入力値エラーがあります: token is required")
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		userID, oldEmail, newEmail, err := s.userRepo.WithTx(tx).ApplyEmailChange(hashToken(request.Token))
+		if err != nil {
+			if strings.Contains(err.Error(), "email already registered") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		if userID == 0 {
+			return fmt.Errorf("入力値エラーがあります: invalid or expired token")
+		}
+
+		// 監査ログを記録
+		audit.ActorUserID = userID
+		return s.auditService.Record(tx, audit, models.AuditActionUpdate, policy.ResourceUser, userID, map[string]string{"email": oldEmail}, map[string]string{"email": newEmail})
+	})
+}
+
+// buildProfileResponse プロフィールレスポンスを作成する
+func (s *UserService) buildProfileResponse(userID int, message string) (*models.UserProfileResponse, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	pendingEmail, err := s.userRepo.GetPendingEmail(userID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	response := &models.UserProfileResponse{
+		Status: "OK",
+		Data: models.UserResponse{
+			UserID: user.UserID,
+			Name:   user.Name,
+			Email:  user.Email,
+			Phone:  user.Phone,
+			Role:   user.Role,
+		},
+		PendingEmail: pendingEmail,
+		Message:      message,
+	}
+
+	return response, nil
+}
+
+// Principal ユーザーIDを検証し、役割を含むプリンシパルを取得する
+func (s *UserService) Principal(userID string) (*models.Principal, error) {
+	userIDInt, err := s.ValidateUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	userRole, err := s.GetUserRole(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user role: %w", err)
+	}
+
+	return &models.Principal{UserID: userIDInt, Role: userRole}, nil
+}
+
+// ListUsers ユーザーの一覧を検索する（管理者のみ）
+func (s *UserService) ListUsers(userID string, request *models.UserListRequest) (*models.UserListResponse, error) {
+	principal, err := s.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionList, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	// ページングのバリデーション
+	if request.Limit <= 0 {
+		request.Limit = defaultUserListLimit
+	}
+	if request.Limit > maxUserListLimit {
+		request.Limit = maxUserListLimit
+	}
+	if request.Offset < 0 {
+		return nil, fmt.Errorf("入力値エラーがあります: offset must not be negative")
+	}
+
+	if request.Role != "" && !assignableRoles[request.Role] {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid role")
+	}
+
+	users, total, err := s.userRepo.ListUsers(strings.TrimSpace(request.Query), request.Role, request.IncludeDeleted, request.Limit, request.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	response := &models.UserListResponse{
+		Count: len(users),
+		Total: total,
+		Users: []models.AdminUserResponse{},
+	}
+	for _, user := range users {
+		response.Users = append(response.Users, toAdminUserResponse(&user))
+	}
+
+	return response, nil
+}
+
+// AdminCreateUser ユーザーを作成する（管理者のみ、管理者ユーザーも作成可能）
+func (s *UserService) AdminCreateUser(userID string, request *models.AdminUserCreateRequest, audit models.AuditContext) (*models.AdminUserResponse, error) {
+	principal, err := s.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionCreate, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	var response models.AdminUserResponse
+	_, err = s.createUser(request.Name, request.Email, request.Password, request.Phone, request.Role, false, func(tx pgx.Tx, user *models.User) error {
+		response = toAdminUserResponse(user)
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceUser, user.UserID, nil, response)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminCreateServiceAccount サービスアカウントを作成する（管理者のみ）
+// サービスアカウントはパスワードでログインできず、発行したAPIキーでのみ利用する
+func (s *UserService) AdminCreateServiceAccount(userID string, request *models.ServiceAccountCreateRequest, audit models.AuditContext) (*models.AdminUserResponse, error) {
+	principal, err := s.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionCreate, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	var response models.AdminUserResponse
+	_, err = s.createUser(request.Name, request.Email, "", "", request.Role, true, func(tx pgx.Tx, user *models.User) error {
+		response = toAdminUserResponse(user)
+		return s.auditService.Record(tx, audit, models.AuditActionCreate, policy.ResourceUser, user.UserID, nil, response)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SetUserActive ユーザーを無効化・再有効化する（管理者のみ）
+func (s *UserService) SetUserActive(userID string, targetUserID string, active bool, audit models.AuditContext) error {
+	principal, err := s.Principal(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionDelete, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return fmt.Errorf("access denied: %w", err)
+	}
+
+	targetUserIDInt, err := parseTargetUserID(targetUserID)
+	if err != nil {
+		return err
+	}
+
+	// 自分自身は無効化できない
+	if targetUserIDInt == principal.UserID {
+		return fmt.Errorf("入力値エラーがあります: you cannot change your own account status")
+	}
+
+	return s.auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.userRepo.WithTx(tx).SetUserDeleted(targetUserIDInt, !active); err != nil {
+			errorMsg := err.Error()
+			if strings.Contains(errorMsg, "user not found") || strings.Contains(errorMsg, "email already registered") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 無効化したユーザーのセッションはすべて終了させる
+		if !active {
+			if _, err := s.sessionRepo.WithTx(tx).RevokeUserSessions(targetUserIDInt, repositories.RevokedReasonAdminRevoked); err != nil {
+				return fmt.Errorf("データベースエラーが発生しました: %v", err)
+			}
+		}
+
+		// 監査ログを記録
+		action := models.AuditActionReactivate
+		if !active {
+			action = models.AuditActionDeactivate
+		}
+		return s.auditService.Record(tx, audit, action, policy.ResourceUser, targetUserIDInt, map[string]bool{"is_deleted": active}, map[string]bool{"is_deleted": !active})
+	})
+}
+
+// ChangeUserRole ユーザーの役割を変更する（管理者のみ）
+func (s *UserService) ChangeUserRole(userID string, targetUserID string, request *models.UserRoleUpdateRequest, audit models.AuditContext) (*models.AdminUserResponse, error) {
+	principal, err := s.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(principal, policy.ActionUpdate, policy.Resource{Type: policy.ResourceUser}); err != nil {
+		return nil, fmt.Errorf("access denied: %w", err)
+	}
+
+	targetUserIDInt, err := parseTargetUserID(targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !assignableRoles[request.Role] {
+		return nil, fmt.Errorf("入力値エラーがあります: invalid role")
+	}
+
+	// 自分自身の役割は変更できない（管理者不在を防ぐ）
+	if targetUserIDInt == principal.UserID {
+		return nil, fmt.Errorf("入力値エラーがあります: you cannot change your own role")
+	}
+
+	var response models.AdminUserResponse
+	err = s.auditService.Transact(func(tx pgx.Tx) error {
+		userRepo := s.userRepo.WithTx(tx)
+
+		// 変更前の状態を取得
+		before, err := userRepo.GetUserByID(targetUserIDInt)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		if before == nil {
+			return fmt.Errorf("user not found")
+		}
+
+		if err := userRepo.UpdateUserRole(targetUserIDInt, request.Role); err != nil {
+			if strings.Contains(err.Error(), "user not found") {
+				return err
+			}
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 旧い役割のトークンを使えないようセッションを終了させる
+		if _, err := s.sessionRepo.WithTx(tx).RevokeUserSessions(targetUserIDInt, repositories.RevokedReasonAdminRevoked); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		user, err := userRepo.GetUserByID(targetUserIDInt)
+		if err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		if user == nil {
+			return fmt.Errorf("user not found")
+		}
+
+		response = toAdminUserResponse(user)
+		return s.auditService.Record(tx, audit, models.AuditActionChangeRole, policy.ResourceUser, targetUserIDInt, toAdminUserResponse(before), response)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// parseTargetUserID 操作対象のユーザーIDを変換・検証する
+func parseTargetUserID(targetUserID string) (int, error) {
+	targetUserIDInt, err := strconv.Atoi(targetUserID)
+	if err != nil || targetUserIDInt <= 0 {
+		return 0, fmt.Errorf("入力値エラーがあります: invalid user ID")
+	}
+	return targetUserIDInt, nil
+}
+
+// toAdminUserResponse ユーザーを管理者向けレスポンスに変換する
+func toAdminUserResponse(user *models.User) models.AdminUserResponse {
+	return models.AdminUserResponse{
+		UserID:           user.UserID,
+		Name:             user.Name,
+		Email:            user.Email,
+		Phone:            user.Phone,
+		Role:             user.Role,
+		IsDeleted:        user.IsDeleted,
+		IsServiceAccount: user.IsServiceAccount,
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+// TestRegisterUserRole 自己登録では学生・保護者のみ選択でき、教師・管理者は選択できないこと
+func TestRegisterUserRole(t *testing.T) {
+	for _, role := range []string{models.RoleTeacher, models.RoleAdmin, "", "Student"} {
+		// 役割の確認はデータベースに触れる前に行う
+		service := &UserService{}
+		request := &models.UserRegistrationRequest{Name: "Taro", Email: "taro@example.com", Password: "password123", Role: role}
+
+		_, err := service.RegisterUser(request, models.AuditContext{})
+		if err == nil || !strings.Contains(err.Error(), "role must be one of student, parent") {
+			t.Errorf("RegisterUser(role=%q) error = %v, want role error", role, err)
+		}
+	}
+
+	for _, role := range []string{models.RoleStudent, models.RoleParent} {
+		if !registrableRoles[role] {
+			t.Errorf("role %q must be registrable", role)
+		}
+	}
+}
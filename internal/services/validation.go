@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/recurrence"
+)
+
+// maxEmailLength メールアドレスの最大長（RFC 5321）
+const maxEmailLength = 254
+
+// isValidEmail メールアドレスの形式をチェックする
+func isValidEmail(email string) bool {
+	if len(email) > maxEmailLength {
+		return false
+	}
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+// normalizeEmail メールアドレスを比較用に正規化する
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// parseFilterTime 検索条件の日時を解析する（RFC3339またはYYYY-MM-DD）
+// 保存している日時と比較できるよう、サーバーのタイムゾーンによらず壁時計の時刻（UTCとして保持）で返す
+// 終了日時に日付のみを指定した場合はその日を含むよう翌日0時にする
+func parseFilterTime(value string, field string, endOfDay bool) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = recurrence.WallClock(t)
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("入力値エラーがあります: %s must be RFC3339 or YYYY-MM-DD", field)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFilterTime(t *testing.T) {
+	// サーバーのタイムゾーンによらず同じ壁時計の時刻になる
+	local := time.Local
+	time.Local = time.FixedZone("UTC-5", -5*60*60)
+	defer func() { time.Local = local }()
+
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+	}{
+		{"2024-01-10", false, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-10", true, time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-10T09:30:00+09:00", false, time.Date(2024, 1, 10, 9, 30, 0, 0, time.UTC)},
+		{"2024-01-10T09:30:00Z", true, time.Date(2024, 1, 10, 9, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseFilterTime(tt.value, "from", tt.endOfDay)
+			if err != nil {
+				t.Fatalf("parseFilterTime(%q) error = %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("parseFilterTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"2024/01/10", "10-01-2024", "2024-01-10 09:30"} {
+		if _, err := parseFilterTime(value, "from", false); err == nil {
+			t.Errorf("parseFilterTime(%q) error = nil, want error", value)
+		}
+	}
+
+	if got, err := parseFilterTime(" ", "from", false); got != nil || err != nil {
+		t.Errorf("parseFilterTime(empty) = %v, %v, want nil, nil", got, err)
+	}
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+)
+
+// maxHeartbeatSeconds 1回のハートビートで記録できる視聴区間の長さの上限（秒）
+// 実際に再生していない区間をまとめて送って視聴済みにできないよう制限する
+const maxHeartbeatSeconds = 300
+
+// maxPlaybackRate 視聴区間として認める再生速度の上限
+// 前回の記録からの経過時間にこの倍率を掛けた長さまでを記録する
+const maxPlaybackRate = 2
+
+// VideoProgressService 動画視聴状況サービスの構造体
+type VideoProgressService struct {
+	progressRepo   *repositories.VideoProgressRepository
+	videoRepo      *repositories.CourseVideoRepository
+	courseRepo     *repositories.CourseRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+	userService    *UserService
+}
+
+// NewVideoProgressService 動画視聴状況サービスのコンストラクタ
+func NewVideoProgressService(progressRepo *repositories.VideoProgressRepository, videoRepo *repositories.CourseVideoRepository, courseRepo *repositories.CourseRepository, enrollmentRepo *repositories.EnrollmentRepository, userService *UserService) *VideoProgressService {
+	return &VideoProgressService{
+		progressRepo:   progressRepo,
+		videoRepo:      videoRepo,
+		courseRepo:     courseRepo,
+		enrollmentRepo: enrollmentRepo,
+		userService:    userService,
+	}
+}
+
+// RecordProgress 学生の視聴区間と再生位置を記録する（受講中の学生本人のみ）
+func (s *VideoProgressService) RecordProgress(userID string, courseID string, videoID string, request *models.VideoProgressRequest) (*models.VideoProgressResponse, error) {
+	principal, courseIDInt, err := s.authorize(userID, courseID, policy.ActionUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := s.getVideo(courseIDInt, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Position == nil {
+		return nil, fmt.Errorf("入力値エラーがあります: position is required")
+	}
+	if !validSeconds(*request.Position) {
+		return nil, fmt.Errorf("入力値エラーがあります: position must be a non-negative number")
+	}
+	if (request.Start == nil) != (request.End == nil) {
+		return nil, fmt.Errorf("入力値エラーがあります: start and end must be specified together")
+	}
+
+	var interval *models.WatchInterval
+	if request.Start != nil {
+		start, end := *request.Start, *request.End
+		if !validSeconds(start) || !validSeconds(end) || end <= start {
+			return nil, fmt.Errorf("入力値エラーがあります: end must be greater than start")
+		}
+		if end-start > maxHeartbeatSeconds {
+			return nil, fmt.Errorf("入力値エラーがあります: watched interval must be at most %d seconds", maxHeartbeatSeconds)
+		}
+		interval = &models.WatchInterval{Start: start, End: end}
+	}
+
+	// 動画の長さの範囲内に収める
+	// 長さが不明な動画は視聴率を計算できないため区間を記録せず、再開位置のみ記録する
+	position := *request.Position
+	if video.DurationSeconds != nil {
+		duration := *video.DurationSeconds
+		position = math.Min(position, duration)
+		if interval != nil {
+			interval.End = math.Min(interval.End, duration)
+			if interval.End <= interval.Start {
+				interval = nil
+			}
+		}
+	} else {
+		position = math.Min(position, maxVideoDurationSeconds)
+		interval = nil
+	}
+
+	progress, err := s.progressRepo.RecordProgress(video.VideoID, principal.UserID, interval, position, maxWatchSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return buildVideoProgressResponse(video, progress), nil
+}
+
+// GetProgress 学生本人の視聴状況（再開位置）を取得する（受講中の学生本人のみ）
+func (s *VideoProgressService) GetProgress(userID string, courseID string, videoID string) (*models.VideoProgressResponse, error) {
+	principal, courseIDInt, err := s.authorize(userID, courseID, policy.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := s.getVideo(courseIDInt, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := s.progressRepo.GetProgress(video.VideoID, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return buildVideoProgressResponse(video, progress), nil
+}
+
+// GetCourseReport 授業の受講者ごとの視聴率と最終視聴日時を集計する（担当教師または管理者）
+// 受講中・修了の学生を対象に、未視聴の学生も含めて返す
+func (s *VideoProgressService) GetCourseReport(userID string, courseID string) (*models.CourseWatchReportResponse, error) {
+	_, courseIDInt, err := s.authorize(userID, courseID, policy.ActionList)
+	if err != nil {
+		return nil, err
+	}
+
+	videos, err := s.videoRepo.ListVideos(courseIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	enrollments, err := s.enrollmentRepo.ListByCourse(courseIDInt, "")
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	progresses, err := s.progressRepo.ListByCourse(courseIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	// 学生ID・動画IDごとの視聴状況
+	progressByStudent := map[int]map[int]*models.VideoWatchProgress{}
+	for i := range progresses {
+		progress := &progresses[i]
+		if progressByStudent[progress.StudentUserID] == nil {
+			progressByStudent[progress.StudentUserID] = map[int]*models.VideoWatchProgress{}
+		}
+		progressByStudent[progress.StudentUserID][progress.VideoID] = progress
+	}
+
+	reportVideos := make([]models.WatchReportVideo, 0, len(videos))
+	for _, video := range videos {
+		reportVideos = append(reportVideos, models.WatchReportVideo{
+			VideoID:         video.VideoID,
+			Filename:        video.Filename,
+			DurationSeconds: video.DurationSeconds,
+		})
+	}
+
+	students := []models.StudentWatchReport{}
+	for _, enrollment := range enrollments {
+		if enrollment.Status == models.EnrollmentStatusDropped {
+			continue
+		}
+
+		report := models.StudentWatchReport{
+			StudentUserID: enrollment.StudentUserID,
+			StudentName:   enrollment.StudentName,
+			StudentEmail:  enrollment.StudentEmail,
+			Videos:        make([]models.StudentVideoWatch, 0, len(videos)),
+		}
+
+		var watchedTotal, durationTotal float64
+		for i := range videos {
+			video := &videos[i]
+			progress := progressByStudent[enrollment.StudentUserID][video.VideoID]
+
+			watch := models.StudentVideoWatch{VideoID: video.VideoID}
+			if progress != nil {
+				watch.WatchedSeconds = progress.WatchedSeconds
+				watch.LastPosition = progress.LastPosition
+				watch.LastWatchedAt = &progress.LastWatchedAt
+				if report.LastWatchedAt == nil || progress.LastWatchedAt.After(*report.LastWatchedAt) {
+					report.LastWatchedAt = &progress.LastWatchedAt
+				}
+			}
+
+			if video.DurationSeconds != nil {
+				watch.CompletionPercent = completionPercent(watch.WatchedSeconds, *video.DurationSeconds)
+				watchedTotal += math.Min(watch.WatchedSeconds, *video.DurationSeconds)
+				durationTotal += *video.DurationSeconds
+			}
+
+			report.Videos = append(report.Videos, watch)
+		}
+
+		report.CompletionPercent = completionPercent(watchedTotal, durationTotal)
+		students = append(students, report)
+	}
+
+	return &models.CourseWatchReportResponse{
+		CourseID: courseIDInt,
+		Videos:   reportVideos,
+		Count:    len(students),
+		Students: students,
+	}, nil
+}
+
+// getVideo 授業の動画を取得する
+func (s *VideoProgressService) getVideo(courseID int, videoID string) (*models.CourseVideo, error) {
+	videoIDInt, err := parseVideoID(videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := s.videoRepo.GetVideo(courseID, videoIDInt)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if video == nil {
+		return nil, fmt.Errorf("video not found")
+	}
+
+	return video, nil
+}
+
+// authorize 授業の視聴状況に対する操作の権限をチェックし、プリンシパルと授業IDを返す
+func (s *VideoProgressService) authorize(userID string, courseID string, action policy.Action) (*models.Principal, int, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	courseIDInt, err := strconv.Atoi(courseID)
+	if err != nil || courseIDInt <= 0 {
+		return nil, 0, fmt.Errorf("入力値エラーがあります: invalid course ID")
+	}
+
+	course, err := s.courseRepo.GetCourseIncludingDeleted(courseIDInt)
+	if err != nil {
+		return nil, 0, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if course == nil || course.IsDeleted {
+		return nil, 0, fmt.Errorf("course not found")
+	}
+
+	resource, err := courseResource(s.courseRepo, principal, policy.ResourceWatch, courseIDInt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := policy.Authorize(principal, action, resource); err != nil {
+		return nil, 0, fmt.Errorf("access denied: %w", err)
+	}
+
+	return principal, courseIDInt, nil
+}
+
+// buildVideoProgressResponse 動画と視聴状況からレスポンスを作成する（未視聴の場合はprogressがnil）
+func buildVideoProgressResponse(video *models.CourseVideo, progress *models.VideoWatchProgress) *models.VideoProgressResponse {
+	response := &models.VideoProgressResponse{
+		VideoID:         video.VideoID,
+		DurationSeconds: video.DurationSeconds,
+		Intervals:       []models.WatchInterval{},
+	}
+
+	if progress != nil {
+		response.LastPosition = progress.LastPosition
+		response.WatchedSeconds = progress.WatchedSeconds
+		response.Intervals = progress.Intervals
+		response.LastWatchedAt = &progress.LastWatchedAt
+	}
+
+	if video.DurationSeconds != nil {
+		response.CompletionPercent = completionPercent(response.WatchedSeconds, *video.DurationSeconds)
+	}
+
+	return response
+}
+
+// completionPercent 視聴率（%、小数点以下1桁）を計算する（長さが0の場合はnil）
+func completionPercent(watched float64, duration float64) *float64 {
+	if duration <= 0 {
+		return nil
+	}
+	percent := math.Round(math.Min(watched/duration, 1)*1000) / 10
+	return &percent
+}
+
+// maxWatchSeconds 前回の記録からの経過時間（初回はnil）から、今回記録できる視聴区間の長さを計算する
+// ハートビートを短い間隔で送っても、実際に経過した時間より長くは視聴済みにならない
+func maxWatchSeconds(sinceLastWatched *time.Duration) float64 {
+	if sinceLastWatched == nil {
+		return maxHeartbeatSeconds
+	}
+	elapsed := math.Max(sinceLastWatched.Seconds(), 0)
+	return math.Min(elapsed*maxPlaybackRate, maxHeartbeatSeconds)
+}
+
+// validSeconds 秒数として有効な値か（0以上の有限な数）
+func validSeconds(value float64) bool {
+	return value >= 0 && !math.IsInf(value, 0) && !math.IsNaN(value)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+)
+
+func TestMaxWatchSeconds(t *testing.T) {
+	duration := func(d time.Duration) *time.Duration { return &d }
+
+	tests := []struct {
+		name             string
+		sinceLastWatched *time.Duration
+		want             float64
+	}{
+		{"first heartbeat", nil, maxHeartbeatSeconds},
+		{"immediately after previous", duration(0), 0},
+		{"10 seconds", duration(10 * time.Second), 10 * maxPlaybackRate},
+		{"long pause", duration(time.Hour), maxHeartbeatSeconds},
+		{"clock skew", duration(-time.Minute), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxWatchSeconds(tt.sinceLastWatched); got != tt.want {
+				t.Errorf("maxWatchSeconds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMaxWatchSecondsRapidHeartbeats 短い間隔でハートビートを繰り返しても経過時間分しか記録されない
+func TestMaxWatchSecondsRapidHeartbeats(t *testing.T) {
+	var intervals []models.WatchInterval
+
+	elapsed := 100 * time.Millisecond
+	for i := 0; i < 1000; i++ {
+		var since *time.Duration
+		if i > 0 {
+			since = &elapsed
+		}
+		start := float64(i) * 10
+		if clamped := models.ClampWatchInterval(models.WatchInterval{Start: start, End: start + 10}, maxWatchSeconds(since)); clamped != nil {
+			intervals = append(intervals, *clamped)
+		}
+	}
+
+	watched := models.TotalWatchedSeconds(models.MergeWatchIntervals(intervals, 0))
+	limit := 10 + 999*elapsed.Seconds()*maxPlaybackRate
+	if watched > limit+1e-6 {
+		t.Errorf("watched %v seconds in %v of wall-clock time, want at most %v", watched, 999*elapsed, limit)
+	}
+}
+
+func TestParseVideoDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"120", 120, false},
+		{" 90.5 ", 90.5, false},
+		{"86400", maxVideoDurationSeconds, false},
+		{"", 0, true},
+		{"0", 0, true},
+		{"-1", 0, true},
+		{"86401", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseVideoDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVideoDuration(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseVideoDuration(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tomoki-den-uhd/go-study/internal/models"
+	"github.com/tomoki-den-uhd/go-study/internal/policy"
+	"github.com/tomoki-den-uhd/go-study/internal/repositories"
+	"github.com/tomoki-den-uhd/go-study/internal/storage"
+)
+
+// 書き込み中のリースの期間と延長する間隔（インスタンスが停止した場合はリースの期間が過ぎると再開できる）
+const (
+	videoUploadLeaseDuration = time.Minute
+	videoUploadLeaseRenewal  = 20 * time.Second
+)
+
+// VideoUploadService 再開可能な動画アップロード（tus 1.0）サービスの構造体
+// 受信途中の内容はPartialStoreに、受信済みのバイト数はDBに保存し、完了時に授業動画として登録する
+type VideoUploadService struct {
+	uploadRepo   *repositories.VideoUploadRepository
+	videoService *CourseVideoService
+	partials     *storage.PartialStore
+	userService  *UserService
+	expiry       time.Duration
+}
+
+// NewVideoUploadService 再開可能な動画アップロードサービスのコンストラクタ
+// expiryは最後に書き込んでから未完了のアップロードを破棄するまでの期間
+func NewVideoUploadService(uploadRepo *repositories.VideoUploadRepository, videoService *CourseVideoService, partials *storage.PartialStore, userService *UserService, expiry time.Duration) *VideoUploadService {
+	return &VideoUploadService{
+		uploadRepo:   uploadRepo,
+		videoService: videoService,
+		partials:     partials,
+		userService:  userService,
+		expiry:       expiry,
+	}
+}
+
+// MaxUploadBytes アップロードできる動画の最大サイズ
+func (s *VideoUploadService) MaxUploadBytes() int64 {
+	return s.videoService.MaxUploadBytes()
+}
+
+// CreateUpload アップロードを作成する（担当教師のみ）
+// metadataはUpload-Metadataヘッダーの値で、filenameとduration（動画の長さ・秒）が必須
+func (s *VideoUploadService) CreateUpload(userID string, courseID string, length int64, metadata string) (*models.VideoUpload, error) {
+	principal, courseIDInt, err := s.videoService.authorize(userID, courseID, policy.ActionCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := parseUploadMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	if values["filename"] == "" {
+		return nil, fmt.Errorf("入力値エラーがあります: filename is required in Upload-Metadata")
+	}
+
+	filename, contentType, err := s.videoService.validateVideoFile(values["filename"], length)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := parseVideoDuration(values["duration"]); err != nil {
+		return nil, err
+	}
+
+	token, _, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &models.VideoUpload{
+		UploadID:     token[:32],
+		CourseID:     courseIDInt,
+		UserID:       principal.UserID,
+		Filename:     filename,
+		ContentType:  contentType,
+		UploadLength: length,
+		Metadata:     metadata,
+		ExpiresAt:    time.Now().Add(s.expiry),
+	}
+
+	if err := s.partials.Create(upload.UploadID); err != nil {
+		return nil, err
+	}
+
+	if err := s.uploadRepo.CreateUpload(upload); err != nil {
+		s.partials.Remove(upload.UploadID)
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	return upload, nil
+}
+
+// GetUpload アップロードの受信状況を取得する（作成したユーザーのみ）
+func (s *VideoUploadService) GetUpload(userID string, courseID string, uploadID string) (*models.VideoUpload, error) {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getOwnUpload(principal, courseID, uploadID)
+}
+
+// WriteChunk offsetの位置から内容を書き込み、更新後のアップロードを返す
+// 全体を受信した時点でストレージに保存して授業動画として登録する
+func (s *VideoUploadService) WriteChunk(ctx context.Context, userID string, courseID string, uploadID string, offset int64, content io.Reader, audit models.AuditContext) (*models.VideoUpload, error) {
+	// 完了時の登録に備えて、書き込みの前に担当教師であることを確認する
+	principal, _, err := s.videoService.authorize(userID, courseID, policy.ActionCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getOwnUpload(principal, courseID, uploadID); err != nil {
+		return nil, err
+	}
+
+	token, unlock, err := s.lock(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// リースを取得してから受信済みのバイト数を読み直す
+	upload, err := s.getOwnUpload(principal, courseID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset != upload.UploadOffset {
+		return nil, fmt.Errorf("upload offset mismatch: expected %d", upload.UploadOffset)
+	}
+
+	if upload.UploadOffset < upload.UploadLength {
+		written, writeErr := s.partials.Append(upload.UploadID, upload.UploadOffset, content, upload.UploadLength-upload.UploadOffset)
+		if errors.Is(writeErr, storage.ErrNotFound) {
+			return nil, fmt.Errorf("upload not found: partial file is missing")
+		}
+
+		// 接続が切れた場合も受信できた分は記録し、クライアントが続きから再開できるようにする
+		if written > 0 {
+			from := upload.UploadOffset
+			upload.UploadOffset += written
+			upload.ExpiresAt = time.Now().Add(s.expiry)
+			if err := s.uploadRepo.UpdateOffset(upload.UploadID, token, from, upload.UploadOffset, upload.ExpiresAt); err != nil {
+				if strings.Contains(err.Error(), "upload offset mismatch") {
+					return nil, err
+				}
+				return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+			}
+		}
+		if writeErr != nil {
+			return nil, writeErr
+		}
+	}
+
+	// 全体を受信していれば登録する（前回の登録に失敗した場合は空の書き込みで再試行できる）
+	if upload.UploadOffset == upload.UploadLength && !upload.IsCompleted() {
+		if err := s.complete(ctx, principal, upload, audit); err != nil {
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+// TerminateUpload アップロードを中止して受信済みの内容を破棄する（作成したユーザーのみ）
+// 完了済みのアップロードを中止しても登録済みの動画は削除しない
+func (s *VideoUploadService) TerminateUpload(userID string, courseID string, uploadID string, audit models.AuditContext) error {
+	principal, err := s.userService.Principal(userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.getOwnUpload(principal, courseID, uploadID); err != nil {
+		return err
+	}
+
+	_, unlock, err := s.lock(uploadID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	upload, err := s.getOwnUpload(principal, courseID, uploadID)
+	if err != nil {
+		return err
+	}
+
+	auditService := s.videoService.auditService
+	err = auditService.Transact(func(tx pgx.Tx) error {
+		if err := s.uploadRepo.WithTx(tx).DeleteUpload(upload.UploadID); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+
+		// 監査ログを記録
+		return auditService.Record(tx, audit, models.AuditActionDelete, policy.ResourceVideo, upload.CourseID, upload, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 中止は記録済みのため、受信途中のファイルを削除できなくてもエラーにしない
+	if err := s.partials.Remove(upload.UploadID); err != nil {
+		log.Printf("failed to remove partial upload %s: %v", upload.UploadID, err)
+	}
+
+	return nil
+}
+
+// complete 受信した内容をストレージに保存して授業動画として登録する
+func (s *VideoUploadService) complete(ctx context.Context, principal *models.Principal, upload *models.VideoUpload, audit models.AuditContext) error {
+	file, err := s.partials.Open(upload.UploadID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("upload not found: partial file is missing")
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// 動画の長さは作成時に検証したUpload-Metadataの値を使う
+	values, err := parseUploadMetadata(upload.Metadata)
+	if err != nil {
+		return err
+	}
+	durationSeconds, err := parseVideoDuration(values["duration"])
+	if err != nil {
+		return err
+	}
+
+	// 動画の登録とアップロードの完了は同じトランザクションで記録する
+	video, err := s.videoService.saveVideo(ctx, principal, upload.CourseID, upload.Filename, upload.ContentType, durationSeconds, file, upload.UploadLength, audit, func(tx pgx.Tx, video *models.CourseVideo) error {
+		if err := s.uploadRepo.WithTx(tx).CompleteUpload(upload.UploadID, video.VideoID); err != nil {
+			return fmt.Errorf("データベースエラーが発生しました: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	upload.VideoID = &video.VideoID
+	upload.CompletedAt = &now
+
+	// 登録済みのため受信途中のファイルは不要
+	if err := s.partials.Remove(upload.UploadID); err != nil {
+		log.Printf("failed to remove partial upload %s: %v", upload.UploadID, err)
+	}
+
+	return nil
+}
+
+// getOwnUpload 操作ユーザーが作成した、期限内のアップロードを取得する
+// 他のユーザーのアップロードは存在しないものとして扱う
+func (s *VideoUploadService) getOwnUpload(principal *models.Principal, courseID string, uploadID string) (*models.VideoUpload, error) {
+	upload, err := s.uploadRepo.GetUpload(uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+
+	if upload == nil || upload.UserID != principal.UserID || fmt.Sprint(upload.CourseID) != courseID {
+		return nil, fmt.Errorf("upload not found")
+	}
+
+	// 完了済みのアップロードは期限後も受信状況を返す
+	if !upload.IsCompleted() && time.Now().After(upload.ExpiresAt) {
+		return nil, fmt.Errorf("upload expired")
+	}
+
+	return upload, nil
+}
+
+// lock アップロードの書き込み中のリースを取得し、リースのトークンと解放する関数を返す（書き込み中の場合はエラー）
+// リースはDBに記録するため別のインスタンスへのリクエストとも競合せず、書き込みの間は定期的に延長する
+func (s *VideoUploadService) lock(uploadID string) (string, func(), error) {
+	token, _, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token = token[:32]
+
+	locked, err := s.uploadRepo.AcquireLease(uploadID, token, time.Now().Add(videoUploadLeaseDuration))
+	if err != nil {
+		return "", nil, fmt.Errorf("データベースエラーが発生しました: %v", err)
+	}
+	if !locked {
+		return "", nil, fmt.Errorf("upload locked: another request is writing to this upload")
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(videoUploadLeaseRenewal)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// 延長できなかった場合もUpdateOffsetがトークンを確認するため、受信済みのバイト数は壊れない
+				if ok, err := s.uploadRepo.RenewLease(uploadID, token, time.Now().Add(videoUploadLeaseDuration)); err != nil || !ok {
+					log.Printf("failed to renew video upload lease %s: %v", uploadID, err)
+				}
+			}
+		}
+	}()
+
+	unlock := func() {
+		close(done)
+		<-stopped
+		if err := s.uploadRepo.ReleaseLease(uploadID, token); err != nil {
+			log.Printf("failed to release video upload lease %s: %v", uploadID, err)
+		}
+	}
+
+	return token, unlock, nil
+}
+
+// PurgeExpiredPeriodically intervalごとに期限切れのアップロードと受信途中のファイルを削除する（ctxが終了するまで戻らない）
+func (s *VideoUploadService) PurgeExpiredPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeExpired()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpired 期限切れのアップロードと受信途中のファイルを削除する
+func (s *VideoUploadService) purgeExpired() {
+	uploadIDs, err := s.uploadRepo.DeleteExpired(time.Now())
+	if err != nil {
+		log.Printf("failed to purge expired video uploads: %v", err)
+		return
+	}
+
+	for _, uploadID := range uploadIDs {
+		if err := s.partials.Remove(uploadID); err != nil {
+			log.Printf("failed to remove partial upload %s: %v", uploadID, err)
+		}
+	}
+}
+
+// parseUploadMetadata Upload-Metadataヘッダー（"キー base64値"のカンマ区切り）を解析する
+func parseUploadMetadata(header string) (map[string]string, error) {
+	values := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return values, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("入力値エラーがあります: invalid Upload-Metadata")
+		}
+
+		key := fields[0]
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("入力値エラーがあります: duplicate Upload-Metadata key %q", key)
+		}
+
+		value := ""
+		if len(fields) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("入力値エラーがあります: Upload-Metadata value for %q must be base64", key)
+			}
+			value = string(decoded)
+		}
+		values[key] = value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// PartialStore 再開可能なアップロードの受信途中のファイルを置くローカルのディレクトリ
+// 完了したファイルはStorageに保存し直すため、保存先がS3でも途中のファイルはローカルに置く
+// 複数のインスタンスで動かす場合はすべてのインスタンスから同じディレクトリを参照できる必要がある（書き込みの直列化はDBに記録するリースで行う）
+type PartialStore struct {
+	dir string
+}
+
+// NewPartialStore PartialStoreのコンストラクタ
+func NewPartialStore(dir string) (*PartialStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create partial upload directory: %w", err)
+	}
+	return &PartialStore{dir: dir}, nil
+}
+
+// path アップロードIDに対応するファイルのパスを返す
+func (s *PartialStore) path(id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("invalid upload id: %q", id)
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !(('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')) {
+			return "", fmt.Errorf("invalid upload id: %q", id)
+		}
+	}
+	return filepath.Join(s.dir, id+".part"), nil
+}
+
+// Create 空のファイルを作成する
+func (s *PartialStore) Create(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create partial upload: %w", err)
+	}
+	return file.Close()
+}
+
+// Append offsetの位置から最大limitバイトを書き込み、書き込んだバイト数を返す
+// 前回の書き込みがoffsetの記録前に中断していた場合に備え、offsetより後ろは切り詰めてから書き込む
+// 途中でエラーになった場合も、それまでに書き込んだバイト数を返す
+func (s *PartialStore) Append(id string, offset int64, r io.Reader, limit int64) (int64, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0o644)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open partial upload: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat partial upload: %w", err)
+	}
+	if info.Size() < offset {
+		return 0, fmt.Errorf("partial upload is shorter than offset: %d < %d", info.Size(), offset)
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		return 0, fmt.Errorf("failed to truncate partial upload: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek partial upload: %w", err)
+	}
+
+	written, copyErr := io.Copy(file, io.LimitReader(r, limit))
+
+	// 記録するoffsetまでの内容は確実に書き込んでおく
+	if err := file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync partial upload: %w", err)
+	}
+	if copyErr != nil {
+		return written, fmt.Errorf("failed to write partial upload: %w", copyErr)
+	}
+
+	return written, nil
+}
+
+// Open 受信済みのファイルを読み込み用に開く
+func (s *PartialStore) Open(id string) (*os.File, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open partial upload: %w", err)
+	}
+
+	return file, nil
+}
+
+// Remove ファイルを削除する（存在しない場合は何もしない）
+func (s *PartialStore) Remove(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete partial upload: %w", err)
+	}
+
+	return nil
+}
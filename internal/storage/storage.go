@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound オブジェクトが存在しない場合のエラー
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo 保存済みオブジェクトの情報
+type ObjectInfo struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage 動画などのファイルを保存するストレージのインターフェース
+// キーは"/"区切りの相対パス（例: courses/1/videos/xxxx.mp4）
+type Storage interface {
+	// Put sizeバイトの内容をキーに保存する（既存のオブジェクトは上書き）
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Stat オブジェクトの情報を取得する（存在しない場合はErrNotFound）
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Get オブジェクトのoffsetバイト目から末尾までを読み込む（存在しない場合はErrNotFound）
+	Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	// Delete オブジェクトを削除する（存在しない場合は何もしない）
+	Delete(ctx context.Context, key string) error
+}
+
+// validateKey キーがストレージの外を指していないかチェックする
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid storage key: %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid storage key: %q", key)
+		}
+	}
+	return nil
+}
+
+// Reader オブジェクトを任意の位置から読み込めるio.ReadSeekCloser
+// Seekでは位置だけを記録し、次のReadでその位置からGetし直すため、Range付きの配信（http.ServeContent）に使える
+type Reader struct {
+	ctx    context.Context
+	store  Storage
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+// NewReader Readerのコンストラクタ（sizeはStatで取得したオブジェクトのサイズ）
+func NewReader(ctx context.Context, store Storage, key string, size int64) *Reader {
+	return &Reader{
+		ctx:   ctx,
+		store: store,
+		key:   key,
+		size:  size,
+	}
+}
+
+// Read 現在の位置から読み込む
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	if r.body == nil {
+		body, err := r.store.Get(r.ctx, r.key, r.offset)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// Seek 読み込み位置を変更する（位置が変わった場合は次のReadで読み込み直す）
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = r.offset + offset
+	case io.SeekEnd:
+		next = r.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	if next < 0 {
+		return 0, fmt.Errorf("negative position: %d", next)
+	}
+
+	if next != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = next
+
+	return next, nil
+}
+
+// Close 読み込み中の内容を閉じる
+func (r *Reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
@@ -0,0 +1,148 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 Appendix BのSHA-1の鍵（ASCIIの"12345678901234567890"）をBase32にしたもの
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCodeRFC6238 RFC 6238 Appendix BのSHA-1のテストベクター（8桁の値の下6桁）と一致すること
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},          // 94287082
+		{1111111109, "081804"},  // 07081804
+		{1111111111, "050471"},  // 14050471
+		{1234567890, "005924"},  // 89005924
+		{2000000000, "279037"},  // 69279037
+		{20000000000, "353130"}, // 65353130
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfc6238Secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d) error = %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+
+		// 小文字のシークレットも受け付ける
+		if lower, _ := Code(strings.ToLower(rfc6238Secret), Step(time.Unix(tt.unix, 0))); lower != tt.want {
+			t.Errorf("Code(lowercase secret, %d) = %s, want %s", tt.unix, lower, tt.want)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code(invalid secret) error = nil, want error")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name   string
+		offset int64 // 現在のステップからの差
+		ok     bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -1, true},
+		{"next step", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfc6238Secret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			step, ok := Validate(rfc6238Secret, code, now)
+			if ok != tt.ok {
+				t.Fatalf("Validate() ok = %v, want %v", ok, tt.ok)
+			}
+			// 一致したステップを返す（使用済みのステップとして記録する）
+			if ok && step != current+tt.offset {
+				t.Errorf("Validate() step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	if _, ok := Validate(rfc6238Secret, " 050471 ", now); !ok {
+		t.Error("Validate() must ignore surrounding spaces")
+	}
+
+	for _, code := range []string{"", "50471", "0504710", "14050471", "abcdef"} {
+		if _, ok := Validate(rfc6238Secret, code, now); ok {
+			t.Errorf("Validate(%q) ok = true, want false", code)
+		}
+	}
+
+	if _, ok := Validate("not base32!", "050471", now); ok {
+		t.Error("Validate(invalid secret) ok = true, want false")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 160ビット（Base32で32文字、パディングなし）
+	if len(secret) != 32 || strings.Contains(secret, "=") {
+		t.Errorf("GenerateSecret() = %q, want 32 unpadded base32 characters", secret)
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("Code(generated secret) error = %v", err)
+	}
+
+	other, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == secret {
+		t.Error("GenerateSecret() returned the same secret twice")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("go study", "student@example.com", rfc6238Secret)
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/go study:student@example.com" {
+		t.Errorf("URI() = %q", uri)
+	}
+
+	want := map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "go study",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for name, value := range want {
+		if got := u.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+-- 有効なユーザー間でメールアドレスを一意にする
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_active_key
+    ON users (lower(email))
+    WHERE is_deleted = false;
@@ -0,0 +1,13 @@
+-- メールアドレス変更の確認トークン
+CREATE TABLE IF NOT EXISTS email_change_requests (
+    email_change_request_id SERIAL PRIMARY KEY,
+    user_id                 INTEGER NOT NULL REFERENCES users (user_id),
+    new_email               VARCHAR(255) NOT NULL,
+    token_hash              CHAR(64) NOT NULL UNIQUE,
+    expires_at              TIMESTAMP NOT NULL,
+    used_at                 TIMESTAMP,
+    created_at              TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS email_change_requests_user_id_idx
+    ON email_change_requests (user_id);
@@ -0,0 +1,22 @@
+-- ログインセッション（リフレッシュトークンのファミリー単位）
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    session_id     SERIAL PRIMARY KEY,
+    user_id        INTEGER NOT NULL REFERENCES users (user_id),
+    created_at     TIMESTAMP NOT NULL DEFAULT now(),
+    revoked_at     TIMESTAMP,
+    revoked_reason VARCHAR(50)
+);
+
+CREATE INDEX IF NOT EXISTS auth_sessions_user_id_idx
+    ON auth_sessions (user_id)
+    WHERE revoked_at IS NULL;
+
+-- リフレッシュトークン（使用のたびにローテーション）
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    refresh_token_id SERIAL PRIMARY KEY,
+    session_id       INTEGER NOT NULL REFERENCES auth_sessions (session_id),
+    token_hash       CHAR(64) NOT NULL UNIQUE,
+    expires_at       TIMESTAMP NOT NULL,
+    used_at          TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL DEFAULT now()
+);
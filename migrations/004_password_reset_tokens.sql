@@ -0,0 +1,12 @@
+-- パスワード再設定トークン（1回限り・有効期限付き）
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    password_reset_token_id SERIAL PRIMARY KEY,
+    user_id                 INTEGER NOT NULL REFERENCES users (user_id),
+    token_hash              CHAR(64) NOT NULL UNIQUE,
+    expires_at              TIMESTAMP NOT NULL,
+    used_at                 TIMESTAMP,
+    created_at              TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx
+    ON password_reset_tokens (user_id);
@@ -0,0 +1,18 @@
+-- 保護者と学生の紐付け
+CREATE TABLE IF NOT EXISTS guardians (
+    guardian_id     SERIAL PRIMARY KEY,
+    parent_user_id  INTEGER NOT NULL REFERENCES users (user_id),
+    student_user_id INTEGER NOT NULL REFERENCES users (user_id),
+    relationship    VARCHAR(50) NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT now(),
+    is_deleted      BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS guardians_parent_student_active_key
+    ON guardians (parent_user_id, student_user_id)
+    WHERE is_deleted = false;
+
+CREATE INDEX IF NOT EXISTS guardians_student_user_id_idx
+    ON guardians (student_user_id)
+    WHERE is_deleted = false;
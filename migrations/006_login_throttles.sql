@@ -0,0 +1,23 @@
+-- ログイン失敗回数のカウンター（アカウント単位・IP単位）
+CREATE TABLE IF NOT EXISTS login_throttles (
+    throttle_key   VARCHAR(320) PRIMARY KEY, -- "account:<email>" または "ip:<address>"
+    failed_count   INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP,
+    locked_until   TIMESTAMP
+);
+
+-- ロックアウトの記録
+CREATE TABLE IF NOT EXISTS lockout_events (
+    lockout_event_id SERIAL PRIMARY KEY,
+    throttle_key     VARCHAR(320) NOT NULL,
+    user_id          INTEGER REFERENCES users (user_id),
+    ip_address       VARCHAR(64) NOT NULL DEFAULT '',
+    failed_count     INTEGER NOT NULL,
+    locked_until     TIMESTAMP NOT NULL,
+    created_at       TIMESTAMP NOT NULL DEFAULT now(),
+    unlocked_at      TIMESTAMP,
+    unlocked_by      INTEGER REFERENCES users (user_id)
+);
+
+CREATE INDEX IF NOT EXISTS lockout_events_created_at_idx
+    ON lockout_events (created_at DESC);
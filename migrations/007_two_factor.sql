@@ -0,0 +1,20 @@
+-- TOTP二要素認証の設定（confirmed_atがNULLの間は登録手続き中）
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id        INTEGER PRIMARY KEY REFERENCES users (user_id),
+    secret         VARCHAR(64) NOT NULL,
+    confirmed_at   TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- 同じコードの再利用防止
+    created_at     TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- リカバリーコード（1回限り）
+CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+    recovery_code_id SERIAL PRIMARY KEY,
+    user_id          INTEGER NOT NULL REFERENCES users (user_id),
+    code_hash        CHAR(64) NOT NULL,
+    used_at          TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS totp_recovery_codes_user_id_idx
+    ON totp_recovery_codes (user_id);
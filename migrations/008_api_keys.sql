@@ -0,0 +1,20 @@
+-- サービスアカウント（パスワードでログインできず、APIキーでのみ利用する）
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_service_account BOOLEAN NOT NULL DEFAULT false;
+
+-- 連携用APIキー（平文は発行時にのみ表示し、SHA-256ハッシュのみ保存する）
+CREATE TABLE IF NOT EXISTS api_keys (
+    api_key_id   SERIAL PRIMARY KEY,
+    user_id      INTEGER NOT NULL REFERENCES users (user_id),
+    name         VARCHAR(100) NOT NULL,
+    key_prefix   VARCHAR(32) NOT NULL UNIQUE, -- キーの識別用（平文で保存）
+    key_hash     CHAR(64) NOT NULL,
+    scopes       TEXT[] NOT NULL,
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(45),
+    created_by   INTEGER NOT NULL REFERENCES users (user_id),
+    created_at   TIMESTAMP NOT NULL DEFAULT now(),
+    revoked_at   TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
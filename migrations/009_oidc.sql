@@ -0,0 +1,23 @@
+-- OIDCログインの一時状態（state・nonce・PKCEのcode_verifier、1回限り）
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    oidc_login_state_id SERIAL PRIMARY KEY,
+    state_hash          CHAR(64) NOT NULL UNIQUE,
+    nonce               VARCHAR(128) NOT NULL,
+    code_verifier       VARCHAR(128) NOT NULL,
+    expires_at          TIMESTAMP NOT NULL,
+    used_at             TIMESTAMP,
+    created_at          TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- 外部IdPのアカウント（発行者とsubjectの組）とユーザーの紐付け
+CREATE TABLE IF NOT EXISTS user_identities (
+    user_identity_id SERIAL PRIMARY KEY,
+    user_id          INTEGER NOT NULL REFERENCES users (user_id),
+    issuer           VARCHAR(255) NOT NULL,
+    subject          VARCHAR(255) NOT NULL,
+    created_at       TIMESTAMP NOT NULL DEFAULT now(),
+    last_login_at    TIMESTAMP,
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities (user_id);
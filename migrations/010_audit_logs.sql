@@ -0,0 +1,36 @@
+-- 変更操作の監査ログ（追記のみ）
+CREATE TABLE IF NOT EXISTS audit_logs (
+    audit_log_id     BIGSERIAL PRIMARY KEY,
+    actor_user_id    INTEGER REFERENCES users (user_id), -- 未認証の操作はNULL
+    actor_api_key_id INTEGER REFERENCES api_keys (api_key_id),
+    action           VARCHAR(50) NOT NULL,
+    entity_type      VARCHAR(50) NOT NULL,
+    entity_id        VARCHAR(64) NOT NULL,
+    before_data      JSONB,
+    after_data       JSONB,
+    changes          JSONB,                               -- 変更された項目ごとの変更前・変更後
+    request_id       VARCHAR(64),
+    ip_address       VARCHAR(45),
+    created_at       TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_actor_idx ON audit_logs (actor_user_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_logs_entity_idx ON audit_logs (entity_type, entity_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_logs_created_at_idx ON audit_logs (created_at);
+
+-- 更新・削除を禁止する
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs;
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
@@ -0,0 +1,24 @@
+-- 名簿インポート（OneRoster形式のCSV）の外部ID
+-- sourcedIdをキーに再インポート時は同じ行を更新する
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sourced_id VARCHAR(255);
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS sourced_id VARCHAR(255);
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS sourced_id VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_sourced_id_key ON users (sourced_id) WHERE sourced_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS courses_sourced_id_key ON courses (sourced_id) WHERE sourced_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS guardians_sourced_id_key ON guardians (sourced_id) WHERE sourced_id IS NOT NULL;
+
+-- 授業の受講登録
+CREATE TABLE IF NOT EXISTS enrollments (
+    enrollment_id   SERIAL PRIMARY KEY,
+    course_id       INTEGER NOT NULL REFERENCES courses (course_id),
+    student_user_id INTEGER NOT NULL REFERENCES users (user_id),
+    status          VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'dropped')),
+    sourced_id      VARCHAR(255),
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (course_id, student_user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS enrollments_sourced_id_key ON enrollments (sourced_id) WHERE sourced_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS enrollments_student_user_id_idx ON enrollments (student_user_id);
@@ -0,0 +1,7 @@
+-- 授業の論理削除と復元
+-- 授業の削除時はテスト・動画も同じdeleted_atで論理削除し、復元時はdeleted_atが一致するものだけを戻す
+-- 成績・出席は変更せず保持する（授業が削除されている間は一覧・詳細に表示しない）
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS deleted_by INTEGER REFERENCES users (user_id);
+ALTER TABLE teacher_tests ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE course_videos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
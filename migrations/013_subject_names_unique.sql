@@ -0,0 +1,5 @@
+-- 教科名は有効な（論理削除されていない）教科の中で一意にする
+-- 大文字・小文字と前後の空白の違いは同じ名前として扱う
+CREATE UNIQUE INDEX IF NOT EXISTS subjects_active_name_key
+    ON subjects (lower(btrim(name)))
+    WHERE is_deleted = false;
@@ -0,0 +1,16 @@
+-- 受講登録の状態に修了（completed）を追加する
+-- 受講中（active）と修了（completed）の学生は授業・公開済みテストを参照でき、取消（dropped）の学生は参照できない
+ALTER TABLE enrollments DROP CONSTRAINT IF EXISTS enrollments_status_check;
+ALTER TABLE enrollments ADD CONSTRAINT enrollments_status_check
+    CHECK (status IN ('active', 'dropped', 'completed'));
+
+-- これまで出席記録から推定していた受講関係を受講登録に移行する
+INSERT INTO enrollments (course_id, student_user_id, status, created_at, updated_at)
+SELECT a.course_id, a.student_user_id, 'active', MIN(a.attended_at), now()
+FROM attendances a
+JOIN users u ON a.student_user_id = u.user_id
+WHERE a.is_deleted = false AND u.role = 'student'
+GROUP BY a.course_id, a.student_user_id
+ON CONFLICT (course_id, student_user_id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS enrollments_course_status_idx ON enrollments (course_id, status);
@@ -0,0 +1,8 @@
+-- 授業動画の保存先（ストレージのキー）とファイル情報
+-- urlには配信エンドポイントのパスを保存し、実体はstorage_keyでストレージから取得する
+ALTER TABLE course_videos ADD COLUMN IF NOT EXISTS storage_key VARCHAR(512);
+ALTER TABLE course_videos ADD COLUMN IF NOT EXISTS content_type VARCHAR(100);
+ALTER TABLE course_videos ADD COLUMN IF NOT EXISTS size_bytes BIGINT;
+ALTER TABLE course_videos ADD COLUMN IF NOT EXISTS uploaded_by INTEGER REFERENCES users (user_id);
+
+CREATE INDEX IF NOT EXISTS course_videos_course_id_idx ON course_videos (course_id) WHERE is_deleted = false;
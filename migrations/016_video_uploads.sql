@@ -0,0 +1,19 @@
+-- 授業動画の再開可能なアップロード（tus 1.0）
+-- 受信済みのバイト数をupload_offsetに保存し、サーバー再起動後も続きから受け付ける
+CREATE TABLE IF NOT EXISTS video_uploads (
+    upload_id     VARCHAR(64) PRIMARY KEY,
+    course_id     INTEGER NOT NULL REFERENCES courses (course_id),
+    user_id       INTEGER NOT NULL REFERENCES users (user_id),
+    filename      VARCHAR(255) NOT NULL,
+    content_type  VARCHAR(100) NOT NULL,
+    upload_length BIGINT NOT NULL CHECK (upload_length > 0),
+    upload_offset BIGINT NOT NULL DEFAULT 0 CHECK (upload_offset >= 0 AND upload_offset <= upload_length),
+    metadata      TEXT NOT NULL DEFAULT '',
+    video_id      INTEGER REFERENCES course_videos (video_id),
+    expires_at    TIMESTAMP NOT NULL,
+    completed_at  TIMESTAMP,
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS video_uploads_expires_at_idx ON video_uploads (expires_at);
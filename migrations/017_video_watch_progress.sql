@@ -0,0 +1,18 @@
+-- 動画の長さ（秒）。プレイヤーから報告された値を最初の1回だけ保存する
+ALTER TABLE course_videos ADD COLUMN IF NOT EXISTS duration_seconds DOUBLE PRECISION;
+
+-- 学生ごとの動画の視聴状況
+-- intervalsは視聴した区間（[{"start":秒,"end":秒}]）を重なりを統合して保存する
+CREATE TABLE IF NOT EXISTS video_watch_progress (
+    video_id        INTEGER NOT NULL REFERENCES course_videos (video_id),
+    student_user_id INTEGER NOT NULL REFERENCES users (user_id),
+    intervals       JSONB NOT NULL DEFAULT '[]',
+    watched_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+    last_position   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    last_watched_at TIMESTAMP NOT NULL DEFAULT now(),
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (video_id, student_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS video_watch_progress_student_user_id_idx ON video_watch_progress (student_user_id);
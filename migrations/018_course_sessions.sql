@@ -0,0 +1,49 @@
+-- 授業の繰り返し（RFC 5545のRRULEとEXDATE）と1回あたりの時間
+-- scheduled_atを初回（DTSTART）とし、recurrence_ruleがNULLの授業は1回のみ
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS recurrence_rule TEXT;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS recurrence_exdates TIMESTAMP[] NOT NULL DEFAULT '{}';
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS duration_minutes INTEGER NOT NULL DEFAULT 60 CHECK (duration_minutes > 0);
+
+-- 繰り返しを展開した授業回
+-- original_starts_atは展開時の日時（RECURRENCE-ID）で、個別に移動・休講した回はis_overrideをtrueにして再展開時も保持する
+CREATE TABLE IF NOT EXISTS course_sessions (
+    session_id         SERIAL PRIMARY KEY,
+    course_id          INTEGER NOT NULL REFERENCES courses (course_id),
+    original_starts_at TIMESTAMP NOT NULL,
+    starts_at          TIMESTAMP NOT NULL,
+    ends_at            TIMESTAMP NOT NULL CHECK (ends_at > starts_at),
+    status             VARCHAR(20) NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'cancelled')),
+    is_override        BOOLEAN NOT NULL DEFAULT false,
+    note               TEXT NOT NULL DEFAULT '',
+    created_at         TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at         TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (course_id, original_starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS course_sessions_starts_at_idx ON course_sessions (starts_at);
+
+-- 既存の授業はscheduled_atの1回の授業回とする
+INSERT INTO course_sessions (course_id, original_starts_at, starts_at, ends_at)
+SELECT course_id, scheduled_at, scheduled_at, scheduled_at + interval '60 minutes'
+FROM courses
+ON CONFLICT (course_id, original_starts_at) DO NOTHING;
+
+-- 出席は授業回ごとに記録する
+-- 既存の出席は学生ごとに最新の1件を授業の唯一の授業回に紐付け、それ以外は授業回なしのまま残す
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS session_id INTEGER REFERENCES course_sessions (session_id);
+
+UPDATE attendances a
+SET session_id = s.session_id
+FROM course_sessions s
+WHERE a.session_id IS NULL
+    AND s.course_id = a.course_id
+    AND a.attendance_id IN (
+        SELECT DISTINCT ON (course_id, student_user_id) attendance_id
+        FROM attendances
+        WHERE is_deleted = false
+        ORDER BY course_id, student_user_id, attended_at DESC, attendance_id DESC
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS attendances_session_student_key
+    ON attendances (session_id, student_user_id)
+    WHERE session_id IS NOT NULL AND is_deleted = false;
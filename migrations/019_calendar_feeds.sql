@@ -0,0 +1,7 @@
+-- カレンダー購読（.ics）用の秘密トークン（ユーザーごとに1つ、平文は発行時にのみ表示しSHA-256ハッシュのみ保存する）
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id      INTEGER PRIMARY KEY REFERENCES users (user_id),
+    token_hash   CHAR(64) NOT NULL UNIQUE,
+    created_at   TIMESTAMP NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMP
+);
@@ -0,0 +1,19 @@
+-- 授業の担当教師（主担当・副担当・アシスタント）
+-- 主担当（owner）は授業ごとに1人で、courses.teacher_user_idにも同じ教師を保持する
+CREATE TABLE IF NOT EXISTS course_staff (
+    course_id  INTEGER NOT NULL REFERENCES courses (course_id),
+    user_id    INTEGER NOT NULL REFERENCES users (user_id),
+    role       VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'co_teacher', 'assistant')),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (course_id, user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS course_staff_owner_key ON course_staff (course_id) WHERE role = 'owner';
+CREATE INDEX IF NOT EXISTS course_staff_user_id_idx ON course_staff (user_id);
+
+-- これまでの担当教師を主担当として移行する
+INSERT INTO course_staff (course_id, user_id, role, created_at, updated_at)
+SELECT course_id, teacher_user_id, 'owner', created_at, now()
+FROM courses
+ON CONFLICT (course_id, user_id) DO NOTHING;
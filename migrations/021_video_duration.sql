@@ -0,0 +1,6 @@
+-- 動画の長さは担当教師がアップロード時に指定する（学生のプレイヤーから報告された値は使わない）
+-- これまでに学生から報告された長さは信頼できないため破棄し、教師が設定し直す
+UPDATE course_videos SET duration_seconds = NULL WHERE duration_seconds IS NOT NULL;
+
+ALTER TABLE course_videos DROP CONSTRAINT IF EXISTS course_videos_duration_seconds_check;
+ALTER TABLE course_videos ADD CONSTRAINT course_videos_duration_seconds_check CHECK (duration_seconds > 0);
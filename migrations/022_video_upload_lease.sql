@@ -0,0 +1,4 @@
+-- 再開可能なアップロードへの書き込み中のリース（複数のインスタンスで同じアップロードへの書き込みを直列にする）
+-- 書き込み中のリクエストが定期的にlocked_untilを延長し、インスタンスが停止した場合は期限切れで解放される
+ALTER TABLE video_uploads ADD COLUMN IF NOT EXISTS lock_token VARCHAR(64);
+ALTER TABLE video_uploads ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
@@ -0,0 +1,10 @@
+-- 要求回数のカウンター（パスワード再設定メールの送信要求など）
+-- ログイン失敗のカウンター（login_throttles）とは分け、失敗回数・ロックには影響させない
+CREATE TABLE IF NOT EXISTS request_counters (
+    counter_key       VARCHAR(320) PRIMARY KEY, -- "reset:account:<email>" または "reset:ip:<address>"
+    request_count     INTEGER NOT NULL DEFAULT 0,
+    window_started_at TIMESTAMP NOT NULL        -- 回数を数え始めた日時（期間を過ぎると1から数え直す）
+);
+
+-- 以前はlogin_throttlesで数えていた送信要求の行を削除する
+DELETE FROM login_throttles WHERE throttle_key LIKE 'reset:%';